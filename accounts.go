@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/gagliardetto/solana-go"
+
+	"sol-tx/jupiterv6"
+)
+
+// jupiterFixedAccountCounts is the number of named accounts each instruction
+// takes before the remaining (per route step) accounts begin
+var jupiterFixedAccountCounts = jupiterv6.FixedAccountCounts
+
+// instructionAccountKeys resolves the account keys referenced by a compiled instruction
+func instructionAccountKeys(inst solana.CompiledInstruction, accountKeys solana.PublicKeySlice) solana.PublicKeySlice {
+	keys := make(solana.PublicKeySlice, 0, len(inst.Accounts))
+	for _, index := range inst.Accounts {
+		if int(index) >= len(accountKeys) {
+			return keys
+		}
+		keys = append(keys, accountKeys[index])
+	}
+	return keys
+}
+
+// remainingAccounts returns the accounts following the fixed accounts of the instruction
+func remainingAccounts(instructionType string, accounts solana.PublicKeySlice) solana.PublicKeySlice {
+	fixed, ok := jupiterFixedAccountCounts[instructionType]
+	if !ok || fixed > len(accounts) {
+		return nil
+	}
+	return accounts[fixed:]
+}
+
+// attachStepAccounts maps the instruction's remaining accounts onto route plan steps
+// for swap variants whose account layout is known
+func attachStepAccounts(params *JupiterSwapParams, accounts solana.PublicKeySlice) {
+	remaining := remainingAccounts(params.InstructionType, accounts)
+	if len(remaining) == 0 {
+		return
+	}
+
+	mapSanctumSAccounts(params, remaining)
+	mapStakeDexAccounts(params, remaining)
+	mapCLMMTickArrays(params, remaining)
+}
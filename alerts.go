@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"sol-tx/jupiterv6"
+)
+
+// AlertCode is a stable machine-readable code for errors and warnings attached to an analysis
+type AlertCode string
+
+// Alert codes. Codes are never reused or renumbered; every code must have a catalog entry.
+const (
+	CodeUnknownDiscriminator   AlertCode = jupiterv6.CodeUnknownDiscriminator
+	CodeTruncatedInstruction   AlertCode = jupiterv6.CodeTruncatedInstruction
+	CodeUnknownSwapVariant     AlertCode = "JUP003"
+	CodeInstructionParseFailed AlertCode = jupiterv6.CodeInstructionParseFailed
+	CodeInstructionTooLarge    AlertCode = "JUP005"
+	CodeSuspectInstruction     AlertCode = "JUP006"
+	CodeEventsMissing          AlertCode = "JUP010"
+	CodeUnparsedEventData      AlertCode = "JUP011"
+	CodeSelfSwapEvent          AlertCode = "JUP012"
+	CodeMintRiskUnavailable    AlertCode = "JUP013"
+	CodePriorityFeeUnavailable AlertCode = "JUP014"
+	CodeEventExtraFields       AlertCode = "JUP015"
+	CodeHookPanicked           AlertCode = "JUP030"
+	CodeLegacyMigration        AlertCode = "JUP040"
+	CodePolicyViolation        AlertCode = "JUP050"
+)
+
+// CatalogEntry documents an alert code
+type CatalogEntry struct {
+	Code        AlertCode `json:"code"`
+	Name        string    `json:"name"`
+	Severity    string    `json:"severity"` // "error" or "warning"
+	Description string    `json:"description"`
+}
+
+// alertCatalog lists every code the analysis can attach
+var alertCatalog = []CatalogEntry{
+	{CodeUnknownDiscriminator, "UnknownDiscriminator", "error", "Jupiter instruction with an unrecognized 8 byte discriminator"},
+	{CodeTruncatedInstruction, "TruncatedInstruction", "error", "Instruction data ended before all fields could be decoded"},
+	{CodeUnknownSwapVariant, "UnknownSwapVariant", "warning", "Route plan step uses a swap variant index this parser does not know"},
+	{CodeInstructionParseFailed, "InstructionParseFailed", "error", "Instruction could not be parsed for another reason"},
+	{CodeInstructionTooLarge, "InstructionTooLarge", "error", "Instruction data exceeds the configured maximum size and was not parsed"},
+	{CodeSuspectInstruction, "SuspectInstruction", "warning", "Parsed values are outside the sanity bounds, likely a misparse; an instruction error with strict bounds"},
+	{CodeEventsMissing, "EventsMissing", "warning", "Jupiter instructions were parsed but no swap events were found"},
+	{CodeUnparsedEventData, "UnparsedEventData", "warning", "Event payload contained bytes after the last decodable event"},
+	{CodeSelfSwapEvent, "SelfSwapEvent", "warning", "Swap event has the same input and output mint"},
+	{CodeMintRiskUnavailable, "MintRiskUnavailable", "warning", "Mint risk signals could not be fetched or decoded for an involved mint"},
+	{CodePriorityFeeUnavailable, "PriorityFeeUnavailable", "warning", "Compute unit prices of the transaction block could not be fetched"},
+	{CodeEventExtraFields, "EventExtraFields", "warning", "Swap event is longer than every registered schema, the trailing bytes are kept as extra_fields"},
+	{CodeHookPanicked, "HookPanicked", "warning", "A user supplied hook panicked and was recovered"},
+	{CodeLegacyMigration, "LegacyMigration", "warning", "Analysis decoded from the legacy JSON printer output lost or repaired data"},
+	{CodePolicyViolation, "PolicyViolation", "warning", "Route uses a swap variant forbidden by WithForbiddenVariants"},
+}
+
+// AlertCatalog returns every alert code with its documentation
+func AlertCatalog() []CatalogEntry {
+	catalog := make([]CatalogEntry, len(alertCatalog))
+	copy(catalog, alertCatalog)
+	return catalog
+}
+
+// Alert is a coded warning attached to an analysis
+type Alert struct {
+	Code    AlertCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// addWarning attaches a coded warning to the analysis
+func (a *JupiterV6Analysis) addWarning(code AlertCode, format string, args ...interface{}) {
+	a.Warnings = append(a.Warnings, Alert{Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Sentinel parse errors used to classify instruction errors
+var (
+	errUnknownDiscriminator = jupiterv6.ErrUnknownDiscriminator
+	errTruncatedInstruction = jupiterv6.ErrTruncatedInstruction
+	errInstructionTooLarge  = errors.New("instruction data too large")
+)
+
+// codeForParseError maps an instruction parse error to its alert code
+func codeForParseError(err error) AlertCode {
+	switch {
+	case errors.Is(err, errUnknownDiscriminator):
+		return CodeUnknownDiscriminator
+	case errors.Is(err, errTruncatedInstruction):
+		return CodeTruncatedInstruction
+	case errors.Is(err, errInstructionTooLarge):
+		return CodeInstructionTooLarge
+	case errors.Is(err, errSuspectInstruction):
+		return CodeSuspectInstruction
+	default:
+		return CodeInstructionParseFailed
+	}
+}
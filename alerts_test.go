@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAlertCodes(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		mutate func([]byte) []byte
+		code   AlertCode
+		error  bool
+	}{
+		{"unknown discriminator", func(data []byte) []byte { data[0] ^= 0xFF; return data }, CodeUnknownDiscriminator, true},
+		{"truncated", func(data []byte) []byte { return data[:len(data)-1] }, CodeTruncatedInstruction, true},
+		// The first step of the route plan follows the discriminator and the vector length
+		{"unknown variant", func(data []byte) []byte { data[12] = 250; return data }, CodeUnknownSwapVariant, false},
+	} {
+		tx, parsedTx := triageFixture(t, "route", 1, tt.mutate)
+		analysis := analyzeTest(t, newTestAnalyzer(), tx, parsedTx)
+
+		var codes []AlertCode
+		for _, instErr := range analysis.Errors {
+			codes = append(codes, instErr.Code)
+		}
+		for _, warning := range analysis.Warnings {
+			codes = append(codes, warning.Code)
+		}
+		if len(codes) == 0 || codes[0] != tt.code || (len(analysis.Errors) > 0) != tt.error {
+			t.Errorf("%s: codes %v, want %s first", tt.name, codes, tt.code)
+		}
+
+		encoded, err := json.Marshal(analysis)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(encoded), `"code":"`+string(tt.code)+`"`) {
+			t.Errorf("%s: code missing from %s", tt.name, encoded)
+		}
+
+		// Every attached code is documented
+		catalog := make(map[AlertCode]bool)
+		for _, entry := range AlertCatalog() {
+			catalog[entry.Code] = true
+		}
+		for _, code := range codes {
+			if !catalog[code] {
+				t.Errorf("%s: code %s has no catalog entry", tt.name, code)
+			}
+		}
+	}
+}
+
+func TestAlertCatalogUnique(t *testing.T) {
+	codes := make(map[AlertCode]bool)
+	names := make(map[string]bool)
+	for _, entry := range AlertCatalog() {
+		if codes[entry.Code] || names[entry.Name] {
+			t.Errorf("duplicate catalog entry %+v", entry)
+		}
+		codes[entry.Code], names[entry.Name] = true, true
+		if entry.Severity != "error" && entry.Severity != "warning" {
+			t.Errorf("%s: severity %q", entry.Code, entry.Severity)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"github.com/gagliardetto/solana-go"
+)
+
+// AMMRegistry maps AMM program IDs, as found in SwapEvent.AMM, to display names
+type AMMRegistry struct {
+	Programs map[solana.PublicKey]string `json:"programs"`
+}
+
+// DefaultAMMRegistry names the AMM programs this package already decodes
+var DefaultAMMRegistry = &AMMRegistry{
+	Programs: map[solana.PublicKey]string{
+		whirlpoolProgramID:    "Whirlpool",
+		raydiumClmmProgramID:  "Raydium CLMM",
+		raydiumAmmV4ProgramID: "Raydium",
+		meteoraDlmmProgramID:  "Meteora DLMM",
+		phoenixProgramID:      "Phoenix",
+		sanctumSProgramID:     "Sanctum Infinity",
+		stakeDexProgramID:     "StakeDex",
+	},
+}
+
+// Lookup returns the name of an AMM program
+func (r *AMMRegistry) Lookup(program solana.PublicKey) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	name, ok := r.Programs[program]
+	return name, ok
+}
+
+// ResolvedEvent is a swap event with its AMM name and UI amounts resolved.
+// Names and UI amounts are empty when the registry or decimals do not know them.
+type ResolvedEvent struct {
+	AMM            solana.PublicKey `json:"amm"`
+	AMMName        string           `json:"amm_name,omitempty"`
+	InputMint      solana.PublicKey `json:"input_mint"`
+	InputAmount    uint64           `json:"input_amount"`
+	InputAmountUI  string           `json:"input_amount_ui,omitempty"`
+	OutputMint     solana.PublicKey `json:"output_mint"`
+	OutputAmount   uint64           `json:"output_amount"`
+	OutputAmountUI string           `json:"output_amount_ui,omitempty"`
+	Dust           bool             `json:"dust,omitempty"`
+}
+
+// ResolveEvents resolves AMM names and UI amounts of events in one pass,
+// leaving events untouched. decimals is keyed by mint.
+func ResolveEvents(events []SwapEvent, ammRegistry *AMMRegistry, decimals map[solana.PublicKey]uint8) []ResolvedEvent {
+	resolved := make([]ResolvedEvent, 0, len(events))
+	for _, event := range events {
+		r := ResolvedEvent{
+			AMM:          event.AMM,
+			InputMint:    event.InputMint,
+			InputAmount:  event.InputAmount,
+			OutputMint:   event.OutputMint,
+			OutputAmount: event.OutputAmount,
+			Dust:         event.Dust,
+		}
+		r.AMMName, _ = ammRegistry.Lookup(event.AMM)
+		if d, ok := decimals[event.InputMint]; ok {
+			r.InputAmountUI = formatUnits(event.InputAmount, d)
+		}
+		if d, ok := decimals[event.OutputMint]; ok {
+			r.OutputAmountUI = formatUnits(event.OutputAmount, d)
+		}
+		resolved = append(resolved, r)
+	}
+	return resolved
+}
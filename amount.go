@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// addUint64Checked returns a + b and false when the sum overflows uint64
+func addUint64Checked(a, b uint64) (uint64, bool) {
+	sum, carry := bits.Add64(a, b, 0)
+	return sum, carry == 0
+}
+
+// BigAmount is an arbitrary precision raw token amount used for aggregates that
+// may exceed uint64. It is serialized to JSON as a decimal string.
+type BigAmount big.Int
+
+// NewBigAmount returns a BigAmount holding v
+func NewBigAmount(v uint64) *BigAmount {
+	return (*BigAmount)(new(big.Int).SetUint64(v))
+}
+
+// bigAmountOf returns a copy of v as a BigAmount
+func bigAmountOf(v *big.Int) *BigAmount {
+	return (*BigAmount)(new(big.Int).Set(v))
+}
+
+// Int returns the amount as a big.Int sharing the same storage
+func (b *BigAmount) Int() *big.Int {
+	return (*big.Int)(b)
+}
+
+// AddUint64 adds v to the amount and returns it
+func (b *BigAmount) AddUint64(v uint64) *BigAmount {
+	b.Int().Add(b.Int(), new(big.Int).SetUint64(v))
+	return b
+}
+
+// String returns the decimal representation
+func (b *BigAmount) String() string {
+	return b.Int().String()
+}
+
+// MarshalJSON encodes the amount as a decimal string
+func (b *BigAmount) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(b.String())), nil
+}
+
+// UnmarshalJSON accepts a decimal string or a bare JSON number
+func (b *BigAmount) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+	if _, ok := b.Int().SetString(text, 10); !ok {
+		return fmt.Errorf("invalid amount %s", data)
+	}
+	return nil
+}
+
+// Rat returns the amount scaled down by decimals
+func (b *BigAmount) Rat(decimals uint8) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	return new(big.Rat).SetFrac(b.Int(), scale)
+}
+
+// Format returns the amount scaled down by decimals, exact and without trailing
+// zeros (e.g. 1500000000 with 9 decimals -> "1.5")
+func (b *BigAmount) Format(decimals uint8) string {
+	text := b.Rat(decimals).FloatString(int(decimals))
+	if strings.Contains(text, ".") {
+		text = strings.TrimRight(strings.TrimRight(text, "0"), ".")
+	}
+	return text
+}
+
+// TokenAmount is a raw amount of a mint with its UI value when the decimals are known
+type TokenAmount struct {
+	Mint solana.PublicKey `json:"mint"`
+	Raw  *BigAmount       `json:"raw"`
+	UI   string           `json:"ui,omitempty"`
+}
+
+// newTokenAmount builds the token amount of raw units of mint, using registry for decimals
+func newTokenAmount(raw uint64, mint solana.PublicKey, registry TokenRegistry) TokenAmount {
+	amount := TokenAmount{Mint: mint, Raw: NewBigAmount(raw)}
+	if registry != nil {
+		if info, ok := registry.Lookup(mint); ok {
+			amount.UI = amount.Raw.Format(info.Decimals)
+		}
+	}
+	return amount
+}
+
+// EventAmounts are the amounts of one swap event
+type EventAmounts struct {
+	AMM    solana.PublicKey `json:"amm"`
+	Input  TokenAmount      `json:"input"`
+	Output TokenAmount      `json:"output"`
+}
+
+// AnalysisAmounts are the amounts of an analysis as arbitrary precision
+// integers, with UI values computed exactly from the registry decimals
+type AnalysisAmounts struct {
+	Input  *TokenAmount   `json:"input,omitempty"`
+	Output *TokenAmount   `json:"output,omitempty"`
+	Events []EventAmounts `json:"events"`
+}
+
+// AmountsOf returns the summary and event amounts of analysis. registry may be
+// nil, in which case only raw amounts are set.
+func AmountsOf(analysis *JupiterV6Analysis, registry TokenRegistry) *AnalysisAmounts {
+	amounts := &AnalysisAmounts{Events: make([]EventAmounts, 0, len(analysis.Events))}
+	events := nonDustEvents(analysis.Events)
+	if len(events) > 0 {
+		first, last := events[0], events[len(events)-1]
+		input := newTokenAmount(first.InputAmount, first.InputMint, registry)
+		output := newTokenAmount(last.OutputAmount, last.OutputMint, registry)
+		amounts.Input, amounts.Output = &input, &output
+	}
+	for _, event := range analysis.Events {
+		amounts.Events = append(amounts.Events, EventAmounts{
+			AMM:    event.AMM,
+			Input:  newTokenAmount(event.InputAmount, event.InputMint, registry),
+			Output: newTokenAmount(event.OutputAmount, event.OutputMint, registry),
+		})
+	}
+	return amounts
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Analyzer analyzes Jupiter V6 transactions with configurable behavior
+type Analyzer struct {
+	rpcClient *rpc.Client
+	// endpoint is the rpc URL the client was created from by NewAnalyzerFromConfig
+	endpoint     string
+	source       TransactionSource
+	lookupTables LookupTableProvider
+	hooks        Hooks
+
+	tokenRegistry TokenRegistry
+	poolRegistry  PoolRegistry
+	integrators   *IntegratorRegistry
+	mintRisks     MintRiskProvider
+	bondingCurves BondingCurveProvider
+	blockFees     BlockFeeProvider
+	txOpts        TransactionOptions
+	commitment    rpc.CommitmentType
+
+	limits  scanLimits
+	layouts []LayoutRange
+	dust    DustThreshold
+
+	// decodeHops enables AMM inner instruction decoding
+	decodeHops bool
+
+	// returnDataEvents also parses a swap event from the transaction return data
+	returnDataEvents bool
+
+	// slotTimes estimates the time of transactions without a block time
+	slotTimes *SlotTimeEstimator
+
+	// rpcSlots bounds in-flight rpc calls when set
+	rpcSlots chan struct{}
+
+	// instructionTypes limits parsing to these instruction types, nil parses all
+	instructionTypes map[string]bool
+
+	// logOutput receives progress messages, stdout by default
+	logOutput io.Writer
+
+	// lenientLookups analyzes transactions whose lookup tables failed to resolve
+	lenientLookups bool
+
+	// noNetwork rejects every rpc call with ErrNetworkDisabled
+	noNetwork bool
+
+	// forbiddenVariants are reported as policy violations, as an error too with forbiddenRouteError
+	forbiddenVariants   map[SwapType]bool
+	forbiddenRouteError bool
+
+	// sanity bounds the values of parsed instructions
+	sanity SanityBounds
+}
+
+// AnalyzerOption configures an Analyzer
+type AnalyzerOption func(*Analyzer)
+
+// NewAnalyzer creates an Analyzer. rpcClient may be nil when only
+// already-fetched transactions are analyzed.
+func NewAnalyzer(rpcClient *rpc.Client, opts ...AnalyzerOption) *Analyzer {
+	a := &Analyzer{
+		rpcClient:  rpcClient,
+		txOpts:     defaultTransactionOptions(),
+		commitment: rpc.CommitmentFinalized,
+		limits:     defaultScanLimits(),
+		logOutput:  os.Stdout,
+		sanity:     DefaultSanityBounds(),
+	}
+	if rpcClient != nil {
+		a.source = NewRPCTransactionSource(rpcClient)
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.noNetwork {
+		a.disableNetwork()
+	}
+	return a
+}
+
+// With returns a copy of the analyzer with opts applied on top. The copy shares
+// the rpc client, transaction source, providers and their caches with a, which
+// is left unchanged, so it is cheap enough to derive per request.
+func (a *Analyzer) With(opts ...AnalyzerOption) *Analyzer {
+	c := *a
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.noNetwork {
+		c.disableNetwork()
+	}
+	return &c
+}
+
+// WithHooks registers lifecycle hooks invoked during analysis
+func WithHooks(hooks Hooks) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.hooks = hooks
+	}
+}
+
+// WithTokenRegistry sets the registry used to denominate amounts in token units
+func WithTokenRegistry(registry TokenRegistry) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.tokenRegistry = registry
+	}
+}
+
+// WithCommitment sets the commitment used by every RPC call of the analyzer.
+// Defaults to finalized. getTransaction does not serve processed, transactions
+// are then fetched at confirmed.
+func WithCommitment(commitment rpc.CommitmentType) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.commitment = commitment
+	}
+}
+
+// commitmentKey is the context key of the commitment passed to providers
+type commitmentKey struct{}
+
+// ContextWithCommitment returns a copy of ctx carrying commitment. The analyzer
+// calls every provider with its commitment attached this way.
+func ContextWithCommitment(ctx context.Context, commitment rpc.CommitmentType) context.Context {
+	return context.WithValue(ctx, commitmentKey{}, commitment)
+}
+
+// CommitmentFromContext returns the commitment carried by ctx, or fallback when it carries none
+func CommitmentFromContext(ctx context.Context, fallback rpc.CommitmentType) rpc.CommitmentType {
+	if commitment, ok := ctx.Value(commitmentKey{}).(rpc.CommitmentType); ok && commitment != "" {
+		return commitment
+	}
+	return fallback
+}
+
+// providerContext attaches the analyzer commitment to ctx for provider calls
+func (a *Analyzer) providerContext(ctx context.Context) context.Context {
+	return ContextWithCommitment(ctx, a.commitment)
+}
+
+// WithInstructionTypes limits full parsing to the given instruction types.
+// Other Jupiter instructions are classified by discriminator and counted in
+// Stats but do not appear in Instructions.
+func WithInstructionTypes(types ...string) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.instructionTypes = make(map[string]bool, len(types))
+		for _, t := range types {
+			a.instructionTypes[t] = true
+		}
+	}
+}
+
+// WithLogOutput redirects the analyzer progress messages, io.Discard silences them
+func WithLogOutput(w io.Writer) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.logOutput = w
+	}
+}
+
+// WithMaxConcurrentRequests bounds the number of simultaneous rpc calls made by
+// the analyzer, independently of any rate limiter on the rpc client. Values
+// below 1 disable the bound.
+func WithMaxConcurrentRequests(n int) AnalyzerOption {
+	return func(a *Analyzer) {
+		if n < 1 {
+			a.rpcSlots = nil
+			return
+		}
+		a.rpcSlots = make(chan struct{}, n)
+	}
+}
+
+// withRPCSlot runs call once a concurrency slot is available
+func (a *Analyzer) withRPCSlot(ctx context.Context, call func() error) error {
+	if a.rpcSlots == nil {
+		return call()
+	}
+
+	select {
+	case a.rpcSlots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-a.rpcSlots }()
+
+	return call()
+}
+
+// scanLimits bounds the work spent on a single transaction
+type scanLimits struct {
+	maxLogLines        int
+	maxEvents          int
+	maxInstructionSize int
+
+	// strictEventLength rejects log events longer than their form
+	strictEventLength bool
+	// failOnUnknownSwapType aborts the analysis at the first unknown swap variant
+	failOnUnknownSwapType bool
+}
+
+// defaultScanLimits returns limits generous enough for any regular transaction
+func defaultScanLimits() scanLimits {
+	return scanLimits{
+		maxLogLines:        20000,
+		maxEvents:          1024,
+		maxInstructionSize: 4096,
+	}
+}
+
+// WithMaxLogLines caps the number of log lines scanned for events
+func WithMaxLogLines(n int) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.limits.maxLogLines = n
+	}
+}
+
+// WithMaxInstructionSize caps the instruction data length accepted for parsing.
+// Larger instructions are reported as errors without being decoded. Values
+// below 1 disable the cap.
+func WithMaxInstructionSize(n int) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.limits.maxInstructionSize = n
+	}
+}
+
+// WithStrictEventLength rejects program data log events whose length is not
+// exactly that of a swap event, instead of decoding their first bytes
+func WithStrictEventLength() AnalyzerOption {
+	return func(a *Analyzer) {
+		a.limits.strictEventLength = true
+	}
+}
+
+// ErrUnknownSwapType is matched by the error Analyze and Decode return for a
+// route plan step with an unmapped swap variant index when
+// WithFailOnUnknownSwapType is set
+var ErrUnknownSwapType = errors.New("unknown swap type")
+
+// WithFailOnUnknownSwapType makes instruction decoding fail on the first route
+// plan step whose swap variant index is unmapped, instead of decoding it as
+// Unknown_N with an UnknownSwapVariant warning. Analyze aborts the whole
+// analysis; Decode returns the error when the option is given to SetDefault.
+// ParseInstructionLite does not decode swap variants and is not affected.
+// Meant for validation runs over fixtures.
+func WithFailOnUnknownSwapType() AnalyzerOption {
+	return func(a *Analyzer) {
+		a.limits.failOnUnknownSwapType = true
+	}
+}
+
+// WithMaxEvents caps the number of events collected per transaction
+func WithMaxEvents(n int) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.limits.maxEvents = n
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Enrichments that can be requested per call with ?enrich=a,b,c
+const (
+	EnrichDecimals     = "decimals"     // token amounts scaled by the registry decimals
+	EnrichSymbols      = "symbols"      // token symbols from the registry
+	EnrichHops         = "hops"         // AMM inner instruction decoding
+	EnrichMintRisk     = "mintrisk"     // mint and freeze authority, Token-2022 extensions
+	EnrichBondingCurve = "bondingcurve" // pump.fun bonding curve state
+	EnrichIntegrator   = "integrator"   // integrator attribution
+	EnrichPools        = "pools"        // route assessment against the pool registry
+)
+
+// knownEnrichments lists the accepted enrich values
+var knownEnrichments = []string{
+	EnrichDecimals, EnrichSymbols, EnrichHops, EnrichMintRisk,
+	EnrichBondingCurve, EnrichIntegrator, EnrichPools,
+}
+
+// AnalysisHandler serves analyses over HTTP from a shared base analyzer.
+// GET ?signature=<sig>&enrich=decimals,symbols runs the base analyzer with only
+// the listed enrichments enabled; without enrich no enrichment is performed.
+// Enrichments missing from the base analyzer configuration stay disabled.
+type AnalysisHandler struct {
+	base *Analyzer
+}
+
+// NewAnalysisHandler creates a handler analyzing with base
+func NewAnalysisHandler(base *Analyzer) *AnalysisHandler {
+	return &AnalysisHandler{base: base}
+}
+
+// parseEnrichments parses a comma separated enrich parameter
+func parseEnrichments(value string) (map[string]bool, error) {
+	enrich := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, k := range knownEnrichments {
+			if name == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unsupported enrichment %q, expected one of %s", name, strings.Join(knownEnrichments, ","))
+		}
+		enrich[name] = true
+	}
+	return enrich, nil
+}
+
+// enrichmentOptions returns the options disabling every enrichment not requested
+func (h *AnalysisHandler) enrichmentOptions(enrich map[string]bool) []AnalyzerOption {
+	var opts []AnalyzerOption
+	switch {
+	case enrich[EnrichDecimals] && enrich[EnrichSymbols]:
+	case enrich[EnrichDecimals] || enrich[EnrichSymbols]:
+		if h.base.tokenRegistry != nil {
+			opts = append(opts, WithTokenRegistry(partialTokenRegistry{
+				registry: h.base.tokenRegistry,
+				decimals: enrich[EnrichDecimals],
+				symbols:  enrich[EnrichSymbols],
+			}))
+		}
+	default:
+		opts = append(opts, WithTokenRegistry(nil))
+	}
+	if !enrich[EnrichHops] {
+		opts = append(opts, WithHopDecoding(false))
+	}
+	if !enrich[EnrichMintRisk] {
+		opts = append(opts, WithMintRiskProvider(nil))
+	}
+	if !enrich[EnrichBondingCurve] {
+		opts = append(opts, WithBondingCurveProvider(nil))
+	}
+	if !enrich[EnrichIntegrator] {
+		opts = append(opts, WithIntegratorRegistry(nil))
+	}
+	if !enrich[EnrichPools] {
+		opts = append(opts, WithPoolRegistry(nil))
+	}
+	return opts
+}
+
+// ServeHTTP analyzes the requested signature and writes the analysis as JSON
+func (h *AnalysisHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	signature, err := solana.SignatureFromBase58(query.Get("signature"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid signature: %v", err), http.StatusBadRequest)
+		return
+	}
+	if query.Has("verify") {
+		http.Error(w, "balance verification is not supported", http.StatusBadRequest)
+		return
+	}
+	enrich, err := parseEnrichments(query.Get("enrich"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	analysis, err := h.base.With(h.enrichmentOptions(enrich)...).AnalyzeSignature(r.Context(), signature)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, ErrTransactionNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(analysis); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// partialTokenRegistry exposes only the decimals or only the symbols of a registry
+type partialTokenRegistry struct {
+	registry TokenRegistry
+	decimals bool
+	symbols  bool
+}
+
+// Lookup returns the token info of mint, without decimals unless exposed and with
+// the mint address in place of the symbol unless exposed
+func (r partialTokenRegistry) Lookup(mint solana.PublicKey) (TokenInfo, bool) {
+	info, ok := r.registry.Lookup(mint)
+	if !ok {
+		return info, false
+	}
+	if !r.decimals {
+		info.Decimals = 0
+	}
+	if !r.symbols {
+		info.Symbol = mint.String()
+	}
+	return info, true
+}
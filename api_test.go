@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// apiFixture serves the ledger fixture, charging a 50 bps platform fee, from a
+// base analyzer with a token registry and an integrator registry
+func apiFixture(t *testing.T) (*Analyzer, *rpc.GetTransactionResult, *solana.Transaction) {
+	fixture, parsedTx := ledgerFixture(t)
+	parsedTx.Message.Instructions[0].Data = testInstruction("route", 0, [][]byte{{SwapTypeToIndex[SwapWhirlpool], 1, 100, 0, 1}}, 1000, 950, 50, 50)
+	tx := testTransactionResult(t, parsedTx, fixture.Meta)
+	mintB := testKey(3)
+	base := newTestAnalyzer(
+		WithTransactionSource(staticSource{tx}),
+		WithTokenRegistry(StaticTokenRegistry{mintB: {Symbol: "BBB", Decimals: 2}}),
+		WithIntegratorRegistry(&IntegratorRegistry{Accounts: map[solana.PublicKey]string{testKey(13): "app"}}),
+	)
+	return base, tx, parsedTx
+}
+
+// apiResponse holds the enriched fields of a served analysis
+type apiResponse struct {
+	ExecutionQuality struct {
+		SlippageAllowanceUI string `json:"slippage_allowance_ui"`
+	} `json:"execution_quality"`
+	Integrator string `json:"integrator"`
+}
+
+func TestAnalysisHandlerEnrichments(t *testing.T) {
+	base, tx, parsedTx := apiFixture(t)
+	mintB := testKey(3)
+	handler := NewAnalysisHandler(base)
+	signature := solana.Signature{1}.String()
+
+	cases := []struct {
+		enrich     string
+		slippage   string
+		integrator string
+	}{
+		{"", "up to 5 " + mintB.String() + " worse than quote", ""},
+		{"decimals", "up to 0.05 " + mintB.String() + " worse than quote", ""},
+		{"symbols", "up to 5 BBB worse than quote", ""},
+		{"decimals,symbols", "up to 0.05 BBB worse than quote", ""},
+		{"integrator", "up to 5 " + mintB.String() + " worse than quote", "app"},
+		{"decimals, symbols ,integrator", "up to 0.05 BBB worse than quote", "app"},
+	}
+	// Concurrent requests with different enrichments share the base analyzer
+	t.Run("parallel", func(t *testing.T) {
+		for round := range 8 {
+			for _, tt := range cases {
+				t.Run(fmt.Sprintf("%d/%s", round, tt.enrich), func(t *testing.T) {
+					t.Parallel()
+					recorder := httptest.NewRecorder()
+					handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/?signature="+signature+"&enrich="+url.QueryEscape(tt.enrich), nil))
+					if recorder.Code != http.StatusOK {
+						t.Fatalf("status %d: %s", recorder.Code, recorder.Body)
+					}
+					var got apiResponse
+					if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+						t.Fatal(err)
+					}
+					if got.ExecutionQuality.SlippageAllowanceUI != tt.slippage || got.Integrator != tt.integrator {
+						t.Errorf("slippage %q integrator %q, want %q %q",
+							got.ExecutionQuality.SlippageAllowanceUI, got.Integrator, tt.slippage, tt.integrator)
+					}
+				})
+			}
+		}
+	})
+
+	// The base analyzer keeps every enrichment
+	analysis := analyzeTest(t, base, tx, parsedTx)
+	if analysis.ExecutionQuality.SlippageAllowanceUI != "up to 0.05 BBB worse than quote" || analysis.Integrator != "app" {
+		t.Errorf("base analyzer changed: slippage %q integrator %q", analysis.ExecutionQuality.SlippageAllowanceUI, analysis.Integrator)
+	}
+}
+
+func TestAnalysisHandlerErrors(t *testing.T) {
+	base, _, _ := apiFixture(t)
+	handler := NewAnalysisHandler(base)
+	signature := solana.Signature{1}.String()
+	for query, status := range map[string]int{
+		"?signature=not-a-signature":                  http.StatusBadRequest,
+		"?signature=" + signature + "&verify=1":       http.StatusBadRequest,
+		"?signature=" + signature + "&enrich=prices":  http.StatusBadRequest,
+		"?signature=" + signature + "&enrich=symbols": http.StatusOK,
+	} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/"+query, nil))
+		if recorder.Code != status {
+			t.Errorf("%s: status %d, want %d", query, recorder.Code, status)
+		}
+	}
+}
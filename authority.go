@@ -0,0 +1,38 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// programAuthoritySeed is the PDA seed of the Jupiter program authorities,
+// sharedAccounts instructions select one with their id byte
+var programAuthoritySeed = []byte("authority")
+
+// programAuthorities caches the derived authority of each id
+var programAuthorities sync.Map // uint8 -> solana.PublicKey
+
+// ProgramAuthority derives the Jupiter program authority PDA for a sharedAccounts id
+func ProgramAuthority(id uint8) solana.PublicKey {
+	if cached, ok := programAuthorities.Load(id); ok {
+		return cached.(solana.PublicKey)
+	}
+	authority, _, err := solana.FindProgramAddress([][]byte{programAuthoritySeed, {id}}, jupiterV6ProgramID)
+	if err != nil {
+		// Only happens when no bump yields an off curve address
+		return solana.PublicKey{}
+	}
+	programAuthorities.Store(id, authority)
+	return authority
+}
+
+// programAuthorityRef returns the program authority of id for JupiterSwapParams,
+// nil when it cannot be derived
+func programAuthorityRef(id uint8) *solana.PublicKey {
+	authority := ProgramAuthority(id)
+	if authority.IsZero() {
+		return nil
+	}
+	return &authority
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// authorityID3 is the Jupiter program authority of id 3, as labeled by explorers
+var authorityID3 = solana.MustPublicKeyFromBase58("6U91aKa8pmMxkJwBCfPTmUEfZi6dHe7DcFq2ALvB2tbB")
+
+func TestProgramAuthority(t *testing.T) {
+	steps := [][]byte{testStep(0, 100, 0, 1)}
+	for _, instructionType := range []string{"sharedAccountsRoute", "sharedAccountsRouteWithTokenLedger", "sharedAccountsExactOutRoute"} {
+		params, err := parseJupiterV6Instruction(testInstruction(instructionType, 3, steps, 1000, 990, 50, 0))
+		if err != nil {
+			t.Fatalf("%s: %v", instructionType, err)
+		}
+		if params.AuthorityID != 3 || params.Authority == nil || !params.Authority.Equals(authorityID3) {
+			t.Errorf("%s: authority %d %v, want 3 %s", instructionType, params.AuthorityID, params.Authority, authorityID3)
+		}
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(encoded), `"authority":"`+authorityID3.String()+`"`) {
+			t.Errorf("%s: authority missing from %s", instructionType, encoded)
+		}
+	}
+
+	params, err := parseJupiterV6Instruction(testInstruction("route", 0, steps, 1000, 990, 50, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Authority != nil || strings.Contains(string(encoded), `"authority"`) {
+		t.Errorf("route has an authority: %s", encoded)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// accountIndex returns the index of account in the message account keys
+func accountIndex(accountKeys solana.PublicKeySlice, account solana.PublicKey) (int, bool) {
+	for i, key := range accountKeys {
+		if key.Equals(account) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// transactionAccountKeys returns the account keys balance indexes refer to: the
+// message keys followed, when the lookups of the message are not resolved, by
+// the loaded writable and readonly addresses recorded in meta
+func transactionAccountKeys(message *solana.Message, meta *rpc.TransactionMeta) solana.PublicKeySlice {
+	if message.IsResolved() || meta == nil {
+		return message.AccountKeys
+	}
+	keys := append(solana.PublicKeySlice{}, message.AccountKeys...)
+	keys = append(keys, meta.LoadedAddresses.Writable...)
+	return append(keys, meta.LoadedAddresses.ReadOnly...)
+}
+
+// findTokenBalance returns the token balance recorded for the account at index
+func findTokenBalance(balances []rpc.TokenBalance, index int) (*rpc.TokenBalance, bool) {
+	for i := range balances {
+		if int(balances[i].AccountIndex) == index {
+			return &balances[i], true
+		}
+	}
+	return nil, false
+}
+
+// tokenBalanceAmount returns the raw amount of a token balance
+func tokenBalanceAmount(balance *rpc.TokenBalance) (uint64, bool) {
+	if balance == nil || balance.UiTokenAmount == nil {
+		return 0, false
+	}
+	amount, err := strconv.ParseUint(balance.UiTokenAmount.Amount, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return amount, true
+}
+
+// netSolDelta returns the native SOL balance change of wallet in lamports,
+// including wrapped SOL accounts created and closed by the transaction but not
+// the transaction fee when wallet paid it
+func netSolDelta(wallet solana.PublicKey, accountKeys solana.PublicKeySlice, meta *rpc.TransactionMeta) (int64, bool) {
+	if meta == nil {
+		return 0, false
+	}
+	index, ok := accountIndex(accountKeys, wallet)
+	if !ok || index >= len(meta.PreBalances) || index >= len(meta.PostBalances) {
+		return 0, false
+	}
+
+	delta := int64(meta.PostBalances[index]) - int64(meta.PreBalances[index])
+	if index == 0 {
+		delta += int64(meta.Fee)
+	}
+	return delta, true
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestNetSolDelta(t *testing.T) {
+	payer, other, missing := testKey(1), testKey(2), testKey(3)
+	keys := solana.PublicKeySlice{payer, other}
+	// The payer paid a 5000 lamport fee and received 1 SOL from a closed
+	// wrapped SOL account, the other wallet spent 2 SOL
+	meta := &rpc.TransactionMeta{
+		Fee:          5000,
+		PreBalances:  []uint64{10_000_000_000, 3_000_000_000},
+		PostBalances: []uint64{10_999_995_000, 1_000_000_000},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		wallet solana.PublicKey
+		meta   *rpc.TransactionMeta
+		delta  int64
+		ok     bool
+	}{
+		{"fee payer", payer, meta, 1_000_000_000, true},
+		{"other signer", other, meta, -2_000_000_000, true},
+		{"not in transaction", missing, meta, 0, false},
+		{"no meta", payer, nil, 0, false},
+		{"short balances", other, &rpc.TransactionMeta{PreBalances: []uint64{1}, PostBalances: []uint64{1}}, 0, false},
+	} {
+		delta, ok := netSolDelta(tt.wallet, keys, tt.meta)
+		if delta != tt.delta || ok != tt.ok {
+			t.Errorf("%s: got %d, %v, want %d, %v", tt.name, delta, ok, tt.delta, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// BatchResult is the outcome of analyzing one signature of a batch
+type BatchResult struct {
+	Signature solana.Signature   `json:"signature"`
+	Analysis  *JupiterV6Analysis `json:"analysis,omitempty"`
+	Summary   *SwapSummary       `json:"summary,omitempty"`
+	Amounts   *AnalysisAmounts   `json:"amounts,omitempty"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// AnalyzeBatch analyzes signatures with up to workers concurrent fetches and
+// calls emit with each result in input order. A failed result keeps the
+// analysis when the error came with one. Rpc calls remain bounded by
+// WithMaxConcurrentRequests when set.
+func (a *Analyzer) AnalyzeBatch(ctx context.Context, signatures []solana.Signature, workers int, emit func(BatchResult)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]chan BatchResult, len(signatures))
+	for i := range results {
+		results[i] = make(chan BatchResult, 1)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := BatchResult{Signature: signatures[i]}
+
+				// A job may be handed out just before cancellation, do not start it
+				if err := ctx.Err(); err != nil {
+					result.Error = err.Error()
+					results[i] <- result
+					continue
+				}
+
+				// Errors such as *ForbiddenRouteError come with the analysis, keep it
+				analysis, err := a.AnalyzeSignature(ctx, signatures[i])
+				result.Analysis = analysis
+				if err != nil {
+					result.Error = err.Error()
+				}
+				results[i] <- result
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range signatures {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				for ; i < len(signatures); i++ {
+					results[i] <- BatchResult{Signature: signatures[i], Error: ctx.Err().Error()}
+				}
+				return
+			}
+		}
+	}()
+
+	for _, result := range results {
+		emit(<-result)
+	}
+	wg.Wait()
+}
+
+// readSignatures reads one signature per line. Blank lines and lines starting
+// with # are ignored, invalid lines are reported through invalid and skipped.
+func readSignatures(r io.Reader, invalid func(line int, text string, err error)) ([]solana.Signature, error) {
+	var signatures []solana.Signature
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		signature, err := solana.SignatureFromBase58(text)
+		if err != nil {
+			invalid(line, text, err)
+			continue
+		}
+		signatures = append(signatures, signature)
+	}
+	return signatures, scanner.Err()
+}
+
+// runSignaturesFile analyzes every signature of path and writes NDJSON to w,
+// keeping only the summary of each analysis when summaryOnly is set and adding
+// arbitrary precision amounts when bigAmounts is set
+func runSignaturesFile(ctx context.Context, analyzer *Analyzer, path string, workers int, summaryOnly, bigAmounts bool, w io.Writer) int {
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening signatures file: %v\n", err)
+		return 1
+	}
+	defer file.Close()
+
+	skipped := 0
+	signatures, err := readSignatures(file, func(line int, text string, err error) {
+		skipped++
+		fmt.Fprintf(os.Stderr, "Skipping line %d %q: %v\n", line, text, err)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading signatures file: %v\n", err)
+		return 1
+	}
+
+	failed := 0
+	encoder := json.NewEncoder(w)
+	analyzer.AnalyzeBatch(ctx, signatures, workers, func(result BatchResult) {
+		if result.Error != "" {
+			failed++
+		}
+		if bigAmounts && result.Analysis != nil {
+			result.Amounts = AmountsOf(result.Analysis, analyzer.tokenRegistry)
+		}
+		if summaryOnly && result.Analysis != nil {
+			result.Summary = &result.Analysis.Summary
+			result.Analysis = nil
+		}
+		if err := encoder.Encode(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing result for %s: %v\n", result.Signature, err)
+		}
+	})
+
+	fmt.Fprintf(os.Stderr, "Analyzed %d signatures, %d failed, %d lines skipped\n", len(signatures), failed, skipped)
+	if failed > 0 || skipped > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// blockingSource serves tx for signature {1} and blocks every other fetch
+// until the context is done, reporting each fetch on started
+type blockingSource struct {
+	tx      *rpc.GetTransactionResult
+	started chan solana.Signature
+
+	mu      sync.Mutex
+	fetched map[solana.Signature]bool
+}
+
+func (s *blockingSource) GetTransaction(ctx context.Context, signature solana.Signature, opts *rpc.GetTransactionOpts) (*rpc.GetTransactionResult, error) {
+	s.mu.Lock()
+	s.fetched[signature] = true
+	s.mu.Unlock()
+	if signature == (solana.Signature{1}) {
+		return s.tx, nil
+	}
+	s.started <- signature
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestAnalyzeBatchCancel(t *testing.T) {
+	tx, _ := ledgerFixture(t)
+	source := &blockingSource{tx: tx, started: make(chan solana.Signature, 2), fetched: make(map[solana.Signature]bool)}
+	a := newTestAnalyzer(WithTransactionSource(source))
+
+	signatures := make([]solana.Signature, 6)
+	for i := range signatures {
+		signatures[i] = solana.Signature{byte(i + 1)}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Cancel once both workers are blocked, on the second and third signature
+	go func() {
+		<-source.started
+		<-source.started
+		cancel()
+	}()
+
+	var results []BatchResult
+	a.AnalyzeBatch(ctx, signatures, 2, func(result BatchResult) {
+		results = append(results, result)
+	})
+
+	if len(results) != len(signatures) {
+		t.Fatalf("emitted %d results, want %d", len(results), len(signatures))
+	}
+	for i, result := range results {
+		if result.Signature != signatures[i] {
+			t.Errorf("result %d is %s, want %s", i, result.Signature, signatures[i])
+		}
+	}
+	if results[0].Error != "" || results[0].Analysis == nil {
+		t.Errorf("completed signature: error %q, analysis %v", results[0].Error, results[0].Analysis)
+	}
+	for i, result := range results[1:3] {
+		if !strings.Contains(result.Error, context.Canceled.Error()) {
+			t.Errorf("in flight result %d: error %q", i+1, result.Error)
+		}
+	}
+	for i, result := range results[3:] {
+		if result.Error != context.Canceled.Error() || result.Analysis != nil {
+			t.Errorf("unstarted result %d: error %q, analysis %v", i+3, result.Error, result.Analysis)
+		}
+		if source.fetched[result.Signature] {
+			t.Errorf("unstarted result %d was fetched", i+3)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+
+	"sol-tx/jupiterv6"
+)
+
+// Borsh layouts of the analysis structs, shared with non-Go consumers.
+// Integers are little endian, strings are a u32 length followed by UTF-8
+// bytes, vectors a u32 count followed by the elements.
+//
+//	Swap:              u8 variant index (SwapTypeToIndex) + variant fields as in the Jupiter IDL
+//	RoutePlanStep:     swap Swap, percent u8, input_index u8, output_index u8 (the IDL layout)
+//	SwapEvent:         amm [32]u8, input_mint [32]u8, input_amount u64,
+//	                   output_mint [32]u8, output_amount u64 (the IDL event fields)
+//	JupiterSwapParams: instruction_type string, instruction_index u32, authority_id u8,
+//	                   route_plan Vec<RoutePlanStep>, in_amount u64, out_amount u64,
+//	                   quoted_out_amount u64, quoted_in_amount u64, slippage_bps u16,
+//	                   platform_fee_bps u8, min_amount_out u64, expire_at Option<i64>
+//	                   (absent in data encoded before it was added)
+//
+// Fields derived after parsing (accounts, fees, wallet, token ledger) are not part
+// of the layouts.
+
+// swapEventBorshSize is the encoded size of a SwapEvent
+const swapEventBorshSize = 32 + 32 + 8 + 32 + 8
+
+// swapVariantIndex returns the enum variant index of a swap type
+func swapVariantIndex(swapType SwapType) (uint8, bool) {
+	if index, ok := SwapTypeToIndex[swapType]; ok {
+		return index, true
+	}
+	var index uint8
+	if _, err := fmt.Sscanf(string(swapType), "Unknown_%d", &index); err == nil {
+		return index, true
+	}
+	return 0, false
+}
+
+// MarshalBorsh encodes the swap as its enum variant index and fields. Swaps
+// with fields can only be encoded when they were decoded from instruction data.
+func (s Swap) MarshalBorsh() ([]byte, error) {
+	index, ok := swapVariantIndex(s.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown swap type %q", s.Type)
+	}
+	if s.raw == nil && jupiterv6.SwapFixedSize(index) > 0 {
+		return nil, fmt.Errorf("swap %s has no encoded fields", s.Type)
+	}
+	return append([]byte{index}, s.raw...), nil
+}
+
+// UnmarshalBorsh decodes a swap encoded by MarshalBorsh
+func (s *Swap) UnmarshalBorsh(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("%w: empty swap", errTruncatedInstruction)
+	}
+	swap, err := decodeSwapType(data[0], data, 1)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errTruncatedInstruction, err)
+	}
+	end := 1 + jupiterv6.SwapFixedSize(data[0]) + swap.variableSize
+	if end != len(data) {
+		return fmt.Errorf("swap %s is %d bytes, got %d", swap.Type, end, len(data))
+	}
+	swap.raw = data[1:end]
+	*s = swap
+	return nil
+}
+
+// appendBorsh appends the IDL encoding of the step
+func (step RoutePlanStep) appendBorsh(data []byte) ([]byte, error) {
+	swap, err := step.Swap.MarshalBorsh()
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, swap...)
+	return append(data, step.Percent, step.InputIndex, step.OutputIndex), nil
+}
+
+// MarshalBorsh encodes the step with the IDL layout
+func (step RoutePlanStep) MarshalBorsh() ([]byte, error) {
+	return step.appendBorsh(nil)
+}
+
+// UnmarshalBorsh decodes a step encoded by MarshalBorsh
+func (step *RoutePlanStep) UnmarshalBorsh(data []byte) error {
+	decoded, offset, err := parseRoutePlanStep(data, 0)
+	if err != nil {
+		return err
+	}
+	if offset != len(data) {
+		return fmt.Errorf("%d trailing bytes after route plan step", len(data)-offset)
+	}
+	*step = decoded
+	return nil
+}
+
+// MarshalBorsh encodes the event fields, without the emit-CPI prefix and discriminator
+func (e SwapEvent) MarshalBorsh() ([]byte, error) {
+	data := make([]byte, 0, swapEventBorshSize)
+	data = append(data, e.AMM[:]...)
+	data = append(data, e.InputMint[:]...)
+	data = binary.LittleEndian.AppendUint64(data, e.InputAmount)
+	data = append(data, e.OutputMint[:]...)
+	data = binary.LittleEndian.AppendUint64(data, e.OutputAmount)
+	return data, nil
+}
+
+// UnmarshalBorsh decodes an event encoded by MarshalBorsh
+func (e *SwapEvent) UnmarshalBorsh(data []byte) error {
+	if len(data) != swapEventBorshSize {
+		return fmt.Errorf("swap event is %d bytes, got %d", swapEventBorshSize, len(data))
+	}
+	*e = SwapEvent{
+		Discriminator: SwapEventDiscriminator,
+		Unknown:       swapEventTypeDiscriminator,
+		AMM:           solana.PublicKeyFromBytes(data[0:32]),
+		InputMint:     solana.PublicKeyFromBytes(data[32:64]),
+		InputAmount:   binary.LittleEndian.Uint64(data[64:72]),
+		OutputMint:    solana.PublicKeyFromBytes(data[72:104]),
+		OutputAmount:  binary.LittleEndian.Uint64(data[104:112]),
+	}
+	return nil
+}
+
+// MarshalBorsh encodes the parsed instruction fields
+func (p JupiterSwapParams) MarshalBorsh() ([]byte, error) {
+	data := binary.LittleEndian.AppendUint32(nil, uint32(len(p.InstructionType)))
+	data = append(data, p.InstructionType...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(p.InstructionIndex))
+	data = append(data, p.AuthorityID)
+
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(p.RoutePlan)))
+	for i, step := range p.RoutePlan {
+		var err error
+		if data, err = step.appendBorsh(data); err != nil {
+			return nil, fmt.Errorf("route plan step %d: %v", i, err)
+		}
+	}
+
+	data = binary.LittleEndian.AppendUint64(data, p.InAmount)
+	data = binary.LittleEndian.AppendUint64(data, p.OutAmount)
+	data = binary.LittleEndian.AppendUint64(data, p.QuotedOutAmount)
+	data = binary.LittleEndian.AppendUint64(data, p.QuotedInAmount)
+	data = binary.LittleEndian.AppendUint16(data, p.SlippageBps)
+	data = append(data, p.PlatformFeeBps)
+	data = binary.LittleEndian.AppendUint64(data, p.MinAmountOut)
+	if p.ExpireAt != nil {
+		data = append(data, 1)
+		data = binary.LittleEndian.AppendUint64(data, uint64(*p.ExpireAt))
+	} else {
+		data = append(data, 0)
+	}
+	return data, nil
+}
+
+// UnmarshalBorsh decodes instruction fields encoded by MarshalBorsh
+func (p *JupiterSwapParams) UnmarshalBorsh(data []byte) error {
+	truncated := fmt.Errorf("%w: borsh swap params", errTruncatedInstruction)
+	if len(data) < 4 {
+		return truncated
+	}
+	offset := 4
+	typeLen := int(binary.LittleEndian.Uint32(data[:4]))
+	if typeLen > len(data)-offset-4-1-4 {
+		return truncated
+	}
+	var decoded JupiterSwapParams
+	decoded.InstructionType = string(data[offset : offset+typeLen])
+	offset += typeLen
+	decoded.InstructionIndex = int(binary.LittleEndian.Uint32(data[offset : offset+4]))
+	offset += 4
+	decoded.AuthorityID = data[offset]
+	offset++
+
+	count := binary.LittleEndian.Uint32(data[offset : offset+4])
+	offset += 4
+	for i := uint32(0); i < count; i++ {
+		step, next, err := parseRoutePlanStep(data, offset)
+		if err != nil {
+			return fmt.Errorf("route plan step %d: %w", i, err)
+		}
+		decoded.RoutePlan = append(decoded.RoutePlan, step)
+		offset = next
+	}
+
+	end := offset + 8*4 + 2 + 1 + 8
+	if end > len(data) {
+		return fmt.Errorf("%w: borsh swap params amounts", errTruncatedInstruction)
+	}
+	// expire_at was appended later, data encoded before has no Option byte
+	expireAt, size, ok := jupiterv6.DecodeOptionalTail(data[end:])
+	if !ok || end+size != len(data) {
+		return fmt.Errorf("%w: borsh swap params expire_at", errTruncatedInstruction)
+	}
+	decoded.ExpireAt = expireAt
+	decoded.InAmount = binary.LittleEndian.Uint64(data[offset : offset+8])
+	decoded.OutAmount = binary.LittleEndian.Uint64(data[offset+8 : offset+16])
+	decoded.QuotedOutAmount = binary.LittleEndian.Uint64(data[offset+16 : offset+24])
+	decoded.QuotedInAmount = binary.LittleEndian.Uint64(data[offset+24 : offset+32])
+	decoded.SlippageBps = binary.LittleEndian.Uint16(data[offset+32 : offset+34])
+	decoded.PlatformFeeBps = data[offset+34]
+	decoded.MinAmountOut = binary.LittleEndian.Uint64(data[offset+35 : offset+43])
+
+	*p = decoded
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"sol-tx/jupiterv6"
+)
+
+// borshSwapFields are the variant fields and their decoded params of every
+// swap variant with fields; the others are unit variants
+var borshSwapFields = map[SwapType]struct {
+	fields []byte
+	params map[string]interface{}
+}{
+	SwapCrema:           {[]byte{1}, map[string]interface{}{"a_to_b": true}},
+	SwapSerum:           {[]byte{1}, map[string]interface{}{"side": "Ask"}},
+	SwapAldrin:          {[]byte{0}, map[string]interface{}{"side": "Bid"}},
+	SwapAldrinV2:        {[]byte{1}, map[string]interface{}{"side": "Ask"}},
+	SwapWhirlpool:       {[]byte{1}, map[string]interface{}{"a_to_b": true}},
+	SwapInvariant:       {[]byte{0}, map[string]interface{}{"x_to_y": false}},
+	SwapDeltaFi:         {[]byte{1}, map[string]interface{}{"stable": true}},
+	SwapMarcoPolo:       {[]byte{1}, map[string]interface{}{"x_to_y": true}},
+	SwapDradex:          {[]byte{0}, map[string]interface{}{"side": "Bid"}},
+	SwapOpenbook:        {[]byte{1}, map[string]interface{}{"side": "Ask"}},
+	SwapPhoenix:         {[]byte{0}, map[string]interface{}{"side": "Bid"}},
+	SwapOpenBookV2:      {[]byte{1}, map[string]interface{}{"side": "Ask"}},
+	SwapObric:           {[]byte{1}, map[string]interface{}{"x_to_y": true}},
+	SwapFoxClaimPartial: {[]byte{1}, map[string]interface{}{"is_y": true}},
+	SwapSolFi:           {[]byte{0}, map[string]interface{}{"is_quote_to_base": false}},
+	SwapSymmetry: {
+		[]byte{7, 0, 0, 0, 0, 0, 0, 0, 0x2a, 0, 0, 0, 0, 0, 0, 0},
+		map[string]interface{}{"from_token_id": uint64(7), "to_token_id": uint64(42)},
+	},
+	SwapStakeDexSwapViaStake:         {[]byte{0x10, 0x27, 0, 0}, map[string]interface{}{"bridge_stake_seed": uint32(10000)}},
+	SwapStakeDexPrefundWithdrawStake: {[]byte{5, 0, 0, 0}, map[string]interface{}{"bridge_stake_seed": uint32(5)}},
+	SwapClone: {
+		[]byte{2, 1, 0},
+		map[string]interface{}{"pool_index": uint8(2), "quantity_is_input": true, "quantity_is_collateral": false},
+	},
+	SwapSanctumS: {
+		[]byte{3, 4, 1, 0, 0, 0, 9, 0, 0, 0},
+		map[string]interface{}{"src_lst_value_calc_accs": uint8(3), "dst_lst_value_calc_accs": uint8(4), "src_lst_index": uint32(1), "dst_lst_index": uint32(9)},
+	},
+	SwapSanctumSAddLiquidity:    {[]byte{1, 6, 0, 0, 0}, map[string]interface{}{"lst_value_calc_accs": uint8(1), "lst_index": uint32(6)}},
+	SwapSanctumSRemoveLiquidity: {[]byte{2, 0, 1, 0, 0}, map[string]interface{}{"lst_value_calc_accs": uint8(2), "lst_index": uint32(256)}},
+	// a_to_b, then Some(RemainingAccountsInfo) with TransferHookA x1 and SupplementalTickArrays x2
+	SwapWhirlpoolSwapV2: {
+		[]byte{1, 1, 2, 0, 0, 0, 0, 1, 6, 2},
+		map[string]interface{}{"a_to_b": true, "remaining_accounts_info": &RemainingAccountsInfo{Slices: []RemainingAccountsSlice{
+			{AccountsType: 0, Length: 1},
+			{AccountsType: 6, Length: 2},
+		}}},
+	},
+}
+
+func TestSwapBorshRoundTrip(t *testing.T) {
+	for swapType, index := range SwapTypeToIndex {
+		t.Run(string(swapType), func(t *testing.T) {
+			want, ok := borshSwapFields[swapType]
+			if !ok {
+				if jupiterv6.SwapFixedSize(index) > 0 {
+					t.Fatalf("variant %d has fields but no borshSwapFields entry", index)
+				}
+				want.params = map[string]interface{}{}
+			}
+			data := append([]byte{index}, want.fields...)
+
+			var swap Swap
+			if err := swap.UnmarshalBorsh(data); err != nil {
+				t.Fatal(err)
+			}
+			if swap.Type != swapType || !reflect.DeepEqual(swap.Params, want.params) {
+				t.Errorf("decoded %s %v, want %s %v", swap.Type, swap.Params, swapType, want.params)
+			}
+
+			encoded, err := swap.MarshalBorsh()
+			if err != nil || !bytes.Equal(encoded, data) {
+				t.Fatalf("encoded %x, %v, want %x", encoded, err, data)
+			}
+			var again Swap
+			if err := again.UnmarshalBorsh(encoded); err != nil || !reflect.DeepEqual(again, swap) {
+				t.Errorf("round trip %+v, %v, want %+v", again, err, swap)
+			}
+
+			if len(want.fields) > 0 {
+				if err := again.UnmarshalBorsh(data[:len(data)-1]); err == nil {
+					t.Errorf("truncated fields decoded as %+v", again)
+				}
+				if err := again.UnmarshalBorsh(append(data, 0)); err == nil {
+					t.Errorf("trailing byte decoded as %+v", again)
+				}
+			}
+		})
+	}
+
+	if _, err := (Swap{Type: SwapWhirlpool}).MarshalBorsh(); err == nil {
+		t.Error("swap with fields and no encoded data was marshaled")
+	}
+}
+
+// Fixed vectors in the route instruction layout: the step bytes as they
+// follow the route plan length, and the params layout of a two hop route
+var (
+	// Whirlpool a_to_b, 100%, 0 -> 1
+	whirlpoolStepHex = "1101640001"
+	// WhirlpoolSwapV2 b_to_a with Some([TransferHookInput x1]), 100%, 1 -> 2
+	whirlpoolV2StepHex = "2f0001010000000301640102"
+	// route, step 1 Saber 100% 0 -> 1, step 2 Whirlpool a_to_b 100% 1 -> 2,
+	// in_amount 1_000_000, out_amount 0, quoted_out_amount 990_000,
+	// quoted_in_amount 0, slippage 50 bps, fee 0, min_amount_out 985_050, no expire_at
+	routeParamsHex = "05000000" + "726f757465" + "00000000" + "00" +
+		"02000000" + "00640001" + "1101640102" +
+		"40420f0000000000" + "0000000000000000" + "301b0f0000000000" + "0000000000000000" +
+		"3200" + "00" + "da070f0000000000" + "00"
+)
+
+func TestRoutePlanStepBorshVectors(t *testing.T) {
+	for _, tt := range []struct {
+		hex    string
+		want   SwapType
+		params map[string]interface{}
+		output uint8
+	}{
+		{whirlpoolStepHex, SwapWhirlpool, map[string]interface{}{"a_to_b": true}, 1},
+		{whirlpoolV2StepHex, SwapWhirlpoolSwapV2, map[string]interface{}{
+			"a_to_b":                  false,
+			"remaining_accounts_info": &RemainingAccountsInfo{Slices: []RemainingAccountsSlice{{AccountsType: 3, Length: 1}}},
+		}, 2},
+	} {
+		data, _ := hex.DecodeString(tt.hex)
+		var step RoutePlanStep
+		if err := step.UnmarshalBorsh(data); err != nil {
+			t.Fatalf("%s: %v", tt.want, err)
+		}
+		if step.Swap.Type != tt.want || !reflect.DeepEqual(step.Swap.Params, tt.params) || step.Percent != 100 || step.OutputIndex != tt.output {
+			t.Errorf("%s: decoded %+v", tt.want, step)
+		}
+		if encoded, err := step.MarshalBorsh(); err != nil || hex.EncodeToString(encoded) != tt.hex {
+			t.Errorf("%s: encoded %x, %v, want %s", tt.want, encoded, err, tt.hex)
+		}
+	}
+}
+
+func TestSwapParamsBorshVector(t *testing.T) {
+	data, _ := hex.DecodeString(routeParamsHex)
+	var params JupiterSwapParams
+	if err := params.UnmarshalBorsh(data); err != nil {
+		t.Fatal(err)
+	}
+	if params.InstructionType != "route" || len(params.RoutePlan) != 2 || params.RoutePlan[1].Swap.Type != SwapWhirlpool ||
+		params.InAmount != 1_000_000 || params.QuotedOutAmount != 990_000 || params.SlippageBps != 50 ||
+		params.MinAmountOut != 985_050 || params.ExpireAt != nil {
+		t.Errorf("decoded %+v", params)
+	}
+	if encoded, err := params.MarshalBorsh(); err != nil || hex.EncodeToString(encoded) != routeParamsHex {
+		t.Errorf("encoded %x, %v, want %s", encoded, err, routeParamsHex)
+	}
+
+	// the same route decoded from instruction data encodes to the same bytes
+	parsed, err := parseJupiterV6Instruction(testInstruction("route", 0, [][]byte{
+		testStep(0, 100, 0, 1), {0x11, 1, 100, 1, 2},
+	}, 1_000_000, 990_000, 50, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoded, err := parsed.MarshalBorsh(); err != nil || hex.EncodeToString(encoded) != routeParamsHex {
+		t.Errorf("parsed instruction encoded %x, %v, want %s", encoded, err, routeParamsHex)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// setupPrograms are the programs wallets add around a swap: compute budget,
+// account creation and closing, wrapping SOL and memos
+var setupPrograms = map[solana.PublicKey]bool{
+	solana.ComputeBudget:                      true,
+	solana.SystemProgramID:                    true,
+	solana.TokenProgramID:                     true,
+	solana.Token2022ProgramID:                 true,
+	solana.SPLAssociatedTokenAccountProgramID: true,
+	solana.MemoProgramID:                      true,
+	memoV1ProgramID:                           true,
+}
+
+// simpleSwapMaxCPIDepth is the deepest stack height of a regular swap:
+// Jupiter (1), an AMM (2), the token program (3) and a transfer hook or
+// nested pool program (4)
+const simpleSwapMaxCPIDepth = 4
+
+// describeTransactionShape records the programs outside Jupiter and setup
+// invoked at the top level, and the deepest invocation stack height found in the logs
+func describeTransactionShape(analysis *JupiterV6Analysis, tx *rpc.GetTransactionResult, parsedTx *solana.Transaction) {
+	seen := make(map[solana.PublicKey]bool)
+	for _, inst := range parsedTx.Message.Instructions {
+		if int(inst.ProgramIDIndex) >= len(parsedTx.Message.AccountKeys) {
+			continue
+		}
+		program := parsedTx.Message.AccountKeys[inst.ProgramIDIndex]
+		if program.Equals(jupiterV6ProgramID) || setupPrograms[program] || seen[program] {
+			continue
+		}
+		seen[program] = true
+		analysis.OtherPrograms = append(analysis.OtherPrograms, program)
+	}
+
+	if len(parsedTx.Message.Instructions) > 0 {
+		analysis.MaxCPIDepth = 1
+	}
+	if tx.Meta == nil {
+		return
+	}
+	// Inner instructions carry no stack height here, the invoke log lines do
+	for _, line := range tx.Meta.LogMessages {
+		if depth, ok := invokeDepth(line); ok && depth > analysis.MaxCPIDepth {
+			analysis.MaxCPIDepth = depth
+		}
+	}
+}
+
+// invokeDepth parses the stack height of a "Program <id> invoke [N]" log line
+func invokeDepth(line string) (int, bool) {
+	if !strings.HasPrefix(line, "Program ") || !strings.HasSuffix(line, "]") {
+		return 0, false
+	}
+	i := strings.LastIndex(line, " invoke [")
+	if i < 0 {
+		return 0, false
+	}
+	depth, err := strconv.Atoi(line[i+len(" invoke [") : len(line)-1])
+	if err != nil {
+		return 0, false
+	}
+	return depth, true
+}
+
+// IsSimpleSwap reports whether the transaction is just a swap: exactly one
+// Jupiter instruction, no top-level program besides Jupiter and setup
+// programs, and no invocation deeper than a regular swap needs. Transactions
+// failing it are bundles, typically from bots, arbitrage or flash loans.
+func (a *JupiterV6Analysis) IsSimpleSwap() bool {
+	return a.Stats.JupiterInstructions == 1 && len(a.OtherPrograms) == 0 && a.MaxCPIDepth <= simpleSwapMaxCPIDepth
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"github.com/gagliardetto/solana-go"
+)
+
+// Concentrated liquidity account layouts within the Jupiter remaining accounts.
+//
+// Each step starts with the AMM program account, followed by the accounts of
+// the AMM swap instruction. Classic variants pass a fixed number of tick
+// arrays; WhirlpoolSwapV2 passes three fixed ones plus the slices described by
+// its remaining_accounts_info, of which the SupplementalTickArrays* slices are
+// tick arrays.
+//
+//	Whirlpool (17):
+//	  [0]       Whirlpool program
+//	  [1..7]    token_program, token_authority, whirlpool, token_owner_account_a,
+//	            token_vault_a, token_owner_account_b, token_vault_b
+//	  [8..10]   tick_array_0, tick_array_1, tick_array_2
+//	  [11]      oracle
+//
+//	WhirlpoolSwapV2 (47):
+//	  [0]       Whirlpool program
+//	  [1..11]   token_program_a, token_program_b, memo_program, token_authority,
+//	            whirlpool, token_mint_a, token_mint_b, token_owner_account_a,
+//	            token_vault_a, token_owner_account_b, token_vault_b
+//	  [12..14]  tick_array_0, tick_array_1, tick_array_2
+//	  [15]      oracle
+//	  [16..]    remaining_accounts_info slices, in order
+//
+//	RaydiumClmm (26):
+//	  [0]       Raydium CLMM program
+//	  [1..9]    payer, amm_config, pool_state, input_token_account,
+//	            output_token_account, input_vault, output_vault,
+//	            observation_state, token_program
+//	  [10]      tick_array
+//
+// Crema (8) and RaydiumClmmV2 (40) are not mapped: their tick arrays are
+// trailing accounts whose count the instruction does not encode.
+var clmmTickArrayLayouts = map[SwapType]struct {
+	program      solana.PublicKey
+	first, count int // tick arrays, relative to the program account
+	fixed        int // accounts after the program account, before any remaining_accounts_info slice
+}{
+	SwapWhirlpool:       {whirlpoolProgramID, 8, 3, 11},
+	SwapWhirlpoolSwapV2: {whirlpoolProgramID, 12, 3, 15},
+	SwapRaydiumClmm:     {raydiumClmmProgramID, 10, 1, 10},
+}
+
+// mapCLMMTickArrays attaches the tick array accounts to concentrated liquidity
+// steps as "tick_arrays", and the supplemental tick arrays of WhirlpoolSwapV2
+// as "supplemental_tick_arrays". The nth step of a program is matched to the
+// nth occurrence of the program in the remaining accounts. Steps whose accounts
+// run past the account list are left untouched.
+func mapCLMMTickArrays(params *JupiterSwapParams, remaining solana.PublicKeySlice) {
+	positions := make(map[solana.PublicKey][]int)
+	for i, key := range remaining {
+		if key.Equals(whirlpoolProgramID) || key.Equals(raydiumClmmProgramID) {
+			positions[key] = append(positions[key], i)
+		}
+	}
+
+	occurrences := make(map[solana.PublicKey]int)
+	for i := range params.RoutePlan {
+		step := &params.RoutePlan[i]
+		layout, ok := clmmTickArrayLayouts[step.Swap.Type]
+		if !ok {
+			continue
+		}
+		occurrence := occurrences[layout.program]
+		if occurrence >= len(positions[layout.program]) {
+			continue
+		}
+		occurrences[layout.program]++
+		start := positions[layout.program][occurrence]
+		if start+layout.fixed >= len(remaining) {
+			continue
+		}
+
+		accounts := map[string]solana.PublicKeySlice{
+			"tick_arrays": remaining[start+layout.first : start+layout.first+layout.count],
+		}
+		if info, ok := step.Swap.Params["remaining_accounts_info"].(*RemainingAccountsInfo); ok {
+			var supplemental solana.PublicKeySlice
+			at := start + layout.fixed + 1
+			for _, slice := range info.Slices {
+				end := at + int(slice.Length)
+				if end > len(remaining) {
+					break
+				}
+				switch slice.AccountsType.String() {
+				case "SupplementalTickArrays", "SupplementalTickArraysOne", "SupplementalTickArraysTwo":
+					supplemental = append(supplemental, remaining[at:end]...)
+				}
+				at = end
+			}
+			if len(supplemental) > 0 {
+				accounts["supplemental_tick_arrays"] = supplemental
+			}
+		}
+
+		if step.Accounts == nil {
+			step.Accounts = accounts
+			continue
+		}
+		for name, keys := range accounts {
+			step.Accounts[name] = keys
+		}
+	}
+}
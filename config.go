@@ -0,0 +1,366 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// redactedValue replaces secrets in an exported Config
+const redactedValue = "REDACTED"
+
+// Config is the serializable configuration of an Analyzer. Providers,
+// registries, hooks and writers cannot be serialized and are passed as
+// Dependencies instead.
+type Config struct {
+	// RPCEndpoint creates the rpc client when Dependencies has none. Credentials
+	// in the URL are redacted by (*Analyzer).Config.
+	RPCEndpoint string `json:"rpc_endpoint,omitempty"`
+	// Commitment of every rpc call. getTransaction does not serve processed,
+	// transactions are then fetched at confirmed.
+	Commitment rpc.CommitmentType `json:"commitment"`
+
+	// MaxSupportedTransactionVersion null omits the field from GetTransaction
+	MaxSupportedTransactionVersion *uint64             `json:"max_supported_transaction_version"`
+	TransactionEncoding            solana.EncodingType `json:"transaction_encoding"`
+	TransactionCommitment          rpc.CommitmentType  `json:"transaction_commitment,omitempty"`
+	MaxConcurrentRequests          int                 `json:"max_concurrent_requests,omitempty"`
+	NoNetwork                      bool                `json:"no_network,omitempty"`
+	LenientLookups                 bool                `json:"lenient_lookups,omitempty"`
+
+	InstructionTypes []string      `json:"instruction_types,omitempty"`
+	Layouts          []LayoutRange `json:"layouts,omitempty"`
+	MaxLogLines      int           `json:"max_log_lines"`
+	MaxEvents        int           `json:"max_events"`
+	// MaxInstructionSize below 1 disables the cap
+	MaxInstructionSize int  `json:"max_instruction_size"`
+	StrictEventLength  bool `json:"strict_event_length,omitempty"`
+	ReturnDataEvents   bool `json:"return_data_events,omitempty"`
+	HopDecoding        bool `json:"hop_decoding,omitempty"`
+	// FailOnUnknownSwapType fails analyses using an unmapped swap variant, for validation runs
+	FailOnUnknownSwapType bool `json:"fail_on_unknown_swap_type,omitempty"`
+
+	// SlotTimes estimates the time of transactions returned without a block time
+	SlotTimes *SlotTimeEstimator `json:"slot_times,omitempty"`
+
+	Dust                DustThreshold `json:"dust"`
+	Sanity              SanityBounds  `json:"sanity"`
+	ForbiddenVariants   []SwapType    `json:"forbidden_variants,omitempty"`
+	ForbiddenRouteError bool          `json:"forbidden_route_error,omitempty"`
+}
+
+// Dependencies are the parts of an analyzer configuration that are not data.
+// Nil fields leave the corresponding feature disabled.
+type Dependencies struct {
+	RPCClient     *rpc.Client
+	Source        TransactionSource
+	LookupTables  LookupTableProvider
+	Hooks         Hooks
+	TokenRegistry TokenRegistry
+	PoolRegistry  PoolRegistry
+	Integrators   *IntegratorRegistry
+	MintRisks     MintRiskProvider
+	BondingCurves BondingCurveProvider
+	BlockFees     BlockFeeProvider
+	// LogOutput defaults to stdout
+	LogOutput io.Writer
+}
+
+// DefaultConfig returns the configuration of NewAnalyzer without options
+func DefaultConfig() Config {
+	txOpts := defaultTransactionOptions()
+	limits := defaultScanLimits()
+	return Config{
+		Commitment:                     rpc.CommitmentFinalized,
+		MaxSupportedTransactionVersion: txOpts.MaxSupportedTransactionVersion,
+		TransactionEncoding:            txOpts.Encoding,
+		MaxLogLines:                    limits.maxLogLines,
+		MaxEvents:                      limits.maxEvents,
+		MaxInstructionSize:             limits.maxInstructionSize,
+		Sanity:                         DefaultSanityBounds(),
+	}
+}
+
+// LoadConfig reads a JSON config. Fields missing from the file keep their DefaultConfig value.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return cfg, fmt.Errorf("error decoding config: %v", err)
+	}
+	return cfg, nil
+}
+
+// configFlags are the analyzer flags of the CLI, layered over the -config file
+type configFlags struct {
+	rpcEndpoint           string
+	commitment            string
+	maxConcurrentRequests int
+	noNetwork             bool
+	hopDecoding           bool
+	strictEventLength     bool
+	failOnUnknownSwapType bool
+}
+
+// registerConfigFlags defines the analyzer flags on fs
+func registerConfigFlags(fs *flag.FlagSet) *configFlags {
+	f := &configFlags{}
+	fs.StringVar(&f.rpcEndpoint, "rpc-endpoint", "", "rpc endpoint, the rate limited mainnet endpoint when empty")
+	fs.StringVar(&f.commitment, "commitment", "", "commitment of the rpc calls: processed, confirmed or finalized")
+	fs.IntVar(&f.maxConcurrentRequests, "max-concurrent-requests", 0, "bound on in-flight rpc calls, 0 for none")
+	fs.BoolVar(&f.noNetwork, "no-network", false, "perform no rpc calls")
+	fs.BoolVar(&f.hopDecoding, "hop-decoding", false, "decode the AMM inner instructions of each hop")
+	fs.BoolVar(&f.strictEventLength, "strict-event-length", false, "reject swap events with unexpected lengths")
+	fs.BoolVar(&f.failOnUnknownSwapType, "fail-on-unknown-swap-type", false, "fail analyses using an unmapped swap variant")
+	return f
+}
+
+// apply overrides the fields of cfg whose flag was set on fs
+func (f *configFlags) apply(cfg *Config, fs *flag.FlagSet) {
+	fs.Visit(func(set *flag.Flag) {
+		switch set.Name {
+		case "rpc-endpoint":
+			cfg.RPCEndpoint = f.rpcEndpoint
+		case "commitment":
+			cfg.Commitment = rpc.CommitmentType(f.commitment)
+		case "max-concurrent-requests":
+			cfg.MaxConcurrentRequests = f.maxConcurrentRequests
+		case "no-network":
+			cfg.NoNetwork = f.noNetwork
+		case "hop-decoding":
+			cfg.HopDecoding = f.hopDecoding
+		case "strict-event-length":
+			cfg.StrictEventLength = f.strictEventLength
+		case "fail-on-unknown-swap-type":
+			cfg.FailOnUnknownSwapType = f.failOnUnknownSwapType
+		}
+	})
+}
+
+// Validate reports every invalid field and inconsistent combination of the config
+func (c Config) Validate() error {
+	var problems []string
+
+	if !validCommitment(c.Commitment) || c.Commitment == "" {
+		problems = append(problems, fmt.Sprintf("commitment %q must be processed, confirmed or finalized", c.Commitment))
+	}
+	if c.TransactionCommitment == rpc.CommitmentProcessed || !validCommitment(c.TransactionCommitment) {
+		problems = append(problems, fmt.Sprintf("transaction_commitment %q must be confirmed or finalized, getTransaction does not serve processed", c.TransactionCommitment))
+	}
+	switch c.TransactionEncoding {
+	case solana.EncodingBase58, solana.EncodingBase64, solana.EncodingBase64Zstd:
+	default:
+		problems = append(problems, fmt.Sprintf("transaction_encoding %q must be a binary encoding (base58, base64, base64+zstd)", c.TransactionEncoding))
+	}
+	if c.NoNetwork && c.RPCEndpoint != "" {
+		problems = append(problems, "no_network forbids rpc calls, remove rpc_endpoint or no_network")
+	}
+	if c.RPCEndpoint != "" {
+		if u, err := url.Parse(c.RPCEndpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("rpc_endpoint %q is not an absolute URL", c.RPCEndpoint))
+		}
+	}
+	if c.MaxConcurrentRequests < 0 {
+		problems = append(problems, "max_concurrent_requests must not be negative, use 0 for no bound")
+	}
+	if c.Sanity.MaxRouteSteps < 0 {
+		problems = append(problems, "sanity.max_route_steps must not be negative, use 0 for no bound")
+	}
+
+	for _, t := range c.InstructionTypes {
+		if _, ok := InstructionDiscriminators[t]; !ok {
+			problems = append(problems, fmt.Sprintf("instruction_types: unknown instruction type %q", t))
+		}
+	}
+	for i, r := range c.Layouts {
+		if _, ok := layoutDecoders[r.Version]; !ok {
+			problems = append(problems, fmt.Sprintf("layouts[%d]: unknown version %q", i, r.Version))
+		}
+		if r.FromSlot > r.ToSlot {
+			problems = append(problems, fmt.Sprintf("layouts[%d]: from_slot %d is after to_slot %d", i, r.FromSlot, r.ToSlot))
+		}
+	}
+	if c.MaxLogLines < 1 {
+		problems = append(problems, "max_log_lines must be positive")
+	}
+	if c.MaxEvents < 1 {
+		problems = append(problems, "max_events must be positive")
+	}
+
+	for _, variant := range c.ForbiddenVariants {
+		if _, ok := SwapTypeToIndex[variant]; !ok {
+			problems = append(problems, fmt.Sprintf("forbidden_variants: unknown swap type %q", variant))
+		}
+	}
+	if c.ForbiddenRouteError && len(c.ForbiddenVariants) == 0 {
+		problems = append(problems, "forbidden_route_error has no effect without forbidden_variants")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validCommitment reports whether commitment is a known commitment level or empty
+func validCommitment(commitment rpc.CommitmentType) bool {
+	switch commitment {
+	case "", rpc.CommitmentProcessed, rpc.CommitmentConfirmed, rpc.CommitmentFinalized:
+		return true
+	}
+	return false
+}
+
+// NewAnalyzerFromConfig validates cfg and creates the analyzer it describes.
+// The rpc client of deps takes precedence over cfg.RPCEndpoint.
+func NewAnalyzerFromConfig(cfg Config, deps Dependencies) (*Analyzer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	client := deps.RPCClient
+	if client == nil && cfg.RPCEndpoint != "" {
+		client = rpc.New(cfg.RPCEndpoint)
+	}
+
+	opts := []AnalyzerOption{
+		WithCommitment(cfg.Commitment),
+		WithTransactionOptions(TransactionOptions{
+			MaxSupportedTransactionVersion: cfg.MaxSupportedTransactionVersion,
+			Encoding:                       cfg.TransactionEncoding,
+			Commitment:                     cfg.TransactionCommitment,
+		}),
+		WithMaxConcurrentRequests(cfg.MaxConcurrentRequests),
+		WithLayoutTable(cfg.Layouts),
+		WithMaxLogLines(cfg.MaxLogLines),
+		WithMaxEvents(cfg.MaxEvents),
+		WithMaxInstructionSize(cfg.MaxInstructionSize),
+		WithReturnDataEvents(cfg.ReturnDataEvents),
+		WithHopDecoding(cfg.HopDecoding),
+		WithDustThreshold(cfg.Dust),
+		WithSanityBounds(cfg.Sanity),
+		WithSlotTimeEstimator(cfg.SlotTimes),
+		WithHooks(deps.Hooks),
+		WithTokenRegistry(deps.TokenRegistry),
+		WithPoolRegistry(deps.PoolRegistry),
+		WithIntegratorRegistry(deps.Integrators),
+		WithMintRiskProvider(deps.MintRisks),
+		WithBondingCurveProvider(deps.BondingCurves),
+		WithPriorityFeeProvider(deps.BlockFees),
+	}
+	if cfg.InstructionTypes != nil {
+		opts = append(opts, WithInstructionTypes(cfg.InstructionTypes...))
+	}
+	if cfg.StrictEventLength {
+		opts = append(opts, WithStrictEventLength())
+	}
+	if cfg.FailOnUnknownSwapType {
+		opts = append(opts, WithFailOnUnknownSwapType())
+	}
+	if cfg.LenientLookups {
+		opts = append(opts, WithLenientLookups())
+	}
+	if len(cfg.ForbiddenVariants) > 0 {
+		opts = append(opts, WithForbiddenVariants(cfg.ForbiddenVariants...))
+	}
+	if cfg.ForbiddenRouteError {
+		opts = append(opts, WithForbiddenRouteError())
+	}
+	if deps.Source != nil {
+		opts = append(opts, WithTransactionSource(deps.Source))
+	}
+	if deps.LookupTables != nil {
+		opts = append(opts, WithLookupTableProvider(deps.LookupTables))
+	}
+	if deps.LogOutput != nil {
+		opts = append(opts, WithLogOutput(deps.LogOutput))
+	}
+	if cfg.NoNetwork {
+		opts = append(opts, WithNoNetwork())
+	}
+
+	a := NewAnalyzer(client, opts...)
+	if client != deps.RPCClient && !a.noNetwork {
+		a.endpoint = cfg.RPCEndpoint
+	}
+	return a, nil
+}
+
+// Config returns the effective configuration of the analyzer. Credentials in
+// the rpc endpoint are redacted; dependencies are not part of the result.
+func (a *Analyzer) Config() Config {
+	cfg := Config{
+		RPCEndpoint:                    redactEndpoint(a.endpoint),
+		Commitment:                     a.commitment,
+		MaxSupportedTransactionVersion: a.txOpts.MaxSupportedTransactionVersion,
+		TransactionEncoding:            a.txOpts.Encoding,
+		TransactionCommitment:          a.txOpts.Commitment,
+		MaxConcurrentRequests:          cap(a.rpcSlots),
+		NoNetwork:                      a.noNetwork,
+		LenientLookups:                 a.lenientLookups,
+		Layouts:                        a.layouts,
+		MaxLogLines:                    a.limits.maxLogLines,
+		MaxEvents:                      a.limits.maxEvents,
+		MaxInstructionSize:             a.limits.maxInstructionSize,
+		StrictEventLength:              a.limits.strictEventLength,
+		FailOnUnknownSwapType:          a.limits.failOnUnknownSwapType,
+		ReturnDataEvents:               a.returnDataEvents,
+		HopDecoding:                    a.decodeHops,
+		Dust:                           a.dust,
+		Sanity:                         a.sanity,
+		SlotTimes:                      a.slotTimes,
+		ForbiddenRouteError:            a.forbiddenRouteError,
+	}
+
+	if a.instructionTypes != nil {
+		cfg.InstructionTypes = make([]string, 0, len(a.instructionTypes))
+		for t := range a.instructionTypes {
+			cfg.InstructionTypes = append(cfg.InstructionTypes, t)
+		}
+		sort.Strings(cfg.InstructionTypes)
+	}
+	for variant := range a.forbiddenVariants {
+		cfg.ForbiddenVariants = append(cfg.ForbiddenVariants, variant)
+	}
+	sort.Slice(cfg.ForbiddenVariants, func(i, j int) bool {
+		return SwapTypeToIndex[cfg.ForbiddenVariants[i]] < SwapTypeToIndex[cfg.ForbiddenVariants[j]]
+	})
+	return cfg
+}
+
+// redactEndpoint hides the user info, query values and path of an rpc URL,
+// where providers put their API keys
+func redactEndpoint(endpoint string) string {
+	if endpoint == "" {
+		return ""
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return redactedValue
+	}
+	if u.User != nil {
+		u.User = url.User(redactedValue)
+	}
+	if u.Path != "" && u.Path != "/" {
+		u.Path = "/" + redactedValue
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query.Set(key, redactedValue)
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Corpus layout
+const (
+	defaultCorpusDir = "testdata/corpus"
+	corpusIndexFile  = "index.json"
+)
+
+// CorpusEntry describes one recorded regression case
+type CorpusEntry struct {
+	Label     string   `json:"label"`
+	Signature string   `json:"signature"`
+	Slot      uint64   `json:"slot"`
+	Variants  []string `json:"variants"`
+}
+
+// CorpusResult is the outcome of re-analyzing one corpus entry
+type CorpusResult struct {
+	Entry CorpusEntry
+	Diff  SnapshotDiff
+	Err   error
+}
+
+// Passed reports whether the entry still matches its expectation
+func (r CorpusResult) Passed() bool {
+	return r.Err == nil && r.Diff.Empty()
+}
+
+// corpusPaths returns the fixture and expectation paths of a label
+func corpusPaths(dir, label string) (fixture, expected string) {
+	return filepath.Join(dir, label+".tx.json"), filepath.Join(dir, label+".expected.json")
+}
+
+// loadCorpusIndex reads the corpus index, an absent index is empty
+func loadCorpusIndex(dir string) ([]CorpusEntry, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, corpusIndexFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []CorpusEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("error decoding corpus index: %v", err)
+	}
+	return entries, nil
+}
+
+// saveCorpusIndex writes the corpus index sorted by label
+func saveCorpusIndex(dir string, entries []CorpusEntry) error {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Label < entries[j].Label })
+	return writeJSONFile(filepath.Join(dir, corpusIndexFile), entries)
+}
+
+// writeJSONFile writes v as indented JSON
+func writeJSONFile(path string, v interface{}) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(raw, '\n'), 0o644)
+}
+
+// sanitizeFixture keeps only what the analyzer reads from a transaction result
+func sanitizeFixture(tx *rpc.GetTransactionResult) *rpc.GetTransactionResult {
+	sanitized := &rpc.GetTransactionResult{
+		Slot:        tx.Slot,
+		BlockTime:   tx.BlockTime,
+		Transaction: tx.Transaction,
+		Version:     tx.Version,
+	}
+	if tx.Meta != nil {
+		meta := *tx.Meta
+		meta.Rewards = nil
+		sanitized.Meta = &meta
+	}
+	return sanitized
+}
+
+// routeVariants lists the distinct swap variants used by the analysis routes
+func routeVariants(analysis *JupiterV6Analysis) []string {
+	seen := make(map[string]bool)
+	var variants []string
+	for _, inst := range analysis.Instructions {
+		for _, step := range inst.RoutePlan {
+			name := string(step.Swap.Type)
+			if !seen[name] {
+				seen[name] = true
+				variants = append(variants, name)
+			}
+		}
+	}
+	sort.Strings(variants)
+	return variants
+}
+
+// addCorpusEntry fetches signature, stores its sanitized fixture and records
+// the current analysis as the expectation
+func addCorpusEntry(ctx context.Context, analyzer *Analyzer, dir, label string, signature solana.Signature) (CorpusEntry, error) {
+	entries, err := loadCorpusIndex(dir)
+	if err != nil {
+		return CorpusEntry{}, err
+	}
+	for _, entry := range entries {
+		if entry.Label == label {
+			return CorpusEntry{}, fmt.Errorf("corpus label %q already exists", label)
+		}
+	}
+
+	tx, _, err := analyzer.fetchTransaction(ctx, signature)
+	if err != nil {
+		return CorpusEntry{}, err
+	}
+	fixture := sanitizeFixture(tx)
+
+	analysis, err := NewAnalyzer(nil, WithNoNetwork(), WithLogOutput(io.Discard)).analyzeOffline(fixture)
+	if err != nil {
+		return CorpusEntry{}, fmt.Errorf("error analyzing %s: %v", signature, err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return CorpusEntry{}, err
+	}
+	fixturePath, expectedPath := corpusPaths(dir, label)
+	if err := writeJSONFile(fixturePath, fixture); err != nil {
+		return CorpusEntry{}, err
+	}
+	if err := writeJSONFile(expectedPath, analysis); err != nil {
+		return CorpusEntry{}, err
+	}
+
+	entry := CorpusEntry{
+		Label:     label,
+		Signature: signature.String(),
+		Slot:      fixture.Slot,
+		Variants:  routeVariants(analysis),
+	}
+	return entry, saveCorpusIndex(dir, append(entries, entry))
+}
+
+// analyzeCorpusEntry re-analyzes the fixture of entry and returns its JSON output
+func analyzeCorpusEntry(dir string, entry CorpusEntry) ([]byte, error) {
+	fixturePath, _ := corpusPaths(dir, entry.Label)
+	tx, err := loadFixture(fixturePath)
+	if err != nil {
+		return nil, err
+	}
+	analysis, err := NewAnalyzer(nil, WithNoNetwork(), WithLogOutput(io.Discard)).analyzeOffline(tx)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(analysis, "", "  ")
+}
+
+// runCorpus re-analyzes every entry and compares it with its expectation
+func runCorpus(dir string) ([]CorpusResult, error) {
+	entries, err := loadCorpusIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]CorpusResult, 0, len(entries))
+	for _, entry := range entries {
+		result := CorpusResult{Entry: entry, Diff: SnapshotDiff{Fixture: entry.Label}}
+		current, err := analyzeCorpusEntry(dir, entry)
+		if err == nil {
+			_, expectedPath := corpusPaths(dir, entry.Label)
+			var expected []byte
+			expected, err = os.ReadFile(expectedPath)
+			if err == nil {
+				result.Diff, err = diffSnapshots(entry.Label, expected, current, nil)
+			}
+		}
+		result.Err = err
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// updateCorpusExpectations rewrites the expectations of the given labels, or all when empty
+func updateCorpusExpectations(dir string, labels []string) error {
+	entries, err := loadCorpusIndex(dir)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		wanted[label] = true
+	}
+
+	updated := 0
+	for _, entry := range entries {
+		if len(wanted) > 0 && !wanted[entry.Label] {
+			continue
+		}
+		current, err := analyzeCorpusEntry(dir, entry)
+		if err != nil {
+			return fmt.Errorf("error analyzing %s: %v", entry.Label, err)
+		}
+		_, expectedPath := corpusPaths(dir, entry.Label)
+		if err := os.WriteFile(expectedPath, append(current, '\n'), 0o644); err != nil {
+			return err
+		}
+		updated++
+	}
+	if updated < len(wanted) {
+		return fmt.Errorf("%d of %d labels not found in corpus", len(wanted)-updated, len(wanted))
+	}
+	return nil
+}
+
+// writeCorpusMatrix prints one pass/fail line per entry
+func writeCorpusMatrix(w io.Writer, results []CorpusResult) {
+	passed := 0
+	for _, result := range results {
+		status := "PASS"
+		detail := ""
+		switch {
+		case result.Err != nil:
+			status = "ERROR"
+			detail = result.Err.Error()
+		case !result.Diff.Empty():
+			status = "FAIL"
+			detail = fmt.Sprintf("%d added, %d removed, %d changed", len(result.Diff.Added), len(result.Diff.Removed), len(result.Diff.Changed))
+		default:
+			passed++
+		}
+		fmt.Fprintf(w, "%-5s %-32s %-10d %s %s\n", status, result.Entry.Label, result.Entry.Slot, strings.Join(result.Entry.Variants, ","), detail)
+	}
+	fmt.Fprintf(w, "%d/%d passed\n", passed, len(results))
+}
+
+// runCorpusCommand implements "corpus add <sig> -label name", "corpus run" and
+// "corpus update [label...]"
+func runCorpusCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: corpus add <signature> -label <name> | corpus run [-v] | corpus update [label...]")
+		return 2
+	}
+
+	flags := flag.NewFlagSet("corpus "+args[0], flag.ContinueOnError)
+	dir := flags.String("dir", defaultCorpusDir, "corpus directory")
+	label := flags.String("label", "", "label of the added case")
+	verbose := flags.Bool("v", false, "print the differences of failing cases")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	// Allow flags after the positional arguments
+	var positional []string
+	for flags.NArg() > 0 {
+		positional = append(positional, flags.Arg(0))
+		if err := flags.Parse(flags.Args()[1:]); err != nil {
+			return 2
+		}
+	}
+
+	switch args[0] {
+	case "add":
+		if len(positional) != 1 || *label == "" {
+			fmt.Fprintln(os.Stderr, "usage: corpus add <signature> -label <name>")
+			return 2
+		}
+		signature, err := solana.SignatureFromBase58(positional[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid signature: %v\n", err)
+			return 2
+		}
+		entry, err := addCorpusEntry(context.Background(), NewAnalyzer(newMainnetRPCClient()), *dir, *label, signature)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error adding corpus entry: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Added %s (slot %d, variants %s), review %s\n", entry.Label, entry.Slot, strings.Join(entry.Variants, ","), entry.Label+".expected.json")
+		return 0
+	case "run":
+		results, err := runCorpus(*dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error running corpus: %v\n", err)
+			return 1
+		}
+		writeCorpusMatrix(os.Stdout, results)
+		failed := false
+		for _, result := range results {
+			if !result.Passed() {
+				failed = true
+				if *verbose && result.Err == nil {
+					writeSnapshotReport(os.Stdout, []SnapshotDiff{result.Diff})
+				}
+			}
+		}
+		if failed {
+			return 1
+		}
+		return 0
+	case "update":
+		if err := updateCorpusExpectations(*dir, positional); err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating expectations: %v\n", err)
+			return 1
+		}
+		fmt.Println("Corpus expectations updated.")
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown corpus command %q\n", args[0])
+		return 2
+	}
+}
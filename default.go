@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ErrDefaultAnalyzerInUse is returned by SetDefault once the default analyzer was created
+var ErrDefaultAnalyzerInUse = errors.New("default analyzer already in use")
+
+// defaults backs the package level Analyze and Decode helpers. The analyzer is
+// created on first use from the options given to SetDefault.
+var defaults struct {
+	mu      sync.Mutex
+	opts    []AnalyzerOption
+	created bool
+
+	once     sync.Once
+	analyzer *Analyzer
+	lookups  *lookupTableCache
+}
+
+// SetDefault configures the default analyzer used by Analyze and Decode. It must
+// be called before their first use and returns ErrDefaultAnalyzerInUse afterwards.
+func SetDefault(opts ...AnalyzerOption) error {
+	defaults.mu.Lock()
+	defer defaults.mu.Unlock()
+	if defaults.created {
+		return ErrDefaultAnalyzerInUse
+	}
+	defaults.opts = append([]AnalyzerOption(nil), opts...)
+	return nil
+}
+
+// defaultAnalyzer returns the default analyzer, creating it on first use.
+// Progress messages are discarded unless SetDefault overrides the log output.
+func defaultAnalyzer() *Analyzer {
+	defaults.once.Do(func() {
+		defaults.mu.Lock()
+		defaults.created = true
+		opts := append([]AnalyzerOption{WithLogOutput(io.Discard)}, defaults.opts...)
+		defaults.mu.Unlock()
+
+		defaults.analyzer = NewAnalyzer(nil, opts...)
+		defaults.lookups = &lookupTableCache{tables: make(map[solana.PublicKey]solana.PublicKeySlice)}
+	})
+	return defaults.analyzer
+}
+
+// Analyze fetches and analyzes the transaction for signature with the default
+// analyzer. Lookup tables fetched through any client are cached for the process,
+// tables extended after being cached are not refetched.
+func Analyze(ctx context.Context, client *rpc.Client, signature solana.Signature) (*JupiterV6Analysis, error) {
+	base := defaultAnalyzer()
+
+	a := *base
+	a.rpcClient = client
+	if a.source == nil {
+		a.source = NewRPCTransactionSource(client)
+	}
+	if a.lookupTables == nil {
+		a.lookupTables = cachedLookupTables{cache: defaults.lookups, upstream: rpcLookupTables{a: &a}}
+	}
+	if a.noNetwork {
+		a.disableNetwork()
+	}
+	return a.AnalyzeSignature(ctx, signature)
+}
+
+// Decode parses Jupiter instruction data with the default analyzer, using the current layout
+func Decode(data []byte) (*JupiterSwapParams, error) {
+	return defaultAnalyzer().parseInstruction(data, math.MaxUint64)
+}
+
+// lookupTableCache holds lookup table addresses shared between analyzers
+type lookupTableCache struct {
+	mu     sync.RWMutex
+	tables map[solana.PublicKey]solana.PublicKeySlice
+}
+
+// cachedLookupTables serves lookup tables from cache, fetching misses from upstream
+type cachedLookupTables struct {
+	cache    *lookupTableCache
+	upstream LookupTableProvider
+}
+
+// GetLookupTable returns the cached addresses of tableID or fetches them
+func (p cachedLookupTables) GetLookupTable(ctx context.Context, tableID solana.PublicKey) (solana.PublicKeySlice, error) {
+	p.cache.mu.RLock()
+	addresses, ok := p.cache.tables[tableID]
+	p.cache.mu.RUnlock()
+	if ok {
+		return addresses, nil
+	}
+
+	addresses, err := p.upstream.GetLookupTable(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+	p.cache.mu.Lock()
+	p.cache.tables[tableID] = addresses
+	p.cache.mu.Unlock()
+	return addresses, nil
+}
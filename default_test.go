@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// resetDefaults forgets the default analyzer so each test configures its own
+func resetDefaults(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		defaults.mu.Lock()
+		defaults.opts, defaults.created = nil, false
+		defaults.mu.Unlock()
+		defaults.once = sync.Once{}
+		defaults.analyzer, defaults.lookups = nil, nil
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestDefaultAnalyzerConcurrentFirstUse(t *testing.T) {
+	resetDefaults(t)
+
+	var created atomic.Int32
+	countCreated := func(*Analyzer) { created.Add(1) }
+	if err := SetDefault(WithNoNetwork(), countCreated); err != nil {
+		t.Fatal(err)
+	}
+
+	data := testInstruction("route", 0, [][]byte{testStep(0, 100, 0, 1)}, 1000, 990, 50, 0)
+	const goroutines = 16
+	analyzers := make([]*Analyzer, goroutines)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			params, err := Decode(data)
+			if err != nil || params.InAmount != 1000 {
+				t.Errorf("goroutine %d: %+v, %v", i, params, err)
+			}
+			analyzers[i] = defaultAnalyzer()
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if n := created.Load(); n != 1 {
+		t.Errorf("default analyzer created %d times, want 1", n)
+	}
+	for i, a := range analyzers {
+		if a != analyzers[0] {
+			t.Errorf("goroutine %d used analyzer %p, goroutine 0 used %p", i, a, analyzers[0])
+		}
+	}
+
+	if err := SetDefault(WithNoNetwork()); !errors.Is(err, ErrDefaultAnalyzerInUse) {
+		t.Errorf("SetDefault after first use = %v, want %v", err, ErrDefaultAnalyzerInUse)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import "github.com/gagliardetto/solana-go"
+
+// DustThreshold marks swap events moving fewer base units than the threshold
+// of their mint as dust. Dust events stay in Events but are left out of the
+// summary and trades. A zero threshold keeps everything.
+type DustThreshold struct {
+	// Default applies to mints without an override, in base units
+	Default uint64 `json:"default"`
+	// PerMint overrides the default for specific mints, in base units
+	PerMint map[solana.PublicKey]uint64 `json:"per_mint,omitempty"`
+}
+
+// forMint returns the threshold applying to mint
+func (d DustThreshold) forMint(mint solana.PublicKey) uint64 {
+	if threshold, ok := d.PerMint[mint]; ok {
+		return threshold
+	}
+	return d.Default
+}
+
+// isDust reports whether either side of the event is below its mint threshold
+func (d DustThreshold) isDust(event SwapEvent) bool {
+	return event.InputAmount < d.forMint(event.InputMint) || event.OutputAmount < d.forMint(event.OutputMint)
+}
+
+// WithDustThreshold flags events below threshold as dust
+func WithDustThreshold(threshold DustThreshold) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.dust = threshold
+	}
+}
+
+// markDustEvents sets the Dust flag of every event below threshold
+func markDustEvents(events []SwapEvent, threshold DustThreshold) {
+	for i := range events {
+		events[i].Dust = threshold.isDust(events[i])
+	}
+}
+
+// nonDustEvents returns the events not flagged as dust
+func nonDustEvents(events []SwapEvent) []SwapEvent {
+	kept := make([]SwapEvent, 0, len(events))
+	for _, event := range events {
+		if !event.Dust {
+			kept = append(kept, event)
+		}
+	}
+	return kept
+}
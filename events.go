@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/binary"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"sol-tx/jupiterv6"
+)
+
+// Anchor event discriminators (first 8 bytes of sha256("event:<Name>"))
+var (
+	swapEventTypeDiscriminator = jupiterv6.SwapEventTypeDiscriminator
+	feeEventTypeDiscriminator  = []byte{0x49, 0x4f, 0x4e, 0x7f, 0xb8, 0xd5, 0x0d, 0xdc}
+)
+
+// EventForm is the framing a swap event was found in
+type EventForm string
+
+const (
+	EventFormEmitCPI EventForm = "emit_cpi" // emit-CPI prefix, event discriminator, body
+	EventFormBare    EventForm = "bare"     // event discriminator, body
+)
+
+// Byte offsets of the SwapEvent fields in the emit-CPI instruction data
+const (
+	SwapEventTypeOffset         = 8   // event type discriminator
+	SwapEventAMMOffset          = 16  // amm
+	SwapEventInputMintOffset    = 48  // input_mint
+	SwapEventInputAmountOffset  = 80  // input_amount
+	SwapEventOutputMintOffset   = 88  // output_mint
+	SwapEventOutputAmountOffset = 120 // output_amount
+	SwapEventSize               = 128
+)
+
+// Event body sizes, excluding the 8 byte event discriminator
+const (
+	swapEventBodySize = SwapEventSize - SwapEventAMMOffset // amm, input_mint, input_amount, output_mint, output_amount
+	feeEventBodySize  = 72                                 // account, mint, amount
+)
+
+// parseEventPayload parses an emit-CPI payload that may carry several
+// concatenated events. The 8 byte emit-CPI prefix (SwapEventDiscriminator) is
+// stripped, then events are decoded one after another while a known event
+// discriminator follows. Fee events are skipped. A swap event takes the
+// largest registered schema ending at a known discriminator or the payload
+// end; bytes up to the payload end that start no known event are kept as its
+// ExtraFields. Decoding stops at the first unknown discriminator or truncated
+// event and the undecoded bytes are returned as remainder.
+func parseEventPayload(data []byte) ([]SwapEvent, []byte) {
+	if len(data) < 8 || !bytesEqual(data[:8], SwapEventDiscriminator) {
+		return nil, nil
+	}
+
+	var events []SwapEvent
+	offset := 8
+	for offset+8 <= len(data) {
+		discriminator := data[offset : offset+8]
+		body := data[offset+8:]
+
+		switch {
+		case bytesEqual(discriminator, swapEventTypeDiscriminator) && len(body) >= swapEventBodySize:
+			event := SwapEvent{Discriminator: SwapEventDiscriminator, Unknown: discriminator, Form: EventFormEmitCPI}
+			schema := selectSwapEventSchema(body, func(size int) bool { return eventBoundary(body[size:]) })
+			size := swapEventBodySize
+			if schema != nil {
+				size = schema.BodySize
+			}
+			if !eventBoundary(body[size:]) {
+				size = len(body)
+			}
+			decodeSwapEvent(&event, body[:size], schema)
+			events = append(events, event)
+			offset += 8 + size
+		case bytesEqual(discriminator, feeEventTypeDiscriminator) && len(body) >= feeEventBodySize:
+			offset += 8 + feeEventBodySize
+		default:
+			return events, data[offset:]
+		}
+	}
+
+	if offset < len(data) {
+		return events, data[offset:]
+	}
+	return events, nil
+}
+
+// WithReturnDataEvents also parses a swap event from the transaction return
+// data when Jupiter set it, after the inner instruction and log events
+func WithReturnDataEvents(enabled bool) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.returnDataEvents = enabled
+	}
+}
+
+// returnDataEvent parses the swap event carried by the Jupiter return data, if any
+func returnDataEvent(meta *rpc.TransactionMeta) (*SwapEvent, bool) {
+	if meta == nil || len(meta.ReturnData.Data.Content) == 0 || !meta.ReturnData.ProgramId.Equals(jupiterV6ProgramID) {
+		return nil, false
+	}
+	event, err := parseJupiterSwapEvent(meta.ReturnData.Data.Content)
+	if err != nil {
+		return nil, false
+	}
+	event.InstructionIndex = -1
+	return event, true
+}
+
+// decodeSwapEventBody decodes the fields following the event discriminator into event
+func decodeSwapEventBody(event *SwapEvent, body []byte) {
+	const base = SwapEventAMMOffset
+	event.AMM = solana.PublicKeyFromBytes(body[SwapEventAMMOffset-base : SwapEventInputMintOffset-base])
+	event.InputMint = solana.PublicKeyFromBytes(body[SwapEventInputMintOffset-base : SwapEventInputAmountOffset-base])
+	event.InputAmount = binary.LittleEndian.Uint64(body[SwapEventInputAmountOffset-base : SwapEventOutputMintOffset-base])
+	event.OutputMint = solana.PublicKeyFromBytes(body[SwapEventOutputMintOffset-base : SwapEventOutputAmountOffset-base])
+	event.OutputAmount = binary.LittleEndian.Uint64(body[SwapEventOutputAmountOffset-base : SwapEventSize-base])
+}
+
+// Encode produces the 128 byte on-chain layout of the event, the inverse of
+// parseJupiterSwapEvent. Empty Discriminator and Unknown fields default to the
+// emit-CPI prefix and the SwapEvent discriminator.
+func (e SwapEvent) Encode() []byte {
+	data := jupiterv6.EncodeSwapEvent(e.AMM, e.InputMint, e.InputAmount, e.OutputMint, e.OutputAmount)
+	if len(e.Discriminator) > 0 {
+		copy(data[:SwapEventTypeOffset], e.Discriminator)
+	}
+	if len(e.Unknown) > 0 {
+		copy(data[SwapEventTypeOffset:SwapEventAMMOffset], e.Unknown)
+	}
+	return data
+}
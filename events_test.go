@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestSwapEventEncodeRoundTrip(t *testing.T) {
+	for _, event := range []SwapEvent{
+		{AMM: testKey(1), InputMint: testKey(2), InputAmount: 1_000_000, OutputMint: testKey(3), OutputAmount: 990_000},
+		{AMM: testKey(4), InputMint: testKey(5), InputAmount: math.MaxUint64, OutputMint: testKey(5), OutputAmount: 0},
+	} {
+		data := event.Encode()
+		if len(data) != SwapEventSize {
+			t.Fatalf("encoded %d bytes, want %d", len(data), SwapEventSize)
+		}
+		parsed, err := parseJupiterSwapEventStrict(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !parsed.AMM.Equals(event.AMM) || !parsed.InputMint.Equals(event.InputMint) || parsed.InputAmount != event.InputAmount ||
+			!parsed.OutputMint.Equals(event.OutputMint) || parsed.OutputAmount != event.OutputAmount {
+			t.Errorf("round trip %+v, want %+v", parsed, event)
+		}
+		if !bytes.Equal(parsed.Discriminator, SwapEventDiscriminator) || !bytes.Equal(parsed.Unknown, swapEventTypeDiscriminator) {
+			t.Errorf("discriminators %X %X", parsed.Discriminator, parsed.Unknown)
+		}
+		if again := parsed.Encode(); !bytes.Equal(again, data) {
+			t.Errorf("re-encoded %X, want %X", again, data)
+		}
+	}
+}
+
+func TestSwapEventOffsets(t *testing.T) {
+	event := SwapEvent{AMM: testKey(1), InputMint: testKey(2), InputAmount: 0x0102030405060708, OutputMint: testKey(3), OutputAmount: 0x1112131415161718}
+	data := event.Encode()
+
+	for _, field := range []struct {
+		name   string
+		offset int
+		want   []byte
+	}{
+		{"discriminator", 0, SwapEventDiscriminator},
+		{"type", SwapEventTypeOffset, swapEventTypeDiscriminator},
+		{"amm", SwapEventAMMOffset, event.AMM[:]},
+		{"input_mint", SwapEventInputMintOffset, event.InputMint[:]},
+		{"input_amount", SwapEventInputAmountOffset, []byte{8, 7, 6, 5, 4, 3, 2, 1}},
+		{"output_mint", SwapEventOutputMintOffset, event.OutputMint[:]},
+		{"output_amount", SwapEventOutputAmountOffset, []byte{0x18, 0x17, 0x16, 0x15, 0x14, 0x13, 0x12, 0x11}},
+	} {
+		if got := data[field.offset : field.offset+len(field.want)]; !bytes.Equal(got, field.want) {
+			t.Errorf("%s at %d: %X, want %X", field.name, field.offset, got, field.want)
+		}
+	}
+	if SwapEventOutputAmountOffset+8 != SwapEventSize {
+		t.Errorf("output_amount ends at %d, event size %d", SwapEventOutputAmountOffset+8, SwapEventSize)
+	}
+}
+
+func TestSelfSwapSummary(t *testing.T) {
+	a, b, c := testKey(2), testKey(3), testKey(4)
+	for _, tt := range []struct {
+		name   string
+		events []SwapEvent
+		self   []bool
+		input  uint64
+		output uint64
+		route  string
+	}{
+		{
+			name: "self swap hop",
+			events: []SwapEvent{
+				{InputMint: a, InputAmount: 1000, OutputMint: b, OutputAmount: 900},
+				{InputMint: b, InputAmount: 900, OutputMint: b, OutputAmount: 899},
+				{InputMint: b, InputAmount: 899, OutputMint: c, OutputAmount: 800},
+			},
+			self:   []bool{false, true, false},
+			input:  1000,
+			output: 800,
+			route:  a.String() + " -> " + b.String() + " -> " + c.String(),
+		},
+		{
+			name: "circular route",
+			events: []SwapEvent{
+				{InputMint: a, InputAmount: 1000, OutputMint: b, OutputAmount: 900},
+				{InputMint: b, InputAmount: 900, OutputMint: a, OutputAmount: 1002},
+			},
+			self:   []bool{false, false},
+			input:  1000,
+			output: 1002,
+			route:  a.String() + " -> " + b.String() + " -> " + a.String(),
+		},
+		{
+			name:   "only a self swap",
+			events: []SwapEvent{{InputMint: a, InputAmount: 1000, OutputMint: a, OutputAmount: 990}},
+			self:   []bool{true},
+			input:  1000,
+			output: 990,
+			route:  a.String(),
+		},
+	} {
+		for i, event := range tt.events {
+			if event.IsSelfSwap() != tt.self[i] {
+				t.Errorf("%s: event %d IsSelfSwap = %v", tt.name, i, !tt.self[i])
+			}
+		}
+		summary := generateSwapSummary(nil, tt.events)
+		if summary.TotalSwaps != len(tt.events) || summary.TotalInput != tt.input || summary.TotalOutput != tt.output || summary.Route != tt.route {
+			t.Errorf("%s: summary %+v, want input %d output %d route %s", tt.name, summary, tt.input, tt.output, tt.route)
+		}
+	}
+}
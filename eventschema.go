@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// SwapEventSchema is a revision of the swap event appending fields to the base
+// body (amm, input_mint, input_amount, output_mint, output_amount). Revisions
+// are selected by body size, largest first, and by Match when set, e.g. to
+// check an embedded version byte.
+type SwapEventSchema struct {
+	Version string
+	// BodySize is the size of the body following the event discriminator, base fields included
+	BodySize int
+	// Match optionally accepts or rejects a body of at least BodySize bytes
+	Match func(body []byte) bool
+	// Decode decodes the appended fields, the body bytes after the base fields, into named values
+	Decode func(extra []byte) (map[string]string, error)
+}
+
+// swapEventSchemas are the registered revisions, largest body first
+var swapEventSchemas []SwapEventSchema
+
+// RegisterSwapEventSchema registers a swap event revision. Registration is not
+// safe for concurrent use with analysis, register revisions at startup.
+func RegisterSwapEventSchema(schema SwapEventSchema) error {
+	if schema.Version == "" || schema.Decode == nil {
+		return fmt.Errorf("invalid swap event schema %q: version and decoder are required", schema.Version)
+	}
+	if schema.BodySize <= swapEventBodySize {
+		return fmt.Errorf("invalid swap event schema %q: body of %d bytes does not extend the %d byte base", schema.Version, schema.BodySize, swapEventBodySize)
+	}
+	for _, registered := range swapEventSchemas {
+		if registered.Version == schema.Version {
+			return fmt.Errorf("swap event schema %q already registered", schema.Version)
+		}
+	}
+	swapEventSchemas = append(swapEventSchemas, schema)
+	sort.SliceStable(swapEventSchemas, func(i, j int) bool {
+		return swapEventSchemas[i].BodySize > swapEventSchemas[j].BodySize
+	})
+	return nil
+}
+
+// selectSwapEventSchema returns the largest registered revision body can hold
+// and that fits accepts, nil for the base layout. fits may be nil.
+func selectSwapEventSchema(body []byte, fits func(size int) bool) *SwapEventSchema {
+	for i := range swapEventSchemas {
+		schema := &swapEventSchemas[i]
+		if len(body) < schema.BodySize || (schema.Match != nil && !schema.Match(body)) {
+			continue
+		}
+		if fits != nil && !fits(schema.BodySize) {
+			continue
+		}
+		return schema
+	}
+	return nil
+}
+
+// decodeSwapEvent decodes body, which holds at least the base fields, with
+// schema (nil for the base layout). Bytes past the schema, or past the base
+// fields when the schema decoder fails, are kept as ExtraFields.
+func decodeSwapEvent(event *SwapEvent, body []byte, schema *SwapEventSchema) {
+	decodeSwapEventBody(event, body)
+	known := swapEventBodySize
+	if schema != nil {
+		if fields, err := schema.Decode(body[swapEventBodySize:schema.BodySize]); err == nil {
+			event.SchemaVersion = schema.Version
+			event.Fields = fields
+			known = schema.BodySize
+		}
+	}
+	if len(body) > known {
+		event.ExtraFields = hex.EncodeToString(body[known:])
+	}
+}
+
+// eventBoundary reports whether rest, the bytes after an event, is empty or
+// starts with a known event discriminator
+func eventBoundary(rest []byte) bool {
+	if len(rest) == 0 {
+		return true
+	}
+	return len(rest) >= 8 && (bytesEqual(rest[:8], swapEventTypeDiscriminator) || bytesEqual(rest[:8], feeEventTypeDiscriminator))
+}
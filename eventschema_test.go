@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// registerTestSchema registers a v2 swap event appending a version byte and a
+// u64 fee, restoring the registered schemas when the test ends
+func registerTestSchema(t *testing.T) {
+	t.Helper()
+	saved := swapEventSchemas
+	swapEventSchemas = nil
+	t.Cleanup(func() { swapEventSchemas = saved })
+
+	err := RegisterSwapEventSchema(SwapEventSchema{
+		Version:  "v2",
+		BodySize: swapEventBodySize + 9,
+		Match:    func(body []byte) bool { return body[swapEventBodySize] == 2 },
+		Decode: func(extra []byte) (map[string]string, error) {
+			fee := binary.LittleEndian.Uint64(extra[1:])
+			if fee == 0 {
+				return nil, errors.New("fee is zero")
+			}
+			return map[string]string{"fee": strconv.FormatUint(fee, 10)}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// lengthenedEvent encodes event in the emit-CPI form with the v2 fields and trailing bytes appended
+func lengthenedEvent(event SwapEvent, version byte, fee uint64, trailing ...byte) []byte {
+	data := append(event.Encode(), version)
+	data = binary.LittleEndian.AppendUint64(data, fee)
+	return append(data, trailing...)
+}
+
+func TestRegisterSwapEventSchema(t *testing.T) {
+	registerTestSchema(t)
+	event := SwapEvent{AMM: testKey(1), InputMint: testKey(2), InputAmount: 1000, OutputMint: testKey(3), OutputAmount: 990}
+
+	for _, tt := range []struct {
+		name    string
+		data    []byte
+		version string
+		fields  map[string]string
+		extra   string
+	}{
+		{"schema", lengthenedEvent(event, 2, 25), "v2", map[string]string{"fee": "25"}, ""},
+		{"schema and trailing bytes", lengthenedEvent(event, 2, 25, 0xde, 0xad), "v2", map[string]string{"fee": "25"}, "dead"},
+		{"version not matched", lengthenedEvent(event, 3, 25), "", nil, "031900000000000000"},
+		{"decoder error", lengthenedEvent(event, 2, 0), "", nil, "020000000000000000"},
+		{"base layout", event.Encode(), "", nil, ""},
+	} {
+		forms := []struct {
+			form EventForm
+			data []byte
+		}{
+			{EventFormEmitCPI, tt.data},
+			{EventFormBare, tt.data[SwapEventTypeOffset:]},
+		}
+		for _, form := range forms {
+			parsed, err := parseJupiterSwapEvent(form.data)
+			if err != nil {
+				t.Fatalf("%s %s: %v", tt.name, form.form, err)
+			}
+			if parsed.Form != form.form || parsed.InputAmount != 1000 || parsed.OutputAmount != 990 || !parsed.OutputMint.Equals(testKey(3)) {
+				t.Errorf("%s %s: base fields %+v", tt.name, form.form, parsed)
+			}
+			if parsed.SchemaVersion != tt.version || !reflect.DeepEqual(parsed.Fields, tt.fields) || parsed.ExtraFields != tt.extra {
+				t.Errorf("%s %s: schema %q fields %v extra %q, want %q %v %q",
+					tt.name, form.form, parsed.SchemaVersion, parsed.Fields, parsed.ExtraFields, tt.version, tt.fields, tt.extra)
+			}
+		}
+	}
+
+	// In a self-CPI payload the schema ends where the next event starts
+	payload := append(lengthenedEvent(event, 2, 25), event.Encode()[SwapEventTypeOffset:]...)
+	events, remainder := parseEventPayload(payload)
+	if len(events) != 2 || len(remainder) != 0 {
+		t.Fatalf("payload decoded %d events, remainder %x", len(events), remainder)
+	}
+	if events[0].SchemaVersion != "v2" || events[0].Fields["fee"] != "25" || events[0].ExtraFields != "" || events[1].SchemaVersion != "" {
+		t.Errorf("payload events %+v", events)
+	}
+}
+
+func TestRegisterSwapEventSchemaInvalid(t *testing.T) {
+	registerTestSchema(t)
+	decode := func([]byte) (map[string]string, error) { return nil, nil }
+
+	for _, tt := range []struct {
+		name   string
+		schema SwapEventSchema
+	}{
+		{"no version", SwapEventSchema{BodySize: swapEventBodySize + 1, Decode: decode}},
+		{"no decoder", SwapEventSchema{Version: "v3", BodySize: swapEventBodySize + 1}},
+		{"base size", SwapEventSchema{Version: "v3", BodySize: swapEventBodySize, Decode: decode}},
+		{"duplicate", SwapEventSchema{Version: "v2", BodySize: swapEventBodySize + 4, Decode: decode}},
+	} {
+		if err := RegisterSwapEventSchema(tt.schema); err == nil {
+			t.Errorf("%s: registered", tt.name)
+		}
+	}
+	if len(swapEventSchemas) != 1 {
+		t.Errorf("%d schemas registered, want 1", len(swapEventSchemas))
+	}
+}
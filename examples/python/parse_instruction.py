@@ -0,0 +1,54 @@
+"""Decode Jupiter V6 instruction data through the C shared library.
+
+Build the library from the repository root first:
+
+    go build -tags cshared -buildmode=c-shared -o libsoltx.so ./libsoltx
+
+Usage: python3 parse_instruction.py [path/to/libsoltx.so] [hex data]
+"""
+
+import ctypes
+import json
+import sys
+
+# route: one Saber step, in_amount 1000, quoted_out_amount 990, slippage 50 bps
+EXAMPLE_DATA = bytes.fromhex(
+    "e517cb977ae3ad2a"  # route discriminator
+    "01000000" "00" "64" "00" "01"  # route_plan: Saber, 100%, 0 -> 1
+    "e803000000000000"  # in_amount
+    "de03000000000000"  # quoted_out_amount
+    "3200"  # slippage_bps
+    "00"  # platform_fee_bps
+)
+
+
+def load(path):
+    lib = ctypes.CDLL(path)
+    lib.ParseInstructionJSON.argtypes = [ctypes.c_char_p, ctypes.c_int, ctypes.POINTER(ctypes.c_int)]
+    # c_void_p keeps the pointer so it can be handed back to FreeString
+    lib.ParseInstructionJSON.restype = ctypes.c_void_p
+    lib.FreeString.argtypes = [ctypes.c_void_p]
+    lib.FreeString.restype = None
+    return lib
+
+
+def parse_instruction(lib, data):
+    """Returns (code, decoded JSON); code 0 is success, 1 invalid input, 2 parse error."""
+    code = ctypes.c_int()
+    pointer = lib.ParseInstructionJSON(data, len(data), ctypes.byref(code))
+    try:
+        return code.value, json.loads(ctypes.string_at(pointer).decode())
+    finally:
+        lib.FreeString(pointer)
+
+
+def main():
+    path = sys.argv[1] if len(sys.argv) > 1 else "./libsoltx.so"
+    data = bytes.fromhex(sys.argv[2]) if len(sys.argv) > 2 else EXAMPLE_DATA
+    code, result = parse_instruction(load(path), data)
+    print(json.dumps(result, indent=2))
+    sys.exit(code)
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"sol-tx/testgen"
+)
+
+func TestOptionalExpireAtTail(t *testing.T) {
+	expireAt := int64(1767225600) // 2026-01-01T00:00:00Z
+	for instructionType := range InstructionDiscriminators {
+		without := testgenSpec(instructionType)
+		with := testgenSpec(instructionType)
+		with.ExpireAt = &expireAt
+
+		for _, tt := range []struct {
+			name string
+			spec testgen.Spec
+			want *int64
+		}{
+			{"without tail", without, nil},
+			{"with tail", with, &expireAt},
+		} {
+			gen, err := testgen.Generate(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			analysis := analyzeTest(t, newTestAnalyzer(), gen.Result, gen.Transaction)
+			if len(analysis.Instructions) != 1 {
+				t.Fatalf("%s %s: errors %v", instructionType, tt.name, analysis.Errors)
+			}
+			params := analysis.Instructions[0]
+			if (params.ExpireAt == nil) != (tt.want == nil) || (tt.want != nil && *params.ExpireAt != *tt.want) {
+				t.Errorf("%s %s: expire_at %v, want %v", instructionType, tt.name, params.ExpireAt, tt.want)
+			}
+			if params.QuotedOutAmount+params.QuotedInAmount != tt.spec.QuotedAmount || params.PlatformFeeBps != tt.spec.PlatformFeeBps {
+				t.Errorf("%s %s: tail misread: %+v", instructionType, tt.name, params)
+			}
+
+			encoded, err := json.Marshal(params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(encoded), `"expire_at"`) != (tt.want != nil) {
+				t.Errorf("%s %s: JSON %s", instructionType, tt.name, encoded)
+			}
+			if trailing, ok := instructionTrailingBytes(gen.Data); !ok || trailing != 0 {
+				t.Errorf("%s %s: %d trailing bytes", instructionType, tt.name, trailing)
+			}
+		}
+
+		// None is a known tail too
+		gen, err := testgen.Generate(without)
+		if err != nil {
+			t.Fatal(err)
+		}
+		params, err := parseJupiterV6Instruction(append(gen.Data, 0))
+		if err != nil || params.ExpireAt != nil {
+			t.Errorf("%s with None tail: %v, expire_at %v", instructionType, err, params.ExpireAt)
+		}
+
+		// A tail followed by more bytes is drift, not an expiry
+		withExtra := append(append([]byte{}, gen.Data...), 1, 0x80, 0x51, 0x01, 0, 0, 0, 0, 0, 0xFF)
+		params, err = parseJupiterV6Instruction(withExtra)
+		if err != nil || params.ExpireAt != nil {
+			t.Errorf("%s with tail and extra byte: %v, expire_at %v", instructionType, err, params.ExpireAt)
+		}
+		if trailing, ok := instructionTrailingBytes(withExtra); !ok || trailing != 10 {
+			t.Errorf("%s with tail and extra byte: %d trailing bytes, want 10", instructionType, trailing)
+		}
+	}
+}
+
+func TestExpireAtBorsh(t *testing.T) {
+	expireAt := int64(1767225600)
+	params := JupiterSwapParams{InstructionType: "route", RoutePlan: []RoutePlanStep{}, InAmount: 1000, ExpireAt: &expireAt}
+	data, err := params.MarshalBorsh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded JupiterSwapParams
+	if err := decoded.UnmarshalBorsh(data); err != nil || decoded.ExpireAt == nil || *decoded.ExpireAt != expireAt {
+		t.Fatalf("round trip: %v, expire_at %v", err, decoded.ExpireAt)
+	}
+
+	// Data encoded before expire_at was added ends at min_amount_out
+	params.ExpireAt = nil
+	data, err = params.MarshalBorsh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded = JupiterSwapParams{}
+	if err := decoded.UnmarshalBorsh(data[:len(data)-1]); err != nil || decoded.ExpireAt != nil || decoded.InAmount != 1000 {
+		t.Errorf("data without expire_at: %v, %+v", err, decoded)
+	}
+}
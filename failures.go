@@ -0,0 +1,137 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// UnknownFailureAMM keys failures that could not be attributed to an AMM
+const UnknownFailureAMM = "unknown"
+
+// customErrorPrefix precedes the hex code of an anchor or program custom error
+const customErrorPrefix = "custom program error: "
+
+// SwapFailure attributes a failed transaction to the AMM whose invocation errored
+type SwapFailure struct {
+	// Program is the AMM program that failed, nil when the failure is unattributable
+	Program *solana.PublicKey `json:"program,omitempty"`
+	// AMM is the registry name or program id of Program, UnknownFailureAMM when nil
+	AMM string `json:"amm"`
+	// Code is the custom error code (e.g. "0x1794") or the runtime error message
+	Code string `json:"code"`
+
+	InputMint  *solana.PublicKey `json:"input_mint,omitempty"`
+	OutputMint *solana.PublicKey `json:"output_mint,omitempty"`
+}
+
+// ammKey names an AMM program for aggregation
+func ammKey(program solana.PublicKey) string {
+	if name, ok := DefaultAMMRegistry.Lookup(program); ok {
+		return name
+	}
+	return program.String()
+}
+
+// failedInvocation finds the innermost "Program <id> failed: <error>" log line
+// and the program it is attributed to: the failing program itself, or the
+// closest caller on the invoke stack when Jupiter or a setup program failed
+// (a token transfer failing inside an AMM is the AMM's failure).
+func failedInvocation(logs []string) (program solana.PublicKey, code string, found bool, attributed bool) {
+	var stack []solana.PublicKey
+	for _, line := range logs {
+		if depth, ok := invokeDepth(line); ok {
+			fields := strings.Fields(line)
+			invoked, err := solana.PublicKeyFromBase58(fields[1])
+			if err != nil || depth < 1 || depth > len(stack)+1 {
+				continue
+			}
+			stack = append(stack[:depth-1], invoked)
+			continue
+		}
+
+		if !strings.HasPrefix(line, "Program ") {
+			continue
+		}
+		rest := line[len("Program "):]
+		i := strings.Index(rest, " failed: ")
+		if i < 0 {
+			continue
+		}
+		code = rest[i+len(" failed: "):]
+		if strings.HasPrefix(code, customErrorPrefix) {
+			code = code[len(customErrorPrefix):]
+		}
+		failing, err := solana.PublicKeyFromBase58(rest[:i])
+		if err != nil {
+			return solana.PublicKey{}, code, true, false
+		}
+
+		// Walk up from the failing program to the first AMM
+		top := len(stack) - 1
+		for top >= 0 && !stack[top].Equals(failing) {
+			top--
+		}
+		if top < 0 {
+			stack, top = append(stack, failing), len(stack)
+		}
+		for j := top; j >= 0; j-- {
+			if !stack[j].Equals(jupiterV6ProgramID) && !setupPrograms[stack[j]] {
+				return stack[j], code, true, true
+			}
+		}
+		return solana.PublicKey{}, code, true, false
+	}
+	return solana.PublicKey{}, "", false, false
+}
+
+// attributeFailure builds the failure of a failed transaction from its logs.
+// The mints come from the token balances of the first instruction token flow.
+func attributeFailure(analysis *JupiterV6Analysis, tx *rpc.GetTransactionResult, accountKeys solana.PublicKeySlice) *SwapFailure {
+	if tx.Meta == nil || tx.Meta.Err == nil {
+		return nil
+	}
+
+	failure := &SwapFailure{AMM: UnknownFailureAMM, Code: transactionErrorCode(analysis.TransactionError)}
+	if program, code, found, attributed := failedInvocation(tx.Meta.LogMessages); found {
+		failure.Code = code
+		if attributed {
+			failure.Program = &program
+			failure.AMM = ammKey(program)
+		}
+	}
+
+	for _, params := range analysis.Instructions {
+		if params.TokenFlow == nil {
+			continue
+		}
+		failure.InputMint = tokenAccountMint(params.TokenFlow.SourceAccount, accountKeys, tx.Meta)
+		failure.OutputMint = tokenAccountMint(params.TokenFlow.DestinationAccount, accountKeys, tx.Meta)
+		break
+	}
+	return failure
+}
+
+// tokenAccountMint returns the mint recorded in the token balances for account
+func tokenAccountMint(account solana.PublicKey, accountKeys solana.PublicKeySlice, meta *rpc.TransactionMeta) *solana.PublicKey {
+	index, found := accountIndex(accountKeys, account)
+	if !found {
+		return nil
+	}
+	for _, balances := range [][]rpc.TokenBalance{meta.PreTokenBalances, meta.PostTokenBalances} {
+		if balance, found := findTokenBalance(balances, index); found {
+			mint := balance.Mint
+			return &mint
+		}
+	}
+	return nil
+}
+
+// failureRate is failures over all attempts, 0 without attempts
+func failureRate(successes, failures uint64) float64 {
+	if successes+failures == 0 {
+		return 0
+	}
+	return float64(failures) / float64(successes+failures)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// failedFixture fails the ledger fixture route with logs and a custom error
+func failedFixture(t *testing.T, logs []string, custom int) (*rpc.GetTransactionResult, *solana.Transaction) {
+	fixture, parsedTx := ledgerFixture(t)
+	meta := *fixture.Meta
+	meta.Err = map[string]interface{}{"InstructionError": []interface{}{0, map[string]interface{}{"Custom": custom}}}
+	meta.LogMessages = logs
+	meta.PostTokenBalances = meta.PreTokenBalances
+	meta.InnerInstructions = nil
+	return testTransactionResult(t, parsedTx, &meta), parsedTx
+}
+
+func TestAttributeFailure(t *testing.T) {
+	jupiter, whirlpool, token := jupiterV6ProgramID.String(), whirlpoolProgramID.String(), solana.TokenProgramID.String()
+	mintA, mintB := testKey(2), testKey(3)
+	for _, tt := range []struct {
+		name    string
+		logs    []string
+		custom  int
+		program *solana.PublicKey
+		amm     string
+		code    string
+	}{
+		{
+			name: "token transfer failing inside an AMM",
+			logs: []string{
+				"Program " + jupiter + " invoke [1]",
+				"Program log: Instruction: Route",
+				"Program " + whirlpool + " invoke [2]",
+				"Program log: Instruction: Swap",
+				"Program " + token + " invoke [3]",
+				"Program log: Instruction: Transfer",
+				"Program log: Error: insufficient funds",
+				"Program " + token + " consumed 4323 of 180219 compute units",
+				"Program " + token + " failed: custom program error: 0x1",
+				"Program " + whirlpool + " consumed 31528 of 207424 compute units",
+				"Program " + whirlpool + " failed: custom program error: 0x1",
+				"Program " + jupiter + " consumed 44872 of 220757 compute units",
+				"Program " + jupiter + " failed: custom program error: 0x1",
+			},
+			custom:  1,
+			program: &whirlpoolProgramID,
+			amm:     "Whirlpool",
+			code:    "0x1",
+		},
+		{
+			name: "AMM error",
+			logs: []string{
+				"Program " + jupiter + " invoke [1]",
+				"Program log: Instruction: Route",
+				"Program " + whirlpool + " invoke [2]",
+				"Program log: Instruction: Swap",
+				"Program log: AnchorError occurred. Error Code: AmountOutBelowMinimum. Error Number: 6036. Error Message: Amount out below minimum threshold.",
+				"Program " + whirlpool + " consumed 28117 of 207424 compute units",
+				"Program " + whirlpool + " failed: custom program error: 0x1794",
+				"Program " + jupiter + " consumed 41461 of 220757 compute units",
+				"Program " + jupiter + " failed: custom program error: 0x1794",
+			},
+			custom:  6036,
+			program: &whirlpoolProgramID,
+			amm:     "Whirlpool",
+			code:    "0x1794",
+		},
+		{
+			name: "Jupiter slippage check",
+			logs: []string{
+				"Program " + jupiter + " invoke [1]",
+				"Program log: Instruction: Route",
+				"Program " + whirlpool + " invoke [2]",
+				"Program log: Instruction: Swap",
+				"Program " + whirlpool + " consumed 33120 of 207424 compute units",
+				"Program " + whirlpool + " success",
+				"Program log: AnchorError occurred. Error Code: SlippageToleranceExceeded. Error Number: 6001. Error Message: Slippage tolerance exceeded.",
+				"Program " + jupiter + " consumed 52318 of 220757 compute units",
+				"Program " + jupiter + " failed: custom program error: 0x1771",
+			},
+			custom: 6001,
+			amm:    UnknownFailureAMM,
+			code:   "0x1771",
+		},
+		{
+			name:   "no logs",
+			custom: 6001,
+			amm:    UnknownFailureAMM,
+			code:   "custom:6001",
+		},
+	} {
+		tx, parsedTx := failedFixture(t, tt.logs, tt.custom)
+		failure := analyzeTest(t, newTestAnalyzer(), tx, parsedTx).Failure
+		if failure == nil {
+			t.Fatalf("%s: no failure", tt.name)
+		}
+		if (failure.Program == nil) != (tt.program == nil) || (tt.program != nil && !failure.Program.Equals(*tt.program)) {
+			t.Errorf("%s: program %v, want %v", tt.name, failure.Program, tt.program)
+		}
+		if failure.AMM != tt.amm || failure.Code != tt.code {
+			t.Errorf("%s: amm %q code %q, want %q %q", tt.name, failure.AMM, failure.Code, tt.amm, tt.code)
+		}
+		if failure.InputMint == nil || !failure.InputMint.Equals(mintA) || failure.OutputMint == nil || !failure.OutputMint.Equals(mintB) {
+			t.Errorf("%s: mints %v -> %v, want %s -> %s", tt.name, failure.InputMint, failure.OutputMint, mintA, mintB)
+		}
+	}
+
+	tx, parsedTx := ledgerFixture(t)
+	if failure := analyzeTest(t, newTestAnalyzer(), tx, parsedTx).Failure; failure != nil {
+		t.Errorf("successful transaction has failure %+v", failure)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// feeFromBps returns amount * bps / 10000 with exact integer math, rounding down
+func feeFromBps(amount uint64, bps uint8) uint64 {
+	hi, lo := bits.Mul64(amount, uint64(bps))
+	quo, _ := bits.Div64(hi, lo, 10000)
+	return quo
+}
+
+// computePlatformFees derives the platform fee amount and mint of each instruction
+// from its own events. exactIn routes charge the fee on the output (last event),
+// exactOut routes on the input (first event). Events of an unknown instruction
+// only count when the transaction has a single instruction. Instructions without
+// a fee or without events are left zero.
+func computePlatformFees(analysis *JupiterV6Analysis) {
+	for i := range analysis.Instructions {
+		inst := &analysis.Instructions[i]
+		if inst.PlatformFeeBps == 0 {
+			continue
+		}
+		var own []SwapEvent
+		for _, event := range analysis.Events {
+			if event.InstructionIndex == inst.InstructionIndex || (event.InstructionIndex < 0 && len(analysis.Instructions) == 1) {
+				own = append(own, event)
+			}
+		}
+		if len(own) == 0 {
+			continue
+		}
+		if isExactOutInstruction(inst.InstructionType) {
+			first := own[0]
+			inst.PlatformFeeMint = first.InputMint
+			inst.PlatformFeeAmount = feeFromBps(first.InputAmount, inst.PlatformFeeBps)
+		} else {
+			last := own[len(own)-1]
+			inst.PlatformFeeMint = last.OutputMint
+			inst.PlatformFeeAmount = feeFromBps(last.OutputAmount, inst.PlatformFeeBps)
+		}
+	}
+}
+
+// TotalPlatformFees sums the platform fees of all instructions, keyed by fee mint.
+// Sums saturate at the uint64 maximum, see TotalPlatformFeesBig for exact totals.
+func (a *JupiterV6Analysis) TotalPlatformFees() map[solana.PublicKey]uint64 {
+	totals := make(map[solana.PublicKey]uint64)
+	for mint, amount := range a.TotalPlatformFeesBig() {
+		if amount.Int().IsUint64() {
+			totals[mint] = amount.Int().Uint64()
+		} else {
+			totals[mint] = math.MaxUint64
+		}
+	}
+	return totals
+}
+
+// TotalPlatformFeesBig sums the platform fees of all instructions, keyed by fee mint
+func (a *JupiterV6Analysis) TotalPlatformFeesBig() map[solana.PublicKey]*BigAmount {
+	totals := make(map[solana.PublicKey]*BigAmount)
+	for _, inst := range a.Instructions {
+		if inst.PlatformFeeAmount == 0 {
+			continue
+		}
+		addAmount(totals, inst.PlatformFeeMint, inst.PlatformFeeAmount)
+	}
+	return totals
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestTotalPlatformFeesAboveUint64(t *testing.T) {
+	mint := testKey(1)
+	analysis := &JupiterV6Analysis{Instructions: []JupiterSwapParams{
+		{PlatformFeeMint: mint, PlatformFeeAmount: math.MaxUint64},
+		{PlatformFeeMint: mint, PlatformFeeAmount: math.MaxUint64},
+		{PlatformFeeMint: mint, PlatformFeeAmount: 2},
+	}}
+
+	// 2 * (2^64 - 1) + 2 = 2^65
+	const want = "36893488147419103232"
+	totals := analysis.TotalPlatformFeesBig()
+	if got := totals[mint].String(); got != want {
+		t.Fatalf("total %s, want %s", got, want)
+	}
+	if got := analysis.TotalPlatformFees()[mint]; got != math.MaxUint64 {
+		t.Errorf("uint64 total %d, want saturated", got)
+	}
+
+	report := AggregateAnalyses([]*JupiterV6Analysis{analysis, analysis})
+	if got := report.PlatformFees[mint].String(); got != "73786976294838206464" {
+		t.Errorf("aggregated fees %s, want 2^66", got)
+	}
+	encoded, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"`+mint.String()+`":"73786976294838206464"`) {
+		t.Errorf("platform fees missing from %s", encoded)
+	}
+}
+
+func TestComputePlatformFeesPerInstruction(t *testing.T) {
+	mintA, mintB, mintC, mintD := testKey(1), testKey(2), testKey(3), testKey(4)
+	analysis := &JupiterV6Analysis{
+		Instructions: []JupiterSwapParams{
+			{InstructionType: "route", InstructionIndex: 0, PlatformFeeBps: 100},
+			{InstructionType: "exactOutRoute", InstructionIndex: 2, PlatformFeeBps: 100},
+		},
+		Events: []SwapEvent{
+			{InstructionIndex: 0, InputMint: mintA, InputAmount: 1000, OutputMint: mintB, OutputAmount: 2000},
+			{InstructionIndex: 2, InputMint: mintC, InputAmount: 500, OutputMint: mintD, OutputAmount: 3000},
+		},
+	}
+	computePlatformFees(analysis)
+
+	// Each fee comes from the events of its own instruction
+	want := map[solana.PublicKey]uint64{mintB: 20, mintC: 5}
+	if totals := analysis.TotalPlatformFees(); !reflect.DeepEqual(totals, want) {
+		t.Errorf("totals %v, want %v", totals, want)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Fill sides, from the route's point of view on the base mint
+const (
+	FillBuy  = "buy"  // the route bought base from the venue
+	FillSell = "sell" // the route sold base to the venue
+)
+
+// Fill is one venue-level execution of a route: a swap event attributed to
+// its AMM, normalized to a canonical base/quote pair. Amounts and prices are
+// in raw token units.
+type Fill struct {
+	Signature solana.Signature `json:"signature"`
+	Slot      uint64           `json:"slot"`
+	// Ordinal is the position of the event in the analysis, in execution order
+	Ordinal   int       `json:"ordinal"`
+	TradeID   string    `json:"trade_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"` // Zero when the transaction time is unknown
+
+	Venue   string           `json:"venue"` // Registry name, else the program id
+	Program solana.PublicKey `json:"program"`
+	// Pool is the pool or market account, nil when only the program is known
+	Pool *solana.PublicKey `json:"pool,omitempty"`
+
+	Base        solana.PublicKey `json:"base"`
+	Quote       solana.PublicKey `json:"quote"`
+	Side        string           `json:"side"`
+	Size        uint64           `json:"size"` // Base amount
+	QuoteAmount uint64           `json:"quote_amount"`
+	Price       string           `json:"price"` // Quote per base, exact to pricePrecision decimals
+}
+
+// FillsFromAnalysis converts every non self-swap, non dust event of the
+// analysis into a fill, in execution order
+func FillsFromAnalysis(analysis *JupiterV6Analysis) []Fill {
+	var fills []Fill
+	timestamp := tradeTime(analysis, time.Time{})
+	for i, event := range analysis.Events {
+		if event.IsSelfSwap() || event.Dust || event.InputAmount == 0 || event.OutputAmount == 0 {
+			continue
+		}
+
+		fill := Fill{
+			Signature: analysis.Signature,
+			Slot:      analysis.Slot,
+			Ordinal:   i,
+			TradeID:   event.TradeID,
+			Timestamp: timestamp,
+			Venue:     ammKey(event.AMM),
+			Program:   event.AMM,
+			Pool:      event.Pool,
+		}
+		fill.Base, fill.Quote = canonicalPair(event.InputMint, event.OutputMint)
+		if fill.Base.Equals(event.OutputMint) {
+			fill.Side = FillBuy
+			fill.Size, fill.QuoteAmount = event.OutputAmount, event.InputAmount
+		} else {
+			fill.Side = FillSell
+			fill.Size, fill.QuoteAmount = event.InputAmount, event.OutputAmount
+		}
+		trade := Trade{BaseAmount: fill.Size, QuoteAmount: fill.QuoteAmount}
+		fill.Price = trade.price().FloatString(pricePrecision)
+		fills = append(fills, fill)
+	}
+	return fills
+}
+
+// FillSink adapts a fill consumer to the analysis sink pipeline, so a sink can
+// take fills instead of analyses:
+//
+//	WrapSink(FillSink(writeFill), HashingMiddleware(key))
+func FillSink(sink func(Fill)) func(*JupiterV6Analysis) {
+	return func(analysis *JupiterV6Analysis) {
+		for _, fill := range FillsFromAnalysis(analysis) {
+			sink(fill)
+		}
+	}
+}
+
+// attachEventPools sets the pool of each event from the AMM inner instructions
+// of its top-level instruction, for AMMs with a hop decoder. The invocations of
+// an AMM are matched in order to the events of that AMM.
+func attachEventPools(analysis *JupiterV6Analysis, parsedTx *solana.Transaction, meta *rpc.TransactionMeta) {
+	if meta == nil {
+		return
+	}
+
+	// Pools by top-level instruction, then AMM, in swap invocation order. AMMs
+	// invoke themselves for other purposes, such as the emit_cpi event of Meteora
+	// DLMM or the log self-CPI of Phoenix, so only invocations that decode as
+	// swaps are counted. An entry is nil when the pool account cannot be resolved.
+	pools := make(map[int]map[solana.PublicKey][]*solana.PublicKey)
+	for _, inner := range meta.InnerInstructions {
+		for _, inst := range inner.Instructions {
+			program, ok := programIDAt(int(inst.ProgramIDIndex), parsedTx.Message.AccountKeys, meta)
+			if !ok {
+				continue
+			}
+			decoder, ok := hopDecoders[program]
+			if !ok {
+				continue
+			}
+
+			if _, ok := decoder.decode(inst.Data); !ok {
+				continue
+			}
+			var pool *solana.PublicKey
+			if position := decoder.pool(inst.Data); position < len(inst.Accounts) {
+				if account, ok := programIDAt(int(inst.Accounts[position]), parsedTx.Message.AccountKeys, meta); ok {
+					pool = &account
+				}
+			}
+
+			index := int(inner.Index)
+			if pools[index] == nil {
+				pools[index] = make(map[solana.PublicKey][]*solana.PublicKey)
+			}
+			pools[index][program] = append(pools[index][program], pool)
+		}
+	}
+
+	used := make(map[int]map[solana.PublicKey]int)
+	for i := range analysis.Events {
+		event := &analysis.Events[i]
+		invocations := pools[event.InstructionIndex][event.AMM]
+		if used[event.InstructionIndex] == nil {
+			used[event.InstructionIndex] = make(map[solana.PublicKey]int)
+		}
+		n := used[event.InstructionIndex][event.AMM]
+		if n < len(invocations) {
+			event.Pool = invocations[n]
+			used[event.InstructionIndex][event.AMM]++
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"sol-tx/jupiterv6"
+)
+
+// dlmmSwapData encodes a Meteora DLMM swap instruction
+func dlmmSwapData(amountIn, minAmountOut uint64) []byte {
+	data := append([]byte{}, anchorSwapDiscriminator...)
+	data = binary.LittleEndian.AppendUint64(data, amountIn)
+	return binary.LittleEndian.AppendUint64(data, minAmountOut)
+}
+
+// TestAttachEventPoolsMultiHop routes through two DLMM pools, each swap
+// followed by the emit_cpi self-invocation of the program, then a Saber pool,
+// which has no hop decoder and falls back to the program only.
+func TestAttachEventPoolsMultiHop(t *testing.T) {
+	var (
+		user   = testKey(1)
+		poolA  = testKey(2)
+		poolB  = testKey(3)
+		mintA  = testKey(4)
+		mintB  = testKey(5)
+		mintC  = testKey(6)
+		saber  = solana.MustPublicKeyFromBase58("SSwpkEEcbUqx4vtoEByFjSkhKdCT862DNVb52nZg1UZ")
+		emitID = append(append([]byte{}, jupiterv6.EmitCPIPrefix...), make([]byte, 8)...)
+	)
+	keys := solana.PublicKeySlice{user, jupiterV6ProgramID, meteoraDlmmProgramID, saber, poolA, poolB}
+	parsedTx := &solana.Transaction{Message: solana.Message{
+		AccountKeys:  keys,
+		Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 1}},
+	}}
+	meta := &rpc.TransactionMeta{InnerInstructions: []rpc.InnerInstruction{{
+		Index: 0,
+		Instructions: []solana.CompiledInstruction{
+			{ProgramIDIndex: 2, Accounts: []uint16{4}, Data: dlmmSwapData(1000, 900)},
+			{ProgramIDIndex: 2, Accounts: []uint16{2}, Data: emitID},
+			{ProgramIDIndex: 2, Accounts: []uint16{5}, Data: dlmmSwapData(900, 800)},
+			{ProgramIDIndex: 2, Accounts: []uint16{2}, Data: emitID},
+			{ProgramIDIndex: 3, Accounts: []uint16{0}, Data: []byte{1}},
+		},
+	}}}
+	analysis := &JupiterV6Analysis{Events: []SwapEvent{
+		{AMM: meteoraDlmmProgramID, InputMint: mintA, InputAmount: 1000, OutputMint: mintB, OutputAmount: 900},
+		{AMM: meteoraDlmmProgramID, InputMint: mintB, InputAmount: 900, OutputMint: mintC, OutputAmount: 800},
+		{AMM: saber, InputMint: mintC, InputAmount: 800, OutputMint: mintA, OutputAmount: 700},
+	}}
+
+	attachEventPools(analysis, parsedTx, meta)
+
+	fills := FillsFromAnalysis(analysis)
+	if len(fills) != 3 {
+		t.Fatalf("got %d fills, want 3", len(fills))
+	}
+	for i, want := range []*solana.PublicKey{&poolA, &poolB, nil} {
+		got := fills[i].Pool
+		switch {
+		case want == nil && got != nil:
+			t.Errorf("fill %d: pool %s, want none", i, got)
+		case want != nil && (got == nil || !got.Equals(*want)):
+			t.Errorf("fill %d: pool %v, want %s", i, got, want)
+		}
+	}
+	if !fills[2].Program.Equals(saber) || fills[2].Venue != saber.String() {
+		t.Errorf("fallback fill: program %s venue %q, want the Saber program", fills[2].Program, fills[2].Venue)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/binary"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// isRouteDiscriminator reports whether data starts with one of the route-family discriminators
+func isRouteDiscriminator(data []byte) bool {
+	_, ok := InstructionTypeOf(data)
+	return ok
+}
+
+// programIDAt resolves an account index against the static keys followed by
+// the loaded writable and readonly addresses, in the order the runtime uses
+func programIDAt(index int, staticKeys solana.PublicKeySlice, meta *rpc.TransactionMeta) (solana.PublicKey, bool) {
+	if index < len(staticKeys) {
+		return staticKeys[index], true
+	}
+	if meta == nil {
+		return solana.PublicKey{}, false
+	}
+
+	index -= len(staticKeys)
+	if index < len(meta.LoadedAddresses.Writable) {
+		return meta.LoadedAddresses.Writable[index], true
+	}
+	index -= len(meta.LoadedAddresses.Writable)
+	if index < len(meta.LoadedAddresses.ReadOnly) {
+		return meta.LoadedAddresses.ReadOnly[index], true
+	}
+	return solana.PublicKey{}, false
+}
+
+// ContainsJupiterRoute reports whether the transaction contains a top-level
+// Jupiter V6 route-family instruction. It only inspects discriminators and
+// does not parse the route plan. For v0 transactions whose lookup tables have
+// not been resolved, the program ID is looked up in meta.LoadedAddresses.
+func ContainsJupiterRoute(parsedTx *solana.Transaction, meta *rpc.TransactionMeta) bool {
+	if parsedTx == nil {
+		return false
+	}
+
+	for _, inst := range parsedTx.Message.Instructions {
+		programID, ok := programIDAt(int(inst.ProgramIDIndex), parsedTx.Message.AccountKeys, meta)
+		if !ok || !programID.Equals(jupiterV6ProgramID) {
+			continue
+		}
+		if isRouteDiscriminator(inst.Data) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsJupiterRouteRaw is the allocation free variant of ContainsJupiterRoute
+// operating directly on a serialized (legacy or v0) message. Static account keys
+// are compared in place; program IDs beyond the static keys are resolved through
+// meta.LoadedAddresses when meta is provided. Malformed messages return false.
+func ContainsJupiterRouteRaw(message []byte, meta *rpc.TransactionMeta) bool {
+	offset := 0
+	if len(message) == 0 {
+		return false
+	}
+
+	// Versioned messages are prefixed with 0x80 | version
+	if message[0]&0x80 != 0 {
+		offset++
+	}
+
+	// Skip the message header
+	offset += 3
+
+	keyCount, n, ok := readCompactU16(message, offset)
+	if !ok {
+		return false
+	}
+	offset += n
+
+	keysStart := offset
+	offset += keyCount * solana.PublicKeyLength
+	if offset > len(message) {
+		return false
+	}
+
+	// Skip the recent blockhash
+	offset += 32
+
+	instructionCount, n, ok := readCompactU16(message, offset)
+	if !ok {
+		return false
+	}
+	offset += n
+
+	for i := 0; i < instructionCount; i++ {
+		if offset >= len(message) {
+			return false
+		}
+		programIDIndex := int(message[offset])
+		offset++
+
+		accountCount, n, ok := readCompactU16(message, offset)
+		if !ok {
+			return false
+		}
+		offset += n + accountCount
+
+		dataLen, n, ok := readCompactU16(message, offset)
+		if !ok {
+			return false
+		}
+		offset += n
+		if offset+dataLen > len(message) {
+			return false
+		}
+		data := message[offset : offset+dataLen]
+		offset += dataLen
+
+		if !isJupiterProgramAt(message, keysStart, keyCount, programIDIndex, meta) {
+			continue
+		}
+		if isRouteDiscriminator(data) {
+			return true
+		}
+	}
+	return false
+}
+
+// isJupiterProgramAt checks the program ID at index without copying static keys
+func isJupiterProgramAt(message []byte, keysStart, keyCount, index int, meta *rpc.TransactionMeta) bool {
+	if index < keyCount {
+		start := keysStart + index*solana.PublicKeyLength
+		return bytesEqual(message[start:start+solana.PublicKeyLength], jupiterV6ProgramID[:])
+	}
+
+	programID, ok := programIDAt(index-keyCount, nil, meta)
+	return ok && programID.Equals(jupiterV6ProgramID)
+}
+
+// readCompactU16 reads a compact-u16 (shortvec) length at offset,
+// returning the value and the number of bytes consumed
+func readCompactU16(data []byte, offset int) (int, int, bool) {
+	value := 0
+	for i := 0; i < 3; i++ {
+		if offset+i >= len(data) {
+			return 0, 0, false
+		}
+		b := data[offset+i]
+		value |= int(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return value, i + 1, true
+		}
+	}
+	return 0, 0, false
+}
+
+// instructionTypesByDiscriminator indexes InstructionDiscriminators by their little-endian u64 value
+var instructionTypesByDiscriminator = func() map[uint64]string {
+	types := make(map[uint64]string, len(InstructionDiscriminators))
+	for name, discriminator := range InstructionDiscriminators {
+		types[binary.LittleEndian.Uint64(discriminator)] = name
+	}
+	return types
+}()
+
+// InstructionTypeOf classifies Jupiter instruction data by its discriminator alone,
+// without parsing the rest of the instruction
+func InstructionTypeOf(data []byte) (string, bool) {
+	if len(data) < 8 {
+		return "", false
+	}
+	name, ok := instructionTypesByDiscriminator[binary.LittleEndian.Uint64(data[:8])]
+	return name, ok
+}
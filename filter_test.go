@@ -0,0 +1,251 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"sol-tx/testgen"
+)
+
+// filterMessage builds a message with a compute budget style instruction
+// followed by a Jupiter instruction carrying data. With lookup the Jupiter
+// program is loaded from an address lookup table and returned in meta.
+func filterMessage(t testing.TB, data []byte, lookup bool) (*solana.Transaction, []byte, *rpc.TransactionMeta) {
+	t.Helper()
+	payer, other := testKey(1), testKey(2)
+	keys := solana.PublicKeySlice{payer, other, jupiterV6ProgramID}
+	meta := &rpc.TransactionMeta{}
+	if lookup {
+		// Index 2 is then the first loaded address
+		keys = keys[:2]
+		meta.LoadedAddresses.ReadOnly = solana.PublicKeySlice{jupiterV6ProgramID}
+	}
+	parsedTx := &solana.Transaction{
+		Signatures: []solana.Signature{{1}},
+		Message: solana.Message{
+			Header:      solana.MessageHeader{NumRequiredSignatures: 1},
+			AccountKeys: keys,
+			Instructions: []solana.CompiledInstruction{
+				{ProgramIDIndex: 1, Data: []byte{2, 0x40, 0x0D, 0x03, 0}},
+				{ProgramIDIndex: 2, Accounts: []uint16{0, 1}, Data: data},
+			},
+		},
+	}
+	if lookup {
+		parsedTx.Message.SetAddressTableLookups([]solana.MessageAddressTableLookup{{AccountKey: testKey(30), ReadonlyIndexes: []uint8{4}}})
+	}
+	message, err := parsedTx.Message.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsedTx, message, meta
+}
+
+func TestContainsJupiterRoute(t *testing.T) {
+	route := testInstruction("route", 0, [][]byte{testStep(0, 100, 0, 1)}, 1000, 900, 50, 0)
+	// More than 127 bytes of data takes a two byte compact-u16 length
+	var steps [][]byte
+	for i := 0; i < 40; i++ {
+		steps = append(steps, testStep(0, 100, uint8(i), uint8(i+1)))
+	}
+	longRoute := testInstruction("route", 0, steps, 1000, 900, 50, 0)
+	if len(longRoute) < 128 {
+		t.Fatalf("long route has %d bytes", len(longRoute))
+	}
+
+	for _, tt := range []struct {
+		name   string
+		data   []byte
+		lookup bool
+		meta   bool
+		want   bool
+	}{
+		{"legacy route", route, false, true, true},
+		{"legacy long route", longRoute, false, true, true},
+		{"legacy setTokenLedger", setTokenLedgerDiscriminator, false, true, false},
+		{"legacy short data", route[:7], false, true, false},
+		{"v0 route from lookup table", route, true, true, true},
+		{"v0 long route from lookup table", longRoute, true, true, true},
+		{"v0 route without meta", route, true, false, false},
+		{"v0 setTokenLedger from lookup table", setTokenLedgerDiscriminator, true, true, false},
+	} {
+		parsedTx, message, meta := filterMessage(t, tt.data, tt.lookup)
+		if !tt.meta {
+			meta = nil
+		}
+		if got := ContainsJupiterRoute(parsedTx, meta); got != tt.want {
+			t.Errorf("%s: ContainsJupiterRoute %v, want %v", tt.name, got, tt.want)
+		}
+		if got := ContainsJupiterRouteRaw(message, meta); got != tt.want {
+			t.Errorf("%s: ContainsJupiterRouteRaw %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestContainsJupiterRouteRawTruncated(t *testing.T) {
+	route := testInstruction("route", 0, [][]byte{testStep(0, 100, 0, 1)}, 1000, 900, 50, 0)
+	for _, lookup := range []bool{false, true} {
+		_, message, meta := filterMessage(t, route, lookup)
+		// The lookup table section of v0 messages follows the instructions
+		end := len(message)
+		if lookup {
+			end -= 1 + 32 + 1 + 1 + 1
+		}
+		for cut := 0; cut < end; cut++ {
+			if ContainsJupiterRouteRaw(message[:cut], meta) {
+				t.Errorf("lookup %v: message truncated to %d of %d bytes matched", lookup, cut, len(message))
+			}
+		}
+		if !ContainsJupiterRouteRaw(message[:end], meta) {
+			t.Errorf("lookup %v: complete instructions not matched", lookup)
+		}
+	}
+}
+
+func TestReadCompactU16(t *testing.T) {
+	for _, tt := range []struct {
+		data  []byte
+		value int
+		size  int
+		ok    bool
+	}{
+		{[]byte{0}, 0, 1, true},
+		{[]byte{0x7F}, 127, 1, true},
+		{[]byte{0x80, 0x01}, 128, 2, true},
+		{[]byte{0xFF, 0xFF, 0x03}, 65535, 3, true},
+		{[]byte{}, 0, 0, false},
+		{[]byte{0x80}, 0, 0, false},
+		{[]byte{0x80, 0x80, 0x80}, 0, 0, false},
+	} {
+		value, size, ok := readCompactU16(tt.data, 0)
+		if value != tt.value || size != tt.size || ok != tt.ok {
+			t.Errorf("%x: got %d, %d, %v", tt.data, value, size, ok)
+		}
+	}
+}
+
+// filterBenchmarkFixture is a generated two hop route transaction
+func filterBenchmarkFixture(b *testing.B) *testgen.Generated {
+	gen, err := testgen.Generate(testgenSpec("route"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	return gen
+}
+
+func BenchmarkContainsJupiterRoute(b *testing.B) {
+	gen := filterBenchmarkFixture(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if !ContainsJupiterRoute(gen.Transaction, gen.Result.Meta) {
+			b.Fatal("route not found")
+		}
+	}
+}
+
+func BenchmarkContainsJupiterRouteRaw(b *testing.B) {
+	gen := filterBenchmarkFixture(b)
+	message, err := gen.Transaction.Message.MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if !ContainsJupiterRouteRaw(message, gen.Result.Meta) {
+			b.Fatal("route not found")
+		}
+	}
+}
+
+// skipBenchmarkFixture is a priced token transfer without a Jupiter
+// instruction, the common case a prefilter rejects
+func skipBenchmarkFixture(b *testing.B) (*solana.Transaction, []byte) {
+	payer, source, destination := testKey(1), testKey(2), testKey(3)
+	parsedTx := &solana.Transaction{
+		Signatures: []solana.Signature{{1}},
+		Message: solana.Message{
+			Header:      solana.MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 2},
+			AccountKeys: solana.PublicKeySlice{payer, source, destination, solana.ComputeBudget, solana.TokenProgramID},
+			Instructions: []solana.CompiledInstruction{
+				{ProgramIDIndex: 3, Data: []byte{2, 0x40, 0x0D, 0x03, 0}},
+				{ProgramIDIndex: 3, Data: []byte{setComputeUnitPriceTag, 0xE8, 0x03, 0, 0, 0, 0, 0, 0}},
+				{ProgramIDIndex: 4, Accounts: []uint16{1, 2, 0}, Data: []byte{3, 0x40, 0x42, 0x0F, 0, 0, 0, 0, 0}},
+			},
+		},
+	}
+	message, err := parsedTx.Message.MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return parsedTx, message
+}
+
+func BenchmarkContainsJupiterRouteSkip(b *testing.B) {
+	parsedTx, _ := skipBenchmarkFixture(b)
+	meta := &rpc.TransactionMeta{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if ContainsJupiterRoute(parsedTx, meta) {
+			b.Fatal("route found in a token transfer")
+		}
+	}
+}
+
+func BenchmarkContainsJupiterRouteRawSkip(b *testing.B) {
+	_, message := skipBenchmarkFixture(b)
+	meta := &rpc.TransactionMeta{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if ContainsJupiterRouteRaw(message, meta) {
+			b.Fatal("route found in a token transfer")
+		}
+	}
+}
+
+// BenchmarkAnalyzeFullParse is the full analysis the prefilter avoids
+func BenchmarkAnalyzeFullParse(b *testing.B) {
+	gen := filterBenchmarkFixture(b)
+	a := newTestAnalyzer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := a.Analyze(gen.Result, gen.Transaction); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestInstructionTypeOf(t *testing.T) {
+	for instructionType, discriminator := range InstructionDiscriminators {
+		if got, ok := InstructionTypeOf(append(append([]byte{}, discriminator...), 1, 2, 3)); !ok || got != instructionType {
+			t.Errorf("%s: classified as %q, %v", instructionType, got, ok)
+		}
+		if _, ok := InstructionTypeOf(discriminator[:7]); ok {
+			t.Errorf("%s: 7 bytes classified", instructionType)
+		}
+	}
+	if _, ok := InstructionTypeOf(setTokenLedgerDiscriminator); ok {
+		t.Error("setTokenLedger classified as a route")
+	}
+}
+
+func TestWithInstructionTypes(t *testing.T) {
+	gen, err := testgen.Generate(testgenSpec("sharedAccountsRoute"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	skipping := analyzeTest(t, newTestAnalyzer(WithInstructionTypes("route")), gen.Result, gen.Transaction)
+	if len(skipping.Instructions) != 0 || skipping.Stats.JupiterInstructions != 1 || skipping.Stats.SkippedInstructions != 1 {
+		t.Errorf("skipped: %d instructions, stats %+v", len(skipping.Instructions), skipping.Stats)
+	}
+	if len(skipping.Results) != 1 || !skipping.Results[0].Skipped {
+		t.Errorf("skipped: results %+v", skipping.Results)
+	}
+
+	keeping := analyzeTest(t, newTestAnalyzer(WithInstructionTypes("route", "sharedAccountsRoute")), gen.Result, gen.Transaction)
+	if len(keeping.Instructions) != 1 || keeping.Stats.SkippedInstructions != 0 {
+		t.Errorf("kept: %d instructions, stats %+v", len(keeping.Instructions), keeping.Stats)
+	}
+}
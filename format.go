@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OneLine summarizes the analysis as a single human readable line, e.g.
+// "route: 1.5 SOL -> 210.3 USDC via Whirlpool+Raydium (slippage 50bps)".
+// Token symbols and decimals are taken from registry when provided,
+// otherwise raw amounts and mint addresses are used. Like the summary it
+// leaves dust events out.
+func (a *JupiterV6Analysis) OneLine(registry TokenRegistry) string {
+	instructionType := "unknown"
+	var slippageBps uint16
+	var amms []string
+	seen := make(map[SwapType]bool)
+
+	if len(a.Instructions) > 0 {
+		instructionType = a.Instructions[0].InstructionType
+		slippageBps = a.Instructions[0].SlippageBps
+	}
+	for _, inst := range a.Instructions {
+		for _, step := range inst.RoutePlan {
+			if !seen[step.Swap.Type] {
+				seen[step.Swap.Type] = true
+				amms = append(amms, string(step.Swap.Type))
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(instructionType + ": ")
+
+	if events := nonDustEvents(a.Events); len(events) > 0 {
+		first := events[0]
+		last := events[len(events)-1]
+		b.WriteString(formatTokenAmount(first.InputAmount, first.InputMint, registry))
+		b.WriteString(" -> ")
+		b.WriteString(formatTokenAmount(last.OutputAmount, last.OutputMint, registry))
+	} else {
+		b.WriteString("no swap events")
+	}
+
+	if len(amms) > 0 {
+		b.WriteString(" via " + strings.Join(amms, "+"))
+	}
+	if len(a.Instructions) > 0 {
+		b.WriteString(fmt.Sprintf(" (slippage %dbps)", slippageBps))
+	}
+
+	return b.String()
+}
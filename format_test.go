@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestOneLine(t *testing.T) {
+	mintA, mintB, mintC := testKey(1), testKey(2), testKey(3)
+	registry := StaticTokenRegistry{
+		mintA: {Symbol: "AAA", Decimals: 2},
+		mintB: {Symbol: "BBB", Decimals: 0},
+	}
+	route := []JupiterSwapParams{{
+		InstructionType: "route",
+		SlippageBps:     50,
+		RoutePlan: []RoutePlanStep{
+			{Swap: Swap{Type: SwapWhirlpool}},
+			{Swap: Swap{Type: SwapRaydium}},
+			{Swap: Swap{Type: SwapWhirlpool}},
+		},
+	}}
+	swap := SwapEvent{InputMint: mintA, InputAmount: 150, OutputMint: mintB, OutputAmount: 210}
+	dustIn := SwapEvent{InputMint: mintC, InputAmount: 1, OutputMint: mintA, OutputAmount: 1, Dust: true}
+	dustOut := SwapEvent{InputMint: mintB, InputAmount: 1, OutputMint: mintC, OutputAmount: 1, Dust: true}
+
+	for _, tt := range []struct {
+		name     string
+		analysis JupiterV6Analysis
+		registry TokenRegistry
+		want     string
+	}{
+		{"registry", JupiterV6Analysis{Instructions: route, Events: []SwapEvent{swap}}, registry,
+			"route: 1.5 AAA -> 210 BBB via Whirlpool+Raydium (slippage 50bps)"},
+		{"raw", JupiterV6Analysis{Instructions: route, Events: []SwapEvent{swap}}, nil,
+			"route: 150 " + mintA.String() + " -> 210 " + mintB.String() + " via Whirlpool+Raydium (slippage 50bps)"},
+		{"dust legs", JupiterV6Analysis{Instructions: route, Events: []SwapEvent{dustIn, swap, dustOut}}, registry,
+			"route: 1.5 AAA -> 210 BBB via Whirlpool+Raydium (slippage 50bps)"},
+		{"only dust", JupiterV6Analysis{Instructions: route, Events: []SwapEvent{dustIn}}, registry,
+			"route: no swap events via Whirlpool+Raydium (slippage 50bps)"},
+		{"empty", JupiterV6Analysis{}, nil, "unknown: no swap events"},
+	} {
+		if got := tt.analysis.OneLine(tt.registry); got != tt.want {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"sol-tx/jupiterv6"
+)
+
+// newTestAnalyzer returns an offline analyzer that does not print
+func newTestAnalyzer(opts ...AnalyzerOption) *Analyzer {
+	return NewAnalyzer(nil, append([]AnalyzerOption{WithNoNetwork(), WithLogOutput(io.Discard)}, opts...)...)
+}
+
+// testKey returns a distinct, deterministic public key
+func testKey(n byte) solana.PublicKey {
+	var key solana.PublicKey
+	key[0], key[31] = n, 0xAA
+	return key
+}
+
+// testStep encodes a route plan step of a unit swap variant
+func testStep(variant, percent, input, output uint8) []byte {
+	return []byte{variant, percent, input, output}
+}
+
+// testInstruction encodes route family instruction data following the IDL.
+// amount is skipped for token ledger variants.
+func testInstruction(instructionType string, id uint8, steps [][]byte, amount, quoted uint64, slippageBps uint16, platformFeeBps uint8) []byte {
+	data, err := jupiterv6.EncodeInstruction(jupiterv6.Instruction{
+		Type:           instructionType,
+		ID:             id,
+		Steps:          steps,
+		Amount:         amount,
+		QuotedAmount:   quoted,
+		SlippageBps:    slippageBps,
+		PlatformFeeBps: platformFeeBps,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// testTokenBalance builds the token balance of the account at index
+func testTokenBalance(index int, mint, owner solana.PublicKey, amount uint64) rpc.TokenBalance {
+	return rpc.TokenBalance{
+		AccountIndex:  uint16(index),
+		Owner:         &owner,
+		Mint:          mint,
+		UiTokenAmount: &rpc.UiTokenAmount{Amount: strconv.FormatUint(amount, 10)},
+	}
+}
+
+// analyzeTest analyzes a transaction and fails the test on error
+func analyzeTest(t *testing.T, a *Analyzer, tx *rpc.GetTransactionResult, parsedTx *solana.Transaction) *JupiterV6Analysis {
+	t.Helper()
+	analysis, err := a.Analyze(tx, parsedTx)
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	return analysis
+}
+
+// testTransactionResult wraps parsedTx and meta as a getTransaction result
+func testTransactionResult(t testing.TB, parsedTx *solana.Transaction, meta *rpc.TransactionMeta) *rpc.GetTransactionResult {
+	t.Helper()
+	raw, err := parsedTx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("encoding transaction: %v", err)
+	}
+	envelope, err := json.Marshal([]string{base64.StdEncoding.EncodeToString(raw), "base64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := &rpc.GetTransactionResult{Meta: meta, Transaction: &rpc.TransactionResultEnvelope{}}
+	if err := result.Transaction.UnmarshalJSON(envelope); err != nil {
+		t.Fatalf("wrapping transaction: %v", err)
+	}
+	return result
+}
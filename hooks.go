@@ -0,0 +1,57 @@
+package main
+
+import "fmt"
+
+// Hooks are optional callbacks invoked synchronously during analysis.
+//
+// For every top-level Jupiter instruction, in transaction order:
+//  1. OnInstructionParsed is called with the parse result (params is nil when err is set)
+//  2. OnUnknownVariant is called once per route plan step with an unknown swap
+//     variant, with the step bytes from the variant index to output_index. The
+//     size of an unknown variant is not known, so its fields are not included.
+//  3. Hooks registered with OnSwapType are called for every route plan step of
+//     their swap type, once the step accounts are mapped
+//
+// After all instructions are parsed, OnEventExtracted is called for every event
+// in extraction order, and finally OnAnalysisComplete is called once with the
+// finished analysis. A panicking hook is recovered and reported as a warning
+// on the analysis; it does not abort the analysis.
+type Hooks struct {
+	OnInstructionParsed func(params *JupiterSwapParams, err error)
+	OnEventExtracted    func(event SwapEvent)
+	OnAnalysisComplete  func(analysis *JupiterV6Analysis)
+	OnUnknownVariant    func(index uint8, payload []byte)
+
+	// swapTypes holds the hooks registered with OnSwapType
+	swapTypes map[SwapType][]func(step RoutePlanStep, instructionData []byte)
+}
+
+// OnSwapType registers fn to be called for every parsed route plan step of swap type t
+func (h *Hooks) OnSwapType(t SwapType, fn func(step RoutePlanStep, instructionData []byte)) {
+	if fn == nil {
+		return
+	}
+	if h.swapTypes == nil {
+		h.swapTypes = make(map[SwapType][]func(step RoutePlanStep, instructionData []byte))
+	}
+	h.swapTypes[t] = append(h.swapTypes[t], fn)
+}
+
+// callHook runs fn, recording a warning on analysis if it panics
+func callHook(analysis *JupiterV6Analysis, name string, fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			analysis.addWarning(CodeHookPanicked, "hook %s panicked: %v", name, r)
+		}
+	}()
+	fn()
+}
+
+// unknownSwapIndex returns the variant index of an unknown swap type
+func unknownSwapIndex(swapType SwapType) (uint8, bool) {
+	var index uint8
+	if _, err := fmt.Sscanf(string(swapType), "Unknown_%d", &index); err != nil {
+		return 0, false
+	}
+	return index, true
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	tx := policyTransaction(t)
+	parsedTx, err := tx.Transaction.GetTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []string
+	var payload []byte
+	hooks := Hooks{
+		OnInstructionParsed: func(params *JupiterSwapParams, err error) { calls = append(calls, "parsed") },
+		OnEventExtracted:    func(event SwapEvent) { calls = append(calls, "event") },
+		OnAnalysisComplete:  func(analysis *JupiterV6Analysis) { calls = append(calls, "complete") },
+		OnUnknownVariant: func(index uint8, data []byte) {
+			calls = append(calls, "unknown")
+			payload = data
+		},
+	}
+	analysis := analyzeTest(t, newTestAnalyzer(WithHooks(hooks)), tx, parsedTx)
+
+	want := []string{"parsed", "unknown", "event", "event", "complete"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls %q, want %q", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("calls %q, want %q", calls, want)
+		}
+	}
+	// The payload is the unknown step only, not the whole instruction
+	if !bytes.Equal(payload, testStep(250, 100, 1, 2)) {
+		t.Errorf("unknown variant payload %v", payload)
+	}
+	for _, warning := range analysis.Warnings {
+		if warning.Code == CodeHookPanicked {
+			t.Errorf("unexpected warning %q", warning.Message)
+		}
+	}
+}
+
+func TestHooksPanicRecovered(t *testing.T) {
+	tx := policyTransaction(t)
+	parsedTx, err := tx.Transaction.GetTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	completed := false
+	hooks := Hooks{
+		OnEventExtracted:   func(event SwapEvent) { panic("boom") },
+		OnAnalysisComplete: func(analysis *JupiterV6Analysis) { completed = true },
+	}
+	analysis := analyzeTest(t, newTestAnalyzer(WithHooks(hooks)), tx, parsedTx)
+
+	if !completed || len(analysis.Events) != 2 {
+		t.Fatalf("analysis aborted: completed %v, %d events", completed, len(analysis.Events))
+	}
+	panics := 0
+	for _, warning := range analysis.Warnings {
+		if warning.Code == CodeHookPanicked {
+			panics++
+			if warning.Message != "hook OnEventExtracted panicked: boom" {
+				t.Errorf("warning %q", warning.Message)
+			}
+		}
+	}
+	if panics != 2 {
+		t.Errorf("%d HookPanicked warnings, want one per event", panics)
+	}
+}
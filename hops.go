@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// AMM program IDs with inner instruction decoders
+var (
+	whirlpoolProgramID    = solana.MustPublicKeyFromBase58("whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc")
+	raydiumClmmProgramID  = solana.MustPublicKeyFromBase58("CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK")
+	raydiumAmmV4ProgramID = solana.MustPublicKeyFromBase58("675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8")
+	meteoraDlmmProgramID  = solana.MustPublicKeyFromBase58("LBUZKhRxPF3XUpBCjp4YzTKgLccjZhTSDM9YuVaPwxo")
+	phoenixProgramID      = solana.MustPublicKeyFromBase58("PhoeNiXZ8ByJGLkxNfZRnkUfjvmuYqLR89jjFHGqdXY")
+)
+
+// Anchor instruction discriminators used by the hop decoders
+var (
+	anchorSwapDiscriminator                = []byte{0xF8, 0xC6, 0x9E, 0x91, 0xE1, 0x75, 0x87, 0xC8}
+	anchorSwapV2Discriminator              = []byte{0x2B, 0x04, 0xED, 0x0B, 0x1A, 0xC9, 0x1E, 0x62}
+	anchorSwapExactOutDiscriminator        = []byte{0xFA, 0x49, 0x65, 0x21, 0x26, 0xCF, 0x4B, 0xB8}
+	anchorSwapWithPriceImpactDiscriminator = []byte{0x38, 0xAD, 0xE6, 0xD0, 0xAD, 0xE4, 0x9C, 0xCD}
+)
+
+// hopDecoder decodes the swap instruction data of one AMM program
+type hopDecoder struct {
+	swapTypes []SwapType
+	decode    func(data []byte) (map[string]interface{}, bool)
+	// pool returns the position of the pool (or market) in the accounts of a decoded swap instruction
+	pool func(data []byte) int
+}
+
+// hopDecoders maps AMM programs to their inner instruction decoders
+var hopDecoders = map[solana.PublicKey]hopDecoder{
+	whirlpoolProgramID:    {[]SwapType{SwapWhirlpool, SwapWhirlpoolSwapV2}, decodeWhirlpoolSwap, whirlpoolPoolPosition},
+	raydiumClmmProgramID:  {[]SwapType{SwapRaydiumClmm, SwapRaydiumClmmV2}, decodeRaydiumClmmSwap, fixedPoolPosition(2)}, // pool_state
+	raydiumAmmV4ProgramID: {[]SwapType{SwapRaydium}, decodeRaydiumAmmV4Swap, fixedPoolPosition(1)},                       // amm
+	meteoraDlmmProgramID:  {[]SwapType{SwapMeteoraDlmm}, decodeMeteoraDlmmSwap, fixedPoolPosition(0)},                    // lb_pair
+	phoenixProgramID:      {[]SwapType{SwapPhoenix}, decodePhoenixSwap, fixedPoolPosition(2)},                            // market
+}
+
+// fixedPoolPosition returns a pool position independent of the instruction
+func fixedPoolPosition(position int) func(data []byte) int {
+	return func([]byte) int { return position }
+}
+
+// whirlpoolPoolPosition is the whirlpool account of swap (token_program,
+// token_authority, whirlpool) or swap_v2 (token_program_a, token_program_b,
+// memo_program, token_authority, whirlpool)
+func whirlpoolPoolPosition(data []byte) int {
+	if bytesEqual(data[:8], anchorSwapV2Discriminator) {
+		return 4
+	}
+	return 2
+}
+
+// WithHopDecoding enables decoding of the AMM swap instructions invoked by
+// Jupiter, attaching the decoded parameters to RoutePlanStep.Hop
+func WithHopDecoding(enabled bool) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.decodeHops = enabled
+	}
+}
+
+// attachHopParams decodes the AMM inner instructions of each Jupiter instruction.
+// Inner instructions are matched in order to the next route plan step of the same AMM.
+func attachHopParams(analysis *JupiterV6Analysis, parsedTx *solana.Transaction, meta *rpc.TransactionMeta) {
+	if meta == nil {
+		return
+	}
+
+	for i := range analysis.Instructions {
+		params := &analysis.Instructions[i]
+		matched := make([]bool, len(params.RoutePlan))
+
+		for _, inner := range meta.InnerInstructions {
+			if int(inner.Index) != params.InstructionIndex {
+				continue
+			}
+
+			for _, inst := range inner.Instructions {
+				programID, ok := programIDAt(int(inst.ProgramIDIndex), parsedTx.Message.AccountKeys, meta)
+				if !ok {
+					continue
+				}
+				decoder, ok := hopDecoders[programID]
+				if !ok {
+					continue
+				}
+				hop, ok := decoder.decode(inst.Data)
+				if !ok {
+					continue
+				}
+
+				for j := range params.RoutePlan {
+					if matched[j] || !containsSwapType(decoder.swapTypes, params.RoutePlan[j].Swap.Type) {
+						continue
+					}
+					params.RoutePlan[j].Hop = hop
+					matched[j] = true
+					break
+				}
+			}
+		}
+	}
+}
+
+// containsSwapType reports whether swapType is in types
+func containsSwapType(types []SwapType, swapType SwapType) bool {
+	for _, t := range types {
+		if t == swapType {
+			return true
+		}
+	}
+	return false
+}
+
+// readUint128 formats a little-endian u128 as a decimal string
+func readUint128(data []byte) string {
+	lo := binary.LittleEndian.Uint64(data[0:8])
+	hi := binary.LittleEndian.Uint64(data[8:16])
+	value := new(big.Int).SetUint64(hi)
+	value.Lsh(value, 64)
+	value.Or(value, new(big.Int).SetUint64(lo))
+	return value.String()
+}
+
+// decodeWhirlpoolSwap decodes swap / swapV2:
+// amount u64, other_amount_threshold u64, sqrt_price_limit u128, amount_specified_is_input bool, a_to_b bool
+func decodeWhirlpoolSwap(data []byte) (map[string]interface{}, bool) {
+	if len(data) < 8+34 {
+		return nil, false
+	}
+	instruction := "swap"
+	switch {
+	case bytesEqual(data[:8], anchorSwapDiscriminator):
+	case bytesEqual(data[:8], anchorSwapV2Discriminator):
+		instruction = "swap_v2"
+	default:
+		return nil, false
+	}
+	args := data[8:]
+	return map[string]interface{}{
+		"instruction":               instruction,
+		"amount":                    binary.LittleEndian.Uint64(args[0:8]),
+		"other_amount_threshold":    binary.LittleEndian.Uint64(args[8:16]),
+		"sqrt_price_limit":          readUint128(args[16:32]),
+		"amount_specified_is_input": args[32] != 0,
+		"a_to_b":                    args[33] != 0,
+	}, true
+}
+
+// decodeRaydiumClmmSwap decodes swap / swap_v2:
+// amount u64, other_amount_threshold u64, sqrt_price_limit_x64 u128, is_base_input bool
+func decodeRaydiumClmmSwap(data []byte) (map[string]interface{}, bool) {
+	if len(data) < 8+33 {
+		return nil, false
+	}
+	instruction := "swap"
+	switch {
+	case bytesEqual(data[:8], anchorSwapDiscriminator):
+	case bytesEqual(data[:8], anchorSwapV2Discriminator):
+		instruction = "swap_v2"
+	default:
+		return nil, false
+	}
+	args := data[8:]
+	return map[string]interface{}{
+		"instruction":            instruction,
+		"amount":                 binary.LittleEndian.Uint64(args[0:8]),
+		"other_amount_threshold": binary.LittleEndian.Uint64(args[8:16]),
+		"sqrt_price_limit_x64":   readUint128(args[16:32]),
+		"is_base_input":          args[32] != 0,
+	}, true
+}
+
+// decodeRaydiumAmmV4Swap decodes the native SwapBaseIn (9) and SwapBaseOut (11) instructions
+func decodeRaydiumAmmV4Swap(data []byte) (map[string]interface{}, bool) {
+	if len(data) < 17 {
+		return nil, false
+	}
+	switch data[0] {
+	case 9:
+		return map[string]interface{}{
+			"instruction":        "swap_base_in",
+			"amount_in":          binary.LittleEndian.Uint64(data[1:9]),
+			"minimum_amount_out": binary.LittleEndian.Uint64(data[9:17]),
+		}, true
+	case 11:
+		return map[string]interface{}{
+			"instruction":   "swap_base_out",
+			"max_amount_in": binary.LittleEndian.Uint64(data[1:9]),
+			"amount_out":    binary.LittleEndian.Uint64(data[9:17]),
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+// decodeMeteoraDlmmSwap decodes swap, swap_exact_out and swap_with_price_impact
+func decodeMeteoraDlmmSwap(data []byte) (map[string]interface{}, bool) {
+	if len(data) < 16 {
+		return nil, false
+	}
+	args := data[8:]
+	switch {
+	case bytesEqual(data[:8], anchorSwapDiscriminator) && len(args) >= 16:
+		return map[string]interface{}{
+			"instruction":    "swap",
+			"amount_in":      binary.LittleEndian.Uint64(args[0:8]),
+			"min_amount_out": binary.LittleEndian.Uint64(args[8:16]),
+		}, true
+	case bytesEqual(data[:8], anchorSwapExactOutDiscriminator) && len(args) >= 16:
+		return map[string]interface{}{
+			"instruction":   "swap_exact_out",
+			"max_in_amount": binary.LittleEndian.Uint64(args[0:8]),
+			"out_amount":    binary.LittleEndian.Uint64(args[8:16]),
+		}, true
+	case bytesEqual(data[:8], anchorSwapWithPriceImpactDiscriminator) && len(args) >= 9:
+		hop := map[string]interface{}{
+			"instruction": "swap_with_price_impact",
+			"amount_in":   binary.LittleEndian.Uint64(args[0:8]),
+		}
+		offset := 8
+		// active_id: Option<i32>
+		if args[offset] == 1 {
+			if len(args) < offset+5 {
+				return nil, false
+			}
+			hop["active_id"] = int32(binary.LittleEndian.Uint32(args[offset+1 : offset+5]))
+			offset += 5
+		} else {
+			offset++
+		}
+		if len(args) < offset+2 {
+			return nil, false
+		}
+		hop["max_price_impact_bps"] = binary.LittleEndian.Uint16(args[offset : offset+2])
+		return hop, true
+	default:
+		return nil, false
+	}
+}
+
+// decodePhoenixSwap decodes the Swap (0) instruction with an ImmediateOrCancel order packet:
+// side u8, price_in_ticks Option<u64>, num_base_lots u64, num_quote_lots u64,
+// min_base_lots_to_fill u64, min_quote_lots_to_fill u64
+func decodePhoenixSwap(data []byte) (map[string]interface{}, bool) {
+	// Instruction tag 0 (Swap) followed by order packet tag 2 (ImmediateOrCancel)
+	if len(data) < 4 || data[0] != 0 || data[1] != 2 {
+		return nil, false
+	}
+	side := "Bid"
+	if data[2] != 0 {
+		side = "Ask"
+	}
+	hop := map[string]interface{}{
+		"instruction": "swap",
+		"side":        side,
+	}
+
+	offset := 3
+	if data[offset] == 1 {
+		if len(data) < offset+9 {
+			return nil, false
+		}
+		hop["price_in_ticks"] = binary.LittleEndian.Uint64(data[offset+1 : offset+9])
+		offset += 9
+	} else {
+		offset++
+	}
+
+	if len(data) < offset+32 {
+		return nil, false
+	}
+	hop["num_base_lots"] = binary.LittleEndian.Uint64(data[offset : offset+8])
+	hop["num_quote_lots"] = binary.LittleEndian.Uint64(data[offset+8 : offset+16])
+	hop["min_base_lots_to_fill"] = binary.LittleEndian.Uint64(data[offset+16 : offset+24])
+	hop["min_quote_lots_to_fill"] = binary.LittleEndian.Uint64(data[offset+24 : offset+32])
+	return hop, true
+}
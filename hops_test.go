@@ -0,0 +1,246 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// hopData concatenates instruction data fields
+func hopData(fields ...[]byte) []byte {
+	var data []byte
+	for _, field := range fields {
+		data = append(data, field...)
+	}
+	return data
+}
+
+func u64(v uint64) []byte { return binary.LittleEndian.AppendUint64(nil, v) }
+func u16(v uint16) []byte { return binary.LittleEndian.AppendUint16(nil, v) }
+
+func TestAnchorDiscriminators(t *testing.T) {
+	for name, discriminator := range map[string][]byte{
+		"swap":                   anchorSwapDiscriminator,
+		"swap_v2":                anchorSwapV2Discriminator,
+		"swap_exact_out":         anchorSwapExactOutDiscriminator,
+		"swap_with_price_impact": anchorSwapWithPriceImpactDiscriminator,
+	} {
+		if sum := sha256.Sum256([]byte("global:" + name)); !bytesEqual(sum[:8], discriminator) {
+			t.Errorf("%s discriminator %X, want %X", name, discriminator, sum[:8])
+		}
+	}
+}
+
+func TestHopDecoders(t *testing.T) {
+	// sqrt price limits as the programs use them: the Whirlpool minimum
+	// (4295048016) and 2^64, one above the u64 range
+	whirlpoolMinSqrtPrice := append(u64(4295048016), u64(0)...)
+	twoTo64 := append(u64(0), u64(1)...)
+
+	for _, tt := range []struct {
+		name     string
+		program  solana.PublicKey
+		data     []byte
+		want     map[string]interface{}
+		position int
+	}{
+		{
+			name:    "whirlpool swap",
+			program: whirlpoolProgramID,
+			// amount, other_amount_threshold, sqrt_price_limit, amount_specified_is_input, a_to_b
+			data: hopData(anchorSwapDiscriminator, u64(1_000_000), u64(985_000), whirlpoolMinSqrtPrice, []byte{1, 1}),
+			want: map[string]interface{}{
+				"instruction": "swap", "amount": uint64(1_000_000), "other_amount_threshold": uint64(985_000),
+				"sqrt_price_limit": "4295048016", "amount_specified_is_input": true, "a_to_b": true,
+			},
+			position: 2,
+		},
+		{
+			name:    "whirlpool swap_v2",
+			program: whirlpoolProgramID,
+			// the same arguments followed by remaining_accounts_info: None
+			data: hopData(anchorSwapV2Discriminator, u64(500), u64(480), twoTo64, []byte{0, 0}, []byte{0}),
+			want: map[string]interface{}{
+				"instruction": "swap_v2", "amount": uint64(500), "other_amount_threshold": uint64(480),
+				"sqrt_price_limit": "18446744073709551616", "amount_specified_is_input": false, "a_to_b": false,
+			},
+			position: 4,
+		},
+		{
+			name:    "raydium clmm swap",
+			program: raydiumClmmProgramID,
+			// amount, other_amount_threshold, sqrt_price_limit_x64, is_base_input
+			data: hopData(anchorSwapDiscriminator, u64(2_000), u64(1_990), make([]byte, 16), []byte{1}),
+			want: map[string]interface{}{
+				"instruction": "swap", "amount": uint64(2_000), "other_amount_threshold": uint64(1_990),
+				"sqrt_price_limit_x64": "0", "is_base_input": true,
+			},
+			position: 2,
+		},
+		{
+			name:    "raydium clmm swap_v2",
+			program: raydiumClmmProgramID,
+			data:    hopData(anchorSwapV2Discriminator, u64(7), u64(6), twoTo64, []byte{0}),
+			want: map[string]interface{}{
+				"instruction": "swap_v2", "amount": uint64(7), "other_amount_threshold": uint64(6),
+				"sqrt_price_limit_x64": "18446744073709551616", "is_base_input": false,
+			},
+			position: 2,
+		},
+		{
+			name:     "raydium amm v4 swap_base_in",
+			program:  raydiumAmmV4ProgramID,
+			data:     hopData([]byte{9}, u64(1_000_000_000), u64(24_500_000)),
+			want:     map[string]interface{}{"instruction": "swap_base_in", "amount_in": uint64(1_000_000_000), "minimum_amount_out": uint64(24_500_000)},
+			position: 1,
+		},
+		{
+			name:     "raydium amm v4 swap_base_out",
+			program:  raydiumAmmV4ProgramID,
+			data:     hopData([]byte{11}, u64(1_010_000), u64(1_000_000)),
+			want:     map[string]interface{}{"instruction": "swap_base_out", "max_amount_in": uint64(1_010_000), "amount_out": uint64(1_000_000)},
+			position: 1,
+		},
+		{
+			name:     "meteora dlmm swap",
+			program:  meteoraDlmmProgramID,
+			data:     hopData(anchorSwapDiscriminator, u64(3_000), u64(2_950)),
+			want:     map[string]interface{}{"instruction": "swap", "amount_in": uint64(3_000), "min_amount_out": uint64(2_950)},
+			position: 0,
+		},
+		{
+			name:     "meteora dlmm swap_exact_out",
+			program:  meteoraDlmmProgramID,
+			data:     hopData(anchorSwapExactOutDiscriminator, u64(3_100), u64(3_000)),
+			want:     map[string]interface{}{"instruction": "swap_exact_out", "max_in_amount": uint64(3_100), "out_amount": uint64(3_000)},
+			position: 0,
+		},
+		{
+			name:    "meteora dlmm swap_with_price_impact",
+			program: meteoraDlmmProgramID,
+			// amount_in, active_id Some(-42), max_price_impact_bps
+			data: hopData(anchorSwapWithPriceImpactDiscriminator, u64(5_000), []byte{1}, binary.LittleEndian.AppendUint32(nil, uint32(0xFFFFFFD6)), u16(100)),
+			want: map[string]interface{}{
+				"instruction": "swap_with_price_impact", "amount_in": uint64(5_000), "active_id": int32(-42), "max_price_impact_bps": uint16(100),
+			},
+			position: 0,
+		},
+		{
+			name:     "meteora dlmm swap_with_price_impact without active_id",
+			program:  meteoraDlmmProgramID,
+			data:     hopData(anchorSwapWithPriceImpactDiscriminator, u64(5_000), []byte{0}, u16(250)),
+			want:     map[string]interface{}{"instruction": "swap_with_price_impact", "amount_in": uint64(5_000), "max_price_impact_bps": uint16(250)},
+			position: 0,
+		},
+		{
+			name:    "phoenix swap",
+			program: phoenixProgramID,
+			// Swap, ImmediateOrCancel: side Ask, price_in_ticks None, num_base_lots,
+			// num_quote_lots, min_base_lots_to_fill, min_quote_lots_to_fill, then
+			// self_trade_behavior, match_limit, client_order_id,
+			// use_only_deposited_funds, last_valid_slot and last_valid_unix_timestamp
+			data: hopData([]byte{0, 2, 1, 0}, u64(25), u64(0), u64(0), u64(1_200),
+				[]byte{1}, []byte{0}, make([]byte, 16), []byte{0}, []byte{0}, []byte{0}),
+			want: map[string]interface{}{
+				"instruction": "swap", "side": "Ask", "num_base_lots": uint64(25), "num_quote_lots": uint64(0),
+				"min_base_lots_to_fill": uint64(0), "min_quote_lots_to_fill": uint64(1_200),
+			},
+			position: 2,
+		},
+		{
+			name:    "phoenix swap with price",
+			program: phoenixProgramID,
+			data:    hopData([]byte{0, 2, 0, 1}, u64(1_500), u64(0), u64(40_000), u64(10), u64(0)),
+			want: map[string]interface{}{
+				"instruction": "swap", "side": "Bid", "price_in_ticks": uint64(1_500), "num_base_lots": uint64(0),
+				"num_quote_lots": uint64(40_000), "min_base_lots_to_fill": uint64(10), "min_quote_lots_to_fill": uint64(0),
+			},
+			position: 2,
+		},
+	} {
+		decoder := hopDecoders[tt.program]
+		hop, ok := decoder.decode(tt.data)
+		if !ok || !reflect.DeepEqual(hop, tt.want) {
+			t.Errorf("%s: decoded %v (%v), want %v", tt.name, hop, ok, tt.want)
+		}
+		if position := decoder.pool(tt.data); position != tt.position {
+			t.Errorf("%s: pool position %d, want %d", tt.name, position, tt.position)
+		}
+		if hop, ok := decoder.decode(tt.data[:len(tt.data)/2]); ok {
+			t.Errorf("%s: truncated data decoded as %v", tt.name, hop)
+		}
+	}
+
+	// Other instructions of the same programs are not swaps
+	for name, tt := range map[string]struct {
+		program solana.PublicKey
+		data    []byte
+	}{
+		"whirlpool two_hop_swap":     {whirlpoolProgramID, hopData([]byte{0xC3, 0x60, 0xED, 0x6C, 0x44, 0xA2, 0xDB, 0xE6}, make([]byte, 40))},
+		"raydium amm v4 deposit":     {raydiumAmmV4ProgramID, hopData([]byte{3}, make([]byte, 24))},
+		"phoenix place limit":        {phoenixProgramID, hopData([]byte{2, 1}, make([]byte, 40))},
+		"meteora dlmm add_liquidity": {meteoraDlmmProgramID, hopData([]byte{0xB5, 0x9D, 0x59, 0x43, 0x8F, 0xB6, 0x34, 0x48}, make([]byte, 16))},
+	} {
+		if hop, ok := hopDecoders[tt.program].decode(tt.data); ok {
+			t.Errorf("%s decoded as %v", name, hop)
+		}
+	}
+}
+
+func TestAttachHopParams(t *testing.T) {
+	whirlpoolSwap := hopData(anchorSwapDiscriminator, u64(1_000), u64(990), make([]byte, 16), []byte{1, 0})
+	clmmSwap := hopData(anchorSwapDiscriminator, u64(990), u64(900), make([]byte, 16), []byte{1})
+	secondWhirlpoolSwap := hopData(anchorSwapDiscriminator, u64(900), u64(880), make([]byte, 16), []byte{1, 1})
+
+	// the Raydium CLMM program comes from a lookup table
+	keys := solana.PublicKeySlice{testKey(1), jupiterV6ProgramID, whirlpoolProgramID, testKey(2)}
+	parsedTx := &solana.Transaction{Message: solana.Message{AccountKeys: keys}}
+	meta := &rpc.TransactionMeta{
+		LoadedAddresses: rpc.LoadedAddresses{ReadOnly: solana.PublicKeySlice{raydiumClmmProgramID}},
+		InnerInstructions: []rpc.InnerInstruction{
+			{Index: 3, Instructions: []solana.CompiledInstruction{
+				{ProgramIDIndex: 2, Data: whirlpoolSwap},
+				{ProgramIDIndex: 4, Data: clmmSwap},
+				{ProgramIDIndex: 3, Data: clmmSwap}, // not an AMM program
+				{ProgramIDIndex: 2, Data: secondWhirlpoolSwap},
+			}},
+			{Index: 5, Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 2, Data: whirlpoolSwap}}},
+		},
+	}
+	analysis := &JupiterV6Analysis{Instructions: []JupiterSwapParams{{
+		InstructionIndex: 3,
+		RoutePlan: []RoutePlanStep{
+			{Swap: Swap{Type: SwapWhirlpool}},
+			{Swap: Swap{Type: SwapSaber}},
+			{Swap: Swap{Type: SwapRaydiumClmmV2}},
+			{Swap: Swap{Type: SwapWhirlpoolSwapV2}},
+			{Swap: Swap{Type: SwapMeteoraDlmm}},
+		},
+	}}}
+
+	attachHopParams(analysis, parsedTx, meta)
+	steps := analysis.Instructions[0].RoutePlan
+	for i, want := range []map[string]interface{}{
+		{"amount": uint64(1_000), "a_to_b": false},
+		nil,
+		{"amount": uint64(990), "is_base_input": true},
+		{"amount": uint64(900), "a_to_b": true},
+		nil,
+	} {
+		if want == nil {
+			if steps[i].Hop != nil {
+				t.Errorf("step %d (%s): hop %v, want none", i, steps[i].Swap.Type, steps[i].Hop)
+			}
+			continue
+		}
+		for field, value := range want {
+			if steps[i].Hop[field] != value {
+				t.Errorf("step %d (%s): %s = %v, want %v", i, steps[i].Swap.Type, field, steps[i].Hop[field], value)
+			}
+		}
+	}
+}
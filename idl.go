@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"sol-tx/jupiterv6"
+)
+
+// IDL is the subset of an Anchor IDL needed to check the swap variant table
+type IDL struct {
+	Types []IDLTypeDef `json:"types"`
+}
+
+// IDLTypeDef is a named struct or enum type of the IDL
+type IDLTypeDef struct {
+	Name string `json:"name"`
+	Type struct {
+		Kind     string           `json:"kind"`
+		Fields   []IDLField       `json:"fields"`
+		Variants []IDLEnumVariant `json:"variants"`
+	} `json:"type"`
+}
+
+// IDLEnumVariant is an enum arm, without fields for unit arms
+type IDLEnumVariant struct {
+	Name   string     `json:"name"`
+	Fields []IDLField `json:"fields"`
+}
+
+// IDLField is a named field, or a bare type for tuple variants
+type IDLField struct {
+	Name string
+	Type json.RawMessage
+}
+
+// UnmarshalJSON accepts {"name": ..., "type": ...} and bare tuple types
+func (f *IDLField) UnmarshalJSON(data []byte) error {
+	var named struct {
+		Name string          `json:"name"`
+		Type json.RawMessage `json:"type"`
+	}
+	if err := json.Unmarshal(data, &named); err == nil && named.Type != nil {
+		f.Name, f.Type = named.Name, named.Type
+		return nil
+	}
+	f.Type = data
+	return nil
+}
+
+// LoadIDL reads an Anchor IDL JSON file
+func LoadIDL(path string) (*IDL, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var idl IDL
+	if err := json.Unmarshal(raw, &idl); err != nil {
+		return nil, fmt.Errorf("error decoding IDL: %v", err)
+	}
+	return &idl, nil
+}
+
+// typeDef returns the named type definition
+func (idl *IDL) typeDef(name string) (*IDLTypeDef, bool) {
+	for i := range idl.Types {
+		if idl.Types[i].Name == name {
+			return &idl.Types[i], true
+		}
+	}
+	return nil, false
+}
+
+// idlPrimitiveSizes is the borsh size of fixed size primitive types
+var idlPrimitiveSizes = map[string]int{
+	"bool": 1, "u8": 1, "i8": 1, "u16": 2, "i16": 2, "u32": 4, "i32": 4,
+	"u64": 8, "i64": 8, "u128": 16, "i128": 16, "publicKey": 32, "pubkey": 32,
+}
+
+// minSize returns the smallest borsh encoding of an IDL type and whether the
+// type has a data dependent size (options, vectors, strings, enums with fields)
+func (idl *IDL) minSize(raw json.RawMessage) (size int, dynamic bool, err error) {
+	var name string
+	if json.Unmarshal(raw, &name) == nil {
+		if size, ok := idlPrimitiveSizes[name]; ok {
+			return size, false, nil
+		}
+		if name == "string" || name == "bytes" {
+			return 4, true, nil
+		}
+		return 0, false, fmt.Errorf("unsupported IDL type %q", name)
+	}
+
+	var composite struct {
+		Defined json.RawMessage   `json:"defined"`
+		Option  json.RawMessage   `json:"option"`
+		Vec     json.RawMessage   `json:"vec"`
+		Array   []json.RawMessage `json:"array"`
+	}
+	if err := json.Unmarshal(raw, &composite); err != nil {
+		return 0, false, fmt.Errorf("unsupported IDL type %s", raw)
+	}
+
+	switch {
+	case composite.Option != nil:
+		return 1, true, nil
+	case composite.Vec != nil:
+		return 4, true, nil
+	case len(composite.Array) == 2:
+		var count int
+		if err := json.Unmarshal(composite.Array[1], &count); err != nil {
+			return 0, false, fmt.Errorf("unsupported array length %s", composite.Array[1])
+		}
+		size, dynamic, err := idl.minSize(composite.Array[0])
+		return size * count, dynamic, err
+	case composite.Defined != nil:
+		// Anchor 0.29 uses "defined": "Name", 0.30 "defined": {"name": "Name"}
+		var definedName string
+		if json.Unmarshal(composite.Defined, &definedName) != nil {
+			var named struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(composite.Defined, &named); err != nil {
+				return 0, false, fmt.Errorf("unsupported defined type %s", composite.Defined)
+			}
+			definedName = named.Name
+		}
+		def, ok := idl.typeDef(definedName)
+		if !ok {
+			return 0, false, fmt.Errorf("undefined IDL type %q", definedName)
+		}
+		if def.Type.Kind == "enum" {
+			for _, variant := range def.Type.Variants {
+				if len(variant.Fields) > 0 {
+					return 1, true, nil
+				}
+			}
+			return 1, false, nil
+		}
+		return idl.fieldsSize(def.Type.Fields)
+	}
+	return 0, false, fmt.Errorf("unsupported IDL type %s", raw)
+}
+
+// fieldsSize sums the minimal size of fields
+func (idl *IDL) fieldsSize(fields []IDLField) (size int, dynamic bool, err error) {
+	for _, field := range fields {
+		fieldSize, fieldDynamic, err := idl.minSize(field.Type)
+		if err != nil {
+			return 0, false, fmt.Errorf("field %s: %v", field.Name, err)
+		}
+		size += fieldSize
+		dynamic = dynamic || fieldDynamic
+	}
+	return size, dynamic, nil
+}
+
+// fixedPrefixSize sums the fields up to the first data dependent one, the part
+// of a variant covered by jupiterv6.SwapFixedSize
+func (idl *IDL) fixedPrefixSize(fields []IDLField) (int, error) {
+	size := 0
+	for _, field := range fields {
+		fieldSize, dynamic, err := idl.minSize(field.Type)
+		if err != nil {
+			return 0, fmt.Errorf("field %s: %v", field.Name, err)
+		}
+		if dynamic {
+			break
+		}
+		size += fieldSize
+	}
+	return size, nil
+}
+
+// VerifySwapTable compares the runtime swap variant table with the Swap enum of
+// the IDL and returns one line per mismatch, naming the variant
+func VerifySwapTable(idl *IDL) ([]string, error) {
+	def, ok := idl.typeDef("Swap")
+	if !ok || def.Type.Kind != "enum" {
+		return nil, fmt.Errorf("IDL has no Swap enum")
+	}
+
+	var diffs []string
+	for i, variant := range def.Type.Variants {
+		index := uint8(i)
+		expected, err := idl.fixedPrefixSize(variant.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("variant %d %s: %v", i, variant.Name, err)
+		}
+		_, dynamic, _ := idl.fieldsSize(variant.Fields)
+
+		// Decode zeroed data to see the variant and parameter names the runtime uses
+		swap, err := decodeSwapType(index, make([]byte, 256), 0)
+		if err != nil {
+			diffs = append(diffs, fmt.Sprintf("variant %d %s: runtime decode failed: %v", i, variant.Name, err))
+			continue
+		}
+		if strings.HasPrefix(string(swap.Type), "Unknown_") {
+			diffs = append(diffs, fmt.Sprintf("variant %d %s: missing from runtime table", i, variant.Name))
+			continue
+		}
+		if string(swap.Type) != variant.Name {
+			diffs = append(diffs, fmt.Sprintf("variant %d %s: runtime name is %s", i, variant.Name, swap.Type))
+		}
+		if size := jupiterv6.SwapFixedSize(index); size != expected {
+			diffs = append(diffs, fmt.Sprintf("variant %d %s: runtime fixed size %d, IDL %d", i, variant.Name, size, expected))
+		}
+		if dynamic && swap.variableSize == 0 {
+			diffs = append(diffs, fmt.Sprintf("variant %d %s: IDL has data dependent fields the runtime does not read", i, variant.Name))
+		}
+
+		var idlFields []string
+		for _, field := range variant.Fields {
+			idlFields = append(idlFields, field.Name)
+		}
+		var runtimeFields []string
+		for name := range swap.Params {
+			runtimeFields = append(runtimeFields, name)
+		}
+		if !dynamic && !sameFieldNames(idlFields, runtimeFields) {
+			diffs = append(diffs, fmt.Sprintf("variant %d %s: runtime fields [%s], IDL [%s]", i, variant.Name,
+				strings.Join(runtimeFields, ", "), strings.Join(idlFields, ", ")))
+		}
+	}
+	return diffs, nil
+}
+
+// sameFieldNames compares field names ignoring case and underscores, the
+// runtime uses snake_case like Anchor 0.30 IDLs while 0.29 IDLs use camelCase.
+// Both slices are sorted in place for the mismatch report.
+func sameFieldNames(idlFields, runtimeFields []string) bool {
+	sort.Strings(idlFields)
+	sort.Strings(runtimeFields)
+	normalized := func(names []string) []string {
+		keys := make([]string, len(names))
+		for i, name := range names {
+			keys[i] = strings.ToLower(strings.ReplaceAll(name, "_", ""))
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	return strings.Join(normalized(idlFields), ",") == strings.Join(normalized(runtimeFields), ",")
+}
+
+// runIDLCheckCommand implements the idl-check subcommand: idl-check <idl.json>
+func runIDLCheckCommand(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: idl-check <jupiter-v6-idl.json>")
+		return 2
+	}
+	idl, err := LoadIDL(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	diffs, err := VerifySwapTable(idl)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	for _, diff := range diffs {
+		fmt.Println(diff)
+	}
+	if len(diffs) > 0 {
+		return 1
+	}
+	fmt.Println("swap variant table matches the IDL")
+	return 0
+}
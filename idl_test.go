@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// idlFixtures are excerpts of the Jupiter V6 IDL holding the Swap enum and the
+// types it references, for the variants of the runtime table
+var idlFixtures = []string{
+	"testdata/idl/jupiter_v6_swap_anchor030.json",
+	"testdata/idl/jupiter_v6_swap_anchor029.json", // camelCase field names
+}
+
+func TestVerifySwapTable(t *testing.T) {
+	for _, path := range idlFixtures {
+		idl, err := LoadIDL(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		diffs, err := VerifySwapTable(idl)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if len(diffs) != 0 {
+			t.Errorf("%s:\n%s", path, strings.Join(diffs, "\n"))
+		}
+	}
+}
+
+func TestVerifySwapTableDrift(t *testing.T) {
+	idl, err := LoadIDL(idlFixtures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	swap, _ := idl.typeDef("Swap")
+	variants := swap.Type.Variants
+	variants[3].Name = "TokenSwapV1"
+	// Crema grows from a bool to a u16 and gains a field
+	variants[8].Fields = []IDLField{
+		{Name: "a_to_b", Type: json.RawMessage(`"u16"`)},
+		{Name: "fee_tier", Type: json.RawMessage(`"u8"`)},
+	}
+	swap.Type.Variants = append(variants, IDLEnumVariant{Name: "NewAmm"})
+
+	diffs, err := VerifySwapTable(idl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"variant 3 TokenSwapV1: runtime name is TokenSwap",
+		"variant 8 Crema: runtime fixed size 1, IDL 3",
+		"variant 8 Crema: runtime fields [a_to_b], IDL [a_to_b, fee_tier]",
+		"variant 62 NewAmm: missing from runtime table",
+	}
+	if strings.Join(diffs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diffs:\n%s\nwant:\n%s", strings.Join(diffs, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Names still differ after normalizing case
+	camel, err := LoadIDL(idlFixtures[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	swap, _ = camel.typeDef("Swap")
+	swap.Type.Variants[17].Fields[0].Name = "aToBee"
+	diffs, err = VerifySwapTable(camel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || diffs[0] != "variant 17 Whirlpool: runtime fields [a_to_b], IDL [aToBee]" {
+		t.Errorf("camelCase diffs %q", diffs)
+	}
+
+	if _, err := VerifySwapTable(&IDL{}); err == nil {
+		t.Error("IDL without Swap enum accepted")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// tradeIDVersion is embedded in every trade ID. Any change to the hashed input
+// or its encoding must bump it, so IDs of different schemes never compare equal.
+const tradeIDVersion = 1
+
+// tradeIDDomain separates trade ID hashes from other uses of SHA-256
+const tradeIDDomain = "jupiter-trade"
+
+// TradeID returns the stable ID of a swap hop: "t<version>_" followed by the
+// first 16 bytes, hex encoded, of
+//
+//	SHA-256("jupiter-trade" || 0x00 || signature (64 bytes) ||
+//	        instruction index (int32, big endian, -1 when unknown) ||
+//	        hop ordinal (uint32, big endian))
+//
+// The hop ordinal counts the events of the same top-level instruction in
+// canonical event order, starting at 0. All integers have a fixed width and
+// byte order, so the ID does not depend on the platform.
+func TradeID(signature solana.Signature, instructionIndex int, hop int) string {
+	input := make([]byte, 0, len(tradeIDDomain)+1+len(signature)+8)
+	input = append(input, tradeIDDomain...)
+	input = append(input, 0)
+	input = append(input, signature[:]...)
+	input = binary.BigEndian.AppendUint32(input, uint32(int32(instructionIndex)))
+	input = binary.BigEndian.AppendUint32(input, uint32(hop))
+	sum := sha256.Sum256(input)
+	return fmt.Sprintf("t%d_%s", tradeIDVersion, hex.EncodeToString(sum[:16]))
+}
+
+// AnalysisID returns the ID of the analysis, its transaction signature
+func (a *JupiterV6Analysis) AnalysisID() string {
+	return a.Signature.String()
+}
+
+// assignTradeIDs sets the TradeID of every event of a signed analysis
+func assignTradeIDs(analysis *JupiterV6Analysis) {
+	if analysis.Signature.IsZero() {
+		return
+	}
+	hops := make(map[int]int)
+	for i := range analysis.Events {
+		event := &analysis.Events[i]
+		event.TradeID = TradeID(analysis.Signature, event.InstructionIndex, hops[event.InstructionIndex])
+		hops[event.InstructionIndex]++
+	}
+}
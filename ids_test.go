@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+
+	"sol-tx/testgen"
+)
+
+func TestTradeID(t *testing.T) {
+	// Pinned vectors, computed from the documented input layout. A change
+	// here needs a tradeIDVersion bump.
+	for _, tt := range []struct {
+		instructionIndex int
+		hop              int
+		want             string
+	}{
+		{2, 0, "t1_cbfc1ad6066f5cc908cd4c829990eea5"},
+		{-1, 1, "t1_b9a6e38530dc4411906f9a3b3983e3ae"},
+	} {
+		if got := TradeID(solana.Signature{1}, tt.instructionIndex, tt.hop); got != tt.want {
+			t.Errorf("TradeID(%d, %d) = %s, want %s", tt.instructionIndex, tt.hop, got, tt.want)
+		}
+	}
+}
+
+func TestAssignTradeIDs(t *testing.T) {
+	gen, err := testgen.Generate(testgenSpec("route"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newTestAnalyzer()
+	analysis := analyzeTest(t, a, gen.Result, gen.Transaction)
+	again := analyzeTest(t, a, gen.Result, gen.Transaction)
+
+	if analysis.AnalysisID() != analysis.Signature.String() {
+		t.Errorf("analysis ID %s", analysis.AnalysisID())
+	}
+	seen := make(map[string]bool)
+	for i, event := range analysis.Events {
+		if want := TradeID(analysis.Signature, event.InstructionIndex, i); event.TradeID != want {
+			t.Errorf("event %d: trade ID %s, want %s", i, event.TradeID, want)
+		}
+		if again.Events[i].TradeID != event.TradeID {
+			t.Errorf("event %d: trade ID changed between analyses", i)
+		}
+		if seen[event.TradeID] {
+			t.Errorf("event %d: duplicate trade ID", i)
+		}
+		seen[event.TradeID] = true
+	}
+
+	// Without a signature there is nothing stable to derive the ID from
+	unsigned := &JupiterV6Analysis{Events: []SwapEvent{{}}}
+	assignTradeIDs(unsigned)
+	if unsigned.Events[0].TradeID != "" {
+		t.Errorf("unsigned trade ID %s", unsigned.Events[0].TradeID)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Integrator values that are not registry names
+const (
+	IntegratorNone    = "none"    // no platform fee was charged
+	IntegratorUnknown = "unknown" // a platform fee was charged to an unregistered account
+)
+
+// IntegratorRegistry maps platform fee accounts, or the owners of those token
+// accounts, to the integrator (wallet, bot, frontend) that set them
+type IntegratorRegistry struct {
+	Accounts map[solana.PublicKey]string `json:"accounts"`
+	Owners   map[solana.PublicKey]string `json:"owners"`
+}
+
+// DefaultIntegratorRegistry is the built-in seed, extended with
+// LoadIntegratorRegistry. It is empty until fee accounts have been verified.
+var DefaultIntegratorRegistry = &IntegratorRegistry{
+	Accounts: map[solana.PublicKey]string{},
+	Owners:   map[solana.PublicKey]string{},
+}
+
+// LoadIntegratorRegistry reads a registry from a JSON file of the form
+// {"accounts": {"<fee account>": "name"}, "owners": {"<owner>": "name"}}
+// merged over the built-in seed
+func LoadIntegratorRegistry(path string) (*IntegratorRegistry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var loaded IntegratorRegistry
+	if err := json.Unmarshal(raw, &loaded); err != nil {
+		return nil, fmt.Errorf("error decoding integrator registry: %v", err)
+	}
+
+	registry := &IntegratorRegistry{
+		Accounts: make(map[solana.PublicKey]string),
+		Owners:   make(map[solana.PublicKey]string),
+	}
+	for _, source := range []*IntegratorRegistry{DefaultIntegratorRegistry, &loaded} {
+		for account, name := range source.Accounts {
+			registry.Accounts[account] = name
+		}
+		for owner, name := range source.Owners {
+			registry.Owners[owner] = name
+		}
+	}
+	return registry, nil
+}
+
+// Lookup returns the integrator of a fee account, matching the account first and its owner second
+func (r *IntegratorRegistry) Lookup(account solana.PublicKey, owner *solana.PublicKey) (string, bool) {
+	if name, ok := r.Accounts[account]; ok {
+		return name, true
+	}
+	if owner != nil {
+		if name, ok := r.Owners[*owner]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// WithIntegratorRegistry tags analyses with the integrator owning the platform fee account
+func WithIntegratorRegistry(registry *IntegratorRegistry) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.integrators = registry
+	}
+}
+
+// platformFeeAccountPositions gives the platform_fee_account position of each instruction type
+var platformFeeAccountPositions = map[string]int{
+	"route":                              6,
+	"routeWithTokenLedger":               6,
+	"exactOutRoute":                      7,
+	"sharedAccountsRoute":                9,
+	"sharedAccountsRouteWithTokenLedger": 9,
+	"sharedAccountsExactOutRoute":        9,
+}
+
+// attributeIntegrator sets the analysis integrator from the platform fee account
+// of the first instruction charging a fee
+func attributeIntegrator(analysis *JupiterV6Analysis, parsedTx *solana.Transaction, meta *rpc.TransactionMeta, registry *IntegratorRegistry) {
+	if registry == nil || len(analysis.Instructions) == 0 {
+		return
+	}
+
+	analysis.Integrator = IntegratorNone
+	for _, params := range analysis.Instructions {
+		if params.PlatformFeeBps == 0 {
+			continue
+		}
+		analysis.Integrator = IntegratorUnknown
+
+		position, ok := platformFeeAccountPositions[params.InstructionType]
+		if !ok || params.InstructionIndex >= len(parsedTx.Message.Instructions) {
+			continue
+		}
+		accounts := parsedTx.Message.Instructions[params.InstructionIndex].Accounts
+		if position >= len(accounts) || int(accounts[position]) >= len(parsedTx.Message.AccountKeys) {
+			continue
+		}
+		index := int(accounts[position])
+		account := parsedTx.Message.AccountKeys[index]
+
+		var owner *solana.PublicKey
+		if meta != nil {
+			for _, balances := range [][]rpc.TokenBalance{meta.PostTokenBalances, meta.PreTokenBalances} {
+				if balance, found := findTokenBalance(balances, index); found && balance.Owner != nil {
+					owner = balance.Owner
+					break
+				}
+			}
+		}
+
+		if name, ok := registry.Lookup(account, owner); ok {
+			analysis.Integrator = name
+			return
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+
+	"sol-tx/testgen"
+)
+
+func TestLoadIntegratorRegistry(t *testing.T) {
+	feeAccount, feeOwner, seeded := testKey(1), testKey(2), testKey(3)
+	DefaultIntegratorRegistry.Accounts[seeded] = "seed"
+	DefaultIntegratorRegistry.Accounts[feeAccount] = "overridden"
+	t.Cleanup(func() {
+		delete(DefaultIntegratorRegistry.Accounts, seeded)
+		delete(DefaultIntegratorRegistry.Accounts, feeAccount)
+	})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "integrators.json")
+	registryJSON := `{
+		"accounts": {"` + feeAccount.String() + `": "wallet"},
+		"owners": {"` + feeOwner.String() + `": "bot"}
+	}`
+	if err := os.WriteFile(path, []byte(registryJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := LoadIntegratorRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, otherOwner := testKey(4), testKey(5)
+	for _, tt := range []struct {
+		name    string
+		account solana.PublicKey
+		owner   *solana.PublicKey
+		want    string
+		found   bool
+	}{
+		{"by account", feeAccount, nil, "wallet", true},
+		{"account before owner", feeAccount, &feeOwner, "wallet", true},
+		{"by owner", other, &feeOwner, "bot", true},
+		{"from the seed", seeded, nil, "seed", true},
+		{"unknown owner", other, &otherOwner, "", false},
+		{"no owner", other, nil, "", false},
+	} {
+		if name, found := registry.Lookup(tt.account, tt.owner); name != tt.want || found != tt.found {
+			t.Errorf("%s: %q %v, want %q %v", tt.name, name, found, tt.want, tt.found)
+		}
+	}
+
+	for name, content := range map[string]string{
+		"not json":      `accounts: {}`,
+		"bad key":       `{"accounts": {"not-a-key": "wallet"}}`,
+		"wrong type":    `{"owners": ["bot"]}`,
+		"name not text": `{"accounts": {"` + feeAccount.String() + `": 7}}`,
+	} {
+		path := filepath.Join(dir, "malformed.json")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if registry, err := LoadIntegratorRegistry(path); err == nil {
+			t.Errorf("%s: loaded %+v", name, registry)
+		}
+	}
+	if _, err := LoadIntegratorRegistry(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("missing file: %v", err)
+	}
+}
+
+func TestWithIntegratorRegistry(t *testing.T) {
+	gen, err := testgen.Generate(testgenSpec("route"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedTx := gen.Transaction
+	instruction := parsedTx.Message.Instructions[0]
+	feeAccount := parsedTx.Message.AccountKeys[instruction.Accounts[platformFeeAccountPositions["route"]]]
+
+	registered := &IntegratorRegistry{Accounts: map[solana.PublicKey]string{feeAccount: "wallet"}}
+	noFee := testgenSpec("route")
+	noFee.PlatformFeeBps = 0
+	genNoFee, err := testgen.Generate(noFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		gen      *testgen.Generated
+		registry *IntegratorRegistry
+		want     string
+	}{
+		{"registered fee account", gen, registered, "wallet"},
+		{"unregistered fee account", gen, &IntegratorRegistry{}, IntegratorUnknown},
+		{"no platform fee", genNoFee, registered, IntegratorNone},
+		{"no registry", gen, nil, ""},
+	} {
+		var opts []AnalyzerOption
+		if tt.registry != nil {
+			opts = append(opts, WithIntegratorRegistry(tt.registry))
+		}
+		analysis := analyzeTest(t, newTestAnalyzer(opts...), tt.gen.Result, tt.gen.Transaction)
+		if analysis.Integrator != tt.want {
+			t.Errorf("%s: integrator %q, want %q", tt.name, analysis.Integrator, tt.want)
+		}
+	}
+}
@@ -0,0 +1,596 @@
+package jupiterv6
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// Sentinel decode errors, matched with errors.Is
+var (
+	ErrUnknownDiscriminator = errors.New("unknown instruction discriminator")
+	ErrTruncatedInstruction = errors.New("truncated instruction")
+)
+
+// Swap is a decoded route plan swap variant
+type Swap struct {
+	Index  uint8
+	Name   string // IDL variant name, Unknown_<index> for variants missing from the table
+	Params map[string]interface{}
+	// Fields holds the borsh encoded variant fields as read from the instruction
+	Fields []byte
+}
+
+// Step is a decoded route plan step
+type Step struct {
+	Swap        Swap
+	Percent     uint8
+	InputIndex  uint8
+	OutputIndex uint8
+	// Raw holds the step as read from the instruction, variant index to output_index
+	Raw []byte
+}
+
+// Tail holds the arguments following the route plan
+type Tail struct {
+	Amount         uint64 // in_amount for exactIn, out_amount for exactOut, zero for token ledger variants
+	QuotedAmount   uint64 // quoted_out_amount for exactIn, quoted_in_amount for exactOut
+	SlippageBps    uint16
+	PlatformFeeBps uint8
+	ExpireAt       *int64 // Optional trailing argument of newer revisions
+}
+
+// DecodedInstruction is a decoded route family instruction. Instruction holds
+// the arguments with the raw steps, so it encodes back to the same data.
+type DecodedInstruction struct {
+	Instruction
+	Route []Step
+	// MinAmountOut is the minimum output for exactIn and the maximum input for
+	// exactOut, SlippageAllowance the absolute difference to the quote
+	MinAmountOut      uint64
+	SlippageAllowance uint64
+}
+
+// DecodeInstruction decodes route family instruction data
+func DecodeInstruction(data []byte) (*DecodedInstruction, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("%w: instruction data too short", ErrTruncatedInstruction)
+	}
+	var decoded DecodedInstruction
+	for name, discriminator := range InstructionDiscriminators {
+		if string(data[:8]) == string(discriminator) {
+			decoded.Type = name
+			break
+		}
+	}
+	if decoded.Type == "" {
+		return nil, fmt.Errorf("%w: %X", ErrUnknownDiscriminator, data[:8])
+	}
+
+	offset := 8
+	if IsShared(decoded.Type) {
+		if offset >= len(data) {
+			return nil, fmt.Errorf("%w: missing id", ErrTruncatedInstruction)
+		}
+		decoded.ID = data[offset]
+		offset++
+	}
+
+	// Make sure the whole route plan fits before allocating it
+	if _, err := RoutePlanByteLength(data, offset); err != nil {
+		return nil, err
+	}
+	count := binary.LittleEndian.Uint32(data[offset : offset+4])
+	offset += 4
+	decoded.Route = make([]Step, count)
+	decoded.Steps = make([][]byte, count)
+	for i := range decoded.Route {
+		step, next, err := DecodeStep(data, offset)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing route plan step %d: %w", i, err)
+		}
+		decoded.Route[i], decoded.Steps[i] = step, step.Raw
+		offset = next
+	}
+
+	tail, err := DecodeTail(data, offset, decoded.Type)
+	if err != nil {
+		return nil, err
+	}
+	decoded.Amount = tail.Amount
+	decoded.QuotedAmount = tail.QuotedAmount
+	decoded.SlippageBps = tail.SlippageBps
+	decoded.PlatformFeeBps = tail.PlatformFeeBps
+	decoded.ExpireAt = tail.ExpireAt
+
+	if IsExactOut(decoded.Type) {
+		decoded.MinAmountOut = ApplySlippageBps(tail.QuotedAmount, tail.SlippageBps, true)
+		decoded.SlippageAllowance = decoded.MinAmountOut - tail.QuotedAmount
+	} else {
+		decoded.MinAmountOut = ApplySlippageBps(tail.QuotedAmount, tail.SlippageBps, false)
+		decoded.SlippageAllowance = tail.QuotedAmount - decoded.MinAmountOut
+	}
+	return &decoded, nil
+}
+
+// DecodeTail decodes the arguments following the route plan at offset.
+// Token ledger variants take their input amount from the ledger and have no
+// in_amount argument: quoted_out_amount u64, slippage_bps u16, platform_fee_bps u8.
+func DecodeTail(data []byte, offset int, instructionType string) (Tail, error) {
+	if offset+TailSize(instructionType) > len(data) {
+		return Tail{}, fmt.Errorf("%w: missing swap amounts", ErrTruncatedInstruction)
+	}
+
+	var tail Tail
+	if !IsTokenLedger(instructionType) {
+		tail.Amount = binary.LittleEndian.Uint64(data[offset : offset+8])
+		offset += 8
+	}
+	tail.QuotedAmount = binary.LittleEndian.Uint64(data[offset : offset+8])
+	offset += 8
+	tail.SlippageBps = binary.LittleEndian.Uint16(data[offset : offset+2])
+	offset += 2
+	tail.PlatformFeeBps = data[offset]
+	offset++
+	// The optional tail is only read when it ends the data, any other trailing
+	// bytes are left to the drift checks
+	if expireAt, size, ok := DecodeOptionalTail(data[offset:]); ok && offset+size == len(data) {
+		tail.ExpireAt = expireAt
+	}
+	return tail, nil
+}
+
+// DecodeStep decodes the route plan step at offset and returns the offset
+// following it
+func DecodeStep(data []byte, offset int) (Step, int, error) {
+	if offset+4 > len(data) {
+		return Step{}, offset, fmt.Errorf("%w: not enough data for route plan step", ErrTruncatedInstruction)
+	}
+
+	stepStart := offset
+	swap, err := DecodeSwap(data[offset], data, offset+1)
+	if err != nil {
+		return Step{}, offset, fmt.Errorf("%w: %v", ErrTruncatedInstruction, err)
+	}
+	offset += 1 + len(swap.Fields)
+	if offset+3 > len(data) {
+		return Step{}, offset, fmt.Errorf("%w: not enough data for route plan step", ErrTruncatedInstruction)
+	}
+
+	step := Step{
+		Swap:        swap,
+		Percent:     data[offset],
+		InputIndex:  data[offset+1],
+		OutputIndex: data[offset+2],
+	}
+	offset += 3
+	step.Raw = data[stepStart:offset]
+	return step, offset, nil
+}
+
+// RoutePlanByteLength returns the number of bytes of the route plan vector at
+// offset, length prefix included, walking step headers and parameter sizes
+// without decoding the steps
+func RoutePlanByteLength(data []byte, offset int) (int, error) {
+	start := offset
+	if offset+4 > len(data) {
+		return 0, fmt.Errorf("%w: missing route plan length", ErrTruncatedInstruction)
+	}
+	routePlanCount := binary.LittleEndian.Uint32(data[offset : offset+4])
+	offset += 4
+
+	// Every step takes at least 4 bytes
+	if uint64(routePlanCount)*4 > uint64(len(data)-offset) {
+		return 0, fmt.Errorf("%w: route plan length %d exceeds data", ErrTruncatedInstruction, routePlanCount)
+	}
+
+	for i := uint32(0); i < routePlanCount; i++ {
+		if offset+4 > len(data) {
+			return 0, fmt.Errorf("%w: not enough data for route plan step %d", ErrTruncatedInstruction, i)
+		}
+		swapTypeIndex := data[offset]
+		offset += 1 + SwapFixedSize(swapTypeIndex)
+
+		// Only WhirlpoolSwapV2 has data dependent parameters after its fixed part
+		if swapTypeIndex == 47 {
+			_, size, err := parseOptionalRemainingAccountsInfo(data, offset)
+			if err != nil {
+				return 0, fmt.Errorf("%w: route plan step %d: WhirlpoolSwapV2: %v", ErrTruncatedInstruction, i, err)
+			}
+			offset += size
+		}
+
+		// percent, input_index and output_index
+		offset += 3
+		if offset > len(data) {
+			return 0, fmt.Errorf("%w: not enough data for route plan step %d", ErrTruncatedInstruction, i)
+		}
+	}
+	return offset - start, nil
+}
+
+// DecodeSwap decodes the fields of the swap variant at index from data at offset
+func DecodeSwap(index uint8, data []byte, offset int) (Swap, error) {
+	v, err := decodeVariant(index, data, offset)
+	if err != nil {
+		return Swap{}, err
+	}
+	end := offset + SwapFixedSize(index) + v.variableSize
+	return Swap{Index: index, Name: v.name, Params: v.params, Fields: data[offset:end]}, nil
+}
+
+// variant is the name and fields of a swap variant, variableSize is the length
+// of data dependent fields on top of SwapFixedSize
+type variant struct {
+	name         string
+	params       map[string]interface{}
+	variableSize int
+}
+
+// decodeVariant decodes the name and fields of the swap variant at index
+func decodeVariant(swapTypeIndex uint8, data []byte, offset int) (variant, error) {
+	switch swapTypeIndex {
+	case 0:
+		return variant{name: "Saber", params: map[string]interface{}{}}, nil
+	case 1:
+		return variant{name: "SaberAddDecimalsDeposit", params: map[string]interface{}{}}, nil
+	case 2:
+		return variant{name: "SaberAddDecimalsWithdraw", params: map[string]interface{}{}}, nil
+	case 3:
+		return variant{name: "TokenSwap", params: map[string]interface{}{}}, nil
+	case 4:
+		return variant{name: "Sencha", params: map[string]interface{}{}}, nil
+	case 5:
+		return variant{name: "Step", params: map[string]interface{}{}}, nil
+	case 6:
+		return variant{name: "Cropper", params: map[string]interface{}{}}, nil
+	case 7:
+		return variant{name: "Raydium", params: map[string]interface{}{}}, nil
+	case 8:
+		// Crema with a_to_b parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for Crema swap")
+		}
+		aToB := data[offset] != 0
+		return variant{name: "Crema", params: map[string]interface{}{"a_to_b": aToB}}, nil
+	case 9:
+		return variant{name: "Lifinity", params: map[string]interface{}{}}, nil
+	case 10:
+		return variant{name: "Mercurial", params: map[string]interface{}{}}, nil
+	case 11:
+		return variant{name: "Cykura", params: map[string]interface{}{}}, nil
+	case 12:
+		// Serum with side parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for Serum swap")
+		}
+		side := "Bid"
+		if data[offset] != 0 {
+			side = "Ask"
+		}
+		return variant{name: "Serum", params: map[string]interface{}{"side": side}}, nil
+	case 13:
+		return variant{name: "MarinadeDeposit", params: map[string]interface{}{}}, nil
+	case 14:
+		return variant{name: "MarinadeUnstake", params: map[string]interface{}{}}, nil
+	case 15:
+		// Aldrin with side parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for Aldrin swap")
+		}
+		side := "Bid"
+		if data[offset] != 0 {
+			side = "Ask"
+		}
+		return variant{name: "Aldrin", params: map[string]interface{}{"side": side}}, nil
+	case 16:
+		// AldrinV2 with side parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for AldrinV2 swap")
+		}
+		side := "Bid"
+		if data[offset] != 0 {
+			side = "Ask"
+		}
+		return variant{name: "AldrinV2", params: map[string]interface{}{"side": side}}, nil
+	case 17:
+		// Whirlpool with a_to_b parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for Whirlpool swap")
+		}
+		aToB := data[offset] != 0
+		return variant{name: "Whirlpool", params: map[string]interface{}{"a_to_b": aToB}}, nil
+	case 18:
+		// Invariant with x_to_y parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for Invariant swap")
+		}
+		xToY := data[offset] != 0
+		return variant{name: "Invariant", params: map[string]interface{}{"x_to_y": xToY}}, nil
+	case 19:
+		return variant{name: "Meteora", params: map[string]interface{}{}}, nil
+	case 20:
+		return variant{name: "GooseFX", params: map[string]interface{}{}}, nil
+	case 21:
+		// DeltaFi with stable parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for DeltaFi swap")
+		}
+		stable := data[offset] != 0
+		return variant{name: "DeltaFi", params: map[string]interface{}{"stable": stable}}, nil
+	case 22:
+		return variant{name: "Balansol", params: map[string]interface{}{}}, nil
+	case 23:
+		// MarcoPolo with x_to_y parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for MarcoPolo swap")
+		}
+		xToY := data[offset] != 0
+		return variant{name: "MarcoPolo", params: map[string]interface{}{"x_to_y": xToY}}, nil
+	case 24:
+		// Dradex with side parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for Dradex swap")
+		}
+		side := "Bid"
+		if data[offset] != 0 {
+			side = "Ask"
+		}
+		return variant{name: "Dradex", params: map[string]interface{}{"side": side}}, nil
+	case 25:
+		return variant{name: "LifinityV2", params: map[string]interface{}{}}, nil
+	case 26:
+		return variant{name: "RaydiumClmm", params: map[string]interface{}{}}, nil
+	case 27:
+		// Openbook with side parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for Openbook swap")
+		}
+		side := "Bid"
+		if data[offset] != 0 {
+			side = "Ask"
+		}
+		return variant{name: "Openbook", params: map[string]interface{}{"side": side}}, nil
+	case 28:
+		// Phoenix with side parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for Phoenix swap")
+		}
+		side := "Bid"
+		if data[offset] != 0 {
+			side = "Ask"
+		}
+		return variant{name: "Phoenix", params: map[string]interface{}{"side": side}}, nil
+	case 29:
+		// Symmetry with token IDs
+		if offset+16 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for Symmetry swap")
+		}
+		fromTokenID := binary.LittleEndian.Uint64(data[offset : offset+8])
+		toTokenID := binary.LittleEndian.Uint64(data[offset+8 : offset+16])
+		return variant{name: "Symmetry", params: map[string]interface{}{
+			"from_token_id": fromTokenID,
+			"to_token_id":   toTokenID,
+		}}, nil
+	case 30:
+		return variant{name: "TokenSwapV2", params: map[string]interface{}{}}, nil
+	case 31:
+		return variant{name: "HeliumTreasuryManagementRedeemV0", params: map[string]interface{}{}}, nil
+	case 32:
+		return variant{name: "StakeDexStakeWrappedSol", params: map[string]interface{}{}}, nil
+	case 33:
+		// StakeDexSwapViaStake with bridge_stake_seed
+		if offset+4 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for StakeDexSwapViaStake swap")
+		}
+		bridgeStakeSeed := binary.LittleEndian.Uint32(data[offset : offset+4])
+		return variant{name: "StakeDexSwapViaStake", params: map[string]interface{}{
+			"bridge_stake_seed": bridgeStakeSeed,
+		}}, nil
+	case 34:
+		return variant{name: "GooseFXV2", params: map[string]interface{}{}}, nil
+	case 35:
+		// The Perps family (35-37, 51-53) are unit variants in the IDL, side and
+		// pool are given by the step accounts rather than the instruction data
+		return variant{name: "Perps", params: map[string]interface{}{}}, nil
+	case 36:
+		return variant{name: "PerpsAddLiquidity", params: map[string]interface{}{}}, nil
+	case 37:
+		return variant{name: "PerpsRemoveLiquidity", params: map[string]interface{}{}}, nil
+	case 38:
+		return variant{name: "MeteoraDlmm", params: map[string]interface{}{}}, nil
+	case 39:
+		// OpenBookV2 with side parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for OpenBookV2 swap")
+		}
+		side := "Bid"
+		if data[offset] != 0 {
+			side = "Ask"
+		}
+		return variant{name: "OpenBookV2", params: map[string]interface{}{"side": side}}, nil
+	case 40:
+		return variant{name: "RaydiumClmmV2", params: map[string]interface{}{}}, nil
+	case 41:
+		// StakeDexPrefundWithdrawStake with bridge_stake_seed
+		if offset+4 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for StakeDexPrefundWithdrawStake swap")
+		}
+		bridgeStakeSeed := binary.LittleEndian.Uint32(data[offset : offset+4])
+		return variant{name: "StakeDexPrefundWithdrawStakeAndDepositStake", params: map[string]interface{}{
+			"bridge_stake_seed": bridgeStakeSeed,
+		}}, nil
+	case 42:
+		// Clone with multiple parameters
+		if offset+3 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for Clone swap")
+		}
+		poolIndex := data[offset]
+		quantityIsInput := data[offset+1] != 0
+		quantityIsCollateral := data[offset+2] != 0
+		return variant{name: "Clone", params: map[string]interface{}{
+			"pool_index":             poolIndex,
+			"quantity_is_input":      quantityIsInput,
+			"quantity_is_collateral": quantityIsCollateral,
+		}}, nil
+	case 43:
+		// SanctumS with multiple parameters
+		if offset+10 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for SanctumS swap")
+		}
+		srcLstValueCalcAccs := data[offset]
+		dstLstValueCalcAccs := data[offset+1]
+		srcLstIndex := binary.LittleEndian.Uint32(data[offset+2 : offset+6])
+		dstLstIndex := binary.LittleEndian.Uint32(data[offset+6 : offset+10])
+		return variant{name: "SanctumS", params: map[string]interface{}{
+			"src_lst_value_calc_accs": srcLstValueCalcAccs,
+			"dst_lst_value_calc_accs": dstLstValueCalcAccs,
+			"src_lst_index":           srcLstIndex,
+			"dst_lst_index":           dstLstIndex,
+		}}, nil
+	case 44:
+		// SanctumSAddLiquidity with parameters
+		if offset+5 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for SanctumSAddLiquidity swap")
+		}
+		lstValueCalcAccs := data[offset]
+		lstIndex := binary.LittleEndian.Uint32(data[offset+1 : offset+5])
+		return variant{name: "SanctumSAddLiquidity", params: map[string]interface{}{
+			"lst_value_calc_accs": lstValueCalcAccs,
+			"lst_index":           lstIndex,
+		}}, nil
+	case 45:
+		// SanctumSRemoveLiquidity with parameters
+		if offset+5 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for SanctumSRemoveLiquidity swap")
+		}
+		lstValueCalcAccs := data[offset]
+		lstIndex := binary.LittleEndian.Uint32(data[offset+1 : offset+5])
+		return variant{name: "SanctumSRemoveLiquidity", params: map[string]interface{}{
+			"lst_value_calc_accs": lstValueCalcAccs,
+			"lst_index":           lstIndex,
+		}}, nil
+	case 46:
+		return variant{name: "RaydiumCP", params: map[string]interface{}{}}, nil
+	case 47:
+		// WhirlpoolSwapV2 with a_to_b and remaining_accounts_info
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for WhirlpoolSwapV2 swap")
+		}
+		aToB := data[offset] != 0
+		info, size, err := parseOptionalRemainingAccountsInfo(data, offset+1)
+		if err != nil {
+			return variant{}, fmt.Errorf("WhirlpoolSwapV2: %v", err)
+		}
+		params := map[string]interface{}{
+			"a_to_b": aToB,
+		}
+		if info != nil {
+			params["remaining_accounts_info"] = info
+		}
+		return variant{name: "WhirlpoolSwapV2", params: params, variableSize: size}, nil
+	case 48:
+		// OneIntro is a unit variant in the IDL, the next byte is the step percent
+		return variant{name: "OneIntro", params: map[string]interface{}{}}, nil
+	case 49:
+		return variant{name: "PumpdotfunWrappedBuy", params: map[string]interface{}{}}, nil
+	case 50:
+		return variant{name: "PumpdotfunWrappedSell", params: map[string]interface{}{}}, nil
+	case 51:
+		return variant{name: "PerpsV2", params: map[string]interface{}{}}, nil
+	case 52:
+		return variant{name: "PerpsV2AddLiquidity", params: map[string]interface{}{}}, nil
+	case 53:
+		return variant{name: "PerpsV2RemoveLiquidity", params: map[string]interface{}{}}, nil
+	case 54:
+		// MoonshotWrappedBuy and MoonshotWrappedSell are unit variants in the IDL,
+		// the amounts are carried by the instruction, not the route step
+		return variant{name: "MoonshotWrappedBuy", params: map[string]interface{}{}}, nil
+	case 55:
+		return variant{name: "MoonshotWrappedSell", params: map[string]interface{}{}}, nil
+	case 56:
+		// StabbleStableSwap and StabbleWeightedSwap are unit variants in the IDL,
+		// the pool is selected by the step accounts, not a parameter
+		return variant{name: "StabbleStableSwap", params: map[string]interface{}{}}, nil
+	case 57:
+		return variant{name: "StabbleWeightedSwap", params: map[string]interface{}{}}, nil
+	case 58:
+		// Obric with x_to_y parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for Obric swap")
+		}
+		xToY := data[offset] != 0
+		return variant{name: "Obric", params: map[string]interface{}{"x_to_y": xToY}}, nil
+	case 59:
+		return variant{name: "FoxBuyFromEstimatedCost", params: map[string]interface{}{}}, nil
+	case 60:
+		// FoxClaimPartial with is_y parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for FoxClaimPartial swap")
+		}
+		isY := data[offset] != 0
+		return variant{name: "FoxClaimPartial", params: map[string]interface{}{"is_y": isY}}, nil
+	case 61:
+		// SolFi with is_quote_to_base parameter
+		if offset+1 > len(data) {
+			return variant{}, fmt.Errorf("not enough data for SolFi swap")
+		}
+		isQuoteToBase := data[offset] != 0
+		return variant{name: "SolFi", params: map[string]interface{}{"is_quote_to_base": isQuoteToBase}}, nil
+	case 76:
+		// Woofi is a unit variant in the IDL, the next byte is the step percent
+		return variant{name: "Woofi", params: map[string]interface{}{}}, nil
+	case 108:
+		return variant{name: "PumpdotfunAmmBuy", params: map[string]interface{}{}}, nil
+	case 109:
+		return variant{name: "PumpdotfunAmmSell", params: map[string]interface{}{}}, nil
+	default:
+		return variant{name: fmt.Sprintf("Unknown_%d", swapTypeIndex), params: map[string]interface{}{}}, nil
+	}
+}
+
+// SwapFixedSize returns the size of the fixed fields of the swap variant at
+// index. WhirlpoolSwapV2 is followed by an Option<RemainingAccountsInfo>.
+func SwapFixedSize(swapTypeIndex uint8) int {
+	switch swapTypeIndex {
+	case 8, 12, 15, 16, 17, 18, 21, 23, 24, 27, 28, 39, 47, 58, 60, 61: // Types with 1 byte parameter
+		return 1
+	case 29: // Symmetry has 16 byte parameters
+		return 16
+	case 33, 41: // Types with 4 byte parameters
+		return 4
+	case 42: // Clone has 3 byte parameters
+		return 3
+	case 43: // SanctumS has 10 byte parameters
+		return 10
+	case 44, 45: // SanctumS Add/Remove Liquidity has 5 byte parameters
+		return 5
+	default:
+		return 0 // No parameters
+	}
+}
+
+// ApplySlippageBps applies bps to amount with exact integer math, rounding down.
+// up selects amount * (10000 + bps) / 10000, otherwise amount * (10000 - bps) / 10000.
+// slippage_bps is a full u16, so the down direction is clamped to zero from
+// 10000 bps on instead of wrapping, and the up direction saturates at MaxUint64.
+func ApplySlippageBps(amount uint64, bps uint16, up bool) uint64 {
+	var factor uint64
+	switch {
+	case up:
+		factor = uint64(10000) + uint64(bps)
+	case bps >= 10000:
+		return 0
+	default:
+		factor = uint64(10000) - uint64(bps)
+	}
+
+	hi, lo := bits.Mul64(amount, factor)
+	if hi >= 10000 {
+		// Result does not fit in uint64
+		return ^uint64(0)
+	}
+	quo, _ := bits.Div64(hi, lo, 10000)
+	return quo
+}
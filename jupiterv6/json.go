@@ -0,0 +1,112 @@
+package jupiterv6
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Result codes of DecodeInstructionJSON
+const (
+	ResultOK           = 0 // the JSON is the decoded instruction
+	ResultInvalidInput = 1 // data is empty, the JSON is an error object
+	ResultFailed       = 2 // the instruction could not be decoded, the JSON is an error object
+)
+
+// Alert codes of decode errors, shared with the analyzer's alert catalog
+const (
+	CodeUnknownDiscriminator   = "JUP001"
+	CodeTruncatedInstruction   = "JUP002"
+	CodeInstructionParseFailed = "JUP004"
+)
+
+// ErrorCode returns the alert code of a decode error
+func ErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrUnknownDiscriminator):
+		return CodeUnknownDiscriminator
+	case errors.Is(err, ErrTruncatedInstruction):
+		return CodeTruncatedInstruction
+	default:
+		return CodeInstructionParseFailed
+	}
+}
+
+// instructionJSON is the JSON form of a decoded instruction, with the field
+// names of the analyzer's swap parameters
+type instructionJSON struct {
+	InstructionType   string     `json:"instruction_type"`
+	ID                uint8      `json:"id,omitempty"`
+	RoutePlan         []stepJSON `json:"route_plan"`
+	InAmount          uint64     `json:"in_amount,omitempty"`
+	OutAmount         uint64     `json:"out_amount,omitempty"`
+	QuotedOutAmount   uint64     `json:"quoted_out_amount,omitempty"`
+	QuotedInAmount    uint64     `json:"quoted_in_amount,omitempty"`
+	SlippageBps       uint16     `json:"slippage_bps"`
+	PlatformFeeBps    uint8      `json:"platform_fee_bps"`
+	MinAmountOut      uint64     `json:"min_amount_out,omitempty"`
+	ExpireAt          *int64     `json:"expire_at,omitempty"`
+	SlippageAllowance uint64     `json:"slippage_allowance"`
+}
+
+type stepJSON struct {
+	Swap struct {
+		Name   string                 `json:"name"`
+		Params map[string]interface{} `json:"params"`
+	} `json:"swap"`
+	Percent     uint8 `json:"percent"`
+	InputIndex  uint8 `json:"input_index"`
+	OutputIndex uint8 `json:"output_index"`
+}
+
+// errorJSON is the JSON returned when decoding fails
+type errorJSON struct {
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error"`
+}
+
+// DecodeInstructionJSON decodes route family instruction data and returns it
+// as JSON with a result code, or an error object with the alert code
+func DecodeInstructionJSON(data []byte) ([]byte, int) {
+	if len(data) == 0 {
+		return marshalError(errorJSON{Error: "empty instruction data"}), ResultInvalidInput
+	}
+	decoded, err := DecodeInstruction(data)
+	if err != nil {
+		return marshalError(errorJSON{Code: ErrorCode(err), Error: err.Error()}), ResultFailed
+	}
+
+	out := instructionJSON{
+		InstructionType:   decoded.Type,
+		ID:                decoded.ID,
+		RoutePlan:         make([]stepJSON, len(decoded.Route)),
+		SlippageBps:       decoded.SlippageBps,
+		PlatformFeeBps:    decoded.PlatformFeeBps,
+		MinAmountOut:      decoded.MinAmountOut,
+		ExpireAt:          decoded.ExpireAt,
+		SlippageAllowance: decoded.SlippageAllowance,
+	}
+	if IsExactOut(decoded.Type) {
+		out.OutAmount, out.QuotedInAmount = decoded.Amount, decoded.QuotedAmount
+	} else {
+		out.InAmount, out.QuotedOutAmount = decoded.Amount, decoded.QuotedAmount
+	}
+	for i, step := range decoded.Route {
+		out.RoutePlan[i].Swap.Name = step.Swap.Name
+		out.RoutePlan[i].Swap.Params = step.Swap.Params
+		out.RoutePlan[i].Percent = step.Percent
+		out.RoutePlan[i].InputIndex = step.InputIndex
+		out.RoutePlan[i].OutputIndex = step.OutputIndex
+	}
+
+	encoded, err := json.Marshal(out)
+	if err != nil {
+		return marshalError(errorJSON{Code: CodeInstructionParseFailed, Error: err.Error()}), ResultFailed
+	}
+	return encoded, ResultOK
+}
+
+// marshalError encodes a decode error, which cannot fail
+func marshalError(e errorJSON) []byte {
+	encoded, _ := json.Marshal(e)
+	return encoded
+}
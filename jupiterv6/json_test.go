@@ -0,0 +1,66 @@
+package jupiterv6
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeInstructionJSON(t *testing.T) {
+	// one Saber step, 100% from index 0 to 1
+	data, err := EncodeInstruction(Instruction{
+		Type:         "route",
+		Steps:        [][]byte{{0x00, 100, 0, 1}},
+		Amount:       1000,
+		QuotedAmount: 990,
+		SlippageBps:  50,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, code := DecodeInstructionJSON(data)
+	var decoded struct {
+		InstructionType string `json:"instruction_type"`
+		RoutePlan       []struct {
+			Swap struct {
+				Name string `json:"name"`
+			} `json:"swap"`
+			Percent     uint8 `json:"percent"`
+			OutputIndex uint8 `json:"output_index"`
+		} `json:"route_plan"`
+		InAmount          uint64 `json:"in_amount"`
+		QuotedOutAmount   uint64 `json:"quoted_out_amount"`
+		SlippageBps       uint16 `json:"slippage_bps"`
+		MinAmountOut      uint64 `json:"min_amount_out"`
+		SlippageAllowance uint64 `json:"slippage_allowance"`
+	}
+	if code != ResultOK || json.Unmarshal(encoded, &decoded) != nil {
+		t.Fatalf("code %d, %s", code, encoded)
+	}
+	if decoded.InstructionType != "route" || decoded.InAmount != 1000 || decoded.QuotedOutAmount != 990 || decoded.SlippageBps != 50 {
+		t.Errorf("decoded %+v", decoded)
+	}
+	if len(decoded.RoutePlan) != 1 || decoded.RoutePlan[0].Swap.Name != "Saber" || decoded.RoutePlan[0].Percent != 100 || decoded.RoutePlan[0].OutputIndex != 1 {
+		t.Errorf("route plan %+v", decoded.RoutePlan)
+	}
+	if decoded.MinAmountOut+decoded.SlippageAllowance != 990 || decoded.SlippageAllowance == 0 {
+		t.Errorf("min amount out %d, allowance %d", decoded.MinAmountOut, decoded.SlippageAllowance)
+	}
+
+	for _, tt := range []struct {
+		name string
+		data []byte
+		code int
+		want string
+	}{
+		{"empty", nil, ResultInvalidInput, ""},
+		{"truncated", data[:len(data)-3], ResultFailed, CodeTruncatedInstruction},
+		{"unknown discriminator", make([]byte, 24), ResultFailed, CodeUnknownDiscriminator},
+	} {
+		encoded, code := DecodeInstructionJSON(tt.data)
+		var decodeError errorJSON
+		if code != tt.code || json.Unmarshal(encoded, &decodeError) != nil || decodeError.Error == "" || decodeError.Code != tt.want {
+			t.Errorf("%s: code %d, %s", tt.name, code, encoded)
+		}
+	}
+}
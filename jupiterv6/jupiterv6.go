@@ -0,0 +1,155 @@
+// Package jupiterv6 holds the Jupiter V6 wire format shared by the analyzer,
+// the testgen package and the C and WASM builds: program address,
+// discriminators, account counts, the instruction decoder and the instruction
+// and event encoders. It only depends on the standard library.
+package jupiterv6
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ProgramAddress is the base58 address of the Jupiter V6 program
+const ProgramAddress = "JUP6LkbZbjS1jKKwapdHNy74zcZ3tLUZoi5QNyVTaV4"
+
+// InstructionDiscriminators are the discriminators of the route family instructions
+var InstructionDiscriminators = map[string][]byte{
+	"route":                              {0xE5, 0x17, 0xCB, 0x97, 0x7A, 0xE3, 0xAD, 0x2A},
+	"routeWithTokenLedger":               {0x96, 0x56, 0x47, 0x74, 0xA7, 0x5D, 0x0E, 0x68},
+	"sharedAccountsRoute":                {0xC1, 0x20, 0x9B, 0x33, 0x41, 0xD6, 0x9C, 0x81},
+	"sharedAccountsRouteWithTokenLedger": {0xE6, 0x79, 0x8F, 0x50, 0x77, 0x9F, 0x6A, 0xAA},
+	"exactOutRoute":                      {0xD0, 0x33, 0xEF, 0x97, 0x7B, 0x2B, 0xED, 0x5C},
+	"sharedAccountsExactOutRoute":        {0xB0, 0xD1, 0x69, 0xA8, 0x9A, 0x7D, 0x45, 0x3E},
+}
+
+// FixedAccountCounts is the number of named accounts each instruction takes
+// before the remaining (per route step) accounts begin
+var FixedAccountCounts = map[string]int{
+	"route":                              9,
+	"routeWithTokenLedger":               10,
+	"sharedAccountsRoute":                13,
+	"sharedAccountsRouteWithTokenLedger": 14,
+	"exactOutRoute":                      11,
+	"sharedAccountsExactOutRoute":        13,
+}
+
+// Emit-CPI framing of the SwapEvent
+var (
+	// EmitCPIPrefix starts the data of every Anchor emit_cpi self-invocation
+	EmitCPIPrefix = []byte{0xe4, 0x45, 0xa5, 0x2e, 0x51, 0xcb, 0x9a, 0x1d}
+	// SwapEventTypeDiscriminator is the first 8 bytes of sha256("event:SwapEvent")
+	SwapEventTypeDiscriminator = []byte{0x40, 0xc6, 0xcd, 0xe8, 0x26, 0x08, 0x71, 0xe2}
+)
+
+// SwapEventSize is the size of the emit-CPI data of a SwapEvent
+const SwapEventSize = 8 + 8 + 32 + 32 + 8 + 32 + 8
+
+// IsShared reports whether the instruction starts with an id byte
+func IsShared(instructionType string) bool {
+	switch instructionType {
+	case "sharedAccountsRoute", "sharedAccountsRouteWithTokenLedger", "sharedAccountsExactOutRoute":
+		return true
+	}
+	return false
+}
+
+// IsExactOut reports whether the instruction takes out_amount and quoted_in_amount
+func IsExactOut(instructionType string) bool {
+	return instructionType == "exactOutRoute" || instructionType == "sharedAccountsExactOutRoute"
+}
+
+// IsTokenLedger reports whether the instruction takes its input amount from a
+// token ledger set by a preceding setTokenLedger instruction
+func IsTokenLedger(instructionType string) bool {
+	return instructionType == "routeWithTokenLedger" || instructionType == "sharedAccountsRouteWithTokenLedger"
+}
+
+// TailSize returns the size of the arguments following the route plan: amount
+// u64, quoted amount u64, slippage_bps u16 and platform_fee_bps u8. Token
+// ledger variants have no in_amount argument.
+func TailSize(instructionType string) int {
+	if IsTokenLedger(instructionType) {
+		return 11
+	}
+	return 19
+}
+
+// DecodeOptionalTail decodes the optional trailing argument that newer program
+// revisions append after platform_fee_bps, expire_at: Option<i64>, a unix
+// timestamp after which the route fails. tail is the data following the fixed
+// tail. It returns the expiry, nil when absent or None, and the number of bytes
+// the argument takes; ok is false when tail does not start with a valid Option.
+// The argument is only part of the layout when size covers the rest of tail.
+func DecodeOptionalTail(tail []byte) (expireAt *int64, size int, ok bool) {
+	switch {
+	case len(tail) == 0:
+		return nil, 0, true
+	case tail[0] == 0:
+		return nil, 1, true
+	case tail[0] == 1 && len(tail) >= 9:
+		value := int64(binary.LittleEndian.Uint64(tail[1:9]))
+		return &value, 9, true
+	}
+	return nil, 0, false
+}
+
+// Instruction holds the arguments of a route family instruction
+type Instruction struct {
+	Type string
+	ID   uint8 // shared accounts variants only
+	// Steps are the encoded route plan steps: variant index, variant fields,
+	// percent, input_index, output_index
+	Steps [][]byte
+	// Amount is in_amount, or out_amount for exact out variants. Token ledger
+	// variants do not encode it.
+	Amount uint64
+	// QuotedAmount is quoted_out_amount, or quoted_in_amount for exact out variants
+	QuotedAmount   uint64
+	SlippageBps    uint16
+	PlatformFeeBps uint8
+	// ExpireAt is encoded as the optional expire_at argument when set, the
+	// instruction then has the tail of newer revisions
+	ExpireAt *int64
+}
+
+// EncodeInstruction encodes the instruction data following the IDL argument order
+func EncodeInstruction(inst Instruction) ([]byte, error) {
+	discriminator, ok := InstructionDiscriminators[inst.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown instruction type %q", inst.Type)
+	}
+
+	data := append([]byte{}, discriminator...)
+	if IsShared(inst.Type) {
+		data = append(data, inst.ID)
+	}
+
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(inst.Steps)))
+	for _, step := range inst.Steps {
+		data = append(data, step...)
+	}
+
+	if !IsTokenLedger(inst.Type) {
+		data = binary.LittleEndian.AppendUint64(data, inst.Amount)
+	}
+	data = binary.LittleEndian.AppendUint64(data, inst.QuotedAmount)
+	data = binary.LittleEndian.AppendUint16(data, inst.SlippageBps)
+	data = append(data, inst.PlatformFeeBps)
+	if inst.ExpireAt != nil {
+		data = append(data, 1)
+		data = binary.LittleEndian.AppendUint64(data, uint64(*inst.ExpireAt))
+	}
+	return data, nil
+}
+
+// EncodeSwapEvent builds the emit-CPI data of a SwapEvent
+func EncodeSwapEvent(amm, inputMint [32]byte, inputAmount uint64, outputMint [32]byte, outputAmount uint64) []byte {
+	data := make([]byte, 0, SwapEventSize)
+	data = append(data, EmitCPIPrefix...)
+	data = append(data, SwapEventTypeDiscriminator...)
+	data = append(data, amm[:]...)
+	data = append(data, inputMint[:]...)
+	data = binary.LittleEndian.AppendUint64(data, inputAmount)
+	data = append(data, outputMint[:]...)
+	return binary.LittleEndian.AppendUint64(data, outputAmount)
+}
@@ -0,0 +1,86 @@
+package jupiterv6
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+// AccountsType is the role of a slice of trailing accounts in a V2 swap
+type AccountsType uint8
+
+// accountsTypeNames follows the AccountsType enum order of the Jupiter V6 IDL
+var accountsTypeNames = []string{
+	"TransferHookA",
+	"TransferHookB",
+	"TransferHookReward",
+	"TransferHookInput",
+	"TransferHookIntermediate",
+	"TransferHookOutput",
+	"SupplementalTickArrays",
+	"SupplementalTickArraysOne",
+	"SupplementalTickArraysTwo",
+}
+
+// String returns the IDL name of the accounts type
+func (t AccountsType) String() string {
+	if int(t) < len(accountsTypeNames) {
+		return accountsTypeNames[t]
+	}
+	return fmt.Sprintf("Unknown_%d", uint8(t))
+}
+
+// MarshalJSON encodes the accounts type by name
+func (t AccountsType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// RemainingAccountsSlice is a run of Length trailing accounts with the same role
+type RemainingAccountsSlice struct {
+	AccountsType AccountsType `json:"accounts_type"`
+	Length       uint8        `json:"length"`
+}
+
+// RemainingAccountsInfo describes how the trailing accounts of a V2 swap are split by role
+type RemainingAccountsInfo struct {
+	Slices []RemainingAccountsSlice `json:"slices"`
+}
+
+// parseOptionalRemainingAccountsInfo decodes an Option<RemainingAccountsInfo> at
+// offset and returns it with the number of bytes consumed, nil for None
+func parseOptionalRemainingAccountsInfo(data []byte, offset int) (*RemainingAccountsInfo, int, error) {
+	if offset+1 > len(data) {
+		return nil, 0, fmt.Errorf("not enough data for remaining_accounts_info option")
+	}
+	if data[offset] == 0 {
+		return nil, 1, nil
+	}
+
+	info, size, err := parseRemainingAccountsInfo(data, offset+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	return info, 1 + size, nil
+}
+
+// parseRemainingAccountsInfo decodes a RemainingAccountsInfo at offset and
+// returns it with the number of bytes consumed
+func parseRemainingAccountsInfo(data []byte, offset int) (*RemainingAccountsInfo, int, error) {
+	if offset+4 > len(data) {
+		return nil, 0, fmt.Errorf("not enough data for remaining_accounts_info length")
+	}
+	count := int(binary.LittleEndian.Uint32(data[offset : offset+4]))
+	if count > (len(data)-offset-4)/2 {
+		return nil, 0, fmt.Errorf("remaining_accounts_info declares %d slices, exceeding data", count)
+	}
+
+	info := &RemainingAccountsInfo{Slices: make([]RemainingAccountsSlice, count)}
+	for i := 0; i < count; i++ {
+		at := offset + 4 + i*2
+		info.Slices[i] = RemainingAccountsSlice{
+			AccountsType: AccountsType(data[at]),
+			Length:       data[at+1],
+		}
+	}
+	return info, 4 + count*2, nil
+}
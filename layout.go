@@ -0,0 +1,75 @@
+package main
+
+import "fmt"
+
+// LayoutVersion identifies the byte layout a Jupiter instruction was decoded with
+type LayoutVersion string
+
+const (
+	// LayoutCurrent is the layout of the published V6 IDL
+	LayoutCurrent LayoutVersion = "v6"
+	// LayoutNoPlatformFee is the early V6 tail without the trailing platform_fee_bps u8
+	LayoutNoPlatformFee LayoutVersion = "v6-no-platform-fee"
+)
+
+// LayoutRange applies a layout to the instructions of slots FromSlot to ToSlot inclusive
+type LayoutRange struct {
+	FromSlot uint64        `json:"from_slot"`
+	ToSlot   uint64        `json:"to_slot"`
+	Version  LayoutVersion `json:"version"`
+}
+
+// layoutDecoders decodes instruction data for each known layout
+var layoutDecoders = map[LayoutVersion]func(data []byte) (*JupiterSwapParams, error){
+	LayoutCurrent:       parseJupiterV6Instruction,
+	LayoutNoPlatformFee: parseNoPlatformFeeInstruction,
+}
+
+// WithLayoutTable sets the slot ranges decoded with historical layouts. Slots
+// outside every range use LayoutCurrent. No range is configured by default.
+func WithLayoutTable(ranges []LayoutRange) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.layouts = ranges
+	}
+}
+
+// layoutForSlot returns the layout configured for slot
+func (a *Analyzer) layoutForSlot(slot uint64) LayoutVersion {
+	for _, r := range a.layouts {
+		if slot >= r.FromSlot && slot <= r.ToSlot {
+			if _, ok := layoutDecoders[r.Version]; ok {
+				return r.Version
+			}
+		}
+	}
+	return LayoutCurrent
+}
+
+// parseInstruction decodes a Jupiter instruction with the layout of its slot
+func (a *Analyzer) parseInstruction(data []byte, slot uint64) (*JupiterSwapParams, error) {
+	if a.limits.maxInstructionSize > 0 && len(data) > a.limits.maxInstructionSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds %d", errInstructionTooLarge, len(data), a.limits.maxInstructionSize)
+	}
+
+	version := a.layoutForSlot(slot)
+	result, err := layoutDecoders[version](data)
+	if result != nil {
+		result.LayoutVersion = version
+	}
+	if err == nil && a.limits.failOnUnknownSwapType {
+		for j, step := range result.RoutePlan {
+			if index, ok := unknownSwapIndex(step.Swap.Type); ok {
+				return nil, fmt.Errorf("%w: route plan step %d uses swap variant %d", ErrUnknownSwapType, j, index)
+			}
+		}
+	}
+	return result, err
+}
+
+// parseNoPlatformFeeInstruction decodes a route family instruction whose tail
+// ends at slippage_bps. The missing platform fee is decoded as zero.
+func parseNoPlatformFeeInstruction(data []byte) (*JupiterSwapParams, error) {
+	padded := make([]byte, len(data)+1)
+	copy(padded, data)
+	return parseJupiterV6Instruction(padded)
+}
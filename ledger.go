@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Ledger entry roles
+const (
+	LedgerRoleUser    = "user"    // accounts of the swapping user
+	LedgerRoleJupiter = "jupiter" // Jupiter program authority and its vault token accounts
+	LedgerRoleFee     = "fee"     // platform fee account
+	LedgerRoleAMM     = "amm"     // accounts passed to the route step AMMs
+)
+
+// Ledger entry kinds
+const (
+	LedgerKindToken    = "token"
+	LedgerKindLamports = "lamports"
+)
+
+// LedgerEntry is the balance change of one account referenced by a Jupiter instruction
+type LedgerEntry struct {
+	Account solana.PublicKey  `json:"account"`
+	Kind    string            `json:"kind"`
+	Role    string            `json:"role"`
+	Owner   *solana.PublicKey `json:"owner,omitempty"`
+	Mint    *solana.PublicKey `json:"mint,omitempty"`
+	Pre     uint64            `json:"pre"`
+	Post    uint64            `json:"post"`
+	Delta   *BigAmount        `json:"delta"`
+}
+
+// fixedAccountRoles labels the fixed accounts of each instruction type by position
+var fixedAccountRoles = map[string]map[int]string{
+	"route":                {1: LedgerRoleUser, 2: LedgerRoleUser, 3: LedgerRoleUser, 4: LedgerRoleUser, 6: LedgerRoleFee},
+	"routeWithTokenLedger": {1: LedgerRoleUser, 2: LedgerRoleUser, 3: LedgerRoleUser, 4: LedgerRoleUser, 6: LedgerRoleFee},
+	"exactOutRoute":        {1: LedgerRoleUser, 2: LedgerRoleUser, 3: LedgerRoleUser, 4: LedgerRoleUser, 7: LedgerRoleFee},
+	"sharedAccountsRoute": {
+		1: LedgerRoleJupiter, 2: LedgerRoleUser, 3: LedgerRoleUser,
+		4: LedgerRoleJupiter, 5: LedgerRoleJupiter, 6: LedgerRoleUser, 9: LedgerRoleFee,
+	},
+	"sharedAccountsRouteWithTokenLedger": {
+		1: LedgerRoleJupiter, 2: LedgerRoleUser, 3: LedgerRoleUser,
+		4: LedgerRoleJupiter, 5: LedgerRoleJupiter, 6: LedgerRoleUser, 9: LedgerRoleFee,
+	},
+	"sharedAccountsExactOutRoute": {
+		1: LedgerRoleJupiter, 2: LedgerRoleUser, 3: LedgerRoleUser,
+		4: LedgerRoleJupiter, 5: LedgerRoleJupiter, 6: LedgerRoleUser, 9: LedgerRoleFee,
+	},
+}
+
+// accountRole labels the account at position of an instruction, "" for fixed
+// accounts that never hold balances of interest (programs, mints, sysvars)
+func accountRole(instructionType string, position int) string {
+	if role, ok := fixedAccountRoles[instructionType][position]; ok {
+		return role
+	}
+	if fixed, ok := jupiterFixedAccountCounts[instructionType]; ok && position >= fixed {
+		return LedgerRoleAMM
+	}
+	return ""
+}
+
+// balanceDelta returns post - pre
+func balanceDelta(pre, post uint64) *BigAmount {
+	return (*BigAmount)(new(big.Int).Sub(new(big.Int).SetUint64(post), new(big.Int).SetUint64(pre)))
+}
+
+// tokenLedgerEntry builds the token entry of the account at index, if it holds tokens
+func tokenLedgerEntry(account solana.PublicKey, index int, meta *rpc.TransactionMeta) (LedgerEntry, bool) {
+	pre, hasPre := findTokenBalance(meta.PreTokenBalances, index)
+	post, hasPost := findTokenBalance(meta.PostTokenBalances, index)
+	if !hasPre && !hasPost {
+		return LedgerEntry{}, false
+	}
+
+	entry := LedgerEntry{Account: account, Kind: LedgerKindToken}
+	for _, balance := range []*rpc.TokenBalance{post, pre} {
+		if balance == nil {
+			continue
+		}
+		if entry.Owner == nil && balance.Owner != nil {
+			owner := *balance.Owner
+			entry.Owner = &owner
+		}
+		if entry.Mint == nil {
+			mint := balance.Mint
+			entry.Mint = &mint
+		}
+	}
+	entry.Pre, _ = tokenBalanceAmount(pre)
+	entry.Post, _ = tokenBalanceAmount(post)
+	entry.Delta = balanceDelta(entry.Pre, entry.Post)
+	return entry, true
+}
+
+// computeLedger collects the token and lamport balance changes of every account
+// referenced by the parsed Jupiter instructions
+func computeLedger(analysis *JupiterV6Analysis, parsedTx *solana.Transaction, meta *rpc.TransactionMeta) {
+	if meta == nil {
+		return
+	}
+	accountKeys := parsedTx.Message.AccountKeys
+	seen := make(map[solana.PublicKey]bool)
+
+	for _, params := range analysis.Instructions {
+		if params.InstructionIndex >= len(parsedTx.Message.Instructions) {
+			continue
+		}
+		accounts := parsedTx.Message.Instructions[params.InstructionIndex].Accounts
+		for position, keyIndex := range accounts {
+			index := int(keyIndex)
+			if index >= len(accountKeys) {
+				break
+			}
+			account := accountKeys[index]
+			role := accountRole(params.InstructionType, position)
+			if role == "" || seen[account] {
+				continue
+			}
+			seen[account] = true
+
+			if entry, ok := tokenLedgerEntry(account, index, meta); ok {
+				// Token accounts of the program authority are Jupiter vaults wherever they appear
+				if params.Authority != nil && entry.Owner != nil && entry.Owner.Equals(*params.Authority) {
+					role = LedgerRoleJupiter
+				}
+				entry.Role = role
+				analysis.Ledger = append(analysis.Ledger, entry)
+				continue
+			}
+
+			// System accounts only matter when their lamports moved
+			if index < len(meta.PreBalances) && index < len(meta.PostBalances) && meta.PreBalances[index] != meta.PostBalances[index] {
+				analysis.Ledger = append(analysis.Ledger, LedgerEntry{
+					Account: account,
+					Kind:    LedgerKindLamports,
+					Role:    role,
+					Pre:     meta.PreBalances[index],
+					Post:    meta.PostBalances[index],
+					Delta:   balanceDelta(meta.PreBalances[index], meta.PostBalances[index]),
+				})
+			}
+		}
+	}
+}
+
+// LedgerEntries returns the balance changes of the accounts touched by the swap
+func (a *JupiterV6Analysis) LedgerEntries() []LedgerEntry {
+	return a.Ledger
+}
+
+// WriteLedgerCSV writes ledger entries as CSV with a header row
+func WriteLedgerCSV(w io.Writer, entries []LedgerEntry) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"account", "kind", "role", "owner", "mint", "pre", "post", "delta"}); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		owner, mint := "", ""
+		if entry.Owner != nil {
+			owner = entry.Owner.String()
+		}
+		if entry.Mint != nil {
+			mint = entry.Mint.String()
+		}
+		record := []string{
+			entry.Account.String(),
+			entry.Kind,
+			entry.Role,
+			owner,
+			mint,
+			strconv.FormatUint(entry.Pre, 10),
+			strconv.FormatUint(entry.Post, 10),
+			entry.Delta.String(),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ledgerFixture is a one hop route of 1000 of mint A into 950 of mint B for
+// the user plus a 5 token platform fee, through a pool with two vaults
+func ledgerFixture(t *testing.T) (*rpc.GetTransactionResult, *solana.Transaction) {
+	user, mintA, mintB, feeOwner, pool := testKey(1), testKey(2), testKey(3), testKey(4), testKey(5)
+	keys := solana.PublicKeySlice{
+		user,                  // 0 payer and user_transfer_authority
+		testKey(11),           // 1 user_source_token_account, mint A
+		testKey(12),           // 2 user_destination_token_account, mint B
+		testKey(13),           // 3 platform_fee_account, mint B
+		testKey(14),           // 4 pool vault, mint A
+		testKey(15),           // 5 pool vault, mint B
+		solana.TokenProgramID, // 6
+		mintB,                 // 7 destination_mint
+		testKey(16),           // 8 event_authority
+		jupiterV6ProgramID,    // 9
+		whirlpoolProgramID,    // 10
+		pool,                  // 11
+	}
+	data := testInstruction("route", 0, [][]byte{{SwapTypeToIndex[SwapWhirlpool], 1, 100, 0, 1}}, 1000, 950, 50, 0)
+	parsedTx := &solana.Transaction{
+		Signatures: []solana.Signature{{1}},
+		Message: solana.Message{
+			Header:      solana.MessageHeader{NumRequiredSignatures: 1},
+			AccountKeys: keys,
+			Instructions: []solana.CompiledInstruction{{
+				ProgramIDIndex: 9,
+				// token_program, user_transfer_authority, user_source_token_account,
+				// user_destination_token_account, destination_token_account (None),
+				// destination_mint, platform_fee_account, event_authority, program,
+				// then the Whirlpool swap accounts
+				Accounts: []uint16{6, 0, 1, 2, 9, 7, 3, 8, 9, 10, 11, 4, 5},
+				Data:     data,
+			}},
+		},
+	}
+
+	event := SwapEvent{AMM: whirlpoolProgramID, InputMint: mintA, InputAmount: 1000, OutputMint: mintB, OutputAmount: 955}
+	preBalances := make([]uint64, len(keys))
+	postBalances := make([]uint64, len(keys))
+	preBalances[0], postBalances[0] = 1_000_000_000, 1_000_000_000-5_000
+	meta := &rpc.TransactionMeta{
+		Fee:          5_000,
+		PreBalances:  preBalances,
+		PostBalances: postBalances,
+		PreTokenBalances: []rpc.TokenBalance{
+			testTokenBalance(1, mintA, user, 1_000),
+			testTokenBalance(2, mintB, user, 0),
+			testTokenBalance(3, mintB, feeOwner, 0),
+			testTokenBalance(4, mintA, pool, 5_000),
+			testTokenBalance(5, mintB, pool, 10_000),
+		},
+		PostTokenBalances: []rpc.TokenBalance{
+			testTokenBalance(1, mintA, user, 0),
+			testTokenBalance(2, mintB, user, 950),
+			testTokenBalance(3, mintB, feeOwner, 5),
+			testTokenBalance(4, mintA, pool, 6_000),
+			testTokenBalance(5, mintB, pool, 9_045),
+		},
+		InnerInstructions: []rpc.InnerInstruction{{Index: 0, Instructions: []solana.CompiledInstruction{
+			{ProgramIDIndex: 9, Accounts: []uint16{8}, Data: event.Encode()},
+		}}},
+	}
+	return testTransactionResult(t, parsedTx, meta), parsedTx
+}
+
+func TestLedgerEntries(t *testing.T) {
+	tx, parsedTx := ledgerFixture(t)
+	analysis := analyzeTest(t, newTestAnalyzer(), tx, parsedTx)
+	entries := analysis.LedgerEntries()
+
+	want := []struct {
+		account int
+		kind    string
+		role    string
+	}{
+		{0, LedgerKindLamports, LedgerRoleUser},
+		{1, LedgerKindToken, LedgerRoleUser},
+		{2, LedgerKindToken, LedgerRoleUser},
+		{3, LedgerKindToken, LedgerRoleFee},
+		{4, LedgerKindToken, LedgerRoleAMM},
+		{5, LedgerKindToken, LedgerRoleAMM},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("%d ledger entries, want %d: %+v", len(entries), len(want), entries)
+	}
+
+	keys := parsedTx.Message.AccountKeys
+	byMint := map[solana.PublicKey]*big.Int{}
+	for i, entry := range entries {
+		w := want[i]
+		if !entry.Account.Equals(keys[w.account]) || entry.Kind != w.kind || entry.Role != w.role {
+			t.Errorf("entry %d: %s %s %s, want account %d %s %s", i, entry.Account, entry.Kind, entry.Role, w.account, w.kind, w.role)
+		}
+		if entry.Delta.Int().Cmp(new(big.Int).Sub(new(big.Int).SetUint64(entry.Post), new(big.Int).SetUint64(entry.Pre))) != 0 {
+			t.Errorf("entry %d: delta %s for %d -> %d", i, entry.Delta, entry.Pre, entry.Post)
+		}
+
+		if entry.Kind == LedgerKindLamports {
+			if entry.Pre != tx.Meta.PreBalances[w.account] || entry.Post != tx.Meta.PostBalances[w.account] || entry.Delta.Int().Int64() != -int64(tx.Meta.Fee) {
+				t.Errorf("entry %d: lamports %d -> %d", i, entry.Pre, entry.Post)
+			}
+			continue
+		}
+		pre, _ := findTokenBalance(tx.Meta.PreTokenBalances, w.account)
+		post, _ := findTokenBalance(tx.Meta.PostTokenBalances, w.account)
+		preAmount, _ := tokenBalanceAmount(pre)
+		postAmount, _ := tokenBalanceAmount(post)
+		if entry.Pre != preAmount || entry.Post != postAmount || entry.Mint == nil || !entry.Mint.Equals(post.Mint) || entry.Owner == nil || !entry.Owner.Equals(*post.Owner) {
+			t.Errorf("entry %d: %+v, want %d -> %d of %s", i, entry, preAmount, postAmount, post.Mint)
+		}
+		if byMint[*entry.Mint] == nil {
+			byMint[*entry.Mint] = new(big.Int)
+		}
+		byMint[*entry.Mint].Add(byMint[*entry.Mint], entry.Delta.Int())
+	}
+
+	// Tokens only move between the listed accounts, every mint balances out
+	if len(byMint) != 2 {
+		t.Errorf("%d mints in the ledger, want 2", len(byMint))
+	}
+	for mint, sum := range byMint {
+		if sum.Sign() != 0 {
+			t.Errorf("mint %s deltas sum to %s", mint, sum)
+		}
+	}
+
+	var csv bytes.Buffer
+	if err := WriteLedgerCSV(&csv, entries); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != len(entries)+1 || !strings.HasSuffix(lines[3], ",0,950,950") || !strings.HasSuffix(lines[1], ",1000000000,999995000,-5000") {
+		t.Errorf("csv:\n%s", csv.String())
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// legacyUint decodes an amount printed either as a number or as a numeric string
+type legacyUint uint64
+
+// UnmarshalJSON accepts 123, "123" and "" (zero)
+func (u *legacyUint) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*u = 0
+		return nil
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount %s", data)
+	}
+	*u = legacyUint(n)
+	return nil
+}
+
+// legacySummary is the summary object of printJupiterV6AnalysisJSON
+type legacySummary struct {
+	TotalSwaps  legacyUint `json:"total_swaps"`
+	InputToken  string     `json:"input_token"`
+	OutputToken string     `json:"output_token"`
+	TotalInput  legacyUint `json:"total_input"`
+	TotalOutput legacyUint `json:"total_output"`
+	Route       string     `json:"route"`
+}
+
+// legacyInstruction is an instruction object of printJupiterV6AnalysisJSON
+type legacyInstruction struct {
+	InstructionType string      `json:"instruction_type"`
+	ID              legacyUint  `json:"id"`
+	InAmount        legacyUint  `json:"in_amount"`
+	QuotedOutAmount legacyUint  `json:"quoted_out_amount"`
+	SlippageBps     legacyUint  `json:"slippage_bps"`
+	PlatformFeeBps  legacyUint  `json:"platform_fee_bps"`
+	MinAmountOut    *legacyUint `json:"min_amount_out"`
+}
+
+// legacyEvent is an event object of printJupiterV6AnalysisJSON
+type legacyEvent struct {
+	AMM          string     `json:"amm"`
+	InputMint    string     `json:"input_mint"`
+	InputAmount  legacyUint `json:"input_amount"`
+	OutputMint   string     `json:"output_mint"`
+	OutputAmount legacyUint `json:"output_amount"`
+}
+
+// legacyAnalysis is the document printed by printJupiterV6AnalysisJSON
+type legacyAnalysis struct {
+	Summary      *legacySummary      `json:"summary"`
+	Instructions []legacyInstruction `json:"instructions"`
+	Events       []legacyEvent       `json:"events"`
+}
+
+// legacyStringLine matches a `"key": "value"` line of the legacy printer, which
+// wrote values with %s and so without escaping
+var legacyStringLine = regexp.MustCompile(`^(\s*"[a-z_]+": ")(.*)("\s*,?\s*)$`)
+
+// repairLegacyJSON re-escapes the string values of a legacy document line by line
+func repairLegacyJSON(data []byte) []byte {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		match := legacyStringLine.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+		escaped, _ := json.Marshal(match[2])
+		lines[i] = match[1] + string(escaped[1:len(escaped)-1]) + match[3]
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// ParseLegacyAnalysisJSON decodes an analysis printed by the hand-rolled JSON
+// printer (printJupiterV6AnalysisJSON) into the current schema. It accepts
+// amounts as numbers or strings, missing fields, documents holding only the
+// summary object and unescaped string values. What could not be carried over
+// faithfully is listed as CodeLegacyMigration warnings on the analysis.
+func ParseLegacyAnalysisJSON(data []byte) (*JupiterV6Analysis, error) {
+	analysis := &JupiterV6Analysis{
+		Instructions: []JupiterSwapParams{},
+		Events:       []SwapEvent{},
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		data = repairLegacyJSON(data)
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("error decoding legacy analysis: %v", err)
+		}
+		analysis.addWarning(CodeLegacyMigration, "repaired unescaped string values")
+	}
+
+	var legacy legacyAnalysis
+	var err error
+	if _, ok := fields["total_swaps"]; ok {
+		// A bare summary object
+		legacy.Summary = &legacySummary{}
+		err = json.Unmarshal(data, legacy.Summary)
+	} else {
+		err = json.Unmarshal(data, &legacy)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error decoding legacy analysis: %v", err)
+	}
+
+	if legacy.Summary != nil {
+		analysis.Summary = SwapSummary{
+			TotalSwaps:  int(legacy.Summary.TotalSwaps),
+			InputToken:  legacy.Summary.InputToken,
+			OutputToken: legacy.Summary.OutputToken,
+			TotalInput:  uint64(legacy.Summary.TotalInput),
+			TotalOutput: uint64(legacy.Summary.TotalOutput),
+			Route:       legacy.Summary.Route,
+		}
+	} else {
+		analysis.addWarning(CodeLegacyMigration, "summary missing")
+	}
+	if fields["instructions"] == nil && fields["events"] == nil {
+		analysis.addWarning(CodeLegacyMigration, "summary only, instructions and events missing")
+	}
+
+	for i, inst := range legacy.Instructions {
+		params := JupiterSwapParams{
+			InstructionType:  inst.InstructionType,
+			InstructionIndex: i,
+			AuthorityID:      uint8(inst.ID),
+			RoutePlan:        []RoutePlanStep{},
+			SlippageBps:      uint16(inst.SlippageBps),
+			PlatformFeeBps:   uint8(inst.PlatformFeeBps),
+		}
+		if isExactOutInstruction(inst.InstructionType) {
+			// The legacy printer wrote the exactIn fields for every instruction
+			analysis.addWarning(CodeLegacyMigration, "instruction %d: %s amounts were not recorded", i, inst.InstructionType)
+			if inst.MinAmountOut != nil {
+				// and min_amount_out held the maximum input of exactOut routes
+				analysis.addWarning(CodeLegacyMigration, "instruction %d: min_amount_out %d is the maximum input, dropped", i, *inst.MinAmountOut)
+			}
+		} else {
+			params.InAmount = uint64(inst.InAmount)
+			params.QuotedOutAmount = uint64(inst.QuotedOutAmount)
+			if inst.MinAmountOut != nil {
+				params.MinAmountOut = uint64(*inst.MinAmountOut)
+			}
+			if params.MinAmountOut != 0 && params.QuotedOutAmount > params.MinAmountOut {
+				params.SlippageAllowance = params.QuotedOutAmount - params.MinAmountOut
+			}
+		}
+		analysis.Instructions = append(analysis.Instructions, params)
+	}
+	if len(legacy.Instructions) > 0 {
+		analysis.addWarning(CodeLegacyMigration, "instruction indexes are positions, route plans and accounts were not recorded")
+	}
+
+	for i, legacyEvent := range legacy.Events {
+		event := SwapEvent{
+			InputAmount:      uint64(legacyEvent.InputAmount),
+			OutputAmount:     uint64(legacyEvent.OutputAmount),
+			InstructionIndex: -1,
+		}
+		for _, key := range []struct {
+			name  string
+			value string
+			dest  *solana.PublicKey
+		}{
+			{"amm", legacyEvent.AMM, &event.AMM},
+			{"input_mint", legacyEvent.InputMint, &event.InputMint},
+			{"output_mint", legacyEvent.OutputMint, &event.OutputMint},
+		} {
+			if key.value == "" {
+				continue
+			}
+			parsed, err := solana.PublicKeyFromBase58(key.value)
+			if err != nil {
+				analysis.addWarning(CodeLegacyMigration, "event %d: invalid %s %q", i, key.name, key.value)
+				continue
+			}
+			*key.dest = parsed
+		}
+		analysis.Events = append(analysis.Events, event)
+	}
+
+	return analysis, nil
+}
+
+// splitLegacyDocuments splits captured printer output into its JSON documents.
+// The printer opens and closes every document with a brace on its own line;
+// text outside documents, such as the text report, is ignored.
+func splitLegacyDocuments(data []byte) [][]byte {
+	var documents [][]byte
+	var current *bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case current == nil && line == "{":
+			current = &bytes.Buffer{}
+			current.WriteString(line + "\n")
+		case current != nil:
+			current.WriteString(line + "\n")
+			if line == "}" {
+				documents = append(documents, current.Bytes())
+				current = nil
+			}
+		}
+	}
+	return documents
+}
+
+// runMigrateCommand implements the migrate subcommand:
+// migrate -in old/ -out new.jsonl
+func runMigrateCommand(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	in := flags.String("in", "", "directory of captured legacy JSON output")
+	out := flags.String("out", "", "JSONL file receiving the migrated analyses")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *in == "" || *out == "" {
+		fmt.Fprintln(os.Stderr, "usage: migrate -in <dir> -out <file.jsonl>")
+		return 2
+	}
+
+	entries, err := os.ReadDir(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", *in, err)
+		return 1
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files = append(files, filepath.Join(*in, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	output, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating %s: %v\n", *out, err)
+		return 1
+	}
+	defer output.Close()
+	writer := bufio.NewWriter(output)
+	encoder := json.NewEncoder(writer)
+
+	migrated, failed, warned := 0, 0, 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			failed++
+			continue
+		}
+		for i, document := range splitLegacyDocuments(data) {
+			analysis, err := ParseLegacyAnalysisJSON(document)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s #%d: %v\n", file, i+1, err)
+				failed++
+				continue
+			}
+			if err := encoder.Encode(analysis); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *out, err)
+				return 1
+			}
+			migrated++
+			if len(analysis.Warnings) > 0 {
+				warned++
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *out, err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "Migrated %d analyses (%d with warnings), %d failed\n", migrated, warned, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
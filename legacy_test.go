@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// parseLegacyFixture parses a legacy printer document from testdata/legacy
+func parseLegacyFixture(t *testing.T, name string) *JupiterV6Analysis {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "legacy", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysis, err := ParseLegacyAnalysisJSON(data)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return analysis
+}
+
+// legacyFixtureEvents are the events of both legacy fixtures
+func legacyFixtureEvents() []SwapEvent {
+	mintMid := solana.MustPublicKeyFromBase58("4k3Dyjzvzp8eMZWUXbBCjEvwSkkk59S5iCNLY3QrkX6R")
+	return []SwapEvent{
+		{AMM: whirlpoolProgramID, InputMint: solana.SolMint, InputAmount: 1_000_000_000, OutputMint: mintMid, OutputAmount: 88_512_000, InstructionIndex: -1},
+		{AMM: solana.MustPublicKeyFromBase58("675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8"), InputMint: mintMid, InputAmount: 88_512_000, OutputMint: mintUSDC, OutputAmount: 150_250_000, InstructionIndex: -1},
+	}
+}
+
+func TestParseLegacyAnalysisJSON(t *testing.T) {
+	// The printer wrote amounts as strings, except the counts and fee bps
+	got := parseLegacyFixture(t, "route.json")
+	want := &JupiterV6Analysis{
+		Summary: SwapSummary{
+			TotalSwaps:  2,
+			InputToken:  solana.SolMint.String(),
+			OutputToken: mintUSDC.String(),
+			TotalInput:  1_000_000_000,
+			TotalOutput: 150_250_000,
+			Route:       "So11111111111111111111111111111111111111112 -> 4k3Dyjzvzp8eMZWUXbBCjEvwSkkk59S5iCNLY3QrkX6R -> EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+		},
+		Instructions: []JupiterSwapParams{{
+			InstructionType: "route",
+			RoutePlan:       []RoutePlanStep{},
+			InAmount:        1_000_000_000,
+			QuotedOutAmount: 150_400_000,
+			SlippageBps:     50,
+		}},
+		Events: legacyFixtureEvents(),
+		Warnings: []Alert{
+			{Code: CodeLegacyMigration, Message: "instruction indexes are positions, route plans and accounts were not recorded"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseLegacyAnalysisJSONNumbers(t *testing.T) {
+	// Hand edited documents mix number and string amounts
+	got := parseLegacyFixture(t, "numbers.json")
+	if got.Summary.TotalInput != 1_000_000_000 || got.Summary.TotalOutput != 150_250_000 || got.Summary.TotalSwaps != 2 {
+		t.Errorf("summary %+v", got.Summary)
+	}
+	if !reflect.DeepEqual(got.Events, legacyFixtureEvents()) {
+		t.Errorf("events %+v", got.Events)
+	}
+
+	wantInstructions := []JupiterSwapParams{
+		{
+			InstructionType:   "route",
+			AuthorityID:       3,
+			RoutePlan:         []RoutePlanStep{},
+			InAmount:          1_000_000_000,
+			QuotedOutAmount:   150_400_000,
+			MinAmountOut:      149_648_000,
+			SlippageAllowance: 752_000,
+			SlippageBps:       50,
+			PlatformFeeBps:    20,
+		},
+		{
+			InstructionType:  "exactOutRoute",
+			InstructionIndex: 1,
+			RoutePlan:        []RoutePlanStep{},
+			SlippageBps:      100,
+		},
+	}
+	if !reflect.DeepEqual(got.Instructions, wantInstructions) {
+		t.Errorf("instructions %+v\nwant %+v", got.Instructions, wantInstructions)
+	}
+	wantWarnings := []Alert{
+		{Code: CodeLegacyMigration, Message: "instruction 1: exactOutRoute amounts were not recorded"},
+		{Code: CodeLegacyMigration, Message: "instruction 1: min_amount_out 5000000 is the maximum input, dropped"},
+		{Code: CodeLegacyMigration, Message: "instruction indexes are positions, route plans and accounts were not recorded"},
+	}
+	if !reflect.DeepEqual(got.Warnings, wantWarnings) {
+		t.Errorf("warnings %+v\nwant %+v", got.Warnings, wantWarnings)
+	}
+}
+
+func TestLegacyUint(t *testing.T) {
+	for input, want := range map[string]legacyUint{
+		`123`:                    123,
+		`"123"`:                  123,
+		`""`:                     0,
+		`null`:                   0,
+		`"18446744073709551615"`: 18446744073709551615,
+	} {
+		var got legacyUint
+		if err := got.UnmarshalJSON([]byte(input)); err != nil || got != want {
+			t.Errorf("%s: %d, %v, want %d", input, got, err, want)
+		}
+	}
+	for _, input := range []string{`-1`, `"1.5"`, `"abc"`, `"18446744073709551616"`} {
+		var got legacyUint
+		if err := got.UnmarshalJSON([]byte(input)); err == nil {
+			t.Errorf("%s: decoded %d", input, got)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+//go:build cshared
+
+// Command libsoltx is the C ABI of the instruction decoder, built from the
+// repository root with
+//
+//	go build -tags cshared -buildmode=c-shared -o libsoltx.so ./libsoltx
+//
+// It imports only the jupiterv6 package, so the library carries no RPC
+// client and no network dependency. See examples/python/parse_instruction.py
+// for a ctypes consumer.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"sol-tx/jupiterv6"
+)
+
+func main() {}
+
+// ParseInstructionJSON decodes the Jupiter V6 instruction data of length bytes
+// at data. It returns a JSON string allocated with malloc, to be released with
+// FreeString, and stores the result code in *code when code is not null.
+//
+//export ParseInstructionJSON
+func ParseInstructionJSON(data *C.uchar, length C.int, code *C.int) *C.char {
+	var raw []byte
+	if data != nil && length > 0 {
+		raw = C.GoBytes(unsafe.Pointer(data), length)
+	}
+	result, status := jupiterv6.DecodeInstructionJSON(raw)
+	if code != nil {
+		*code = C.int(status)
+	}
+	return C.CString(string(result))
+}
+
+// FreeString releases a string returned by ParseInstructionJSON
+//
+//export FreeString
+func FreeString(s *C.char) {
+	C.free(unsafe.Pointer(s))
+}
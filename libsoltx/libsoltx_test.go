@@ -0,0 +1,63 @@
+//go:build cshared
+
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestPythonExample builds the shared library and decodes through the ctypes
+// example, skipped where python3 is not installed
+func TestPythonExample(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not installed")
+	}
+
+	lib := filepath.Join(t.TempDir(), "libsoltx.so")
+	build := exec.Command("go", "build", "-tags", "cshared", "-buildmode=c-shared", "-o", lib, ".")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("build: %v\n%s", err, out)
+	}
+	script := filepath.Join("..", "examples", "python", "parse_instruction.py")
+
+	out, err := exec.Command(python, script, lib).Output()
+	if err != nil {
+		t.Fatalf("example data: %v\n%s", err, out)
+	}
+	var decoded struct {
+		InstructionType string `json:"instruction_type"`
+		RoutePlan       []struct {
+			Swap struct {
+				Name string `json:"name"`
+			} `json:"swap"`
+		} `json:"route_plan"`
+		InAmount        uint64 `json:"in_amount"`
+		QuotedOutAmount uint64 `json:"quoted_out_amount"`
+		SlippageBps     uint16 `json:"slippage_bps"`
+	}
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	if decoded.InstructionType != "route" || len(decoded.RoutePlan) != 1 || decoded.RoutePlan[0].Swap.Name != "Saber" ||
+		decoded.InAmount != 1000 || decoded.QuotedOutAmount != 990 || decoded.SlippageBps != 50 {
+		t.Errorf("decoded %s", out)
+	}
+
+	// the route discriminator and a route plan length with no steps after it
+	out, err = exec.Command(python, script, lib, "e517cb977ae3ad2a01000000").Output()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 2 {
+		t.Fatalf("truncated data: %v\n%s", err, out)
+	}
+	var decodeError struct {
+		Code  string `json:"code"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(out, &decodeError); err != nil || decodeError.Code != "JUP002" || decodeError.Error == "" {
+		t.Errorf("truncated data: %s", out)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+)
+
+// floodLogs returns lines log lines of a looping program with a swap event
+// every eventEvery lines
+func floodLogs(lines, eventEvery int) []string {
+	event := SwapEvent{AMM: testKey(1), InputMint: testKey(2), InputAmount: 1000, OutputMint: testKey(3), OutputAmount: 990}
+	data := programDataPrefix + base64.StdEncoding.EncodeToString(event.Encode())
+	logs := make([]string, 0, lines)
+	for i := 0; i < lines; i++ {
+		if eventEvery > 0 && i%eventEvery == eventEvery-1 {
+			logs = append(logs, data)
+			continue
+		}
+		logs = append(logs, fmt.Sprintf("Program log: iteration %d", i))
+	}
+	return logs
+}
+
+func TestLogScanLimits(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		logs      []string
+		limits    scanLimits
+		events    int
+		scanned   int
+		lineHit   bool
+		eventHit  bool
+		truncated bool
+	}{
+		{"within limits", floodLogs(1000, 100), defaultScanLimits(), 10, 1000, false, false, false},
+		{"log line limit", floodLogs(10000, 100), scanLimits{maxLogLines: 1000, maxEvents: 1024}, 10, 1000, true, false, false},
+		{"event limit", floodLogs(1000, 100), scanLimits{maxLogLines: 20000, maxEvents: 3}, 3, 300, false, true, false},
+		{"log truncated", append(floodLogs(500, 100), append([]string{"Log truncated"}, floodLogs(500, 100)...)...), defaultScanLimits(), 5, 501, false, false, true},
+	} {
+		var stats AnalysisStats
+		events := extractJupiterEventsFromLogs(tt.logs, tt.limits, &stats)
+		if len(events) != tt.events || stats.LogLinesScanned != tt.scanned {
+			t.Errorf("%s: %d events after %d lines, want %d after %d", tt.name, len(events), stats.LogLinesScanned, tt.events, tt.scanned)
+		}
+		if stats.LogLineLimitHit != tt.lineHit || stats.EventLimitHit != tt.eventHit || stats.LogsTruncated != tt.truncated {
+			t.Errorf("%s: stats %+v", tt.name, stats)
+		}
+	}
+}
+
+func TestAnalyzeEventLimit(t *testing.T) {
+	tx, parsedTx := triageFixture(t, "route", 1, nil)
+	tx.Meta.LogMessages = floodLogs(1000, 100)
+	analysis := analyzeTest(t, newTestAnalyzer(WithMaxEvents(4)), tx, parsedTx)
+	// Two inner instruction events and two of the log events
+	if len(analysis.Events) != 4 || !analysis.Stats.EventLimitHit {
+		t.Errorf("%d events, stats %+v", len(analysis.Events), analysis.Stats)
+	}
+}
+
+func BenchmarkExtractEventsFromLogFlood(b *testing.B) {
+	logs := floodLogs(10000, 1000)
+	limits := defaultScanLimits()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var stats AnalysisStats
+		if events := extractJupiterEventsFromLogs(logs, limits, &stats); len(events) != 10 {
+			b.Fatalf("%d events", len(events))
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+
+	"sol-tx/jupiterv6"
+)
+
+// ParseInstructionLite returns the instruction type and amounts of Jupiter
+// instruction data without decoding the route plan, for coarse filtering before
+// a full parse. For exactIn variants inAmount is in_amount and outAmount
+// quoted_out_amount; for exactOut variants inAmount is quoted_in_amount and
+// outAmount out_amount. Token ledger variants have no in_amount argument, their
+// inAmount is zero.
+func ParseInstructionLite(data []byte) (instructionType string, inAmount, outAmount uint64, err error) {
+	if len(data) < 8 {
+		return "", 0, 0, fmt.Errorf("%w: instruction data too short", errTruncatedInstruction)
+	}
+	for name, discriminator := range InstructionDiscriminators {
+		if bytesEqual(data[:8], discriminator) {
+			instructionType = name
+			break
+		}
+	}
+	if instructionType == "" {
+		return "", 0, 0, fmt.Errorf("%w: %X", errUnknownDiscriminator, data[:8])
+	}
+
+	offset := 8
+	if jupiterv6.IsShared(instructionType) {
+		offset++ // Skip ID
+	}
+	length, err := jupiterv6.RoutePlanByteLength(data, offset)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	tail, err := jupiterv6.DecodeTail(data, offset+length, instructionType)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if isExactOutInstruction(instructionType) {
+		return instructionType, tail.QuotedAmount, tail.Amount, nil
+	}
+	return instructionType, tail.Amount, tail.QuotedAmount, nil
+}
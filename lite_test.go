@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"sol-tx/testgen"
+)
+
+func TestParseInstructionLite(t *testing.T) {
+	for instructionType := range InstructionDiscriminators {
+		spec := testgenSpec(instructionType)
+		data, err := testgen.EncodeInstruction(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gotType, inAmount, outAmount, err := ParseInstructionLite(data)
+		if err != nil {
+			t.Fatalf("%s: %v", instructionType, err)
+		}
+		full, err := parseJupiterV6Instruction(data)
+		if err != nil {
+			t.Fatalf("%s: %v", instructionType, err)
+		}
+		wantIn, wantOut := full.InAmount, full.QuotedOutAmount
+		if isExactOutInstruction(instructionType) {
+			wantIn, wantOut = full.QuotedInAmount, full.OutAmount
+		}
+		if gotType != instructionType || inAmount != wantIn || outAmount != wantOut {
+			t.Errorf("%s: lite got %s %d -> %d, full parse %d -> %d", instructionType, gotType, inAmount, outAmount, wantIn, wantOut)
+		}
+
+		if _, _, _, err := ParseInstructionLite(data[:len(data)-1]); err == nil {
+			t.Errorf("%s: truncated data parsed", instructionType)
+		}
+	}
+}
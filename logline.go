@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// logLineVersion is embedded in every LogLine as v=; bump it whenever fields
+// are added, removed, renamed or change meaning
+const logLineVersion = 1
+
+// LogLine formats the analysis as a single greppable key=value line:
+//
+//	v=1 sig=<signature> wallet=<user wallet> pair=<in>/<out> in=<ui amount> out=<ui amount>
+//	route=<variant>><variant> slippage_bps=<bps> status=ok|partial|failed [code=<code>]
+//
+// Symbols and decimals come from registry when it knows the mint, otherwise
+// shortened mints and raw amounts are used. status is failed for failed
+// transactions, with the custom program error or the transaction error as code,
+// and partial when some Jupiter instruction could not be parsed, with its alert
+// code. Values containing spaces, quotes or '=' are quoted.
+func (a *JupiterV6Analysis) LogLine(registry TokenRegistry) string {
+	fields := []string{"v", strconv.Itoa(logLineVersion), "sig", a.Signature.String()}
+
+	wallet := "-"
+	var route []string
+	slippage := "-"
+	if len(a.Instructions) > 0 {
+		wallet = a.Instructions[0].UserWallet.String()
+		slippage = strconv.Itoa(int(a.Instructions[0].SlippageBps))
+	}
+	for _, inst := range a.Instructions {
+		for _, step := range inst.RoutePlan {
+			route = append(route, string(step.Swap.Type))
+		}
+	}
+	fields = append(fields, "wallet", wallet)
+
+	events := nonDustEvents(a.Events)
+	if len(events) > 0 {
+		first, last := events[0], events[len(events)-1]
+		fields = append(fields,
+			"pair", logSymbol(first.InputMint, registry)+"/"+logSymbol(last.OutputMint, registry),
+			"in", logAmount(first.InputAmount, first.InputMint, registry),
+			"out", logAmount(last.OutputAmount, last.OutputMint, registry))
+	} else {
+		fields = append(fields, "pair", "-", "in", "-", "out", "-")
+	}
+
+	if len(route) == 0 {
+		route = []string{"-"}
+	}
+	fields = append(fields, "route", strings.Join(route, ">"), "slippage_bps", slippage)
+
+	switch {
+	case a.TransactionError != "":
+		fields = append(fields, "status", "failed", "code", transactionErrorCode(a.TransactionError))
+	case len(a.Errors) > 0:
+		fields = append(fields, "status", "partial", "code", string(a.Errors[0].Code))
+	default:
+		fields = append(fields, "status", "ok")
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(fields); i += 2 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(fields[i])
+		b.WriteByte('=')
+		b.WriteString(logValue(fields[i+1]))
+	}
+	return b.String()
+}
+
+// logValue quotes values that would break key=value parsing
+func logValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// logSymbol returns the registry symbol of mint or a shortened mint address
+func logSymbol(mint solana.PublicKey, registry TokenRegistry) string {
+	if registry != nil {
+		if info, ok := registry.Lookup(mint); ok && info.Symbol != "" {
+			return info.Symbol
+		}
+	}
+	address := mint.String()
+	if len(address) <= 8 {
+		return address
+	}
+	return address[:4] + ".." + address[len(address)-4:]
+}
+
+// logAmount returns the UI amount when the decimals of mint are known, the raw amount otherwise
+func logAmount(amount uint64, mint solana.PublicKey, registry TokenRegistry) string {
+	if registry != nil {
+		if info, ok := registry.Lookup(mint); ok {
+			return formatUnits(amount, info.Decimals)
+		}
+	}
+	return strconv.FormatUint(amount, 10)
+}
+
+// transactionErrorCode extracts the custom program error of an instruction
+// error, e.g. {"InstructionError":[2,{"Custom":6001}]} -> "custom:6001", and
+// returns the error itself otherwise ("AccountInUse" -> AccountInUse)
+func transactionErrorCode(transactionError string) string {
+	var name string
+	if json.Unmarshal([]byte(transactionError), &name) == nil {
+		return name
+	}
+	var decoded struct {
+		InstructionError []json.RawMessage `json:"InstructionError"`
+	}
+	if json.Unmarshal([]byte(transactionError), &decoded) == nil && len(decoded.InstructionError) == 2 {
+		var custom struct {
+			Custom *uint32 `json:"Custom"`
+		}
+		if json.Unmarshal(decoded.InstructionError[1], &custom) == nil && custom.Custom != nil {
+			return "custom:" + strconv.FormatUint(uint64(*custom.Custom), 10)
+		}
+	}
+	return transactionError
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"sol-tx/testgen"
+)
+
+func TestLogLine(t *testing.T) {
+	gen, err := testgen.Generate(testgenSpec("route"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysis := analyzeTest(t, newTestAnalyzer(), gen.Result, gen.Transaction)
+	first, last := analysis.Events[0], analysis.Events[len(analysis.Events)-1]
+	registry := StaticTokenRegistry{gen.Mints[0]: {Symbol: "SOL", Decimals: 9}}
+	output := gen.Mints[2].String()
+	prefix := fmt.Sprintf("v=1 sig=%s wallet=%s pair=SOL/%s in=%s out=%d route=Saber>Whirlpool slippage_bps=50",
+		analysis.Signature, gen.Payer, output[:4]+".."+output[len(output)-4:], formatUnits(first.InputAmount, 9), last.OutputAmount)
+
+	if got, want := analysis.LogLine(registry), prefix+" status=ok"; got != want {
+		t.Errorf("ok:\n got %s\nwant %s", got, want)
+	}
+
+	analysis.TransactionError = `{"InstructionError":[2,{"Custom":6001}]}`
+	if got, want := analysis.LogLine(registry), prefix+" status=failed code=custom:6001"; got != want {
+		t.Errorf("failed:\n got %s\nwant %s", got, want)
+	}
+
+	// Symbols with spaces are quoted so the line still splits on spaces
+	registry[gen.Mints[0]] = TokenInfo{Symbol: "WRAPPED SOL", Decimals: 9}
+	analysis.TransactionError = ""
+	analysis.Errors = []InstructionError{{Code: CodeUnknownSwapVariant}}
+	want := fmt.Sprintf("v=1 sig=%s wallet=%s pair=\"WRAPPED SOL/%s\" in=%s out=%d route=Saber>Whirlpool slippage_bps=50 status=partial code=%s",
+		analysis.Signature, gen.Payer, output[:4]+".."+output[len(output)-4:], formatUnits(first.InputAmount, 9), last.OutputAmount, CodeUnknownSwapVariant)
+	if got := analysis.LogLine(registry); got != want {
+		t.Errorf("partial:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestLogLineEmpty(t *testing.T) {
+	got := (&JupiterV6Analysis{}).LogLine(nil)
+	want := "v=1 sig=1111111111111111111111111111111111111111111111111111111111111111 wallet=- pair=- in=- out=- route=- slippage_bps=- status=ok"
+	if got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestTransactionErrorCode(t *testing.T) {
+	for transactionError, want := range map[string]string{
+		`{"InstructionError":[2,{"Custom":6001}]}`: "custom:6001",
+		`"AccountInUse"`: "AccountInUse",
+		`{"InstructionError":[0,"InvalidAccountData"]}`: `{"InstructionError":[0,"InvalidAccountData"]}`,
+		`not json`: "not json",
+	} {
+		if got := transactionErrorCode(transactionError); got != want {
+			t.Errorf("%s: got %s, want %s", transactionError, got, want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gagliardetto/solana-go"
+	lookup "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// LookupTableProvider supplies the addresses of address lookup tables
+type LookupTableProvider interface {
+	GetLookupTable(ctx context.Context, tableID solana.PublicKey) (solana.PublicKeySlice, error)
+}
+
+// StaticLookupTables is a LookupTableProvider backed by a fixed map, such as a
+// snapshot loaded from disk
+type StaticLookupTables map[solana.PublicKey]solana.PublicKeySlice
+
+// GetLookupTable returns the addresses recorded for tableID
+func (t StaticLookupTables) GetLookupTable(ctx context.Context, tableID solana.PublicKey) (solana.PublicKeySlice, error) {
+	addresses, ok := t[tableID]
+	if !ok {
+		return nil, fmt.Errorf("lookup table %s not in snapshot", tableID)
+	}
+	return addresses, nil
+}
+
+// LoadLookupTableSnapshot reads a JSON object mapping table IDs to their address lists
+func LoadLookupTableSnapshot(path string) (StaticLookupTables, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tables StaticLookupTables
+	if err := json.Unmarshal(raw, &tables); err != nil {
+		return nil, fmt.Errorf("error decoding lookup table snapshot: %v", err)
+	}
+	return tables, nil
+}
+
+// WithLookupTableProvider resolves lookup tables from provider instead of the rpc client
+func WithLookupTableProvider(provider LookupTableProvider) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.lookupTables = provider
+	}
+}
+
+// WithLenientLookups analyzes transactions whose lookup tables fail to resolve
+// (closed table, rpc error) with their static account keys only instead of
+// failing. Such analyses have LookupsFullyResolved unset.
+func WithLenientLookups() AnalyzerOption {
+	return func(a *Analyzer) {
+		a.lenientLookups = true
+	}
+}
+
+// lookupsFullyResolved reports whether every account key of the message is known
+func lookupsFullyResolved(tx *solana.Transaction) bool {
+	if !tx.Message.IsVersioned() || tx.Message.IsResolved() {
+		return true
+	}
+	lookups := tx.Message.GetAddressTableLookups()
+	return lookups == nil || lookups.NumLookups() == 0
+}
+
+// rpcLookupTables fetches lookup tables with the analyzer's rpc settings
+type rpcLookupTables struct {
+	a *Analyzer
+}
+
+// GetLookupTable fetches and decodes the lookup table account
+func (p rpcLookupTables) GetLookupTable(ctx context.Context, tableID solana.PublicKey) (solana.PublicKeySlice, error) {
+	var info *rpc.GetAccountInfoResult
+	err := p.a.withRPCSlot(ctx, func() error {
+		var err error
+		info, err = p.a.rpcClient.GetAccountInfoWithOpts(
+			ctx,
+			tableID,
+			&rpc.GetAccountInfoOpts{Commitment: CommitmentFromContext(ctx, p.a.commitment)},
+		)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching lookup table: %v", err)
+	}
+
+	tableContent, err := lookup.DecodeAddressLookupTableState(info.GetBinary())
+	if err != nil {
+		return nil, fmt.Errorf("error decoding lookup table: %v", err)
+	}
+	return tableContent.Addresses, nil
+}
+
+// lookupTableProvider returns the configured provider, falling back to the rpc
+// client, or nil when neither is available or the network is disabled
+func (a *Analyzer) lookupTableProvider() LookupTableProvider {
+	if a.lookupTables != nil {
+		return a.lookupTables
+	}
+	if a.rpcClient != nil && !a.noNetwork {
+		return rpcLookupTables{a: a}
+	}
+	return nil
+}
+
+// resolveLookupsFromMeta resolves address lookup tables offline from the loaded
+// addresses recorded in the transaction meta. The runtime orders loaded addresses
+// as all writable then all readonly entries in lookup order, which is used to
+// rebuild the referenced table slots.
+func resolveLookupsFromMeta(tx *solana.Transaction, meta *rpc.TransactionMeta) error {
+	if !tx.Message.IsVersioned() || tx.Message.IsResolved() {
+		return nil
+	}
+
+	lookups := tx.Message.GetAddressTableLookups()
+	if lookups == nil || lookups.NumLookups() == 0 {
+		return nil
+	}
+	if meta == nil {
+		return fmt.Errorf("transaction meta missing, cannot resolve lookups offline")
+	}
+
+	writable := meta.LoadedAddresses.Writable
+	readonly := meta.LoadedAddresses.ReadOnly
+
+	resolutions := make(map[solana.PublicKey]solana.PublicKeySlice)
+	place := func(tableID solana.PublicKey, index uint8, key solana.PublicKey) {
+		table := resolutions[tableID]
+		for len(table) <= int(index) {
+			table = append(table, solana.PublicKey{})
+		}
+		table[index] = key
+		resolutions[tableID] = table
+	}
+
+	w, r := 0, 0
+	for _, lookup := range lookups {
+		for _, index := range lookup.WritableIndexes {
+			if w >= len(writable) {
+				return fmt.Errorf("meta has fewer writable loaded addresses than lookups")
+			}
+			place(lookup.AccountKey, index, writable[w])
+			w++
+		}
+	}
+	for _, lookup := range lookups {
+		for _, index := range lookup.ReadonlyIndexes {
+			if r >= len(readonly) {
+				return fmt.Errorf("meta has fewer readonly loaded addresses than lookups")
+			}
+			place(lookup.AccountKey, index, readonly[r])
+			r++
+		}
+	}
+
+	if err := tx.Message.SetAddressTables(resolutions); err != nil {
+		return fmt.Errorf("error setting address tables: %v", err)
+	}
+	if err := tx.Message.ResolveLookups(); err != nil {
+		return fmt.Errorf("error resolving lookups: %v", err)
+	}
+	return nil
+}
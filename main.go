@@ -2,46 +2,167 @@ package main
 
 import (
 	"context"
-	"encoding/binary"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/gagliardetto/solana-go"
-	lookup "github.com/gagliardetto/solana-go/programs/address-lookup-table"
 	"github.com/gagliardetto/solana-go/rpc"
 	"golang.org/x/time/rate"
+
+	"sol-tx/jupiterv6"
 )
 
 // InstructionDiscriminators Jupiter V6 instruction type discriminators
-var InstructionDiscriminators = map[string][]byte{
-	"route":                              {0xE5, 0x17, 0xCB, 0x97, 0x7A, 0xE3, 0xAD, 0x2A},
-	"routeWithTokenLedger":               {0x96, 0x56, 0x47, 0x74, 0xA7, 0x5D, 0x0E, 0x68},
-	"sharedAccountsRoute":                {0xC1, 0x20, 0x9B, 0x33, 0x41, 0xD6, 0x9C, 0x81},
-	"sharedAccountsRouteWithTokenLedger": {0xE6, 0x79, 0x8F, 0x50, 0x77, 0x9F, 0x6A, 0xAA},
-	"exactOutRoute":                      {0xD0, 0x33, 0xEF, 0x97, 0x7B, 0x2B, 0xED, 0x5C},
-	"sharedAccountsExactOutRoute":        {0xB0, 0xD1, 0x69, 0xA8, 0x9A, 0x7D, 0x45, 0x3E},
-}
+var InstructionDiscriminators = jupiterv6.InstructionDiscriminators
 
 // SwapEventDiscriminator Jupiter V6 Event Discriminator (first 8 bytes of the first event)
-var SwapEventDiscriminator = []byte{0xe4, 0x45, 0xa5, 0x2e, 0x51, 0xcb, 0x9a, 0x1d}
+var SwapEventDiscriminator = jupiterv6.EmitCPIPrefix
 
 // SwapEvent represents a Jupiter V6 swap event
 type SwapEvent struct {
 	Discriminator []byte           `json:"discriminator"`
-	Unknown       []byte           `json:"unknown"`       // Bytes 8-15, unknown field
+	Unknown       []byte           `json:"unknown"`       // Bytes 8-15, event type discriminator
 	AMM           solana.PublicKey `json:"amm"`           // Bytes 16-47, AMM program address
 	InputMint     solana.PublicKey `json:"input_mint"`    // Bytes 48-79, input token address
 	InputAmount   uint64           `json:"input_amount"`  // Bytes 80-87, input amount
 	OutputMint    solana.PublicKey `json:"output_mint"`   // Bytes 88-119, output token address
 	OutputAmount  uint64           `json:"output_amount"` // Bytes 120-127, output amount
+
+	// Form tells whether the event came behind the emit-CPI prefix or bare
+	Form EventForm `json:"form,omitempty"`
+
+	// Dust is set when an amount is below the configured dust threshold
+	Dust bool `json:"dust,omitempty"`
+
+	// InstructionIndex is the top-level instruction that emitted the event, -1 when unknown
+	InstructionIndex int `json:"instruction_index"`
+	// Pool is the pool or market account the AMM swapped against, set for AMMs with a hop decoder
+	Pool *solana.PublicKey `json:"pool,omitempty"`
+	// TradeID identifies the hop across systems, see TradeID
+	TradeID string `json:"trade_id,omitempty"`
+
+	// SchemaVersion is the registered SwapEventSchema the event was decoded with, empty for the base layout
+	SchemaVersion string `json:"schema_version,omitempty"`
+	// Fields holds the fields decoded by the schema beyond the base layout
+	Fields map[string]string `json:"fields,omitempty"`
+	// ExtraFields is the hex of trailing bytes no registered schema decoded
+	ExtraFields string `json:"extra_fields,omitempty"`
 }
 
 // JupiterV6Analysis represents the complete Jupiter V6 transaction analysis result
 type JupiterV6Analysis struct {
+	// Signature is the first signature of the transaction
+	Signature solana.Signature `json:"signature"`
+	Slot      uint64           `json:"slot,omitempty"`
+	// TransactionError is the JSON encoded error of a failed transaction
+	TransactionError string `json:"transaction_error,omitempty"`
+	// Timestamp is the block time of the transaction, estimated or unknown when the node sent none
+	Timestamp Timestamp `json:"timestamp"`
+
 	Instructions []JupiterSwapParams `json:"instructions"`
-	Events       []SwapEvent         `json:"events"`
-	Summary      SwapSummary         `json:"summary"`
+	// Events are in on-chain execution order, Events[0] is the first executed swap
+	Events   []SwapEvent        `json:"events"`
+	Summary  SwapSummary        `json:"summary"`
+	Warnings []Alert            `json:"warnings,omitempty"`
+	Errors   []InstructionError `json:"errors,omitempty"`
+	// Results has one entry per Jupiter instruction, in transaction order
+	Results []InstructionResult `json:"results"`
+
+	// LookupsFullyResolved is false when address lookup tables were left unresolved,
+	// account derived fields (ledger, platform fee account, step accounts) are then incomplete
+	LookupsFullyResolved bool `json:"lookups_fully_resolved"`
+
+	// JupiterVersion is the Jupiter version invoked according to the program logs
+	JupiterVersion string `json:"jupiter_version,omitempty"`
+
+	Stats AnalysisStats `json:"stats"`
+
+	ExecutionQuality *ExecutionQuality `json:"execution_quality,omitempty"`
+	RouteAssessment  *RouteAssessment  `json:"route_assessment,omitempty"`
+
+	// Integrator is the app that sent the swap according to the platform fee
+	// account, set when an integrator registry is configured
+	Integrator string `json:"integrator,omitempty"`
+
+	// Ledger lists the balance changes of the accounts referenced by the instructions
+	Ledger []LedgerEntry `json:"ledger,omitempty"`
+
+	// ForbiddenVariants lists the variants forbidden by WithForbiddenVariants the route used
+	ForbiddenVariants []SwapType `json:"forbidden_variants,omitempty"`
+
+	// MintRisks has the risk signals of each event mint, set when a mint risk provider is configured
+	MintRisks []MintRisk `json:"mint_risks,omitempty"`
+
+	// OtherPrograms lists the top-level programs besides Jupiter and setup programs, see IsSimpleSwap
+	OtherPrograms []solana.PublicKey `json:"other_programs,omitempty"`
+	// MaxCPIDepth is the deepest invocation stack height, 1 when nothing was invoked through CPI
+	MaxCPIDepth int `json:"max_cpi_depth"`
+
+	// Memos are the SPL Memo notes of the transaction, such as order IDs or
+	// referral codes, in execution order
+	Memos []string `json:"memos,omitempty"`
+
+	// Failure attributes a failed transaction to the AMM whose invocation errored
+	Failure *SwapFailure `json:"failure,omitempty"`
+
+	// PriorityFee rates the compute unit price against the block, set when a block fee provider is configured
+	PriorityFee *PriorityFee `json:"priority_fee,omitempty"`
+}
+
+// InstructionTypes returns the distinct instruction types of the parsed instructions, sorted
+func (a *JupiterV6Analysis) InstructionTypes() []string {
+	seen := make(map[string]bool)
+	types := []string{}
+	for _, inst := range a.Instructions {
+		if !seen[inst.InstructionType] {
+			seen[inst.InstructionType] = true
+			types = append(types, inst.InstructionType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// AnalysisStats counts the Jupiter instructions seen during analysis
+type AnalysisStats struct {
+	JupiterInstructions int `json:"jupiter_instructions"` // Top-level Jupiter instructions
+	ParsedInstructions  int `json:"parsed_instructions"`
+	SkippedInstructions int `json:"skipped_instructions"` // Filtered out by WithInstructionTypes
+	FailedInstructions  int `json:"failed_instructions"`
+
+	LogLinesScanned int  `json:"log_lines_scanned"`
+	LogLineLimitHit bool `json:"log_line_limit_hit"` // Stopped at the log line limit
+	LogsTruncated   bool `json:"logs_truncated"`     // Runtime truncated the logs
+	EventLimitHit   bool `json:"event_limit_hit"`    // Stopped at the event limit
+
+	EventsWithExtraFields int `json:"events_with_extra_fields,omitempty"` // Events longer than any registered schema
+}
+
+// InstructionError records a Jupiter instruction that failed to parse
+type InstructionError struct {
+	Index int       `json:"index"` // Top-level instruction index in the transaction
+	Code  AlertCode `json:"code"`
+	Error string    `json:"error"`
+}
+
+// InstructionResult is the outcome of one Jupiter instruction of the transaction.
+// Exactly one of Params, Error or Skipped is set.
+type InstructionResult struct {
+	Index int    `json:"index"` // Top-level instruction index in the transaction
+	Data  []byte `json:"data"`
+	// Params points into Instructions and is left out of JSON, join on index instead
+	Params  *JupiterSwapParams `json:"-"`
+	Skipped bool               `json:"skipped,omitempty"` // filtered out by WithInstructionTypes
+	Code    AlertCode          `json:"code,omitempty"`
+	Error   string             `json:"error,omitempty"`
 }
 
 // SwapSummary represents swap summary information
@@ -52,6 +173,10 @@ type SwapSummary struct {
 	TotalInput  uint64 `json:"total_input"`
 	TotalOutput uint64 `json:"total_output"`
 	Route       string `json:"route"`
+
+	// NetSolDelta is the native SOL change of the user in lamports, excluding
+	// the transaction fee, set when SOL is one side of the swap
+	NetSolDelta int64 `json:"net_sol_delta,omitempty"`
 }
 
 // SwapType Represents different swap protocol types
@@ -198,6 +323,13 @@ var SwapTypeToIndex = map[SwapType]uint8{
 type Swap struct {
 	Type   SwapType               `json:"name"`
 	Params map[string]interface{} `json:"params"`
+
+	// variableSize is the length of data dependent parameters, on top of
+	// the fixed size from jupiterv6.SwapFixedSize
+	variableSize int
+
+	// raw holds the borsh encoded variant fields as read from the instruction
+	raw []byte
 }
 
 // RoutePlanStep represents a step in the route plan
@@ -206,687 +338,250 @@ type RoutePlanStep struct {
 	Percent     uint8 `json:"percent"`
 	InputIndex  uint8 `json:"input_index"`
 	OutputIndex uint8 `json:"output_index"`
+
+	// Accounts holds named account ranges for variants with a known account layout
+	Accounts map[string]solana.PublicKeySlice `json:"accounts,omitempty"`
+
+	// Hop holds the decoded AMM swap instruction parameters (see WithHopDecoding)
+	Hop map[string]interface{} `json:"hop,omitempty"`
+
+	// BondingCurve is the pump.fun curve of wrapped pump.fun steps (see WithBondingCurveProvider)
+	BondingCurve *BondingCurveState `json:"bonding_curve,omitempty"`
+
+	// raw holds the step as read from the instruction, variant index to output_index
+	raw []byte
 }
 
 // JupiterSwapParams represents Jupiter swap parameters
 type JupiterSwapParams struct {
-	InstructionType string          `json:"instruction_type"`
-	ID              uint8           `json:"id,omitempty"`
-	RoutePlan       []RoutePlanStep `json:"route_plan"`
-	InAmount        uint64          `json:"in_amount,omitempty"`
-	OutAmount       uint64          `json:"out_amount,omitempty"`
-	QuotedOutAmount uint64          `json:"quoted_out_amount,omitempty"`
-	QuotedInAmount  uint64          `json:"quoted_in_amount,omitempty"`
-	SlippageBps     uint16          `json:"slippage_bps"`
-	PlatformFeeBps  uint8           `json:"platform_fee_bps"`
-	MinAmountOut    uint64          `json:"min_amount_out,omitempty"`
+	InstructionType  string            `json:"instruction_type"`
+	InstructionIndex int               `json:"instruction_index"` // Top-level instruction index in the transaction
+	LayoutVersion    LayoutVersion     `json:"layout_version,omitempty"`
+	AuthorityID      uint8             `json:"id,omitempty"`
+	Authority        *solana.PublicKey `json:"authority,omitempty"` // Program authority PDA of AuthorityID, sharedAccounts variants only
+	RoutePlan        []RoutePlanStep   `json:"route_plan"`
+	InAmount         uint64            `json:"in_amount,omitempty"`
+	OutAmount        uint64            `json:"out_amount,omitempty"`
+	QuotedOutAmount  uint64            `json:"quoted_out_amount,omitempty"`
+	QuotedInAmount   uint64            `json:"quoted_in_amount,omitempty"`
+	SlippageBps      uint16            `json:"slippage_bps"`
+	PlatformFeeBps   uint8             `json:"platform_fee_bps"`
+	MinAmountOut     uint64            `json:"min_amount_out,omitempty"`
+
+	// ExpireAt is the unix time after which the route fails, from the optional
+	// expire_at argument of newer program revisions. Nil when absent or None.
+	ExpireAt *int64 `json:"expire_at,omitempty"`
+
+	// SlippageAllowance is the absolute slippage allowed by SlippageBps, in output
+	// token units for exactIn (quoted_out - min_out) and input token units for
+	// exactOut (max_in - quoted_in)
+	SlippageAllowance   uint64 `json:"slippage_allowance"`
+	SlippageAllowanceUI string `json:"slippage_allowance_ui,omitempty"`
+
+	// PlatformFeeAmount is the platform fee charged in PlatformFeeMint, derived from the events
+	PlatformFeeAmount uint64           `json:"platform_fee_amount,omitempty"`
+	PlatformFeeMint   solana.PublicKey `json:"platform_fee_mint,omitempty"`
+
+	// UserWallet is the wallet the swap is attributed to, UserWalletSource tells where it was found
+	UserWallet       solana.PublicKey `json:"user_wallet"`
+	UserWalletSource string           `json:"user_wallet_source,omitempty"`
+
+	// TokenFlow has the source and destination token accounts and their owners
+	TokenFlow *TokenFlow `json:"token_flow,omitempty"`
+
+	// TokenLedger is set for token ledger variants, whose in_amount is recovered from the transaction
+	TokenLedger *TokenLedgerInfo `json:"token_ledger,omitempty"`
+
+	// Suspect lists the values outside the sanity bounds (see WithSanityBounds)
+	Suspect []SanityViolation `json:"suspect,omitempty"`
 }
 
 // Jupiter V6 Program ID
-var jupiterV6ProgramID = solana.MustPublicKeyFromBase58("JUP6LkbZbjS1jKKwapdHNy74zcZ3tLUZoi5QNyVTaV4")
+var jupiterV6ProgramID = solana.MustPublicKeyFromBase58(jupiterv6.ProgramAddress)
 
 // parseJupiterV6Instruction parses Jupiter V6 instruction data
 func parseJupiterV6Instruction(data []byte) (*JupiterSwapParams, error) {
-	if len(data) < 8 {
-		return nil, fmt.Errorf("instruction data too short")
-	}
-
-	// Check discriminator to determine instruction type
-	discriminator := data[:8]
-
-	// Check various instruction types
-	if bytesEqual(discriminator, InstructionDiscriminators["route"]) {
-		return parseRouteInstruction(data, "route")
-	} else if bytesEqual(discriminator, InstructionDiscriminators["routeWithTokenLedger"]) {
-		return parseRouteInstruction(data, "routeWithTokenLedger")
-	} else if bytesEqual(discriminator, InstructionDiscriminators["sharedAccountsRoute"]) {
-		return parseSharedAccountsRoute(data, "sharedAccountsRoute")
-	} else if bytesEqual(discriminator, InstructionDiscriminators["sharedAccountsRouteWithTokenLedger"]) {
-		return parseSharedAccountsRoute(data, "sharedAccountsRouteWithTokenLedger")
-	} else if bytesEqual(discriminator, InstructionDiscriminators["exactOutRoute"]) {
-		return parseExactOutRoute(data, "exactOutRoute")
-	} else if bytesEqual(discriminator, InstructionDiscriminators["sharedAccountsExactOutRoute"]) {
-		return parseSharedAccountsRoute(data, "sharedAccountsExactOutRoute")
+	decoded, err := jupiterv6.DecodeInstruction(data)
+	if err != nil {
+		return nil, err
 	}
-
-	return nil, fmt.Errorf("unknown instruction discriminator: %X", discriminator)
+	return paramsFromDecoded(decoded), nil
 }
 
-// parseRouteInstruction parses route and routeWithTokenLedger instructions
-func parseRouteInstruction(data []byte, instructionType string) (*JupiterSwapParams, error) {
-	offset := 8 // Skip discriminator
-
-	// Parse route plan count
-	routePlanCount := binary.LittleEndian.Uint32(data[offset : offset+4])
-	offset += 4
-
-	// Parse each route plan step
-	routePlan := make([]RoutePlanStep, routePlanCount)
-	for i := uint32(0); i < routePlanCount; i++ {
-		step, newOffset, err := parseRoutePlanStep(data, offset)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing route plan step %d: %v", i, err)
-		}
-		routePlan[i] = step
-		offset = newOffset
+// paramsFromDecoded converts a decoded instruction to swap parameters
+func paramsFromDecoded(decoded *jupiterv6.DecodedInstruction) *JupiterSwapParams {
+	params := &JupiterSwapParams{
+		InstructionType:   decoded.Type,
+		RoutePlan:         make([]RoutePlanStep, len(decoded.Route)),
+		SlippageBps:       decoded.SlippageBps,
+		PlatformFeeBps:    decoded.PlatformFeeBps,
+		ExpireAt:          decoded.ExpireAt,
+		MinAmountOut:      decoded.MinAmountOut, // The maximum input amount for exactOut
+		SlippageAllowance: decoded.SlippageAllowance,
 	}
-
-	// Parse other parameters
-	inAmount := binary.LittleEndian.Uint64(data[offset : offset+8])
-	offset += 8
-
-	quotedOutAmount := binary.LittleEndian.Uint64(data[offset : offset+8])
-	offset += 8
-
-	slippageBps := binary.LittleEndian.Uint16(data[offset : offset+2])
-	offset += 2
-
-	platformFeeBps := data[offset]
-
-	// Calculate min_amount_out
-	minAmountOut := uint64(float64(quotedOutAmount) * (1.0 - float64(slippageBps)/10000.0))
-
-	return &JupiterSwapParams{
-		InstructionType: instructionType,
-		RoutePlan:       routePlan,
-		InAmount:        inAmount,
-		QuotedOutAmount: quotedOutAmount,
-		SlippageBps:     slippageBps,
-		PlatformFeeBps:  platformFeeBps,
-		MinAmountOut:    minAmountOut,
-	}, nil
-}
-
-// parseSharedAccountsRoute parses sharedAccountsRoute type instructions
-func parseSharedAccountsRoute(data []byte, instructionType string) (*JupiterSwapParams, error) {
-	offset := 8 // Skip discriminator
-
-	// Parse ID
-	id := data[offset]
-	offset++
-
-	// Parse route plan count
-	routePlanCount := binary.LittleEndian.Uint32(data[offset : offset+4])
-	offset += 4
-
-	// Parse each route plan step
-	routePlan := make([]RoutePlanStep, routePlanCount)
-	for i := uint32(0); i < routePlanCount; i++ {
-		step, newOffset, err := parseRoutePlanStep(data, offset)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing route plan step %d: %v", i, err)
-		}
-		routePlan[i] = step
-		offset = newOffset
+	if jupiterv6.IsShared(decoded.Type) {
+		params.AuthorityID = decoded.ID
+		params.Authority = programAuthorityRef(decoded.ID)
 	}
-
-	var inAmount, quotedOutAmount, minAmountOut uint64
-
-	// Parse remaining fields based on instruction type
-	if instructionType == "sharedAccountsExactOutRoute" {
-		// exactOut instruction has a different structure
-		quotedOutAmount = binary.LittleEndian.Uint64(data[offset : offset+8])
-		offset += 8
-
-		inAmount = binary.LittleEndian.Uint64(data[offset : offset+8])
-		offset += 8
-
-		slippageBps := binary.LittleEndian.Uint16(data[offset : offset+2])
-		offset += 2
-
-		platformFeeBps := data[offset]
-
-		// For exactOut, calculate maximum input amount
-		maxAmountIn := uint64(float64(inAmount) * (1.0 + float64(slippageBps)/10000.0))
-
-		return &JupiterSwapParams{
-			InstructionType: instructionType,
-			ID:              id,
-			RoutePlan:       routePlan,
-			OutAmount:       quotedOutAmount,
-			QuotedInAmount:  inAmount,
-			SlippageBps:     slippageBps,
-			PlatformFeeBps:  platformFeeBps,
-			MinAmountOut:    maxAmountIn, // Stored in this field
-		}, nil
+	if isExactOutInstruction(decoded.Type) {
+		params.OutAmount, params.QuotedInAmount = decoded.Amount, decoded.QuotedAmount
 	} else {
-		// Standard route instruction
-		inAmount = binary.LittleEndian.Uint64(data[offset : offset+8])
-		offset += 8
-
-		quotedOutAmount = binary.LittleEndian.Uint64(data[offset : offset+8])
-		offset += 8
-
-		slippageBps := binary.LittleEndian.Uint16(data[offset : offset+2])
-		offset += 2
-
-		platformFeeBps := data[offset]
-
-		// Calculate min_amount_out
-		minAmountOut = uint64(float64(quotedOutAmount) * (1.0 - float64(slippageBps)/10000.0))
-
-		return &JupiterSwapParams{
-			InstructionType: instructionType,
-			ID:              id,
-			RoutePlan:       routePlan,
-			InAmount:        inAmount,
-			QuotedOutAmount: quotedOutAmount,
-			SlippageBps:     slippageBps,
-			PlatformFeeBps:  platformFeeBps,
-			MinAmountOut:    minAmountOut,
-		}, nil
+		params.InAmount, params.QuotedOutAmount = decoded.Amount, decoded.QuotedAmount
 	}
+	for i, step := range decoded.Route {
+		params.RoutePlan[i] = stepFromDecoded(step)
+	}
+	return params
 }
 
-// parseExactOutRoute parses exactOutRoute instructions
-func parseExactOutRoute(data []byte, instructionType string) (*JupiterSwapParams, error) {
-	offset := 8 // Skip discriminator
-
-	// Parse route plan count
-	routePlanCount := binary.LittleEndian.Uint32(data[offset : offset+4])
-	offset += 4
-
-	// Parse each route plan step
-	routePlan := make([]RoutePlanStep, routePlanCount)
-	for i := uint32(0); i < routePlanCount; i++ {
-		step, newOffset, err := parseRoutePlanStep(data, offset)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing route plan step %d: %v", i, err)
-		}
-		routePlan[i] = step
-		offset = newOffset
+// stepFromDecoded converts a decoded route plan step
+func stepFromDecoded(step jupiterv6.Step) RoutePlanStep {
+	return RoutePlanStep{
+		Swap:        swapFromDecoded(step.Swap),
+		Percent:     step.Percent,
+		InputIndex:  step.InputIndex,
+		OutputIndex: step.OutputIndex,
+		raw:         step.Raw,
 	}
+}
 
-	// exactOut instruction structure
-	outAmount := binary.LittleEndian.Uint64(data[offset : offset+8])
-	offset += 8
-
-	quotedInAmount := binary.LittleEndian.Uint64(data[offset : offset+8])
-	offset += 8
-
-	slippageBps := binary.LittleEndian.Uint16(data[offset : offset+2])
-	offset += 2
-
-	platformFeeBps := data[offset]
-
-	// Calculate maximum input amount
-	maxAmountIn := uint64(float64(quotedInAmount) * (1.0 + float64(slippageBps)/10000.0))
-
-	return &JupiterSwapParams{
-		InstructionType: instructionType,
-		RoutePlan:       routePlan,
-		OutAmount:       outAmount,
-		QuotedInAmount:  quotedInAmount,
-		SlippageBps:     slippageBps,
-		PlatformFeeBps:  platformFeeBps,
-		MinAmountOut:    maxAmountIn, // For exactOut, this is actually the max input amount
-	}, nil
+// swapFromDecoded converts a decoded swap variant
+func swapFromDecoded(swap jupiterv6.Swap) Swap {
+	return Swap{
+		Type:         SwapType(swap.Name),
+		Params:       swap.Params,
+		variableSize: len(swap.Fields) - jupiterv6.SwapFixedSize(swap.Index),
+		raw:          swap.Fields,
+	}
 }
 
 // parseRoutePlanStep parses a single route plan step
 func parseRoutePlanStep(data []byte, offset int) (RoutePlanStep, int, error) {
-	if offset+4 > len(data) {
-		return RoutePlanStep{}, offset, fmt.Errorf("not enough data for route plan step")
-	}
-
-	// Parse swap type (1 byte)
-	swapTypeIndex := data[offset]
-	offset++
-
-	// Determine swap type and parameters based on index
-	swap, err := decodeSwapType(swapTypeIndex, data, offset)
+	step, next, err := jupiterv6.DecodeStep(data, offset)
 	if err != nil {
-		return RoutePlanStep{}, offset, err
+		return RoutePlanStep{}, next, err
 	}
-
-	// Update offset based on swap type parameter size
-	offset = updateOffsetForSwapType(swapTypeIndex, offset)
-
-	// Parse percent
-	percent := data[offset]
-	offset++
-
-	// Parse input_index
-	inputIndex := data[offset]
-	offset++
-
-	// Parse output_index
-	outputIndex := data[offset]
-	offset++
-
-	return RoutePlanStep{
-		Swap:        swap,
-		Percent:     percent,
-		InputIndex:  inputIndex,
-		OutputIndex: outputIndex,
-	}, offset, nil
+	return stepFromDecoded(step), next, nil
 }
 
 // decodeSwapType decodes swap type based on index
 func decodeSwapType(swapTypeIndex uint8, data []byte, offset int) (Swap, error) {
-	switch swapTypeIndex {
-	case 0:
-		return Swap{Type: SwapSaber, Params: map[string]interface{}{}}, nil
-	case 1:
-		return Swap{Type: SwapSaberAddDecimalsDeposit, Params: map[string]interface{}{}}, nil
-	case 2:
-		return Swap{Type: SwapSaberAddDecimalsWithdraw, Params: map[string]interface{}{}}, nil
-	case 3:
-		return Swap{Type: SwapTokenSwap, Params: map[string]interface{}{}}, nil
-	case 4:
-		return Swap{Type: SwapSencha, Params: map[string]interface{}{}}, nil
-	case 5:
-		return Swap{Type: SwapStep, Params: map[string]interface{}{}}, nil
-	case 6:
-		return Swap{Type: SwapCropper, Params: map[string]interface{}{}}, nil
-	case 7:
-		return Swap{Type: SwapRaydium, Params: map[string]interface{}{}}, nil
-	case 8:
-		// Crema with a_to_b parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for Crema swap")
-		}
-		aToB := data[offset] != 0
-		return Swap{Type: SwapCrema, Params: map[string]interface{}{"a_to_b": aToB}}, nil
-	case 9:
-		return Swap{Type: SwapLifinity, Params: map[string]interface{}{}}, nil
-	case 10:
-		return Swap{Type: SwapMercurial, Params: map[string]interface{}{}}, nil
-	case 11:
-		return Swap{Type: SwapCykura, Params: map[string]interface{}{}}, nil
-	case 12:
-		// Serum with side parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for Serum swap")
-		}
-		side := "Bid"
-		if data[offset] != 0 {
-			side = "Ask"
-		}
-		return Swap{Type: SwapSerum, Params: map[string]interface{}{"side": side}}, nil
-	case 13:
-		return Swap{Type: SwapMarinadeDeposit, Params: map[string]interface{}{}}, nil
-	case 14:
-		return Swap{Type: SwapMarinadeUnstake, Params: map[string]interface{}{}}, nil
-	case 15:
-		// Aldrin with side parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for Aldrin swap")
-		}
-		side := "Bid"
-		if data[offset] != 0 {
-			side = "Ask"
-		}
-		return Swap{Type: SwapAldrin, Params: map[string]interface{}{"side": side}}, nil
-	case 16:
-		// AldrinV2 with side parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for AldrinV2 swap")
-		}
-		side := "Bid"
-		if data[offset] != 0 {
-			side = "Ask"
-		}
-		return Swap{Type: SwapAldrinV2, Params: map[string]interface{}{"side": side}}, nil
-	case 17:
-		// Whirlpool with a_to_b parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for Whirlpool swap")
-		}
-		aToB := data[offset] != 0
-		return Swap{Type: SwapWhirlpool, Params: map[string]interface{}{"a_to_b": aToB}}, nil
-	case 18:
-		// Invariant with x_to_y parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for Invariant swap")
-		}
-		xToY := data[offset] != 0
-		return Swap{Type: SwapInvariant, Params: map[string]interface{}{"x_to_y": xToY}}, nil
-	case 19:
-		return Swap{Type: SwapMeteora, Params: map[string]interface{}{}}, nil
-	case 20:
-		return Swap{Type: SwapGooseFX, Params: map[string]interface{}{}}, nil
-	case 21:
-		// DeltaFi with stable parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for DeltaFi swap")
-		}
-		stable := data[offset] != 0
-		return Swap{Type: SwapDeltaFi, Params: map[string]interface{}{"stable": stable}}, nil
-	case 22:
-		return Swap{Type: SwapBalansol, Params: map[string]interface{}{}}, nil
-	case 23:
-		// MarcoPolo with x_to_y parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for MarcoPolo swap")
-		}
-		xToY := data[offset] != 0
-		return Swap{Type: SwapMarcoPolo, Params: map[string]interface{}{"x_to_y": xToY}}, nil
-	case 24:
-		// Dradex with side parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for Dradex swap")
-		}
-		side := "Bid"
-		if data[offset] != 0 {
-			side = "Ask"
-		}
-		return Swap{Type: SwapDradex, Params: map[string]interface{}{"side": side}}, nil
-	case 25:
-		return Swap{Type: SwapLifinityV2, Params: map[string]interface{}{}}, nil
-	case 26:
-		return Swap{Type: SwapRaydiumClmm, Params: map[string]interface{}{}}, nil
-	case 27:
-		// Openbook with side parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for Openbook swap")
-		}
-		side := "Bid"
-		if data[offset] != 0 {
-			side = "Ask"
-		}
-		return Swap{Type: SwapOpenbook, Params: map[string]interface{}{"side": side}}, nil
-	case 28:
-		// Phoenix with side parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for Phoenix swap")
-		}
-		side := "Bid"
-		if data[offset] != 0 {
-			side = "Ask"
-		}
-		return Swap{Type: SwapPhoenix, Params: map[string]interface{}{"side": side}}, nil
-	case 29:
-		// Symmetry with token IDs
-		if offset+16 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for Symmetry swap")
-		}
-		fromTokenID := binary.LittleEndian.Uint64(data[offset : offset+8])
-		toTokenID := binary.LittleEndian.Uint64(data[offset+8 : offset+16])
-		return Swap{Type: SwapSymmetry, Params: map[string]interface{}{
-			"from_token_id": fromTokenID,
-			"to_token_id":   toTokenID,
-		}}, nil
-	case 30:
-		return Swap{Type: SwapTokenSwapV2, Params: map[string]interface{}{}}, nil
-	case 31:
-		return Swap{Type: SwapHeliumTreasuryManagement, Params: map[string]interface{}{}}, nil
-	case 32:
-		return Swap{Type: SwapStakeDexStakeWrappedSol, Params: map[string]interface{}{}}, nil
-	case 33:
-		// StakeDexSwapViaStake with bridge_stake_seed
-		if offset+4 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for StakeDexSwapViaStake swap")
-		}
-		bridgeStakeSeed := binary.LittleEndian.Uint32(data[offset : offset+4])
-		return Swap{Type: SwapStakeDexSwapViaStake, Params: map[string]interface{}{
-			"bridge_stake_seed": bridgeStakeSeed,
-		}}, nil
-	case 34:
-		return Swap{Type: SwapGooseFXV2, Params: map[string]interface{}{}}, nil
-	case 35:
-		return Swap{Type: SwapPerps, Params: map[string]interface{}{}}, nil
-	case 36:
-		return Swap{Type: SwapPerpsAddLiquidity, Params: map[string]interface{}{}}, nil
-	case 37:
-		return Swap{Type: SwapPerpsRemoveLiquidity, Params: map[string]interface{}{}}, nil
-	case 38:
-		return Swap{Type: SwapMeteoraDlmm, Params: map[string]interface{}{}}, nil
-	case 39:
-		// OpenBookV2 with side parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for OpenBookV2 swap")
-		}
-		side := "Bid"
-		if data[offset] != 0 {
-			side = "Ask"
-		}
-		return Swap{Type: SwapOpenBookV2, Params: map[string]interface{}{"side": side}}, nil
-	case 40:
-		return Swap{Type: SwapRaydiumClmmV2, Params: map[string]interface{}{}}, nil
-	case 41:
-		// StakeDexPrefundWithdrawStake with bridge_stake_seed
-		if offset+4 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for StakeDexPrefundWithdrawStake swap")
-		}
-		bridgeStakeSeed := binary.LittleEndian.Uint32(data[offset : offset+4])
-		return Swap{Type: SwapStakeDexPrefundWithdrawStake, Params: map[string]interface{}{
-			"bridge_stake_seed": bridgeStakeSeed,
-		}}, nil
-	case 42:
-		// Clone with multiple parameters
-		if offset+3 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for Clone swap")
-		}
-		poolIndex := data[offset]
-		quantityIsInput := data[offset+1] != 0
-		quantityIsCollateral := data[offset+2] != 0
-		return Swap{Type: SwapClone, Params: map[string]interface{}{
-			"pool_index":             poolIndex,
-			"quantity_is_input":      quantityIsInput,
-			"quantity_is_collateral": quantityIsCollateral,
-		}}, nil
-	case 43:
-		// SanctumS with multiple parameters
-		if offset+10 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for SanctumS swap")
-		}
-		srcLstValueCalcAccs := data[offset]
-		dstLstValueCalcAccs := data[offset+1]
-		srcLstIndex := binary.LittleEndian.Uint32(data[offset+2 : offset+6])
-		dstLstIndex := binary.LittleEndian.Uint32(data[offset+6 : offset+10])
-		return Swap{Type: SwapSanctumS, Params: map[string]interface{}{
-			"src_lst_value_calc_accs": srcLstValueCalcAccs,
-			"dst_lst_value_calc_accs": dstLstValueCalcAccs,
-			"src_lst_index":           srcLstIndex,
-			"dst_lst_index":           dstLstIndex,
-		}}, nil
-	case 44:
-		// SanctumSAddLiquidity with parameters
-		if offset+5 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for SanctumSAddLiquidity swap")
-		}
-		lstValueCalcAccs := data[offset]
-		lstIndex := binary.LittleEndian.Uint32(data[offset+1 : offset+5])
-		return Swap{Type: SwapSanctumSAddLiquidity, Params: map[string]interface{}{
-			"lst_value_calc_accs": lstValueCalcAccs,
-			"lst_index":           lstIndex,
-		}}, nil
-	case 45:
-		// SanctumSRemoveLiquidity with parameters
-		if offset+5 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for SanctumSRemoveLiquidity swap")
-		}
-		lstValueCalcAccs := data[offset]
-		lstIndex := binary.LittleEndian.Uint32(data[offset+1 : offset+5])
-		return Swap{Type: SwapSanctumSRemoveLiquidity, Params: map[string]interface{}{
-			"lst_value_calc_accs": lstValueCalcAccs,
-			"lst_index":           lstIndex,
-		}}, nil
-	case 46:
-		return Swap{Type: SwapRaydiumCP, Params: map[string]interface{}{}}, nil
-	case 47:
-		// WhirlpoolSwapV2 with a_to_b and remaining_accounts_info
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for WhirlpoolSwapV2 swap")
-		}
-		aToB := data[offset] != 0
-		// remaining_accounts_info is optional and complex, skip for now
-		return Swap{Type: SwapWhirlpoolSwapV2, Params: map[string]interface{}{
-			"a_to_b": aToB,
-		}}, nil
-	case 48:
-		return Swap{Type: SwapOneIntro, Params: map[string]interface{}{}}, nil
-	case 49:
-		return Swap{Type: SwapPumpdotfunWrappedBuy, Params: map[string]interface{}{}}, nil
-	case 50:
-		return Swap{Type: SwapPumpdotfunWrappedSell, Params: map[string]interface{}{}}, nil
-	case 51:
-		return Swap{Type: SwapPerpsV2, Params: map[string]interface{}{}}, nil
-	case 52:
-		return Swap{Type: SwapPerpsV2AddLiquidity, Params: map[string]interface{}{}}, nil
-	case 53:
-		return Swap{Type: SwapPerpsV2RemoveLiquidity, Params: map[string]interface{}{}}, nil
-	case 54:
-		return Swap{Type: SwapMoonshotWrappedBuy, Params: map[string]interface{}{}}, nil
-	case 55:
-		return Swap{Type: SwapMoonshotWrappedSell, Params: map[string]interface{}{}}, nil
-	case 56:
-		return Swap{Type: SwapStabbleStableSwap, Params: map[string]interface{}{}}, nil
-	case 57:
-		return Swap{Type: SwapStabbleWeightedSwap, Params: map[string]interface{}{}}, nil
-	case 58:
-		// Obric with x_to_y parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for Obric swap")
-		}
-		xToY := data[offset] != 0
-		return Swap{Type: SwapObric, Params: map[string]interface{}{"x_to_y": xToY}}, nil
-	case 59:
-		return Swap{Type: SwapFoxBuyFromEstimatedCost, Params: map[string]interface{}{}}, nil
-	case 60:
-		// FoxClaimPartial with is_y parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for FoxClaimPartial swap")
-		}
-		isY := data[offset] != 0
-		return Swap{Type: SwapFoxClaimPartial, Params: map[string]interface{}{"is_y": isY}}, nil
-	case 61:
-		// SolFi with is_quote_to_base parameter
-		if offset+1 > len(data) {
-			return Swap{}, fmt.Errorf("not enough data for SolFi swap")
-		}
-		isQuoteToBase := data[offset] != 0
-		return Swap{Type: SwapSolFi, Params: map[string]interface{}{"is_quote_to_base": isQuoteToBase}}, nil
-	case 76:
-		return Swap{Type: Woofi, Params: map[string]interface{}{}}, nil
-	case 108:
-		return Swap{Type: SwapPumpdotfunAmmBuy, Params: map[string]interface{}{}}, nil
-	case 109:
-		return Swap{Type: SwapPumpdotfunAmmSell, Params: map[string]interface{}{}}, nil
-	default:
-		return Swap{Type: SwapType(fmt.Sprintf("Unknown_%d", swapTypeIndex)), Params: map[string]interface{}{}}, nil
-	}
-}
-
-// updateOffsetForSwapType updates the offset based on swap type
-func updateOffsetForSwapType(swapTypeIndex uint8, offset int) int {
-	switch swapTypeIndex {
-	case 8, 12, 15, 16, 17, 18, 21, 23, 24, 27, 28, 39, 47, 58, 60, 61: // Types with 1 byte parameter
-		return offset + 1
-	case 29: // Symmetry has 16 byte parameters
-		return offset + 16
-	case 33, 41: // Types with 4 byte parameters
-		return offset + 4
-	case 42: // Clone has 3 byte parameters
-		return offset + 3
-	case 43: // SanctumS has 10 byte parameters
-		return offset + 10
-	case 44, 45: // SanctumS Add/Remove Liquidity has 5 byte parameters
-		return offset + 5
-	default:
-		return offset // No parameters
+	swap, err := jupiterv6.DecodeSwap(swapTypeIndex, data, offset)
+	if err != nil {
+		return Swap{}, err
 	}
+	return swapFromDecoded(swap), nil
 }
 
 // printJupiterV6Results prints detailed parsing results
-func printJupiterV6Results(params *JupiterSwapParams) {
-	fmt.Println("\n=== Jupiter V6 Instruction Analysis ===")
-	fmt.Printf("Instruction Type: %s\n", params.InstructionType)
+func printJupiterV6Results(w io.Writer, params *JupiterSwapParams) {
+	fmt.Fprintln(w, "\n=== Jupiter V6 Instruction Analysis ===")
+	fmt.Fprintf(w, "Instruction Type: %s\n", params.InstructionType)
 
-	if params.ID != 0 {
-		fmt.Printf("ID: %d\n", params.ID)
+	if params.AuthorityID != 0 {
+		fmt.Fprintf(w, "ID: %d\n", params.AuthorityID)
+	}
+	if params.Authority != nil {
+		fmt.Fprintf(w, "Authority: %s\n", *params.Authority)
 	}
 
-	fmt.Printf("\nRoute Plan (%d steps):\n", len(params.RoutePlan))
+	fmt.Fprintf(w, "\nRoute Plan (%d steps):\n", len(params.RoutePlan))
 	for i, step := range params.RoutePlan {
-		fmt.Printf("  Step %d:\n", i+1)
-		fmt.Printf("    Swap: %s\n", step.Swap.Type)
+		fmt.Fprintf(w, "  Step %d:\n", i+1)
+		fmt.Fprintf(w, "    Swap: %s\n", step.Swap.Type)
 		if len(step.Swap.Params) > 0 {
-			fmt.Printf("    Parameters: %v\n", step.Swap.Params)
+			fmt.Fprintf(w, "    Parameters: %v\n", step.Swap.Params)
 		}
-		fmt.Printf("    Percent: %d%%\n", step.Percent)
-		fmt.Printf("    Input Index: %d -> Output Index: %d\n", step.InputIndex, step.OutputIndex)
+		fmt.Fprintf(w, "    Percent: %d%%\n", step.Percent)
+		fmt.Fprintf(w, "    Input Index: %d -> Output Index: %d\n", step.InputIndex, step.OutputIndex)
 	}
 
-	fmt.Printf("\nSwap Parameters:\n")
+	fmt.Fprintf(w, "\nSwap Parameters:\n")
 	if params.InAmount != 0 {
-		fmt.Printf("  In Amount: %d\n", params.InAmount)
+		fmt.Fprintf(w, "  In Amount: %d\n", params.InAmount)
 	}
 	if params.OutAmount != 0 {
-		fmt.Printf("  Out Amount: %d\n", params.OutAmount)
+		fmt.Fprintf(w, "  Out Amount: %d\n", params.OutAmount)
 	}
 	if params.QuotedOutAmount != 0 {
-		fmt.Printf("  Quoted Out Amount: %d\n", params.QuotedOutAmount)
+		fmt.Fprintf(w, "  Quoted Out Amount: %d\n", params.QuotedOutAmount)
 	}
 	if params.QuotedInAmount != 0 {
-		fmt.Printf("  Quoted In Amount: %d\n", params.QuotedInAmount)
+		fmt.Fprintf(w, "  Quoted In Amount: %d\n", params.QuotedInAmount)
 	}
-	fmt.Printf("  Slippage BPS: %d (%.2f%%)\n", params.SlippageBps, float64(params.SlippageBps)/100.0)
-	fmt.Printf("  Platform Fee BPS: %d (%.2f%%)\n", params.PlatformFeeBps, float64(params.PlatformFeeBps)/100.0)
+	fmt.Fprintf(w, "  Slippage BPS: %d (%.2f%%)\n", params.SlippageBps, float64(params.SlippageBps)/100.0)
+	fmt.Fprintf(w, "  Platform Fee BPS: %d (%.2f%%)\n", params.PlatformFeeBps, float64(params.PlatformFeeBps)/100.0)
 	if params.MinAmountOut != 0 {
-		fmt.Printf("  Min Amount Out: %d\n", params.MinAmountOut)
+		fmt.Fprintf(w, "  Min Amount Out: %d\n", params.MinAmountOut)
+	}
+	if params.ExpireAt != nil {
+		fmt.Fprintf(w, "  Expire At: %s\n", time.Unix(*params.ExpireAt, 0).UTC().Format(time.RFC3339))
+	}
+	if params.SlippageAllowanceUI != "" {
+		fmt.Fprintf(w, "  Slippage Allowance: %d (%s)\n", params.SlippageAllowance, params.SlippageAllowanceUI)
+	} else {
+		fmt.Fprintf(w, "  Slippage Allowance: %d\n", params.SlippageAllowance)
 	}
 
 	// Display token amounts with 6 decimal places
-	fmt.Printf("\nFormatted Values (6 decimals):\n")
+	fmt.Fprintf(w, "\nFormatted Values (6 decimals):\n")
 	if params.InAmount != 0 {
-		fmt.Printf("  In Amount: %.6f\n", float64(params.InAmount)/1000000.0)
+		fmt.Fprintf(w, "  In Amount: %.6f\n", float64(params.InAmount)/1000000.0)
 	}
 	if params.OutAmount != 0 {
-		fmt.Printf("  Out Amount: %.6f\n", float64(params.OutAmount)/1000000.0)
+		fmt.Fprintf(w, "  Out Amount: %.6f\n", float64(params.OutAmount)/1000000.0)
 	}
 	if params.QuotedOutAmount != 0 {
-		fmt.Printf("  Quoted Out Amount: %.6f\n", float64(params.QuotedOutAmount)/1000000.0)
+		fmt.Fprintf(w, "  Quoted Out Amount: %.6f\n", float64(params.QuotedOutAmount)/1000000.0)
 	}
 	if params.QuotedInAmount != 0 {
-		fmt.Printf("  Quoted In Amount: %.6f\n", float64(params.QuotedInAmount)/1000000.0)
+		fmt.Fprintf(w, "  Quoted In Amount: %.6f\n", float64(params.QuotedInAmount)/1000000.0)
 	}
 	if params.MinAmountOut != 0 {
-		fmt.Printf("  Min Amount Out: %.6f\n", float64(params.MinAmountOut)/1000000.0)
+		fmt.Fprintf(w, "  Min Amount Out: %.6f\n", float64(params.MinAmountOut)/1000000.0)
 	}
 
 	// Generate JSON format output
-	fmt.Printf("\nJSON Format:\n")
-	printJSONFormat(params)
+	fmt.Fprintf(w, "\nJSON Format:\n")
+	printJSONFormat(w, params)
 }
 
 // printJSONFormat prints JSON format output
-func printJSONFormat(params *JupiterSwapParams) {
-	fmt.Printf("{\n")
-	fmt.Printf("  \"instruction_type\": \"%s\",\n", params.InstructionType)
-	if params.ID != 0 {
-		fmt.Printf("  \"id\": %d,\n", params.ID)
+func printJSONFormat(w io.Writer, params *JupiterSwapParams) {
+	fmt.Fprintf(w, "{\n")
+	fmt.Fprintf(w, "  \"instruction_type\": \"%s\",\n", params.InstructionType)
+	if params.AuthorityID != 0 {
+		fmt.Fprintf(w, "  \"id\": %d,\n", params.AuthorityID)
 	}
-	fmt.Printf("  \"route_plan\": [\n")
+	fmt.Fprintf(w, "  \"route_plan\": [\n")
 	for i, step := range params.RoutePlan {
-		fmt.Printf("    {\n")
-		fmt.Printf("      \"swap\": {\"%s\": %s},\n", step.Swap.Type, formatParams(step.Swap.Params))
-		fmt.Printf("      \"percent\": %d,\n", step.Percent)
-		fmt.Printf("      \"input_index\": %d,\n", step.InputIndex)
-		fmt.Printf("      \"output_index\": %d\n", step.OutputIndex)
+		fmt.Fprintf(w, "    {\n")
+		fmt.Fprintf(w, "      \"swap\": {\"%s\": %s},\n", step.Swap.Type, formatParams(step.Swap.Params))
+		fmt.Fprintf(w, "      \"percent\": %d,\n", step.Percent)
+		fmt.Fprintf(w, "      \"input_index\": %d,\n", step.InputIndex)
+		fmt.Fprintf(w, "      \"output_index\": %d\n", step.OutputIndex)
 		if i < len(params.RoutePlan)-1 {
-			fmt.Printf("    },\n")
+			fmt.Fprintf(w, "    },\n")
 		} else {
-			fmt.Printf("    }\n")
+			fmt.Fprintf(w, "    }\n")
 		}
 	}
-	fmt.Printf("  ],\n")
+	fmt.Fprintf(w, "  ],\n")
 	if params.InAmount != 0 {
-		fmt.Printf("  \"in_amount\": \"%d\",\n", params.InAmount)
+		fmt.Fprintf(w, "  \"in_amount\": \"%d\",\n", params.InAmount)
 	}
 	if params.OutAmount != 0 {
-		fmt.Printf("  \"out_amount\": \"%d\",\n", params.OutAmount)
+		fmt.Fprintf(w, "  \"out_amount\": \"%d\",\n", params.OutAmount)
 	}
 	if params.QuotedOutAmount != 0 {
-		fmt.Printf("  \"quoted_out_amount\": \"%d\",\n", params.QuotedOutAmount)
+		fmt.Fprintf(w, "  \"quoted_out_amount\": \"%d\",\n", params.QuotedOutAmount)
 	}
 	if params.QuotedInAmount != 0 {
-		fmt.Printf("  \"quoted_in_amount\": \"%d\",\n", params.QuotedInAmount)
+		fmt.Fprintf(w, "  \"quoted_in_amount\": \"%d\",\n", params.QuotedInAmount)
 	}
-	fmt.Printf("  \"slippage_bps\": \"%d\",\n", params.SlippageBps)
-	fmt.Printf("  \"platform_fee_bps\": %d\n", params.PlatformFeeBps)
-	fmt.Printf("}\n")
+	fmt.Fprintf(w, "  \"slippage_bps\": \"%d\",\n", params.SlippageBps)
+	fmt.Fprintf(w, "  \"platform_fee_bps\": %d\n", params.PlatformFeeBps)
+	fmt.Fprintf(w, "}\n")
 }
 
 // formatParams formats parameters as a JSON string
@@ -906,6 +601,9 @@ func formatParams(params map[string]interface{}) string {
 			parts = append(parts, fmt.Sprintf("\"%s\": %d", k, val))
 		case uint64:
 			parts = append(parts, fmt.Sprintf("\"%s\": %d", k, val))
+		case *RemainingAccountsInfo:
+			encoded, _ := json.Marshal(val)
+			parts = append(parts, fmt.Sprintf("\"%s\": %s", k, encoded))
 		default:
 			parts = append(parts, fmt.Sprintf("\"%s\": %v", k, val))
 		}
@@ -926,8 +624,13 @@ func bytesEqual(a, b []byte) bool {
 	return true
 }
 
-// resolveAddressLookupTables resolves address lookup tables
-func resolveAddressLookupTables(tx *solana.Transaction, rpcClient *rpc.Client) error {
+// resolveAddressLookupTables resolves address lookup tables at the given commitment
+func resolveAddressLookupTables(tx *solana.Transaction, rpcClient *rpc.Client, commitment rpc.CommitmentType) error {
+	return NewAnalyzer(rpcClient, WithCommitment(commitment)).resolveAddressLookupTables(context.Background(), tx)
+}
+
+// resolveAddressLookupTables resolves address lookup tables using the analyzer's rpc settings
+func (a *Analyzer) resolveAddressLookupTables(ctx context.Context, tx *solana.Transaction) error {
 	if !tx.Message.IsVersioned() {
 		return nil // Not a versioned transaction
 	}
@@ -937,28 +640,24 @@ func resolveAddressLookupTables(tx *solana.Transaction, rpcClient *rpc.Client) e
 		return nil // No lookups to resolve
 	}
 
+	provider := a.lookupTableProvider()
+	if provider == nil {
+		return fmt.Errorf("analyzer has no lookup table provider")
+	}
+
 	tableIDs := lookups.GetTableIDs()
-	//fmt.Printf("Found %d lookup tables\n", len(tableIDs))
 
 	resolutions := make(map[solana.PublicKey]solana.PublicKeySlice)
 	for _, tableID := range tableIDs {
-		fmt.Printf("Fetching lookup table: %s\n", tableID.String())
-
-		info, err := rpcClient.GetAccountInfo(
-			context.Background(),
-			tableID,
-		)
-		if err != nil {
-			return fmt.Errorf("error fetching lookup table: %v", err)
-		}
+		fmt.Fprintf(a.logOutput, "Fetching lookup table: %s\n", tableID.String())
 
-		tableContent, err := lookup.DecodeAddressLookupTableState(info.GetBinary())
+		addresses, err := provider.GetLookupTable(a.providerContext(ctx), tableID)
 		if err != nil {
-			return fmt.Errorf("error decoding lookup table: %v", err)
+			return err
 		}
 
-		resolutions[tableID] = tableContent.Addresses
-		fmt.Printf("Resolved %d addresses from lookup table\n", len(tableContent.Addresses))
+		resolutions[tableID] = addresses
+		fmt.Fprintf(a.logOutput, "Resolved %d addresses from lookup table\n", len(addresses))
 	}
 
 	// Set the address tables
@@ -973,7 +672,7 @@ func resolveAddressLookupTables(tx *solana.Transaction, rpcClient *rpc.Client) e
 		return fmt.Errorf("error resolving lookups: %v", err)
 	}
 
-	fmt.Println("Successfully resolved address lookups!")
+	fmt.Fprintln(a.logOutput, "Successfully resolved address lookups!")
 	return nil
 }
 
@@ -993,37 +692,52 @@ func boolToCheckmark(b bool) string {
 	return "✗"
 }
 
-// parseJupiterSwapEvent parses Jupiter V6 Swap Event
-func parseJupiterSwapEvent(data []byte) (*SwapEvent, error) {
-	if len(data) < 128 {
-		return nil, fmt.Errorf("swap event data too short: %d bytes", len(data))
-	}
+// IsSelfSwap reports whether the event swaps a mint into itself (a degenerate hop)
+func (e SwapEvent) IsSelfSwap() bool {
+	return e.InputMint.Equals(e.OutputMint)
+}
 
-	// Check discriminator
-	if !bytesEqual(data[:8], SwapEventDiscriminator) {
+// parseJupiterSwapEvent parses a swap event in either form: behind the 8 byte
+// emit-CPI prefix, as in self-CPI instruction data, or starting at the event
+// discriminator, as in emit! program data logs. Bytes after the event are ignored.
+func parseJupiterSwapEvent(data []byte) (*SwapEvent, error) {
+	var event SwapEvent
+	switch {
+	case len(data) >= 8 && bytesEqual(data[:8], swapEventTypeDiscriminator):
+		event = SwapEvent{Unknown: data[:8], Form: EventFormBare}
+		data = data[8:]
+	case len(data) >= SwapEventAMMOffset && bytesEqual(data[:SwapEventTypeOffset], SwapEventDiscriminator):
+		if !bytesEqual(data[SwapEventTypeOffset:SwapEventAMMOffset], swapEventTypeDiscriminator) {
+			return nil, fmt.Errorf("emit-CPI payload is not a swap event: %X", data[SwapEventTypeOffset:SwapEventAMMOffset])
+		}
+		event = SwapEvent{
+			Discriminator: data[:SwapEventTypeOffset],
+			Unknown:       data[SwapEventTypeOffset:SwapEventAMMOffset],
+			Form:          EventFormEmitCPI,
+		}
+		data = data[SwapEventAMMOffset:]
+	default:
 		return nil, fmt.Errorf("invalid swap event discriminator")
 	}
 
-	event := &SwapEvent{
-		Discriminator: data[:8],
-		Unknown:       data[8:16],
+	if len(data) < swapEventBodySize {
+		return nil, fmt.Errorf("swap event data too short: %d byte body", len(data))
 	}
+	decodeSwapEvent(&event, data, selectSwapEventSchema(data, nil))
+	return &event, nil
+}
 
-	// Parse AMM address (bytes 16-47)
-	event.AMM = solana.PublicKeyFromBytes(data[16:48])
-
-	// Parse Input Mint (bytes 48-79)
-	event.InputMint = solana.PublicKeyFromBytes(data[48:80])
-
-	// Parse Input Amount (bytes 80-87, little-endian)
-	event.InputAmount = binary.LittleEndian.Uint64(data[80:88])
-
-	// Parse Output Mint (bytes 88-119)
-	event.OutputMint = solana.PublicKeyFromBytes(data[88:120])
-
-	// Parse Output Amount (bytes 120-127, little-endian)
-	event.OutputAmount = binary.LittleEndian.Uint64(data[120:128])
-
+// parseJupiterSwapEventStrict is parseJupiterSwapEvent rejecting data longer
+// than the detected event form and schema, so a payload with an unexpected
+// header or a trailing event is not silently decoded from its first bytes
+func parseJupiterSwapEventStrict(data []byte) (*SwapEvent, error) {
+	event, err := parseJupiterSwapEvent(data)
+	if err != nil {
+		return nil, err
+	}
+	if event.ExtraFields != "" {
+		return nil, fmt.Errorf("swap event is %d bytes, %d more than its %s form and schema", len(data), len(event.ExtraFields)/2, event.Form)
+	}
 	return event, nil
 }
 
@@ -1035,18 +749,24 @@ func parseJupiterSwapEventFromBase58(base58Data string) (*SwapEvent, error) {
 }
 
 // extractJupiterEvents extracts Jupiter events from transaction inner instructions
-func extractJupiterEvents(tx *rpc.GetTransactionResult) ([]SwapEvent, error) {
+// and logs, in on-chain execution order (see orderedEvent).
+// Bytes that could not be decoded after a known event are returned as remainders.
+// Hit limits are recorded on stats.
+func extractJupiterEvents(tx *rpc.GetTransactionResult, limits scanLimits, stats *AnalysisStats) ([]SwapEvent, [][]byte, error) {
 	var events []SwapEvent
+	var remainders [][]byte
 
 	if tx.Meta == nil || tx.Meta.InnerInstructions == nil {
-		return events, nil
+		return events, remainders, nil
 	}
 
 	parseTx, err := tx.Transaction.GetTransaction()
 	if err != nil {
-		return events, nil
+		return events, remainders, nil
 	}
 
+	var ordered []orderedEvent
+
 	// Iterate through all inner instructions
 	for _, innerInst := range tx.Meta.InnerInstructions {
 		for _, inst := range innerInst.Instructions {
@@ -1054,18 +774,14 @@ func extractJupiterEvents(tx *rpc.GetTransactionResult) ([]SwapEvent, error) {
 			if inst.ProgramIDIndex < uint16(len(parseTx.Message.AccountKeys)) {
 				programID := parseTx.Message.AccountKeys[inst.ProgramIDIndex]
 				if programID.Equals(jupiterV6ProgramID) {
-					// Try to parse as Swap Event
-					if len(inst.Data) == 128 {
-						// Convert base58 encoded data to bytes
-						data := []byte(inst.Data)
-
-						// Check if it's a Swap Event
-						if bytesEqual(data[:8], SwapEventDiscriminator) {
-							event, err := parseJupiterSwapEvent(data)
-							if err == nil {
-								events = append(events, *event)
-							}
-						}
+					// Parse every event carried by the self-CPI payload
+					parsed, remainder := parseEventPayload(inst.Data)
+					for _, event := range parsed {
+						event.InstructionIndex = int(innerInst.Index)
+						ordered = append(ordered, orderedEvent{event: event})
+					}
+					if len(remainder) > 0 {
+						remainders = append(remainders, remainder)
 					}
 				}
 			}
@@ -1073,33 +789,142 @@ func extractJupiterEvents(tx *rpc.GetTransactionResult) ([]SwapEvent, error) {
 	}
 
 	// Also check logs for event data
-	if tx.Meta.LogMessages != nil {
-		for _, logMsg := range tx.Meta.LogMessages {
-			// Check if it's a program data log
-			if strings.Contains(logMsg, "Program data: ") {
-				// Extract data part
-				parts := strings.Split(logMsg, "Program data: ")
-				if len(parts) > 1 {
-					base58Data := strings.TrimSpace(parts[1])
-
-					// Try to parse as Swap Event
-					event, err := parseJupiterSwapEventFromBase58(base58Data)
-					if err == nil {
-						events = append(events, *event)
-					}
-				}
+	for _, event := range extractJupiterEventsFromLogs(tx.Meta.LogMessages, limits, stats) {
+		ordered = append(ordered, orderedEvent{event: event, fromLogs: true})
+	}
+
+	events = sortEvents(ordered)
+	if len(events) > limits.maxEvents {
+		events = events[:limits.maxEvents]
+		stats.EventLimitHit = true
+	}
+
+	return events, remainders, nil
+}
+
+// orderedEvent is an event with its source. Events are ordered by the
+// top-level instruction that emitted them; within one instruction, inner
+// instruction events precede log events and each source keeps its own order,
+// which is execution order.
+type orderedEvent struct {
+	event    SwapEvent
+	fromLogs bool // found in a "Program data: " log line
+}
+
+// sortEvents returns the events in canonical execution order. Log events whose
+// instruction is unknown (logs without invoke lines) keep their place after
+// every positioned event.
+func sortEvents(ordered []orderedEvent) []SwapEvent {
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if (a.event.InstructionIndex < 0) != (b.event.InstructionIndex < 0) {
+			return b.event.InstructionIndex < 0
+		}
+		if a.event.InstructionIndex != b.event.InstructionIndex {
+			return a.event.InstructionIndex < b.event.InstructionIndex
+		}
+		return !a.fromLogs && b.fromLogs
+	})
+
+	events := make([]SwapEvent, len(ordered))
+	for i, o := range ordered {
+		events[i] = o.event
+	}
+	return events
+}
+
+// programDataPrefix prefixes log lines emitted with sol_log_data
+const programDataPrefix = "Program data: "
+
+// logTruncatedMarker is logged by the runtime once the log budget is exhausted
+const logTruncatedMarker = "Log truncated"
+
+// topLevelInvokeSuffix ends the log line of a top-level instruction invocation
+const topLevelInvokeSuffix = " invoke [1]"
+
+// extractJupiterEventsFromLogs extracts Jupiter events from "Program data: " log lines.
+// Scanning stops after limits.maxLogLines lines, at the "Log truncated" marker or once
+// limits.maxEvents events were found. The instruction index of an event is the
+// top-level instruction whose logs carried it, -1 when no invoke line preceded it.
+func extractJupiterEventsFromLogs(logs []string, limits scanLimits, stats *AnalysisStats) []SwapEvent {
+	var events []SwapEvent
+	instruction := -1
+
+	for i, logMsg := range logs {
+		if i >= limits.maxLogLines {
+			stats.LogLineLimitHit = true
+			break
+		}
+		stats.LogLinesScanned++
+
+		// Cheap prefix check before any allocation
+		if !strings.HasPrefix(logMsg, programDataPrefix) {
+			if strings.HasPrefix(logMsg, "Program ") && strings.HasSuffix(logMsg, topLevelInvokeSuffix) {
+				instruction++
+			}
+			if strings.HasPrefix(logMsg, logTruncatedMarker) {
+				stats.LogsTruncated = true
+				break
+			}
+			continue
+		}
+
+		// A swap event needs at least 128 bytes of payload
+		encoded := strings.TrimSpace(logMsg[len(programDataPrefix):])
+		if len(encoded) < 128 {
+			continue
+		}
+
+		// Program data logs are base64 encoded, fall back to the raw string otherwise
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			data = []byte(encoded)
+		}
+
+		// Try to parse as Swap Event
+		parse := parseJupiterSwapEvent
+		if limits.strictEventLength {
+			parse = parseJupiterSwapEventStrict
+		}
+		event, err := parse(data)
+		if err == nil {
+			event.InstructionIndex = instruction
+			events = append(events, *event)
+			if len(events) >= limits.maxEvents {
+				stats.EventLimitHit = true
+				break
 			}
 		}
 	}
 
-	return events, nil
+	return events
 }
 
 // analyzeJupiterV6Transaction fully analyzes Jupiter V6 transaction
 func analyzeJupiterV6Transaction(tx *rpc.GetTransactionResult, parsedTx *solana.Transaction) (*JupiterV6Analysis, error) {
+	return NewAnalyzer(nil).Analyze(tx, parsedTx)
+}
+
+// Analyze fully analyzes a Jupiter V6 transaction
+func (a *Analyzer) Analyze(tx *rpc.GetTransactionResult, parsedTx *solana.Transaction) (*JupiterV6Analysis, error) {
+	if tx == nil || parsedTx == nil {
+		return nil, ErrTransactionNotFound
+	}
+
 	analysis := &JupiterV6Analysis{
-		Instructions: []JupiterSwapParams{},
-		Events:       []SwapEvent{},
+		Instructions:         []JupiterSwapParams{},
+		Events:               []SwapEvent{},
+		LookupsFullyResolved: lookupsFullyResolved(parsedTx),
+		Timestamp:            deriveTimestamp(tx, a.slotTimes),
+		Slot:                 tx.Slot,
+	}
+	if len(parsedTx.Signatures) > 0 {
+		analysis.Signature = parsedTx.Signatures[0]
+	}
+	if tx.Meta != nil && tx.Meta.Err != nil {
+		if raw, err := json.Marshal(tx.Meta.Err); err == nil {
+			analysis.TransactionError = string(raw)
+		}
 	}
 
 	// 1. Parse instructions
@@ -1111,32 +936,174 @@ func analyzeJupiterV6Transaction(tx *rpc.GetTransactionResult, parsedTx *solana.
 
 		programID := parsedTx.Message.AccountKeys[programIDIndex]
 		if programID.Equals(jupiterV6ProgramID) {
-			fmt.Printf("\nAnalyzing Jupiter instruction at index %d\n", i)
+			analysis.Stats.JupiterInstructions++
+
+			// Skip instruction types the caller is not interested in
+			if a.instructionTypes != nil {
+				if instructionType, ok := InstructionTypeOf(inst.Data); ok && !a.instructionTypes[instructionType] {
+					analysis.Stats.SkippedInstructions++
+					analysis.Results = append(analysis.Results, InstructionResult{Index: i, Data: inst.Data, Skipped: true})
+					continue
+				}
+			}
+
+			fmt.Fprintf(a.logOutput, "\nAnalyzing Jupiter instruction at index %d\n", i)
 
 			// Parse instruction
-			result, err := parseJupiterV6Instruction(inst.Data)
+			result, err := a.parseInstruction(inst.Data, tx.Slot)
+			if err == nil {
+				if err = checkSanity(result, a.sanity, instructionAccountKeys(inst, parsedTx.Message.AccountKeys), parsedTx.Message.AccountKeys, tx.Meta); err != nil {
+					result = nil
+				}
+			}
+			if a.hooks.OnInstructionParsed != nil {
+				callHook(analysis, "OnInstructionParsed", func() { a.hooks.OnInstructionParsed(result, err) })
+			}
+			if errors.Is(err, ErrUnknownSwapType) {
+				return nil, fmt.Errorf("instruction %d: %w", i, err)
+			}
 			if err != nil {
-				fmt.Printf("Error parsing instruction: %v\n", err)
+				analysis.Stats.FailedInstructions++
+				analysis.Errors = append(analysis.Errors, InstructionError{Index: i, Code: codeForParseError(err), Error: err.Error()})
+				analysis.Results = append(analysis.Results, InstructionResult{Index: i, Data: inst.Data, Code: codeForParseError(err), Error: err.Error()})
 				continue
 			}
+			analysis.Results = append(analysis.Results, InstructionResult{Index: i, Data: inst.Data})
+			for _, violation := range result.Suspect {
+				analysis.addWarning(CodeSuspectInstruction, "instruction %d: %s", i, violation.Message)
+			}
+
+			for _, step := range result.RoutePlan {
+				index, ok := unknownSwapIndex(step.Swap.Type)
+				if !ok {
+					continue
+				}
+				analysis.addWarning(CodeUnknownSwapVariant, "instruction %d uses unknown swap variant %d", i, index)
+				if a.hooks.OnUnknownVariant != nil {
+					callHook(analysis, "OnUnknownVariant", func() { a.hooks.OnUnknownVariant(index, step.raw) })
+				}
+			}
 
+			result.InstructionIndex = i
+
+			accounts := instructionAccountKeys(inst, parsedTx.Message.AccountKeys)
+			deriveUserWallet(result, accounts, &parsedTx.Message, tx.Meta)
+			deriveTokenFlow(result, accounts, parsedTx.Message.AccountKeys, tx.Meta)
+
+			// Map remaining accounts onto route plan steps
+			attachStepAccounts(result, accounts)
+
+			for _, step := range result.RoutePlan {
+				for _, fn := range a.hooks.swapTypes[step.Swap.Type] {
+					callHook(analysis, "OnSwapType("+string(step.Swap.Type)+")", func() { fn(step, inst.Data) })
+				}
+			}
+
+			analysis.Stats.ParsedInstructions++
 			analysis.Instructions = append(analysis.Instructions, *result)
 		}
 	}
 
+	describeTransactionShape(analysis, tx, parsedTx)
+	extractMemos(analysis, parsedTx, tx.Meta)
+	analysis.Failure = attributeFailure(analysis, tx, parsedTx.Message.AccountKeys)
+	if tx.Meta != nil {
+		analysis.JupiterVersion = detectJupiterVersion(tx.Meta.LogMessages)
+	}
+
 	// 2. Extract events
-	events, err := extractJupiterEvents(tx)
+	events, remainders, err := extractJupiterEvents(tx, a.limits, &analysis.Stats)
 	if err != nil {
 		return nil, fmt.Errorf("error extracting events: %v", err)
 	}
+	if a.returnDataEvents && len(events) < a.limits.maxEvents {
+		if event, ok := returnDataEvent(tx.Meta); ok {
+			events = append(events, *event)
+		}
+	}
 	analysis.Events = events
+	assignTradeIDs(analysis)
+	attachEventPools(analysis, parsedTx, tx.Meta)
+	markDustEvents(analysis.Events, a.dust)
+	checkExactOutAmounts(analysis, a.sanity)
+	for _, remainder := range remainders {
+		analysis.addWarning(CodeUnparsedEventData, "unparsed event data (%d bytes): %X", len(remainder), remainder)
+	}
+	for i, event := range analysis.Events {
+		if event.IsSelfSwap() {
+			analysis.addWarning(CodeSelfSwapEvent, "event %d swaps %s into itself", i, event.InputMint)
+		}
+		if event.ExtraFields != "" {
+			analysis.Stats.EventsWithExtraFields++
+			analysis.addWarning(CodeEventExtraFields, "event %d has %d bytes past its schema: %s", i, len(event.ExtraFields)/2, event.ExtraFields)
+		}
+	}
+	if a.hooks.OnEventExtracted != nil {
+		for _, event := range analysis.Events {
+			callHook(analysis, "OnEventExtracted", func() { a.hooks.OnEventExtracted(event) })
+		}
+	}
+
+	// Recover real input amounts of token ledger variants
+	correlateTokenLedger(analysis, parsedTx, tx.Meta)
+
+	if a.decodeHops {
+		attachHopParams(analysis, parsedTx, tx.Meta)
+	}
+
+	computePlatformFees(analysis)
+	computeLedger(analysis, parsedTx, tx.Meta)
+	attributeIntegrator(analysis, parsedTx, tx.Meta, a.integrators)
+	ctx := a.providerContext(context.Background())
+	attachMintRisks(ctx, analysis, a.mintRisks)
+	attachBondingCurves(ctx, analysis, parsedTx, a.bondingCurves)
+	attachPriorityFee(ctx, analysis, tx.Slot, parsedTx, a.blockFees)
+
+	if analysis.Stats.JupiterInstructions > 0 && len(analysis.Events) == 0 {
+		analysis.addWarning(CodeEventsMissing, "no swap events found for %d Jupiter instructions", analysis.Stats.JupiterInstructions)
+	}
+
+	// 3. Generate summary, leaving dust legs out
+	analysis.Summary = generateSwapSummary(analysis.Instructions, nonDustEvents(analysis.Events))
+	if len(analysis.Instructions) > 0 && involvesSol(analysis.Events) {
+		if delta, ok := netSolDelta(analysis.Instructions[0].UserWallet, parsedTx.Message.AccountKeys, tx.Meta); ok {
+			analysis.Summary.NetSolDelta = delta
+		}
+	}
+
+	// 4. Compare quote with execution
+	analysis.ExecutionQuality = computeExecutionQuality(analysis, a.tokenRegistry)
 
-	// 3. Generate summary
-	analysis.Summary = generateSwapSummary(analysis.Instructions, analysis.Events)
+	// 5. Assess route shape
+	analysis.RouteAssessment = assessRoute(analysis, a.poolRegistry)
+
+	checkForbiddenVariants(analysis, a.forbiddenVariants)
+
+	linkInstructionResults(analysis)
+
+	if a.hooks.OnAnalysisComplete != nil {
+		callHook(analysis, "OnAnalysisComplete", func() { a.hooks.OnAnalysisComplete(analysis) })
+	}
 
 	return analysis, nil
 }
 
+// linkInstructionResults points parsed results at their final entry in Instructions
+func linkInstructionResults(analysis *JupiterV6Analysis) {
+	for i := range analysis.Results {
+		result := &analysis.Results[i]
+		if result.Skipped || result.Error != "" {
+			continue
+		}
+		for j := range analysis.Instructions {
+			if analysis.Instructions[j].InstructionIndex == result.Index {
+				result.Params = &analysis.Instructions[j]
+				break
+			}
+		}
+	}
+}
+
 // generateSwapSummary generates swap summary
 func generateSwapSummary(instructions []JupiterSwapParams, events []SwapEvent) SwapSummary {
 	summary := SwapSummary{
@@ -1153,9 +1120,12 @@ func generateSwapSummary(instructions []JupiterSwapParams, events []SwapEvent) S
 		summary.OutputToken = lastEvent.OutputMint.String()
 		summary.TotalOutput = lastEvent.OutputAmount
 
-		// Build route information
+		// Build route information, self-swaps do not advance the route
 		route := []string{summary.InputToken}
 		for _, event := range events {
+			if event.IsSelfSwap() {
+				continue
+			}
 			route = append(route, event.OutputMint.String())
 		}
 		summary.Route = strings.Join(route, " -> ")
@@ -1164,157 +1134,237 @@ func generateSwapSummary(instructions []JupiterSwapParams, events []SwapEvent) S
 	return summary
 }
 
+// involvesSol reports whether SOL is the input or output of a non dust event
+func involvesSol(events []SwapEvent) bool {
+	for _, event := range nonDustEvents(events) {
+		if event.InputMint.Equals(solana.SolMint) || event.OutputMint.Equals(solana.SolMint) {
+			return true
+		}
+	}
+	return false
+}
+
 // printSwapEvent prints detailed information of a Swap Event
-func printSwapEvent(event SwapEvent, index int) {
-	fmt.Printf("\n=== Swap Event %d ===\n", index+1)
-	fmt.Printf("Discriminator: %X\n", event.Discriminator)
-	fmt.Printf("Unknown Field: %X\n", event.Unknown)
-	fmt.Printf("AMM: %s\n", event.AMM.String())
-	fmt.Printf("Input Mint: %s\n", event.InputMint.String())
-	fmt.Printf("Input Amount: %d\n", event.InputAmount)
-	fmt.Printf("Output Mint: %s\n", event.OutputMint.String())
-	fmt.Printf("Output Amount: %d\n", event.OutputAmount)
+func printSwapEvent(w io.Writer, event SwapEvent, index int) {
+	fmt.Fprintf(w, "\n=== Swap Event %d ===\n", index+1)
+	fmt.Fprintf(w, "Discriminator: %X\n", event.Discriminator)
+	fmt.Fprintf(w, "Unknown Field: %X\n", event.Unknown)
+	fmt.Fprintf(w, "AMM: %s\n", event.AMM.String())
+	fmt.Fprintf(w, "Input Mint: %s\n", event.InputMint.String())
+	fmt.Fprintf(w, "Input Amount: %d\n", event.InputAmount)
+	fmt.Fprintf(w, "Output Mint: %s\n", event.OutputMint.String())
+	fmt.Fprintf(w, "Output Amount: %d\n", event.OutputAmount)
 
 	// Format to 6 decimal places
-	fmt.Printf("\nFormatted Values (6 decimals):\n")
-	fmt.Printf("  Input Amount: %.6f\n", float64(event.InputAmount)/1000000.0)
-	fmt.Printf("  Output Amount: %.6f\n", float64(event.OutputAmount)/1000000.0)
+	fmt.Fprintf(w, "\nFormatted Values (6 decimals):\n")
+	fmt.Fprintf(w, "  Input Amount: %.6f\n", float64(event.InputAmount)/1000000.0)
+	fmt.Fprintf(w, "  Output Amount: %.6f\n", float64(event.OutputAmount)/1000000.0)
+}
+
+// PrintAnalysis writes the human readable analysis report to w
+func PrintAnalysis(w io.Writer, analysis *JupiterV6Analysis) {
+	printJupiterV6Analysis(w, analysis)
 }
 
 // printJupiterV6Analysis prints complete Jupiter V6 analysis results
-func printJupiterV6Analysis(analysis *JupiterV6Analysis) {
-	fmt.Println("\n=== Jupiter V6 Transaction Analysis ===")
+func printJupiterV6Analysis(w io.Writer, analysis *JupiterV6Analysis) {
+	fmt.Fprintln(w, "\n=== Jupiter V6 Transaction Analysis ===")
 
 	// Print summary
-	fmt.Printf("\nSummary:\n")
-	fmt.Printf("  Total Swaps: %d\n", analysis.Summary.TotalSwaps)
-	fmt.Printf("  Input Token: %s\n", analysis.Summary.InputToken)
-	fmt.Printf("  Output Token: %s\n", analysis.Summary.OutputToken)
-	fmt.Printf("  Total Input: %d (%.6f)\n", analysis.Summary.TotalInput, float64(analysis.Summary.TotalInput)/1000000.0)
-	fmt.Printf("  Total Output: %d (%.6f)\n", analysis.Summary.TotalOutput, float64(analysis.Summary.TotalOutput)/1000000.0)
-	fmt.Printf("  Route: %s\n", analysis.Summary.Route)
+	fmt.Fprintf(w, "\nSummary:\n")
+	fmt.Fprintf(w, "  Total Swaps: %d\n", analysis.Summary.TotalSwaps)
+	fmt.Fprintf(w, "  Input Token: %s\n", analysis.Summary.InputToken)
+	fmt.Fprintf(w, "  Output Token: %s\n", analysis.Summary.OutputToken)
+	fmt.Fprintf(w, "  Total Input: %d (%.6f)\n", analysis.Summary.TotalInput, float64(analysis.Summary.TotalInput)/1000000.0)
+	fmt.Fprintf(w, "  Total Output: %d (%.6f)\n", analysis.Summary.TotalOutput, float64(analysis.Summary.TotalOutput)/1000000.0)
+	fmt.Fprintf(w, "  Route: %s\n", analysis.Summary.Route)
+	if analysis.Summary.NetSolDelta != 0 {
+		fmt.Fprintf(w, "  Net SOL Delta: %d lamports\n", analysis.Summary.NetSolDelta)
+	}
+	if analysis.JupiterVersion != "" {
+		fmt.Fprintf(w, "  Jupiter Version: %s\n", analysis.JupiterVersion)
+	}
+
+	// Print execution quality
+	if q := analysis.ExecutionQuality; q != nil {
+		fmt.Fprintf(w, "\nExecution Quality:\n")
+		fmt.Fprintf(w, "  Quoted Amount: %d\n", q.QuotedAmount)
+		fmt.Fprintf(w, "  Executed Amount: %d\n", q.ExecutedAmount)
+		fmt.Fprintf(w, "  Slippage Allowance: %s\n", q.SlippageAllowanceUI)
+		fmt.Fprintf(w, "  Realized: %s\n", q.RealizedUI)
+	}
+
+	if r := analysis.RouteAssessment; r != nil {
+		fmt.Fprintf(w, "\nRoute Assessment (heuristic):\n")
+		fmt.Fprintf(w, "  Direct Pool Known: %t\n", r.DirectPoolKnown)
+		fmt.Fprintf(w, "  Hops Used: %d\n", r.HopsUsed)
+		fmt.Fprintf(w, "  Split Count: %d\n", r.SplitCount)
+	}
 
 	// Print instruction details
-	fmt.Printf("\nInstructions (%d):\n", len(analysis.Instructions))
+	fmt.Fprintf(w, "\nInstructions (%d):\n", len(analysis.Instructions))
 	for i, inst := range analysis.Instructions {
-		fmt.Printf("\n--- Instruction %d ---\n", i+1)
-		printJupiterV6Results(&inst)
+		fmt.Fprintf(w, "\n--- Instruction %d ---\n", i+1)
+		printJupiterV6Results(w, &inst)
+	}
+
+	// Print instruction parse errors
+	if len(analysis.Errors) > 0 {
+		fmt.Fprintf(w, "\nInstruction Errors (%d):\n", len(analysis.Errors))
+		for _, instErr := range analysis.Errors {
+			fmt.Fprintf(w, "  [%s] Instruction %d: %s\n", instErr.Code, instErr.Index, instErr.Error)
+		}
+	}
+
+	// Print warnings
+	if len(analysis.Warnings) > 0 {
+		fmt.Fprintf(w, "\nWarnings (%d):\n", len(analysis.Warnings))
+		for _, warning := range analysis.Warnings {
+			fmt.Fprintf(w, "  [%s] %s\n", warning.Code, warning.Message)
+		}
 	}
 
 	// Print event details
-	fmt.Printf("\nSwap Events (%d):\n", len(analysis.Events))
+	fmt.Fprintf(w, "\nSwap Events (%d):\n", len(analysis.Events))
 	for i, event := range analysis.Events {
-		printSwapEvent(event, i)
+		printSwapEvent(w, event, i)
 	}
 
 	// Generate JSON output
-	fmt.Printf("\n=== JSON Output ===\n")
-	printJupiterV6AnalysisJSON(analysis)
+	fmt.Fprintf(w, "\n=== JSON Output ===\n")
+	printJupiterV6AnalysisJSON(w, analysis)
 }
 
 // printJupiterV6AnalysisJSON prints analysis results in JSON format
-func printJupiterV6AnalysisJSON(analysis *JupiterV6Analysis) {
-	fmt.Printf("{\n")
-	fmt.Printf("  \"summary\": {\n")
-	fmt.Printf("    \"total_swaps\": %d,\n", analysis.Summary.TotalSwaps)
-	fmt.Printf("    \"input_token\": \"%s\",\n", analysis.Summary.InputToken)
-	fmt.Printf("    \"output_token\": \"%s\",\n", analysis.Summary.OutputToken)
-	fmt.Printf("    \"total_input\": \"%d\",\n", analysis.Summary.TotalInput)
-	fmt.Printf("    \"total_output\": \"%d\",\n", analysis.Summary.TotalOutput)
-	fmt.Printf("    \"route\": \"%s\"\n", analysis.Summary.Route)
-	fmt.Printf("  },\n")
-
-	fmt.Printf("  \"instructions\": [\n")
+func printJupiterV6AnalysisJSON(w io.Writer, analysis *JupiterV6Analysis) {
+	fmt.Fprintf(w, "{\n")
+	fmt.Fprintf(w, "  \"summary\": {\n")
+	fmt.Fprintf(w, "    \"total_swaps\": %d,\n", analysis.Summary.TotalSwaps)
+	fmt.Fprintf(w, "    \"input_token\": \"%s\",\n", analysis.Summary.InputToken)
+	fmt.Fprintf(w, "    \"output_token\": \"%s\",\n", analysis.Summary.OutputToken)
+	fmt.Fprintf(w, "    \"total_input\": \"%d\",\n", analysis.Summary.TotalInput)
+	fmt.Fprintf(w, "    \"total_output\": \"%d\",\n", analysis.Summary.TotalOutput)
+	fmt.Fprintf(w, "    \"route\": \"%s\"\n", analysis.Summary.Route)
+	fmt.Fprintf(w, "  },\n")
+
+	fmt.Fprintf(w, "  \"instructions\": [\n")
 	for i, inst := range analysis.Instructions {
-		fmt.Printf("    {\n")
-		fmt.Printf("      \"instruction_type\": \"%s\",\n", inst.InstructionType)
-		if inst.ID != 0 {
-			fmt.Printf("      \"id\": %d,\n", inst.ID)
+		fmt.Fprintf(w, "    {\n")
+		fmt.Fprintf(w, "      \"instruction_type\": \"%s\",\n", inst.InstructionType)
+		if inst.AuthorityID != 0 {
+			fmt.Fprintf(w, "      \"id\": %d,\n", inst.AuthorityID)
 		}
-		fmt.Printf("      \"in_amount\": \"%d\",\n", inst.InAmount)
-		fmt.Printf("      \"quoted_out_amount\": \"%d\",\n", inst.QuotedOutAmount)
-		fmt.Printf("      \"slippage_bps\": \"%d\",\n", inst.SlippageBps)
-		fmt.Printf("      \"platform_fee_bps\": %d\n", inst.PlatformFeeBps)
+		fmt.Fprintf(w, "      \"in_amount\": \"%d\",\n", inst.InAmount)
+		fmt.Fprintf(w, "      \"quoted_out_amount\": \"%d\",\n", inst.QuotedOutAmount)
+		fmt.Fprintf(w, "      \"slippage_bps\": \"%d\",\n", inst.SlippageBps)
+		fmt.Fprintf(w, "      \"platform_fee_bps\": %d\n", inst.PlatformFeeBps)
 		if i < len(analysis.Instructions)-1 {
-			fmt.Printf("    },\n")
+			fmt.Fprintf(w, "    },\n")
 		} else {
-			fmt.Printf("    }\n")
+			fmt.Fprintf(w, "    }\n")
 		}
 	}
-	fmt.Printf("  ],\n")
+	fmt.Fprintf(w, "  ],\n")
 
-	fmt.Printf("  \"events\": [\n")
+	fmt.Fprintf(w, "  \"events\": [\n")
 	for i, event := range analysis.Events {
-		fmt.Printf("    {\n")
-		fmt.Printf("      \"amm\": \"%s\",\n", event.AMM.String())
-		fmt.Printf("      \"input_mint\": \"%s\",\n", event.InputMint.String())
-		fmt.Printf("      \"input_amount\": \"%d\",\n", event.InputAmount)
-		fmt.Printf("      \"output_mint\": \"%s\",\n", event.OutputMint.String())
-		fmt.Printf("      \"output_amount\": \"%d\"\n", event.OutputAmount)
+		fmt.Fprintf(w, "    {\n")
+		fmt.Fprintf(w, "      \"amm\": \"%s\",\n", event.AMM.String())
+		fmt.Fprintf(w, "      \"input_mint\": \"%s\",\n", event.InputMint.String())
+		fmt.Fprintf(w, "      \"input_amount\": \"%d\",\n", event.InputAmount)
+		fmt.Fprintf(w, "      \"output_mint\": \"%s\",\n", event.OutputMint.String())
+		fmt.Fprintf(w, "      \"output_amount\": \"%d\"\n", event.OutputAmount)
 		if i < len(analysis.Events)-1 {
-			fmt.Printf("    },\n")
+			fmt.Fprintf(w, "    },\n")
 		} else {
-			fmt.Printf("    }\n")
+			fmt.Fprintf(w, "    }\n")
 		}
 	}
-	fmt.Printf("  ]\n")
-	fmt.Printf("}\n")
+	fmt.Fprintf(w, "  ]\n")
+	fmt.Fprintf(w, "}\n")
 }
 
-func main() {
-	// Transaction signature
-	txSignature := solana.MustSignatureFromBase58("5Mckd1q1vKHP7X4r45gcdNoy9gKfjG3jYUG6vyx6tPB3MzKrD44hHiP89PnPGQTV1p6NG56rz1jp6AyxKFtyo4aR")
+// newCLIAnalyzer creates the analyzer of the main command from configFile when
+// set, with the analyzer flags set on the command line applied on top. The rate
+// limited mainnet client is used unless the configuration names an endpoint.
+func newCLIAnalyzer(configFile string, flags *configFlags, fs *flag.FlagSet) (*Analyzer, error) {
+	cfg := DefaultConfig()
+	if configFile != "" {
+		var err error
+		if cfg, err = LoadConfig(configFile); err != nil {
+			return nil, err
+		}
+	}
+	flags.apply(&cfg, fs)
 
-	// Initialize RPC client with rate limiting
-	rpcClient := rpc.NewWithCustomRPCClient(rpc.NewWithLimiter(
+	var deps Dependencies
+	if cfg.RPCEndpoint == "" && !cfg.NoNetwork {
+		deps.RPCClient = newMainnetRPCClient()
+	}
+	return NewAnalyzerFromConfig(cfg, deps)
+}
+
+// newMainnetRPCClient initializes a mainnet RPC client with rate limiting
+func newMainnetRPCClient() *rpc.Client {
+	return rpc.NewWithCustomRPCClient(rpc.NewWithLimiter(
 		rpc.MainNetBeta.RPC,
 		rate.Every(time.Second),
 		5,
 	))
+}
 
-	// Get transaction with version support
-	version := uint64(0)
-	tx, err := rpcClient.GetTransaction(
-		context.Background(),
-		txSignature,
-		&rpc.GetTransactionOpts{
-			MaxSupportedTransactionVersion: &version,
-			Encoding:                       solana.EncodingBase64,
-		},
-	)
-	if err != nil {
-		fmt.Printf("Error getting transaction: %v\n", err)
-		return
+func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "snapshot":
+			os.Exit(runSnapshotCommand(os.Args[2:]))
+		case "corpus":
+			os.Exit(runCorpusCommand(os.Args[2:]))
+		case "idl-check":
+			os.Exit(runIDLCheckCommand(os.Args[2:]))
+		case "triage":
+			os.Exit(runTriageCommand(os.Args[2:]))
+		case "migrate":
+			os.Exit(runMigrateCommand(os.Args[2:]))
+		}
 	}
 
-	// Parse the transaction
-	parsedTx, err := tx.Transaction.GetTransaction()
+	signaturesFile := flag.String("signatures-file", "", "analyze the signatures of this file, one per line, and print NDJSON")
+	workers := flag.Int("workers", 4, "concurrent analyses with -signatures-file")
+	summaryOnly := flag.Bool("summary-only", false, "emit only the swap summary of each transaction with -signatures-file")
+	bigAmounts := flag.Bool("big-amounts", false, "add arbitrary precision amounts to each result with -signatures-file")
+	configFile := flag.String("config", "", "load the analyzer configuration from this JSON file, flags take precedence")
+	analyzerFlags := registerConfigFlags(flag.CommandLine)
+	flag.Parse()
+
+	analyzer, err := newCLIAnalyzer(*configFile, analyzerFlags, flag.CommandLine)
 	if err != nil {
-		fmt.Printf("Error parsing transaction: %v\n", err)
-		return
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
-	// Process versioned transactions with address lookup tables
-	if parsedTx.Message.IsVersioned() {
-		err = resolveAddressLookupTables(parsedTx, rpcClient)
-		if err != nil {
-			fmt.Printf("Error resolving address lookup tables: %v\n", err)
-			return
-		}
+	if *signaturesFile != "" {
+		os.Exit(runSignaturesFile(context.Background(), analyzer.With(WithLogOutput(os.Stderr)), *signaturesFile, *workers, *summaryOnly, *bigAmounts, os.Stdout))
 	}
 
-	// Debug print the transaction
-	fmt.Println("Transaction details:")
-	fmt.Printf("  Instructions count: %d\n", len(parsedTx.Message.Instructions))
-	fmt.Printf("  Is versioned: %v\n", parsedTx.Message.IsVersioned())
+	// Transaction signature
+	txSignature := solana.MustSignatureFromBase58("5Mckd1q1vKHP7X4r45gcdNoy9gKfjG3jYUG6vyx6tPB3MzKrD44hHiP89PnPGQTV1p6NG56rz1jp6AyxKFtyo4aR")
+
+	// Get transaction with version support and resolve address lookup tables
+	tx, parsedTx, err := analyzer.fetchTransaction(context.Background(), txSignature)
+	if err != nil {
+		fmt.Printf("Error fetching transaction: %v\n", err)
+		return
+	}
 
 	// Perform complete Jupiter V6 analysis
-	analysis, err := analyzeJupiterV6Transaction(tx, parsedTx)
+	analysis, err := analyzer.Analyze(tx, parsedTx)
 	if err != nil {
 		fmt.Printf("Error analyzing Jupiter V6 transaction: %v\n", err)
 		return
 	}
 
 	// Print analysis results
-	printJupiterV6Analysis(analysis)
+	printJupiterV6Analysis(os.Stdout, analysis)
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// memoV1ProgramID is the first SPL Memo program, still used by some bots.
+// solana.MemoProgramID is the current (v2) program.
+var memoV1ProgramID = solana.MustPublicKeyFromBase58("Memo1UhkJRfHyvLMcVucJwxXeuD728EqVDDwQDxFMNo")
+
+// maxMemoLength caps the bytes decoded from one memo, the longest memo that
+// fits a transaction is about this size
+const maxMemoLength = 566
+
+// isMemoProgram reports whether program is one of the SPL Memo programs
+func isMemoProgram(program solana.PublicKey) bool {
+	return program.Equals(solana.MemoProgramID) || program.Equals(memoV1ProgramID)
+}
+
+// extractMemos collects the memos of the transaction in execution order: each
+// top-level instruction, then the instructions it invoked
+func extractMemos(analysis *JupiterV6Analysis, parsedTx *solana.Transaction, meta *rpc.TransactionMeta) {
+	inner := make(map[int][]solana.CompiledInstruction)
+	if meta != nil {
+		for _, set := range meta.InnerInstructions {
+			inner[int(set.Index)] = append(inner[int(set.Index)], set.Instructions...)
+		}
+	}
+
+	add := func(inst solana.CompiledInstruction) {
+		program, ok := programIDAt(int(inst.ProgramIDIndex), parsedTx.Message.AccountKeys, meta)
+		if ok && isMemoProgram(program) {
+			analysis.Memos = append(analysis.Memos, decodeMemo(inst.Data))
+		}
+	}
+	for i, inst := range parsedTx.Message.Instructions {
+		add(inst)
+		for _, invoked := range inner[i] {
+			add(invoked)
+		}
+	}
+}
+
+// decodeMemo returns the UTF-8 text of a memo, cut to maxMemoLength bytes.
+// Bytes that are not valid UTF-8 are escaped as \xNN rather than dropped.
+func decodeMemo(data []byte) string {
+	truncated := len(data) > maxMemoLength
+	if truncated {
+		data = data[:maxMemoLength]
+	}
+
+	var text strings.Builder
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size <= 1 {
+			fmt.Fprintf(&text, "\\x%02x", data[0])
+			data = data[1:]
+			continue
+		}
+		text.Write(data[:size])
+		data = data[size:]
+	}
+	if truncated {
+		text.WriteString("…")
+	}
+	return text.String()
+}
+
+// MemoContains reports whether one of the memos of the analysis contains substr
+func (a *JupiterV6Analysis) MemoContains(substr string) bool {
+	for _, memo := range a.Memos {
+		if strings.Contains(memo, substr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestDecodeMemo(t *testing.T) {
+	long := strings.Repeat("a", maxMemoLength-1) + "é"
+	for _, tt := range []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"ascii", []byte("order:42"), "order:42"},
+		{"utf-8", []byte("swap ✓"), "swap ✓"},
+		{"invalid byte", []byte{'a', 0xff, 'b'}, `a\xffb`},
+		{"empty", nil, ""},
+		{"at limit", []byte(strings.Repeat("a", maxMemoLength)), strings.Repeat("a", maxMemoLength)},
+		{"over limit", []byte(strings.Repeat("a", maxMemoLength+10)), strings.Repeat("a", maxMemoLength) + "…"},
+		// The cut falls inside the two byte rune, its first byte is escaped
+		{"cut rune", []byte(long), strings.Repeat("a", maxMemoLength-1) + `\xc3…`},
+	} {
+		if got := decodeMemo(tt.data); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExtractMemos(t *testing.T) {
+	keys := solana.PublicKeySlice{testKey(1), solana.MemoProgramID, memoV1ProgramID, jupiterV6ProgramID}
+	parsedTx := &solana.Transaction{
+		Message: solana.Message{
+			AccountKeys: keys,
+			Instructions: []solana.CompiledInstruction{
+				{ProgramIDIndex: 3, Data: []byte("not a memo")},
+				{ProgramIDIndex: 1, Data: []byte("order:42")},
+				// Index 4 is the memo program loaded from a lookup table
+				{ProgramIDIndex: 4, Data: []byte("loaded")},
+			},
+		},
+	}
+	meta := &rpc.TransactionMeta{
+		InnerInstructions: []rpc.InnerInstruction{
+			{Index: 0, Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 2, Data: []byte("bot")}}},
+		},
+	}
+	meta.LoadedAddresses.ReadOnly = solana.PublicKeySlice{solana.MemoProgramID}
+
+	analysis := &JupiterV6Analysis{}
+	extractMemos(analysis, parsedTx, meta)
+	want := []string{"bot", "order:42", "loaded"}
+	if strings.Join(analysis.Memos, "|") != strings.Join(want, "|") {
+		t.Errorf("memos %q, want %q", analysis.Memos, want)
+	}
+	if !analysis.MemoContains("order:") || analysis.MemoContains("not a memo") {
+		t.Error("MemoContains")
+	}
+}
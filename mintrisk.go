@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Mint account layout shared by both token programs
+const (
+	mintBaseSize          = 82
+	mintAccountTypeOffset = 165 // Token-2022 account type byte, extensions follow
+	accountTypeMint       = 1
+)
+
+// Token-2022 mint extension types with a risk flag
+const (
+	extensionPermanentDelegate = 12
+	extensionTransferHook      = 14
+)
+
+// extensionNames names the Token-2022 mint extensions reported in MintRisk.Extensions
+var extensionNames = map[uint16]string{
+	1:  "TransferFeeConfig",
+	3:  "MintCloseAuthority",
+	4:  "ConfidentialTransferMint",
+	6:  "DefaultAccountState",
+	9:  "NonTransferable",
+	10: "InterestBearingConfig",
+	12: "PermanentDelegate",
+	14: "TransferHook",
+	16: "ConfidentialTransferFeeConfig",
+	18: "MetadataPointer",
+	19: "TokenMetadata",
+	20: "GroupPointer",
+	21: "TokenGroup",
+	22: "GroupMemberPointer",
+	23: "TokenGroupMember",
+}
+
+// MintRisk holds the risk signals of a mint account
+type MintRisk struct {
+	Mint                solana.PublicKey  `json:"mint"`
+	Program             solana.PublicKey  `json:"program"`
+	MintAuthority       *solana.PublicKey `json:"mint_authority,omitempty"`
+	FreezeAuthority     *solana.PublicKey `json:"freeze_authority,omitempty"`
+	PermanentDelegate   *solana.PublicKey `json:"permanent_delegate,omitempty"`
+	TransferHookProgram *solana.PublicKey `json:"transfer_hook_program,omitempty"`
+	Extensions          []string          `json:"extensions,omitempty"` // Token-2022 extension names
+}
+
+// HasRisk reports whether any authority or risky extension is present
+func (r *MintRisk) HasRisk() bool {
+	return r.MintAuthority != nil || r.FreezeAuthority != nil || r.PermanentDelegate != nil || r.TransferHookProgram != nil
+}
+
+// readCOptionKey reads a COption<Pubkey>: a u32 tag followed by the key
+func readCOptionKey(data []byte) *solana.PublicKey {
+	if binary.LittleEndian.Uint32(data[:4]) == 0 {
+		return nil
+	}
+	key := solana.PublicKeyFromBytes(data[4:36])
+	return &key
+}
+
+// readOptionalNonZeroKey reads a Token-2022 OptionalNonZeroPubkey, all zero means none
+func readOptionalNonZeroKey(data []byte) *solana.PublicKey {
+	key := solana.PublicKeyFromBytes(data[:32])
+	if key.IsZero() {
+		return nil
+	}
+	return &key
+}
+
+// DecodeMintRisk decodes a mint account owned by the token or Token-2022 program
+func DecodeMintRisk(mint, owner solana.PublicKey, data []byte) (*MintRisk, error) {
+	if !owner.Equals(solana.TokenProgramID) && !owner.Equals(solana.Token2022ProgramID) {
+		return nil, fmt.Errorf("mint %s is owned by %s, not a token program", mint, owner)
+	}
+	if len(data) < mintBaseSize {
+		return nil, fmt.Errorf("mint %s account too short: %d bytes", mint, len(data))
+	}
+
+	// mint_authority COption<Pubkey>, supply u64, decimals u8, is_initialized bool, freeze_authority COption<Pubkey>
+	risk := &MintRisk{
+		Mint:            mint,
+		Program:         owner,
+		MintAuthority:   readCOptionKey(data[0:36]),
+		FreezeAuthority: readCOptionKey(data[46:82]),
+	}
+	if !owner.Equals(solana.Token2022ProgramID) || len(data) <= mintAccountTypeOffset {
+		return risk, nil
+	}
+	if data[mintAccountTypeOffset] != accountTypeMint {
+		return nil, fmt.Errorf("mint %s has account type %d", mint, data[mintAccountTypeOffset])
+	}
+
+	// Extensions are TLV entries: type u16, length u16, value
+	offset := mintAccountTypeOffset + 1
+	for offset+4 <= len(data) {
+		extensionType := binary.LittleEndian.Uint16(data[offset : offset+2])
+		length := int(binary.LittleEndian.Uint16(data[offset+2 : offset+4]))
+		offset += 4
+		if extensionType == 0 {
+			break // Uninitialized, rest of the account is padding
+		}
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("mint %s extension %d truncated", mint, extensionType)
+		}
+		value := data[offset : offset+length]
+		offset += length
+
+		name, ok := extensionNames[extensionType]
+		if !ok {
+			name = fmt.Sprintf("Extension%d", extensionType)
+		}
+		risk.Extensions = append(risk.Extensions, name)
+
+		switch extensionType {
+		case extensionPermanentDelegate:
+			if length >= 32 {
+				risk.PermanentDelegate = readOptionalNonZeroKey(value)
+			}
+		case extensionTransferHook:
+			// authority, program_id
+			if length >= 64 {
+				risk.TransferHookProgram = readOptionalNonZeroKey(value[32:64])
+			}
+		}
+	}
+	return risk, nil
+}
+
+// MintRiskProvider supplies the risk signals of mints
+type MintRiskProvider interface {
+	MintRisk(ctx context.Context, mint solana.PublicKey) (*MintRisk, error)
+}
+
+// MintAccount is a recorded mint account
+type MintAccount struct {
+	Owner solana.PublicKey `json:"owner"`
+	Data  []byte           `json:"data"` // base64 in JSON
+}
+
+// MintAccountSnapshot is a MintRiskProvider backed by recorded mint accounts
+type MintAccountSnapshot map[solana.PublicKey]MintAccount
+
+// MintRisk decodes the recorded account of mint
+func (s MintAccountSnapshot) MintRisk(ctx context.Context, mint solana.PublicKey) (*MintRisk, error) {
+	account, ok := s[mint]
+	if !ok {
+		return nil, fmt.Errorf("mint %s not in snapshot", mint)
+	}
+	return DecodeMintRisk(mint, account.Owner, account.Data)
+}
+
+// LoadMintAccountSnapshot reads a JSON object mapping mints to their owner and base64 account data
+func LoadMintAccountSnapshot(path string) (MintAccountSnapshot, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot MintAccountSnapshot
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return nil, fmt.Errorf("error decoding mint account snapshot: %v", err)
+	}
+	return snapshot, nil
+}
+
+// rpcMintRisks fetches mint accounts over rpc, caching decoded results
+type rpcMintRisks struct {
+	client     *rpc.Client
+	commitment rpc.CommitmentType
+
+	mu    sync.Mutex
+	cache map[solana.PublicKey]*MintRisk
+}
+
+// NewRPCMintRiskProvider fetches mint accounts with client. Mint authorities
+// rarely change, decoded mints are cached for the provider lifetime. Analyzers
+// fetch at their own commitment, commitment applies to other callers.
+func NewRPCMintRiskProvider(client *rpc.Client, commitment rpc.CommitmentType) MintRiskProvider {
+	return &rpcMintRisks{
+		client:     client,
+		commitment: commitment,
+		cache:      make(map[solana.PublicKey]*MintRisk),
+	}
+}
+
+// MintRisk returns the cached risk of mint, fetching the account on a miss
+func (p *rpcMintRisks) MintRisk(ctx context.Context, mint solana.PublicKey) (*MintRisk, error) {
+	p.mu.Lock()
+	risk, ok := p.cache[mint]
+	p.mu.Unlock()
+	if ok {
+		return risk, nil
+	}
+
+	info, err := p.client.GetAccountInfoWithOpts(ctx, mint, &rpc.GetAccountInfoOpts{Commitment: CommitmentFromContext(ctx, p.commitment)})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching mint account: %v", err)
+	}
+	risk, err = DecodeMintRisk(mint, info.Value.Owner, info.GetBinary())
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.cache[mint] = risk
+	p.mu.Unlock()
+	return risk, nil
+}
+
+// WithMintRiskProvider attaches the risk signals of every mint involved in the swap
+func WithMintRiskProvider(provider MintRiskProvider) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.mintRisks = provider
+	}
+}
+
+// attachMintRisks looks up the risk of each event mint once, in event order
+func attachMintRisks(ctx context.Context, analysis *JupiterV6Analysis, provider MintRiskProvider) {
+	if provider == nil {
+		return
+	}
+	seen := make(map[solana.PublicKey]bool)
+	for _, event := range analysis.Events {
+		for _, mint := range []solana.PublicKey{event.InputMint, event.OutputMint} {
+			if seen[mint] {
+				continue
+			}
+			seen[mint] = true
+
+			risk, err := provider.MintRisk(ctx, mint)
+			if err != nil {
+				analysis.addWarning(CodeMintRiskUnavailable, "mint %s: %v", mint, err)
+				continue
+			}
+			analysis.MintRisks = append(analysis.MintRisks, *risk)
+		}
+	}
+}
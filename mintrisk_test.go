@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestDecodeMintRisk(t *testing.T) {
+	snapshot, err := LoadMintAccountSnapshot(filepath.Join("testdata", "mints", "snapshot.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := func(s string) *solana.PublicKey {
+		k := solana.MustPublicKeyFromBase58(s)
+		return &k
+	}
+	stablecoinAuthority := key("9nEfZqzTP3dfVWmzQy54TzsZqSQqDFVW4PhXdG9vYCVD")
+
+	for _, tt := range []struct {
+		name string
+		mint string
+		want MintRisk
+		risk bool
+	}{
+		{
+			name: "USDC",
+			mint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+			want: MintRisk{
+				Program:         solana.TokenProgramID,
+				MintAuthority:   key("BJE5MMbqXjVwjAF7oxwPYXnTXDyspzZyt4vwenNw5ruG"),
+				FreezeAuthority: key("7dGbd2QZcCKcTndnHcTL8q7SMVXAkp688NTQYwrRCrar"),
+			},
+			risk: true,
+		},
+		{
+			name: "memecoin with revoked authorities",
+			mint: "Dp1Tqi8rhd1gVSu6Wz6bD4Jqp2Tw1khTd9Ffnj3tWXvH",
+			want: MintRisk{Program: solana.TokenProgramID},
+		},
+		{
+			name: "Token-2022 stablecoin",
+			mint: "2b1kV6DkPAnxd5ixfnxCpjxmKwqjjaYmCZfHsFu24GXo",
+			want: MintRisk{
+				Program:             solana.Token2022ProgramID,
+				MintAuthority:       stablecoinAuthority,
+				FreezeAuthority:     stablecoinAuthority,
+				PermanentDelegate:   stablecoinAuthority,
+				TransferHookProgram: key("HooKD5NC9QNxk25QuzCssB8ecrEzGt6eXEPBUxWp1LaR"),
+				Extensions:          []string{"MintCloseAuthority", "TransferFeeConfig", "PermanentDelegate", "TransferHook", "MetadataPointer"},
+			},
+			risk: true,
+		},
+		{
+			name: "Token-2022 memecoin",
+			mint: "MemeZ22Tz2wLq5kP3hWq1Hq6u3rZ3s1y9Yf8WcTkD2x",
+			want: MintRisk{
+				Program:    solana.Token2022ProgramID,
+				Extensions: []string{"MetadataPointer", "Extension99"},
+			},
+		},
+	} {
+		mint := solana.MustPublicKeyFromBase58(tt.mint)
+		risk, err := snapshot.MintRisk(context.Background(), mint)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		tt.want.Mint = mint
+		if !reflect.DeepEqual(*risk, tt.want) {
+			t.Errorf("%s: %+v, want %+v", tt.name, *risk, tt.want)
+		}
+		if risk.HasRisk() != tt.risk {
+			t.Errorf("%s: HasRisk %v, want %v", tt.name, risk.HasRisk(), tt.risk)
+		}
+	}
+}
+
+func TestDecodeMintRiskInvalid(t *testing.T) {
+	snapshot, err := LoadMintAccountSnapshot(filepath.Join("testdata", "mints", "snapshot.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mint := solana.MustPublicKeyFromBase58("2b1kV6DkPAnxd5ixfnxCpjxmKwqjjaYmCZfHsFu24GXo")
+	data := snapshot[mint].Data
+
+	wrongType := append([]byte{}, data...)
+	wrongType[mintAccountTypeOffset] = 2 // a token account
+	for _, tt := range []struct {
+		name  string
+		owner solana.PublicKey
+		data  []byte
+	}{
+		{"not a token program", solana.SystemProgramID, data},
+		{"shorter than a mint", solana.Token2022ProgramID, data[:mintBaseSize-1]},
+		{"not a mint account type", solana.Token2022ProgramID, wrongType},
+		{"truncated extension", solana.Token2022ProgramID, data[:len(data)-1]},
+	} {
+		if risk, err := DecodeMintRisk(mint, tt.owner, tt.data); err == nil {
+			t.Errorf("%s: decoded %+v", tt.name, risk)
+		}
+	}
+
+	// The token program ignores data past the base layout
+	if risk, err := DecodeMintRisk(mint, solana.TokenProgramID, data); err != nil || risk.Extensions != nil || risk.PermanentDelegate != nil {
+		t.Errorf("token program mint with trailing data: %+v, %v", risk, err)
+	}
+	if _, err := snapshot.MintRisk(context.Background(), testKey(1)); err == nil {
+		t.Error("mint missing from the snapshot decoded")
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// pricePrecision is the number of decimal places of Trade and Candle prices
+const pricePrecision = 18
+
+// Well known stablecoin mints
+var (
+	mintUSDC = solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	mintUSDT = solana.MustPublicKeyFromBase58("Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB")
+)
+
+// quoteMintPriority lists mints preferred as the quote side of a pair, most preferred first
+var quoteMintPriority = []solana.PublicKey{
+	mintUSDC,
+	mintUSDT,
+	solana.SolMint, // wSOL
+}
+
+// canonicalPair orders two mints into (base, quote). Well known quote mints
+// always take the quote side, other pairs are ordered by mint bytes.
+func canonicalPair(a, b solana.PublicKey) (base, quote solana.PublicKey) {
+	for _, mint := range quoteMintPriority {
+		if b.Equals(mint) {
+			return a, b
+		}
+		if a.Equals(mint) {
+			return b, a
+		}
+	}
+	if bytesCompare(a[:], b[:]) <= 0 {
+		return a, b
+	}
+	return b, a
+}
+
+// bytesCompare compares two byte slices lexicographically
+func bytesCompare(a, b []byte) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// Trade is a single swap hop normalized to a canonical base/quote pair.
+// Amounts and prices are in raw token units (not adjusted for decimals).
+type Trade struct {
+	Timestamp   time.Time        `json:"timestamp"`
+	Base        solana.PublicKey `json:"base"`
+	Quote       solana.PublicKey `json:"quote"`
+	BaseAmount  uint64           `json:"base_amount"`
+	QuoteAmount uint64           `json:"quote_amount"`
+	Price       string           `json:"price"` // Quote per base, exact to pricePrecision decimals
+	Integrator  string           `json:"integrator,omitempty"`
+	TradeID     string           `json:"trade_id,omitempty"`
+}
+
+// price returns the exact quote per base price of the trade
+func (t Trade) price() *big.Rat {
+	if t.BaseAmount == 0 {
+		return nil
+	}
+	return new(big.Rat).SetFrac(new(big.Int).SetUint64(t.QuoteAmount), new(big.Int).SetUint64(t.BaseAmount))
+}
+
+// TradesFromAnalysis converts every non self-swap, non dust event of the analysis into a trade at
+// timestamp. A zero timestamp uses the analysis timestamp; trades of an analysis whose time is
+// unknown keep a zero Timestamp, which consumers must handle.
+func TradesFromAnalysis(analysis *JupiterV6Analysis, timestamp time.Time) []Trade {
+	var trades []Trade
+	timestamp = tradeTime(analysis, timestamp)
+	for _, event := range analysis.Events {
+		if event.IsSelfSwap() || event.Dust || event.InputAmount == 0 || event.OutputAmount == 0 {
+			continue
+		}
+
+		trade := Trade{Timestamp: timestamp, Integrator: analysis.Integrator, TradeID: event.TradeID}
+		trade.Base, trade.Quote = canonicalPair(event.InputMint, event.OutputMint)
+		if trade.Base.Equals(event.InputMint) {
+			trade.BaseAmount, trade.QuoteAmount = event.InputAmount, event.OutputAmount
+		} else {
+			trade.BaseAmount, trade.QuoteAmount = event.OutputAmount, event.InputAmount
+		}
+		trade.Price = trade.price().FloatString(pricePrecision)
+		trades = append(trades, trade)
+	}
+	return trades
+}
+
+// Candle is an OHLCV bucket for one pair and interval
+type Candle struct {
+	Base        solana.PublicKey `json:"base"`
+	Quote       solana.PublicKey `json:"quote"`
+	Interval    time.Duration    `json:"interval"`
+	Start       time.Time        `json:"start"`
+	Open        string           `json:"open"`
+	High        string           `json:"high"`
+	Low         string           `json:"low"`
+	Close       string           `json:"close"`
+	BaseVolume  *BigAmount       `json:"base_volume"`
+	QuoteVolume *BigAmount       `json:"quote_volume"`
+	Trades      int              `json:"trades"`
+	Integrator  string           `json:"integrator,omitempty"` // set when grouping by integrator
+}
+
+// candleKey identifies a bucket
+type candleKey struct {
+	base, quote solana.PublicKey
+	interval    time.Duration
+	start       int64
+	integrator  string
+}
+
+// candleState is the mutable state of an open bucket
+type candleState struct {
+	open, high, low, close *big.Rat
+	openTime, closeTime    time.Time
+	baseVolume             *big.Int
+	quoteVolume            *big.Int
+	trades                 int
+}
+
+// AggregatorOHLC maintains per-pair OHLCV buckets for a set of intervals.
+// A bucket closes once a trade arrives whose timestamp is at least
+// allowedLateness past the bucket end. Late trades still update open buckets;
+// trades for closed buckets are rejected and counted once per trade.
+type AggregatorOHLC struct {
+	mu              sync.Mutex
+	intervals       []time.Duration
+	allowedLateness time.Duration
+	buckets         map[candleKey]*candleState
+	watermark       time.Time
+	closed          chan<- Candle
+	rejectedLate    uint64
+	byIntegrator    bool
+}
+
+// NewAggregatorOHLC creates an aggregator for the given intervals (e.g. 1m, 5m, 1h).
+// Closed candles are sent to closed when it is not nil.
+func NewAggregatorOHLC(intervals []time.Duration, allowedLateness time.Duration, closed chan<- Candle) *AggregatorOHLC {
+	return &AggregatorOHLC{
+		intervals:       intervals,
+		allowedLateness: allowedLateness,
+		buckets:         make(map[candleKey]*candleState),
+		closed:          closed,
+	}
+}
+
+// GroupByIntegrator keeps separate candles per trade integrator. It must be
+// called before the first trade is added.
+func (g *AggregatorOHLC) GroupByIntegrator() *AggregatorOHLC {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.byIntegrator = true
+	return g
+}
+
+// Add records a trade in every interval bucket, returning an error when the trade
+// falls into an already closed bucket. The trade still updates the buckets of
+// the intervals that are open.
+func (g *AggregatorOHLC) Add(trade Trade) error {
+	price := trade.price()
+	if price == nil {
+		return fmt.Errorf("trade has zero base amount")
+	}
+	if trade.Timestamp.IsZero() {
+		return fmt.Errorf("%w: trade cannot be bucketed", ErrUnknownTimestamp)
+	}
+
+	g.mu.Lock()
+	var closed []Candle
+	var err error
+
+	for _, interval := range g.intervals {
+		start := trade.Timestamp.Truncate(interval)
+		if !start.Add(interval + g.allowedLateness).After(g.watermark) {
+			err = fmt.Errorf("trade at %s falls into closed %s bucket", trade.Timestamp, interval)
+			continue
+		}
+
+		key := candleKey{base: trade.Base, quote: trade.Quote, interval: interval, start: start.UnixNano()}
+		if g.byIntegrator {
+			key.integrator = trade.Integrator
+		}
+		state, ok := g.buckets[key]
+		if !ok {
+			state = &candleState{
+				open: price, high: price, low: price, close: price,
+				openTime: trade.Timestamp, closeTime: trade.Timestamp,
+				baseVolume:  new(big.Int),
+				quoteVolume: new(big.Int),
+			}
+			g.buckets[key] = state
+		}
+
+		if trade.Timestamp.Before(state.openTime) {
+			state.open, state.openTime = price, trade.Timestamp
+		}
+		if !trade.Timestamp.Before(state.closeTime) {
+			state.close, state.closeTime = price, trade.Timestamp
+		}
+		if price.Cmp(state.high) > 0 {
+			state.high = price
+		}
+		if price.Cmp(state.low) < 0 {
+			state.low = price
+		}
+		state.baseVolume.Add(state.baseVolume, new(big.Int).SetUint64(trade.BaseAmount))
+		state.quoteVolume.Add(state.quoteVolume, new(big.Int).SetUint64(trade.QuoteAmount))
+		state.trades++
+	}
+	if err != nil {
+		g.rejectedLate++
+	}
+
+	if trade.Timestamp.After(g.watermark) {
+		g.watermark = trade.Timestamp
+		closed = g.closeBuckets()
+	}
+	g.mu.Unlock()
+
+	if g.closed != nil {
+		for _, candle := range closed {
+			g.closed <- candle
+		}
+	}
+	return err
+}
+
+// closeBuckets removes and returns the buckets that can no longer receive trades
+func (g *AggregatorOHLC) closeBuckets() []Candle {
+	var closed []Candle
+	for key, state := range g.buckets {
+		end := time.Unix(0, key.start).Add(key.interval + g.allowedLateness)
+		if !end.After(g.watermark) {
+			closed = append(closed, newCandle(key, state))
+			delete(g.buckets, key)
+		}
+	}
+	sortCandles(closed)
+	return closed
+}
+
+// Snapshot returns the currently open candles
+func (g *AggregatorOHLC) Snapshot() []Candle {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	candles := make([]Candle, 0, len(g.buckets))
+	for key, state := range g.buckets {
+		candles = append(candles, newCandle(key, state))
+	}
+	sortCandles(candles)
+	return candles
+}
+
+// RejectedLate returns the number of trades rejected for closed buckets. A
+// trade rejected for several intervals counts once.
+func (g *AggregatorOHLC) RejectedLate() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rejectedLate
+}
+
+// newCandle builds the exported candle of a bucket
+func newCandle(key candleKey, state *candleState) Candle {
+	return Candle{
+		Base:        key.base,
+		Quote:       key.quote,
+		Interval:    key.interval,
+		Start:       time.Unix(0, key.start).UTC(),
+		Open:        state.open.FloatString(pricePrecision),
+		High:        state.high.FloatString(pricePrecision),
+		Low:         state.low.FloatString(pricePrecision),
+		Close:       state.close.FloatString(pricePrecision),
+		BaseVolume:  bigAmountOf(state.baseVolume),
+		QuoteVolume: bigAmountOf(state.quoteVolume),
+		Trades:      state.trades,
+		Integrator:  key.integrator,
+	}
+}
+
+// sortCandles orders candles by start, interval, base and quote for deterministic output
+func sortCandles(candles []Candle) {
+	sort.Slice(candles, func(i, j int) bool {
+		a, b := candles[i], candles[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		if a.Interval != b.Interval {
+			return a.Interval < b.Interval
+		}
+		if c := bytesCompare(a.Base[:], b.Base[:]); c != 0 {
+			return c < 0
+		}
+		if c := bytesCompare(a.Quote[:], b.Quote[:]); c != 0 {
+			return c < 0
+		}
+		return a.Integrator < b.Integrator
+	})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestCanonicalPair(t *testing.T) {
+	low, high := testKey(1), testKey(2)
+	for _, tt := range []struct {
+		name        string
+		a, b        solana.PublicKey
+		base, quote solana.PublicKey
+	}{
+		{"usdc quotes sol", solana.SolMint, mintUSDC, solana.SolMint, mintUSDC},
+		{"usdc quotes usdt", mintUSDC, mintUSDT, mintUSDT, mintUSDC},
+		{"usdt quotes sol", mintUSDT, solana.SolMint, solana.SolMint, mintUSDT},
+		{"sol quotes a token", solana.SolMint, high, high, solana.SolMint},
+		{"byte order", high, low, low, high},
+	} {
+		for _, swapped := range []bool{false, true} {
+			a, b := tt.a, tt.b
+			if swapped {
+				a, b = b, a
+			}
+			if base, quote := canonicalPair(a, b); !base.Equals(tt.base) || !quote.Equals(tt.quote) {
+				t.Errorf("%s (swapped %v): %s/%s, want %s/%s", tt.name, swapped, base, quote, tt.base, tt.quote)
+			}
+		}
+	}
+}
+
+func TestAggregatorOHLC(t *testing.T) {
+	base := testKey(1)
+	at := func(clock string) time.Time {
+		ts, err := time.Parse(time.TimeOnly, clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return time.Date(2026, 1, 2, ts.Hour(), ts.Minute(), ts.Second(), 0, time.UTC)
+	}
+	trade := func(clock string, baseAmount, quoteAmount uint64) Trade {
+		return Trade{Timestamp: at(clock), Base: base, Quote: mintUSDC, BaseAmount: baseAmount, QuoteAmount: quoteAmount}
+	}
+	price := func(p string) string { return p + "." + strings.Repeat("0", pricePrecision) }
+
+	closed := make(chan Candle, 10)
+	g := NewAggregatorOHLC([]time.Duration{time.Minute, 5 * time.Minute}, 10*time.Second, closed)
+	for _, tr := range []Trade{
+		trade("12:00:05", 100, 200),
+		trade("12:00:30", 100, 300),
+		trade("12:00:20", 100, 100), // out of order: low, but neither open nor close
+		trade("12:01:05", 10, 40),   // next minute, within the lateness of 12:00
+		trade("12:00:50", 50, 250),  // late but allowed
+	} {
+		if err := g.Add(tr); err != nil {
+			t.Fatalf("trade at %s: %v", tr.Timestamp, err)
+		}
+	}
+	if len(closed) != 0 {
+		t.Fatalf("%d candles closed within the allowed lateness", len(closed))
+	}
+
+	// The watermark passes 12:01:10 and closes the 12:00 minute
+	if err := g.Add(trade("12:01:15", 10, 50)); err != nil {
+		t.Fatal(err)
+	}
+	if len(closed) != 1 {
+		t.Fatalf("%d candles closed, want 1", len(closed))
+	}
+	candle := <-closed
+	if !candle.Start.Equal(at("12:00:00")) || candle.Interval != time.Minute || !candle.Base.Equals(base) || !candle.Quote.Equals(mintUSDC) {
+		t.Errorf("closed candle %s %s %s/%s", candle.Start, candle.Interval, candle.Base, candle.Quote)
+	}
+	if candle.Open != price("2") || candle.High != price("5") || candle.Low != price("1") || candle.Close != price("5") ||
+		candle.BaseVolume.Int().Uint64() != 350 || candle.QuoteVolume.Int().Uint64() != 850 || candle.Trades != 4 {
+		t.Errorf("closed candle %+v", candle)
+	}
+
+	// Rejected for the closed minute, still recorded in the open 5 minute bucket
+	if err := g.Add(trade("12:00:59", 100, 600)); err == nil {
+		t.Error("trade for a closed bucket accepted")
+	}
+	// Rejected for both intervals, counted once
+	if err := g.Add(trade("11:59:00", 100, 100)); err == nil {
+		t.Error("trade for closed buckets accepted")
+	}
+	if n := g.RejectedLate(); n != 2 {
+		t.Errorf("RejectedLate = %d, want 2", n)
+	}
+
+	open := g.Snapshot()
+	if len(open) != 2 {
+		t.Fatalf("%d open candles, want 2: %+v", len(open), open)
+	}
+	fiveMinutes, minute := open[0], open[1]
+	if fiveMinutes.Interval != 5*time.Minute || !fiveMinutes.Start.Equal(at("12:00:00")) ||
+		fiveMinutes.Open != price("2") || fiveMinutes.High != price("6") || fiveMinutes.Low != price("1") || fiveMinutes.Close != price("5") ||
+		fiveMinutes.BaseVolume.Int().Uint64() != 470 || fiveMinutes.QuoteVolume.Int().Uint64() != 1540 || fiveMinutes.Trades != 7 {
+		t.Errorf("5m candle %+v", fiveMinutes)
+	}
+	if minute.Interval != time.Minute || !minute.Start.Equal(at("12:01:00")) || minute.Open != price("4") || minute.Close != price("5") || minute.Trades != 2 {
+		t.Errorf("1m candle %+v", minute)
+	}
+
+	if err := g.Add(Trade{Timestamp: at("12:02:00"), Base: base, Quote: mintUSDC, QuoteAmount: 1}); err == nil {
+		t.Error("trade with zero base amount accepted")
+	}
+	if err := g.Add(Trade{Base: base, Quote: mintUSDC, BaseAmount: 1, QuoteAmount: 1}); !errors.Is(err, ErrUnknownTimestamp) {
+		t.Errorf("trade without timestamp: %v", err)
+	}
+}
+
+func TestAggregatorOHLCGroupByIntegrator(t *testing.T) {
+	start := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	trades := []Trade{
+		{Timestamp: start, Base: testKey(1), Quote: mintUSDC, BaseAmount: 1, QuoteAmount: 2, Integrator: "alpha"},
+		{Timestamp: start.Add(time.Second), Base: testKey(1), Quote: mintUSDC, BaseAmount: 1, QuoteAmount: 3, Integrator: "beta"},
+	}
+
+	merged := NewAggregatorOHLC([]time.Duration{time.Minute}, 0, nil)
+	grouped := NewAggregatorOHLC([]time.Duration{time.Minute}, 0, nil).GroupByIntegrator()
+	for _, tr := range trades {
+		if err := merged.Add(tr); err != nil {
+			t.Fatal(err)
+		}
+		if err := grouped.Add(tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if candles := merged.Snapshot(); len(candles) != 1 || candles[0].Trades != 2 || candles[0].Integrator != "" {
+		t.Errorf("merged candles %+v", candles)
+	}
+	candles := grouped.Snapshot()
+	if len(candles) != 2 || candles[0].Integrator != "alpha" || candles[1].Integrator != "beta" || candles[0].Trades != 1 || candles[1].Trades != 1 {
+		t.Errorf("grouped candles %+v", candles)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// ErrForbiddenRoute is matched by the error returned when a route uses a
+// forbidden variant and WithForbiddenRouteError is set
+var ErrForbiddenRoute = errors.New("route uses a forbidden swap variant")
+
+// ForbiddenRouteError reports the forbidden variants of a route. Analysis is
+// the complete analysis, kept for audit.
+type ForbiddenRouteError struct {
+	Variants []SwapType
+	Analysis *JupiterV6Analysis
+}
+
+// Error lists the forbidden variants
+func (e *ForbiddenRouteError) Error() string {
+	names := make([]string, len(e.Variants))
+	for i, variant := range e.Variants {
+		names[i] = string(variant)
+	}
+	return fmt.Sprintf("%v: %s", ErrForbiddenRoute, strings.Join(names, ", "))
+}
+
+// Is matches ErrForbiddenRoute
+func (e *ForbiddenRouteError) Is(target error) bool {
+	return target == ErrForbiddenRoute
+}
+
+// variantPrograms maps swap variants to the AMM program found in their events,
+// for the programs this package knows
+var variantPrograms = map[SwapType]solana.PublicKey{
+	SwapWhirlpool:                    whirlpoolProgramID,
+	SwapWhirlpoolSwapV2:              whirlpoolProgramID,
+	SwapRaydium:                      raydiumAmmV4ProgramID,
+	SwapRaydiumClmm:                  raydiumClmmProgramID,
+	SwapRaydiumClmmV2:                raydiumClmmProgramID,
+	SwapMeteoraDlmm:                  meteoraDlmmProgramID,
+	SwapPhoenix:                      phoenixProgramID,
+	SwapSanctumS:                     sanctumSProgramID,
+	SwapSanctumSAddLiquidity:         sanctumSProgramID,
+	SwapSanctumSRemoveLiquidity:      sanctumSProgramID,
+	SwapStakeDexStakeWrappedSol:      stakeDexProgramID,
+	SwapStakeDexSwapViaStake:         stakeDexProgramID,
+	SwapStakeDexPrefundWithdrawStake: stakeDexProgramID,
+	SwapPumpdotfunWrappedBuy:         pumpFunProgramID,
+	SwapPumpdotfunWrappedSell:        pumpFunProgramID,
+}
+
+// WithForbiddenVariants flags analyses whose route uses any of variants with a
+// PolicyViolation warning. Both the route plan and the AMM programs of the
+// events are checked, so a variant hidden behind an unknown index is still
+// caught when its program is known.
+func WithForbiddenVariants(variants ...SwapType) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.forbiddenVariants = make(map[SwapType]bool, len(variants))
+		for _, variant := range variants {
+			a.forbiddenVariants[variant] = true
+		}
+	}
+}
+
+// WithForbiddenRouteError makes AnalyzeSignature and AnalyzeConfirmedSignature
+// return a *ForbiddenRouteError, along with the analysis, when a forbidden
+// variant is used
+func WithForbiddenRouteError() AnalyzerOption {
+	return func(a *Analyzer) {
+		a.forbiddenRouteError = true
+	}
+}
+
+// checkForbiddenVariants records the forbidden variants used by the analysis
+func checkForbiddenVariants(analysis *JupiterV6Analysis, forbidden map[SwapType]bool) {
+	if len(forbidden) == 0 {
+		return
+	}
+
+	seen := make(map[SwapType]bool)
+	flag := func(variant SwapType, where string) {
+		if seen[variant] {
+			return
+		}
+		seen[variant] = true
+		analysis.ForbiddenVariants = append(analysis.ForbiddenVariants, variant)
+		analysis.addWarning(CodePolicyViolation, "route uses forbidden variant %s (%s)", variant, where)
+	}
+
+	for _, inst := range analysis.Instructions {
+		for i, step := range inst.RoutePlan {
+			if forbidden[step.Swap.Type] {
+				flag(step.Swap.Type, fmt.Sprintf("instruction %d step %d", inst.InstructionIndex, i))
+			}
+		}
+	}
+	variants := forbiddenInIndexOrder(forbidden)
+	for i, event := range analysis.Events {
+		for _, variant := range variants {
+			if program, ok := variantPrograms[variant]; ok && event.AMM.Equals(program) {
+				flag(variant, fmt.Sprintf("event %d", i))
+			}
+		}
+	}
+}
+
+// forbiddenInIndexOrder sorts the forbidden variants by swap variant index so
+// warnings are deterministic. Variants without an index come last by name.
+func forbiddenInIndexOrder(forbidden map[SwapType]bool) []SwapType {
+	variants := make([]SwapType, 0, len(forbidden))
+	for variant := range forbidden {
+		variants = append(variants, variant)
+	}
+	sort.Slice(variants, func(i, j int) bool {
+		a, aKnown := swapVariantIndex(variants[i])
+		b, bKnown := swapVariantIndex(variants[j])
+		if aKnown != bKnown {
+			return aKnown
+		}
+		if a != b {
+			return a < b
+		}
+		return variants[i] < variants[j]
+	})
+	return variants
+}
+
+// forbiddenRouteResult returns the analysis with a *ForbiddenRouteError when
+// WithForbiddenRouteError is set and the route uses a forbidden variant
+func (a *Analyzer) forbiddenRouteResult(analysis *JupiterV6Analysis, err error) (*JupiterV6Analysis, error) {
+	if err == nil && a.forbiddenRouteError && len(analysis.ForbiddenVariants) > 0 {
+		return analysis, &ForbiddenRouteError{Variants: analysis.ForbiddenVariants, Analysis: analysis}
+	}
+	return analysis, err
+}
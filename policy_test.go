@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// staticSource serves one transaction for every signature
+type staticSource struct {
+	tx *rpc.GetTransactionResult
+}
+
+func (s staticSource) GetTransaction(ctx context.Context, signature solana.Signature, opts *rpc.GetTransactionOpts) (*rpc.GetTransactionResult, error) {
+	return s.tx, nil
+}
+
+// policyTransaction routes through Whirlpool, then an unknown variant whose
+// event is emitted by Raydium CLMM
+func policyTransaction(t *testing.T) *rpc.GetTransactionResult {
+	payer, mintA, mintB, mintC := testKey(1), testKey(2), testKey(3), testKey(4)
+	keys := solana.PublicKeySlice{payer, jupiterV6ProgramID}
+	for i := byte(10); i < 20; i++ {
+		keys = append(keys, testKey(i))
+	}
+	steps := [][]byte{
+		{SwapTypeToIndex[SwapWhirlpool], 1, 100, 0, 1}, // a_to_b
+		testStep(250, 100, 1, 2),
+	}
+	data := testInstruction("route", 0, steps, 1000, 900, 50, 0)
+	parsedTx := &solana.Transaction{
+		Signatures: []solana.Signature{{1}},
+		Message: solana.Message{
+			Header:       solana.MessageHeader{NumRequiredSignatures: 1},
+			AccountKeys:  keys,
+			Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 1, Accounts: []uint16{2, 0, 3, 4, 1, 5, 1, 6, 1}, Data: data}},
+		},
+	}
+	events := []SwapEvent{
+		{AMM: whirlpoolProgramID, InputMint: mintA, InputAmount: 1000, OutputMint: mintB, OutputAmount: 950},
+		{AMM: raydiumClmmProgramID, InputMint: mintB, InputAmount: 950, OutputMint: mintC, OutputAmount: 900},
+	}
+	var inner []solana.CompiledInstruction
+	for _, event := range events {
+		inner = append(inner, solana.CompiledInstruction{ProgramIDIndex: 1, Data: event.Encode()})
+	}
+	meta := &rpc.TransactionMeta{InnerInstructions: []rpc.InnerInstruction{{Index: 0, Instructions: inner}}}
+	return testTransactionResult(t, parsedTx, meta)
+}
+
+// policyWarnings returns the messages of the PolicyViolation warnings
+func policyWarnings(analysis *JupiterV6Analysis) []string {
+	var messages []string
+	for _, warning := range analysis.Warnings {
+		if warning.Code == CodePolicyViolation {
+			messages = append(messages, warning.Message)
+		}
+	}
+	return messages
+}
+
+func TestForbiddenVariantsSoft(t *testing.T) {
+	tx := policyTransaction(t)
+	a := newTestAnalyzer(WithTransactionSource(staticSource{tx}), WithForbiddenVariants(SwapRaydiumClmmV2, SwapWhirlpool, SwapRaydiumClmm))
+	analysis, err := a.AnalyzeSignature(context.Background(), solana.Signature{1})
+	if err != nil {
+		t.Fatalf("soft mode returned %v", err)
+	}
+
+	// Whirlpool from the route plan, then the variants of the event program in
+	// variant index order
+	want := []SwapType{SwapWhirlpool, SwapRaydiumClmm, SwapRaydiumClmmV2}
+	if !reflect.DeepEqual(analysis.ForbiddenVariants, want) {
+		t.Errorf("forbidden variants %v, want %v", analysis.ForbiddenVariants, want)
+	}
+	warnings := policyWarnings(analysis)
+	if len(warnings) != 3 || warnings[0] != "route uses forbidden variant Whirlpool (instruction 0 step 0)" {
+		t.Errorf("warnings %q", warnings)
+	}
+	for i := 0; i < 10; i++ {
+		again, _ := a.AnalyzeSignature(context.Background(), solana.Signature{1})
+		if !reflect.DeepEqual(policyWarnings(again), warnings) {
+			t.Fatalf("warnings %q, then %q", warnings, policyWarnings(again))
+		}
+	}
+}
+
+func TestForbiddenVariantsUnknownVariant(t *testing.T) {
+	tx := policyTransaction(t)
+	a := newTestAnalyzer(WithTransactionSource(staticSource{tx}), WithForbiddenVariants(SwapRaydiumClmm))
+	analysis, err := a.AnalyzeSignature(context.Background(), solana.Signature{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Instructions[0].RoutePlan[1].Swap.Type != "Unknown_250" {
+		t.Fatalf("step 1 decoded as %s", analysis.Instructions[0].RoutePlan[1].Swap.Type)
+	}
+	// The unknown variant hides the hop from the route plan, its event does not
+	if warnings := policyWarnings(analysis); len(warnings) != 1 || warnings[0] != "route uses forbidden variant RaydiumClmm (event 1)" {
+		t.Errorf("warnings %q", warnings)
+	}
+}
+
+func TestForbiddenVariantsHard(t *testing.T) {
+	tx := policyTransaction(t)
+	a := newTestAnalyzer(WithTransactionSource(staticSource{tx}), WithForbiddenVariants(SwapWhirlpool), WithForbiddenRouteError())
+
+	analysis, err := a.AnalyzeSignature(context.Background(), solana.Signature{1})
+	var forbidden *ForbiddenRouteError
+	if !errors.Is(err, ErrForbiddenRoute) || !errors.As(err, &forbidden) {
+		t.Fatalf("error %v, want a ForbiddenRouteError", err)
+	}
+	if analysis == nil || forbidden.Analysis != analysis || len(analysis.Instructions) != 1 {
+		t.Errorf("analysis not kept for audit: %v", analysis)
+	}
+	if !reflect.DeepEqual(forbidden.Variants, []SwapType{SwapWhirlpool}) {
+		t.Errorf("variants %v", forbidden.Variants)
+	}
+
+	var results []BatchResult
+	a.AnalyzeBatch(context.Background(), []solana.Signature{{1}, {2}}, 2, func(result BatchResult) {
+		results = append(results, result)
+	})
+	for _, result := range results {
+		if result.Error == "" || result.Analysis == nil {
+			t.Errorf("batch result %s: error %q, analysis kept %v", result.Signature, result.Error, result.Analysis != nil)
+		}
+	}
+
+	clean := newTestAnalyzer(WithTransactionSource(staticSource{tx}), WithForbiddenVariants(SwapPhoenix), WithForbiddenRouteError())
+	if _, err := clean.AnalyzeSignature(context.Background(), solana.Signature{1}); err != nil {
+		t.Errorf("allowed route returned %v", err)
+	}
+}
+
+func TestForbiddenVariantsHardConfirmed(t *testing.T) {
+	// The rpc server only answers getSignatureStatuses, the transaction comes from the source
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":1},"value":[{"slot":1,"confirmations":null,"err":null,"confirmationStatus":"finalized"}]}}`)
+	}))
+	defer server.Close()
+
+	a := NewAnalyzer(rpc.New(server.URL), WithTransactionSource(staticSource{policyTransaction(t)}),
+		WithForbiddenVariants(SwapWhirlpool), WithForbiddenRouteError(), WithLogOutput(io.Discard))
+	analysis, err := a.AnalyzeConfirmedSignature(context.Background(), solana.Signature{1}, rpc.CommitmentConfirmed)
+	if !errors.Is(err, ErrForbiddenRoute) || analysis == nil {
+		t.Errorf("error %v, analysis kept %v", err, analysis != nil)
+	}
+}
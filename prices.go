@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// DerivedPrice is the last execution price of a canonical pair observed in a
+// time bucket, used to value long-tail tokens when no external price history
+// exists. Prices are in raw token units (quote per base, not adjusted for decimals).
+type DerivedPrice struct {
+	Base       solana.PublicKey `json:"base"`
+	Quote      solana.PublicKey `json:"quote"`
+	Bucket     time.Time        `json:"bucket"`
+	Price      string           `json:"price"` // exact to pricePrecision decimals
+	ObservedAt time.Time        `json:"observed_at"`
+	Signature  solana.Signature `json:"signature"` // swap the price was derived from
+
+	// Staleness is how long before the requested time the price was observed, set on lookups
+	Staleness time.Duration `json:"staleness,omitempty"`
+}
+
+// PriceStore persists derived prices per pair and bucket
+type PriceStore interface {
+	// Put records price, replacing an earlier observation of the same pair and bucket
+	Put(price DerivedPrice) error
+	// Latest returns the most recent price of the canonical pair observed at or before at
+	Latest(base, quote solana.PublicKey, at time.Time) (DerivedPrice, bool, error)
+}
+
+// MemoryPriceStore is an in-memory PriceStore
+type MemoryPriceStore struct {
+	mu     sync.RWMutex
+	prices map[PoolPair][]DerivedPrice // sorted by bucket
+}
+
+// NewMemoryPriceStore creates an empty in-memory price store
+func NewMemoryPriceStore() *MemoryPriceStore {
+	return &MemoryPriceStore{prices: make(map[PoolPair][]DerivedPrice)}
+}
+
+// Put records price, keeping the latest observation per bucket
+func (s *MemoryPriceStore) Put(price DerivedPrice) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pair := PoolPair{Base: price.Base, Quote: price.Quote}
+	series := s.prices[pair]
+	i := sort.Search(len(series), func(i int) bool { return !series[i].Bucket.Before(price.Bucket) })
+	if i < len(series) && series[i].Bucket.Equal(price.Bucket) {
+		if !price.ObservedAt.Before(series[i].ObservedAt) {
+			series[i] = price
+		}
+		return nil
+	}
+	series = append(series, DerivedPrice{})
+	copy(series[i+1:], series[i:])
+	series[i] = price
+	s.prices[pair] = series
+	return nil
+}
+
+// Latest returns the most recent price of the pair observed at or before at
+func (s *MemoryPriceStore) Latest(base, quote solana.PublicKey, at time.Time) (DerivedPrice, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	series := s.prices[PoolPair{Base: base, Quote: quote}]
+	for i := len(series) - 1; i >= 0; i-- {
+		if !series[i].ObservedAt.After(at) {
+			return series[i], true, nil
+		}
+	}
+	return DerivedPrice{}, false, nil
+}
+
+// PriceRecorder derives pair prices from analyzed swaps into a PriceStore
+type PriceRecorder struct {
+	store  PriceStore
+	bucket time.Duration
+}
+
+// NewPriceRecorder records the last execution price of every pair per bucket into store
+func NewPriceRecorder(store PriceStore, bucket time.Duration) *PriceRecorder {
+	return &PriceRecorder{store: store, bucket: bucket}
+}
+
+// Record stores the prices of the swaps of analysis, executed at timestamp in signature
+func (r *PriceRecorder) Record(signature solana.Signature, analysis *JupiterV6Analysis, timestamp time.Time) error {
+	for _, trade := range TradesFromAnalysis(analysis, timestamp) {
+		if trade.Timestamp.IsZero() {
+			return fmt.Errorf("%w: price of %s cannot be recorded", ErrUnknownTimestamp, signature)
+		}
+		err := r.store.Put(DerivedPrice{
+			Base:       trade.Base,
+			Quote:      trade.Quote,
+			Bucket:     trade.Timestamp.Truncate(r.bucket).UTC(),
+			Price:      trade.Price,
+			ObservedAt: trade.Timestamp,
+			Signature:  signature,
+		})
+		if err != nil {
+			return fmt.Errorf("error storing price of %s/%s: %v", trade.Base, trade.Quote, err)
+		}
+	}
+	return nil
+}
+
+// HistoricalPrice returns the price of base in quote units at time at, derived
+// from the latest swap of the pair no more than maxStaleness earlier. Pairs
+// stored in the other orientation are inverted.
+func HistoricalPrice(store PriceStore, base, quote solana.PublicKey, at time.Time, maxStaleness time.Duration) (*DerivedPrice, error) {
+	canonicalBase, canonicalQuote := canonicalPair(base, quote)
+	price, ok, err := store.Latest(canonicalBase, canonicalQuote, at)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("no derived price for %s/%s before %s", base, quote, at)
+	}
+	price.Staleness = at.Sub(price.ObservedAt)
+	if price.Staleness > maxStaleness {
+		return nil, fmt.Errorf("derived price for %s/%s is %s old", base, quote, price.Staleness)
+	}
+
+	if !canonicalBase.Equals(base) {
+		rat, ok := new(big.Rat).SetString(price.Price)
+		if !ok || rat.Sign() == 0 {
+			return nil, fmt.Errorf("derived price %q of %s/%s cannot be inverted", price.Price, canonicalBase, canonicalQuote)
+		}
+		price.Base, price.Quote = base, quote
+		price.Price = rat.Inv(rat).FloatString(pricePrecision)
+	}
+	return &price, nil
+}
+
+// ValueAt values a raw amount of mint in raw quote units at time at using
+// HistoricalPrice, returning the value with the price it was derived from
+func ValueAt(store PriceStore, amount uint64, mint, quote solana.PublicKey, at time.Time, maxStaleness time.Duration) (*BigAmount, *DerivedPrice, error) {
+	price, err := HistoricalPrice(store, mint, quote, at, maxStaleness)
+	if err != nil {
+		return nil, nil, err
+	}
+	rat, ok := new(big.Rat).SetString(price.Price)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid derived price %q", price.Price)
+	}
+	rat.Mul(rat, new(big.Rat).SetInt(new(big.Int).SetUint64(amount)))
+	return bigAmountOf(new(big.Int).Quo(rat.Num(), rat.Denom())), price, nil
+}
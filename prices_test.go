@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestMemoryPriceStoreLatest(t *testing.T) {
+	base, quote := testKey(2), testKey(3)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	price := func(bucket, observed time.Duration, value string) DerivedPrice {
+		return DerivedPrice{Base: base, Quote: quote, Bucket: start.Add(bucket), ObservedAt: start.Add(observed), Price: value}
+	}
+
+	store := NewMemoryPriceStore()
+	// Put out of bucket order, the store keeps the series sorted
+	for _, p := range []DerivedPrice{
+		price(2*time.Minute, 2*time.Minute+30*time.Second, "3"),
+		price(0, 10*time.Second, "1"),
+		price(time.Minute, time.Minute+20*time.Second, "2"),
+		// An older observation of a stored bucket is ignored
+		price(time.Minute, time.Minute+5*time.Second, "stale"),
+		// A newer one replaces it
+		price(0, 40*time.Second, "1b"),
+	} {
+		if err := store.Put(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if series := store.prices[PoolPair{Base: base, Quote: quote}]; len(series) != 3 ||
+		!series[0].Bucket.Equal(start) || !series[1].Bucket.Equal(start.Add(time.Minute)) || !series[2].Bucket.Equal(start.Add(2*time.Minute)) {
+		t.Fatalf("series not sorted by bucket: %+v", series)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		at    time.Duration
+		price string
+		found bool
+	}{
+		{"before the first observation", 0, "", false},
+		{"at an observation", 40 * time.Second, "1b", true},
+		{"between buckets", 50 * time.Second, "1b", true},
+		{"in a bucket before its observation", time.Minute + 10*time.Second, "1b", true},
+		{"at the start of a later bucket", 2 * time.Minute, "2", true},
+		{"after the last observation", time.Hour, "3", true},
+	} {
+		got, found, err := store.Latest(base, quote, start.Add(tt.at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found != tt.found || got.Price != tt.price {
+			t.Errorf("%s: %q %v, want %q %v", tt.name, got.Price, found, tt.price, tt.found)
+		}
+	}
+	if _, found, _ := store.Latest(quote, base, start.Add(time.Hour)); found {
+		t.Error("pair found in the other orientation")
+	}
+}
+
+func TestPriceRecorder(t *testing.T) {
+	tx, parsedTx := ledgerFixture(t)
+	analysis := analyzeTest(t, newTestAnalyzer(), tx, parsedTx)
+	store := NewMemoryPriceStore()
+	recorder := NewPriceRecorder(store, time.Minute)
+
+	at := time.Date(2024, 3, 1, 12, 0, 42, 0, time.UTC)
+	if err := recorder.Record(solana.Signature{1}, analysis, at); err != nil {
+		t.Fatal(err)
+	}
+	// The swap of 1000 A into 955 B prices A at 0.955 B
+	price, err := HistoricalPrice(store, testKey(2), testKey(3), at.Add(time.Minute), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price.Price != "0.955000000000000000" || !price.Bucket.Equal(at.Truncate(time.Minute)) ||
+		price.Signature != (solana.Signature{1}) || price.Staleness != time.Minute {
+		t.Errorf("recorded %+v", price)
+	}
+	inverted, err := HistoricalPrice(store, testKey(3), testKey(2), at, time.Hour)
+	if err != nil || inverted.Price != "1.047120418848167539" {
+		t.Errorf("inverted %+v, %v", inverted, err)
+	}
+	if _, err := HistoricalPrice(store, testKey(2), testKey(3), at.Add(2*time.Hour), time.Hour); err == nil {
+		t.Error("stale price returned")
+	}
+
+	// Without a timestamp the analysis time is unknown
+	if err := recorder.Record(solana.Signature{2}, analysis, time.Time{}); !errors.Is(err, ErrUnknownTimestamp) {
+		t.Errorf("Record without time: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// setComputeUnitPriceTag is the ComputeBudget instruction tag of SetComputeUnitPrice
+const setComputeUnitPriceTag = 3
+
+// maxCachedBlocks bounds the number of slots kept by the rpc block fee cache
+const maxCachedBlocks = 256
+
+// ComputeUnitPrice returns the micro-lamport compute unit price set by the
+// transaction's SetComputeUnitPrice instruction, if any
+func ComputeUnitPrice(tx *solana.Transaction) (uint64, bool) {
+	for _, inst := range tx.Message.Instructions {
+		if int(inst.ProgramIDIndex) >= len(tx.Message.AccountKeys) || !tx.Message.AccountKeys[inst.ProgramIDIndex].Equals(solana.ComputeBudget) {
+			continue
+		}
+		if len(inst.Data) >= 9 && inst.Data[0] == setComputeUnitPriceTag {
+			return binary.LittleEndian.Uint64(inst.Data[1:9]), true
+		}
+	}
+	return 0, false
+}
+
+// PriorityFee rates the compute unit price of a transaction against its block
+type PriorityFee struct {
+	ComputeUnitPrice uint64 `json:"compute_unit_price"` // micro-lamports, 0 when not set
+	// Percentile is the percentile rank (0-100) of ComputeUnitPrice among the
+	// block transactions that set a price, ties count half
+	Percentile float64 `json:"percentile"`
+	// BlockMedian is the median compute unit price of those transactions
+	BlockMedian uint64 `json:"block_median"`
+	// BlockPriced is the number of block transactions that set a price
+	BlockPriced int `json:"block_priced"`
+}
+
+// ratePriorityFee computes the percentile and median of price among the block prices
+func ratePriorityFee(price uint64, blockPrices []uint64) *PriorityFee {
+	fee := &PriorityFee{ComputeUnitPrice: price, BlockPriced: len(blockPrices)}
+	if len(blockPrices) == 0 {
+		return fee
+	}
+
+	sorted := make([]uint64, len(blockPrices))
+	copy(sorted, blockPrices)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	below := sort.Search(len(sorted), func(i int) bool { return sorted[i] >= price })
+	equal := sort.Search(len(sorted), func(i int) bool { return sorted[i] > price }) - below
+	fee.Percentile = (float64(below) + float64(equal)/2) / float64(len(sorted)) * 100
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		fee.BlockMedian = sorted[mid]
+	} else {
+		// Average without overflowing
+		fee.BlockMedian = sorted[mid-1] + (sorted[mid]-sorted[mid-1])/2
+	}
+	return fee
+}
+
+// BlockFeeProvider supplies the compute unit prices set by the transactions of a block
+type BlockFeeProvider interface {
+	BlockComputeUnitPrices(ctx context.Context, slot uint64) ([]uint64, error)
+}
+
+// rpcBlockFees fetches blocks over rpc, caching the prices of each slot
+type rpcBlockFees struct {
+	client     *rpc.Client
+	commitment rpc.CommitmentType
+
+	mu    sync.Mutex
+	cache map[uint64][]uint64
+	slots []uint64 // cached slots, oldest first
+}
+
+// NewRPCBlockFeeProvider fetches full blocks with client. A block is fetched
+// once, the prices of the last maxCachedBlocks slots are kept. Analyzers fetch
+// at their own commitment, commitment applies to other callers. Blocks are not
+// served at processed, they are then fetched at confirmed.
+func NewRPCBlockFeeProvider(client *rpc.Client, commitment rpc.CommitmentType) BlockFeeProvider {
+	return &rpcBlockFees{
+		client:     client,
+		commitment: commitment,
+		cache:      make(map[uint64][]uint64),
+	}
+}
+
+// BlockComputeUnitPrices returns the cached prices of slot, fetching the block on a miss
+func (p *rpcBlockFees) BlockComputeUnitPrices(ctx context.Context, slot uint64) ([]uint64, error) {
+	p.mu.Lock()
+	prices, ok := p.cache[slot]
+	p.mu.Unlock()
+	if ok {
+		return prices, nil
+	}
+
+	rewards := false
+	maxVersion := uint64(0)
+	block, err := p.client.GetBlockWithOpts(ctx, slot, &rpc.GetBlockOpts{
+		Encoding:                       solana.EncodingBase64,
+		TransactionDetails:             rpc.TransactionDetailsFull,
+		Rewards:                        &rewards,
+		Commitment:                     transactionCommitment(CommitmentFromContext(ctx, p.commitment)),
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching block: %v", err)
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %d not available", slot)
+	}
+
+	prices = []uint64{}
+	for _, blockTx := range block.Transactions {
+		_, parsedTx, err := blockTransaction(slot, blockTx)
+		if err != nil {
+			continue
+		}
+		if price, ok := ComputeUnitPrice(parsedTx); ok {
+			prices = append(prices, price)
+		}
+	}
+
+	p.mu.Lock()
+	if _, ok := p.cache[slot]; !ok {
+		p.cache[slot] = prices
+		p.slots = append(p.slots, slot)
+		if len(p.slots) > maxCachedBlocks {
+			delete(p.cache, p.slots[0])
+			p.slots = p.slots[1:]
+		}
+	}
+	p.mu.Unlock()
+	return prices, nil
+}
+
+// WithPriorityFeeProvider rates the compute unit price of each analyzed
+// transaction against its block. Every uncached slot costs a full block fetch.
+func WithPriorityFeeProvider(provider BlockFeeProvider) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.blockFees = provider
+	}
+}
+
+// attachPriorityFee sets analysis.PriorityFee from the prices of the transaction block
+func attachPriorityFee(ctx context.Context, analysis *JupiterV6Analysis, slot uint64, parsedTx *solana.Transaction, provider BlockFeeProvider) {
+	if provider == nil {
+		return
+	}
+	prices, err := provider.BlockComputeUnitPrices(ctx, slot)
+	if err != nil {
+		analysis.addWarning(CodePriorityFeeUnavailable, "slot %d: %v", slot, err)
+		return
+	}
+	price, _ := ComputeUnitPrice(parsedTx)
+	analysis.PriorityFee = ratePriorityFee(price, prices)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRatePriorityFee(t *testing.T) {
+	const top = math.MaxUint64
+	for _, tt := range []struct {
+		name  string
+		price uint64
+		block []uint64
+		want  PriorityFee
+	}{
+		{"empty block", 500, nil, PriorityFee{ComputeUnitPrice: 500}},
+		{"all tied", 100, []uint64{100, 100, 100, 100}, PriorityFee{ComputeUnitPrice: 100, Percentile: 50, BlockMedian: 100, BlockPriced: 4}},
+		{"odd count", 20, []uint64{30, 10, 20}, PriorityFee{ComputeUnitPrice: 20, Percentile: 50, BlockMedian: 20, BlockPriced: 3}},
+		{"even count", 25, []uint64{40, 10, 30, 20}, PriorityFee{ComputeUnitPrice: 25, Percentile: 50, BlockMedian: 25, BlockPriced: 4}},
+		{"even count median near MaxUint64", top, []uint64{top, top - 1, 1, top}, PriorityFee{ComputeUnitPrice: top, Percentile: 75, BlockMedian: top - 1, BlockPriced: 4}},
+		{"even count median of MaxUint64", 0, []uint64{top, top}, PriorityFee{BlockMedian: top, BlockPriced: 2}},
+		{"above every block price", 1000, []uint64{10, 20, 30, 40}, PriorityFee{ComputeUnitPrice: 1000, Percentile: 100, BlockMedian: 25, BlockPriced: 4}},
+		{"below every block price", 5, []uint64{10, 20, 30, 40}, PriorityFee{ComputeUnitPrice: 5, Percentile: 0, BlockMedian: 25, BlockPriced: 4}},
+	} {
+		block := append([]uint64(nil), tt.block...)
+		got := ratePriorityFee(tt.price, block)
+		if *got != tt.want {
+			t.Errorf("%s: %+v, want %+v", tt.name, *got, tt.want)
+		}
+		if !reflect.DeepEqual(block, tt.block) {
+			t.Errorf("%s: block prices reordered to %v", tt.name, block)
+		}
+	}
+}
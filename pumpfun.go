@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// pumpFunProgramID is the pump.fun bonding curve program
+var pumpFunProgramID = solana.MustPublicKeyFromBase58("6EF8rrecthR5Dkzon8Nwu78hRvfCKubJ14M5uBEwF6P")
+
+// bondingCurveDiscriminator is the anchor account discriminator of BondingCurve
+var bondingCurveDiscriminator = func() []byte {
+	sum := sha256.Sum256([]byte("account:BondingCurve"))
+	return sum[:8]
+}()
+
+// pumpFunInitialRealTokenReserves is the real token reserve of a new curve, the
+// curve graduates once it is sold out
+const pumpFunInitialRealTokenReserves = 793_100_000_000_000
+
+// BondingCurveState is the decoded pump.fun bonding curve of a route step token
+type BondingCurveState struct {
+	Account              solana.PublicKey `json:"account"`
+	Mint                 solana.PublicKey `json:"mint"`
+	VirtualTokenReserves uint64           `json:"virtual_token_reserves"`
+	VirtualSolReserves   uint64           `json:"virtual_sol_reserves"`
+	RealTokenReserves    uint64           `json:"real_token_reserves"`
+	RealSolReserves      uint64           `json:"real_sol_reserves"`
+	TokenTotalSupply     uint64           `json:"token_total_supply"`
+	Complete             bool             `json:"complete"`
+
+	// Price is the implied lamports per raw token unit, from the virtual reserves
+	Price string `json:"price"`
+	// Progress is the percent of the real token reserve sold, 100 at graduation
+	Progress float64 `json:"progress"`
+}
+
+// BondingCurveAddress derives the bonding curve account of a pump.fun mint
+func BondingCurveAddress(mint solana.PublicKey) (solana.PublicKey, error) {
+	address, _, err := solana.FindProgramAddress([][]byte{[]byte("bonding-curve"), mint[:]}, pumpFunProgramID)
+	return address, err
+}
+
+// DecodeBondingCurve decodes a bonding curve account:
+// discriminator [8]u8, virtual_token_reserves u64, virtual_sol_reserves u64,
+// real_token_reserves u64, real_sol_reserves u64, token_total_supply u64, complete bool
+func DecodeBondingCurve(account, mint solana.PublicKey, data []byte) (*BondingCurveState, error) {
+	if len(data) < 8+5*8+1 {
+		return nil, fmt.Errorf("bonding curve account too short: %d bytes", len(data))
+	}
+	if !bytes.Equal(data[:8], bondingCurveDiscriminator) {
+		return nil, fmt.Errorf("account %s is not a bonding curve", account)
+	}
+
+	state := &BondingCurveState{
+		Account:              account,
+		Mint:                 mint,
+		VirtualTokenReserves: binary.LittleEndian.Uint64(data[8:16]),
+		VirtualSolReserves:   binary.LittleEndian.Uint64(data[16:24]),
+		RealTokenReserves:    binary.LittleEndian.Uint64(data[24:32]),
+		RealSolReserves:      binary.LittleEndian.Uint64(data[32:40]),
+		TokenTotalSupply:     binary.LittleEndian.Uint64(data[40:48]),
+		Complete:             data[48] != 0,
+	}
+	if state.VirtualTokenReserves > 0 {
+		price := new(big.Rat).SetFrac(new(big.Int).SetUint64(state.VirtualSolReserves), new(big.Int).SetUint64(state.VirtualTokenReserves))
+		state.Price = price.FloatString(pricePrecision)
+	}
+	if state.Complete || state.RealTokenReserves == 0 {
+		state.Progress = 100
+	} else if state.RealTokenReserves < pumpFunInitialRealTokenReserves {
+		state.Progress = 100 * float64(pumpFunInitialRealTokenReserves-state.RealTokenReserves) / pumpFunInitialRealTokenReserves
+	}
+	return state, nil
+}
+
+// BondingCurveProvider supplies the bonding curve account data of pump.fun mints
+type BondingCurveProvider interface {
+	BondingCurve(ctx context.Context, mint solana.PublicKey) (*BondingCurveState, error)
+}
+
+// BondingCurveSnapshot is a BondingCurveProvider backed by recorded bonding
+// curve accounts, keyed by curve address
+type BondingCurveSnapshot map[solana.PublicKey][]byte
+
+// BondingCurve decodes the recorded curve of mint
+func (s BondingCurveSnapshot) BondingCurve(ctx context.Context, mint solana.PublicKey) (*BondingCurveState, error) {
+	account, err := BondingCurveAddress(mint)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := s[account]
+	if !ok {
+		return nil, fmt.Errorf("bonding curve %s not in snapshot", account)
+	}
+	return DecodeBondingCurve(account, mint, data)
+}
+
+// cachedCurve is a fetched curve and when it expires
+type cachedCurve struct {
+	state   *BondingCurveState
+	expires time.Time
+}
+
+// rpcBondingCurves fetches curve accounts over rpc with a short lived cache
+type rpcBondingCurves struct {
+	client     *rpc.Client
+	commitment rpc.CommitmentType
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu    sync.Mutex
+	cache map[solana.PublicKey]cachedCurve
+}
+
+// NewRPCBondingCurveProvider fetches curve accounts with client. Curves change
+// with every trade, fetched states are reused for ttl only. now defaults to time.Now.
+// Analyzers fetch at their own commitment, commitment applies to other callers.
+func NewRPCBondingCurveProvider(client *rpc.Client, commitment rpc.CommitmentType, ttl time.Duration, now func() time.Time) BondingCurveProvider {
+	if now == nil {
+		now = time.Now
+	}
+	return &rpcBondingCurves{
+		client:     client,
+		commitment: commitment,
+		ttl:        ttl,
+		now:        now,
+		cache:      make(map[solana.PublicKey]cachedCurve),
+	}
+}
+
+// BondingCurve returns the cached curve of mint, fetching it when missing or expired
+func (p *rpcBondingCurves) BondingCurve(ctx context.Context, mint solana.PublicKey) (*BondingCurveState, error) {
+	now := p.now()
+	p.mu.Lock()
+	cached, ok := p.cache[mint]
+	p.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.state, nil
+	}
+
+	account, err := BondingCurveAddress(mint)
+	if err != nil {
+		return nil, err
+	}
+	info, err := p.client.GetAccountInfoWithOpts(ctx, account, &rpc.GetAccountInfoOpts{Commitment: CommitmentFromContext(ctx, p.commitment)})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching bonding curve: %v", err)
+	}
+	state, err := DecodeBondingCurve(account, mint, info.GetBinary())
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.cache[mint] = cachedCurve{state: state, expires: now.Add(p.ttl)}
+	p.mu.Unlock()
+	return state, nil
+}
+
+// WithBondingCurveProvider attaches the bonding curve state to pump.fun wrapped route steps
+func WithBondingCurveProvider(provider BondingCurveProvider) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.bondingCurves = provider
+	}
+}
+
+// attachBondingCurves finds the token of each PumpdotfunWrappedBuy/Sell step and
+// attaches its curve. A step token is an event mint whose derived bonding curve
+// is one of the instruction accounts; tokens are assigned to steps in order.
+func attachBondingCurves(ctx context.Context, analysis *JupiterV6Analysis, parsedTx *solana.Transaction, provider BondingCurveProvider) {
+	if provider == nil {
+		return
+	}
+
+	for i := range analysis.Instructions {
+		params := &analysis.Instructions[i]
+		var steps []*RoutePlanStep
+		for j := range params.RoutePlan {
+			switch params.RoutePlan[j].Swap.Type {
+			case SwapPumpdotfunWrappedBuy, SwapPumpdotfunWrappedSell:
+				steps = append(steps, &params.RoutePlan[j])
+			}
+		}
+		if len(steps) == 0 || params.InstructionIndex >= len(parsedTx.Message.Instructions) {
+			continue
+		}
+
+		accounts := make(map[solana.PublicKey]bool)
+		for _, key := range instructionAccountKeys(parsedTx.Message.Instructions[params.InstructionIndex], parsedTx.Message.AccountKeys) {
+			accounts[key] = true
+		}
+
+		seen := make(map[solana.PublicKey]bool)
+		var mints []solana.PublicKey
+		for _, event := range analysis.Events {
+			for _, mint := range []solana.PublicKey{event.InputMint, event.OutputMint} {
+				if seen[mint] || mint.Equals(solana.SolMint) {
+					continue
+				}
+				seen[mint] = true
+				if curve, err := BondingCurveAddress(mint); err == nil && accounts[curve] {
+					mints = append(mints, mint)
+				}
+			}
+		}
+
+		for j, step := range steps {
+			if j >= len(mints) {
+				break
+			}
+			state, err := provider.BondingCurve(ctx, mints[j])
+			if err != nil {
+				continue
+			}
+			step.BondingCurve = state
+		}
+	}
+}
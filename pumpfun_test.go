@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadCurveFixtures reads the base64 bonding curve accounts in testdata/curves
+func loadCurveFixtures(t *testing.T) map[string][]byte {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", "curves", "snapshot.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var encoded map[string]string
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		t.Fatal(err)
+	}
+	curves := make(map[string][]byte, len(encoded))
+	for name, data := range encoded {
+		if curves[name], err = base64.StdEncoding.DecodeString(data); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	return curves
+}
+
+func TestDecodeBondingCurve(t *testing.T) {
+	curves := loadCurveFixtures(t)
+	for _, tt := range []struct {
+		name     string
+		price    string
+		progress float64
+		complete bool
+	}{
+		{"new", "0.000027958993476235", 0, false},
+		{"active", "0.000111835973904939", 100 * 536_500_000_000_000.0 / pumpFunInitialRealTokenReserves, false},
+		{"complete", "0.000410880168120757", 100, true},
+	} {
+		mint := testKey(2)
+		account, err := BondingCurveAddress(mint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Through the snapshot provider, which derives the curve address
+		state, err := BondingCurveSnapshot{account: curves[tt.name]}.BondingCurve(context.Background(), mint)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if state.Account != account || state.Mint != mint {
+			t.Errorf("%s: account %s mint %s", tt.name, state.Account, state.Mint)
+		}
+		if state.Price != tt.price {
+			t.Errorf("%s: price %s, want %s", tt.name, state.Price, tt.price)
+		}
+		if math.Abs(state.Progress-tt.progress) > 1e-9 {
+			t.Errorf("%s: progress %v, want %v", tt.name, state.Progress, tt.progress)
+		}
+		if state.Complete != tt.complete {
+			t.Errorf("%s: complete %v, want %v", tt.name, state.Complete, tt.complete)
+		}
+		if state.TokenTotalSupply != 1_000_000_000_000_000 {
+			t.Errorf("%s: total supply %d", tt.name, state.TokenTotalSupply)
+		}
+	}
+}
+
+func TestDecodeBondingCurveInvalid(t *testing.T) {
+	data := loadCurveFixtures(t)["active"]
+	wrongDiscriminator := append([]byte{}, data...)
+	wrongDiscriminator[0] ^= 0xff
+	for name, data := range map[string][]byte{
+		"short":               data[:48],
+		"wrong discriminator": wrongDiscriminator,
+	} {
+		if state, err := DecodeBondingCurve(testKey(1), testKey(2), data); err == nil {
+			t.Errorf("%s: decoded %+v", name, state)
+		}
+	}
+	if _, err := (BondingCurveSnapshot{}).BondingCurve(context.Background(), testKey(2)); err == nil {
+		t.Error("curve missing from the snapshot decoded")
+	}
+
+	// A drained real reserve counts as graduated before the flag is set
+	drained := append([]byte{}, data...)
+	copy(drained[24:32], make([]byte, 8))
+	state, err := DecodeBondingCurve(testKey(1), testKey(2), drained)
+	if err != nil || state.Complete || state.Progress != 100 {
+		t.Errorf("drained curve: %+v, %v", state, err)
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+)
+
+// quoteResponse is the subset of the Jupiter API quoteResponse used for route reconstruction
+type quoteResponse struct {
+	InputMint            string `json:"inputMint"`
+	InAmount             string `json:"inAmount"`
+	OutputMint           string `json:"outputMint"`
+	OutAmount            string `json:"outAmount"`
+	OtherAmountThreshold string `json:"otherAmountThreshold"`
+	SwapMode             string `json:"swapMode"`
+	SlippageBps          uint16 `json:"slippageBps"`
+	PlatformFee          *struct {
+		Amount string `json:"amount"`
+		FeeBps uint8  `json:"feeBps"`
+	} `json:"platformFee"`
+	RoutePlan []struct {
+		SwapInfo struct {
+			AmmKey     string `json:"ammKey"`
+			Label      string `json:"label"`
+			InputMint  string `json:"inputMint"`
+			OutputMint string `json:"outputMint"`
+			InAmount   string `json:"inAmount"`
+			OutAmount  string `json:"outAmount"`
+		} `json:"swapInfo"`
+		Percent int `json:"percent"`
+	} `json:"routePlan"`
+}
+
+// quoteLabelSwapTypes translates Jupiter API AMM labels to swap types.
+// Labels that are not listed become a Label_ swap type carrying the API label.
+var quoteLabelSwapTypes = map[string]SwapType{
+	"Saber":                 SwapSaber,
+	"Saber (Decimals)":      SwapSaberAddDecimalsDeposit,
+	"Token Swap":            SwapTokenSwap,
+	"Orca V1":               SwapTokenSwap,
+	"Orca V2":               SwapTokenSwap,
+	"Step":                  SwapStep,
+	"Cropper":               SwapCropper,
+	"Raydium":               SwapRaydium,
+	"Crema":                 SwapCrema,
+	"Lifinity V1":           SwapLifinity,
+	"Lifinity V2":           SwapLifinityV2,
+	"Mercurial":             SwapMercurial,
+	"Cykura":                SwapCykura,
+	"Serum":                 SwapSerum,
+	"Marinade":              SwapMarinadeDeposit,
+	"Aldrin":                SwapAldrin,
+	"Aldrin V2":             SwapAldrinV2,
+	"Whirlpool":             SwapWhirlpool,
+	"Invariant":             SwapInvariant,
+	"Meteora":               SwapMeteora,
+	"GooseFX":               SwapGooseFX,
+	"GooseFX GAMMA":         SwapGooseFXV2,
+	"DeltaFi":               SwapDeltaFi,
+	"Balansol":              SwapBalansol,
+	"Marco Polo":            SwapMarcoPolo,
+	"Dradex":                SwapDradex,
+	"Raydium CLMM":          SwapRaydiumClmm,
+	"Openbook":              SwapOpenbook,
+	"Phoenix":               SwapPhoenix,
+	"Symmetry":              SwapSymmetry,
+	"Helium Network":        SwapHeliumTreasuryManagement,
+	"Perps":                 SwapPerps,
+	"Meteora DLMM":          SwapMeteoraDlmm,
+	"OpenBook V2":           SwapOpenBookV2,
+	"Clone Protocol":        SwapClone,
+	"Sanctum":               SwapStakeDexSwapViaStake,
+	"Sanctum Infinity":      SwapSanctumS,
+	"Raydium CP":            SwapRaydiumCP,
+	"1DEX":                  SwapOneIntro,
+	"Pump.fun":              SwapPumpdotfunWrappedBuy,
+	"Moonshot":              SwapMoonshotWrappedBuy,
+	"Stabble Stable Swap":   SwapStabbleStableSwap,
+	"Stabble Weighted Swap": SwapStabbleWeightedSwap,
+	"Obric V2":              SwapObric,
+	"Fox":                   SwapFoxBuyFromEstimatedCost,
+	"SolFi":                 SwapSolFi,
+	"Woofi":                 Woofi,
+	"Pump.fun Amm":          SwapPumpdotfunAmmBuy,
+}
+
+// ParseQuoteResponse converts a Jupiter API quoteResponse into the same
+// JupiterSwapParams produced from on-chain route instructions. Route plan
+// token indices are assigned in order of first appearance, starting with the
+// input mint. Each step keeps the API amm_key, label, mints and amounts in its params.
+func ParseQuoteResponse(raw []byte) (*JupiterSwapParams, error) {
+	var quote quoteResponse
+	if err := json.Unmarshal(raw, &quote); err != nil {
+		return nil, fmt.Errorf("error decoding quote response: %v", err)
+	}
+
+	inAmount, err := parseQuoteAmount(quote.InAmount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inAmount: %v", err)
+	}
+	outAmount, err := parseQuoteAmount(quote.OutAmount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid outAmount: %v", err)
+	}
+	threshold, err := parseQuoteAmount(quote.OtherAmountThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("invalid otherAmountThreshold: %v", err)
+	}
+
+	tokenIndices := map[string]uint8{quote.InputMint: 0}
+	indexOf := func(mint string) uint8 {
+		if index, ok := tokenIndices[mint]; ok {
+			return index
+		}
+		index := uint8(len(tokenIndices))
+		tokenIndices[mint] = index
+		return index
+	}
+
+	routePlan := make([]RoutePlanStep, 0, len(quote.RoutePlan))
+	for i, step := range quote.RoutePlan {
+		if step.Percent < 0 || step.Percent > 100 {
+			return nil, fmt.Errorf("route plan step %d has invalid percent %d", i, step.Percent)
+		}
+		stepIn, err := parseQuoteAmount(step.SwapInfo.InAmount)
+		if err != nil {
+			return nil, fmt.Errorf("route plan step %d: invalid inAmount: %v", i, err)
+		}
+		stepOut, err := parseQuoteAmount(step.SwapInfo.OutAmount)
+		if err != nil {
+			return nil, fmt.Errorf("route plan step %d: invalid outAmount: %v", i, err)
+		}
+
+		swapType, ok := quoteLabelSwapTypes[step.SwapInfo.Label]
+		if !ok {
+			swapType = SwapType(quoteLabelSwapTypePrefix + step.SwapInfo.Label)
+		}
+
+		routePlan = append(routePlan, RoutePlanStep{
+			Swap: Swap{Type: swapType, Params: map[string]interface{}{
+				"amm_key":     step.SwapInfo.AmmKey,
+				"label":       step.SwapInfo.Label,
+				"input_mint":  step.SwapInfo.InputMint,
+				"output_mint": step.SwapInfo.OutputMint,
+				"in_amount":   stepIn,
+				"out_amount":  stepOut,
+			}},
+			Percent:     uint8(step.Percent),
+			InputIndex:  indexOf(step.SwapInfo.InputMint),
+			OutputIndex: indexOf(step.SwapInfo.OutputMint),
+		})
+	}
+
+	params := &JupiterSwapParams{
+		RoutePlan:   routePlan,
+		SlippageBps: quote.SlippageBps,
+	}
+	if quote.PlatformFee != nil {
+		params.PlatformFeeBps = quote.PlatformFee.FeeBps
+	}
+
+	if quote.SwapMode == "ExactOut" {
+		params.InstructionType = "exactOutRoute"
+		params.OutAmount = outAmount
+		params.QuotedInAmount = inAmount
+		params.MinAmountOut = threshold // For exactOut, this is actually the max input amount
+		if threshold > inAmount {
+			params.SlippageAllowance = threshold - inAmount
+		}
+	} else {
+		params.InstructionType = "route"
+		params.InAmount = inAmount
+		params.QuotedOutAmount = outAmount
+		params.MinAmountOut = threshold
+		if outAmount > threshold {
+			params.SlippageAllowance = outAmount - threshold
+		}
+	}
+
+	return params, nil
+}
+
+// parseQuoteAmount parses the string encoded u64 amounts of the Jupiter API
+func parseQuoteAmount(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// QuoteComparison describes how an executed analysis differs from a quote
+type QuoteComparison struct {
+	QuotedSteps       int        `json:"quoted_steps"`
+	ExecutedSteps     int        `json:"executed_steps"`
+	QuotedSwapTypes   []SwapType `json:"quoted_swap_types"`
+	ExecutedSwapTypes []SwapType `json:"executed_swap_types"`
+	SameRoute         bool       `json:"same_route"`
+	QuotedAmount      uint64     `json:"quoted_amount"`
+	ExecutedAmount    uint64     `json:"executed_amount"`
+	ExactOut          bool       `json:"exact_out"`
+	// ShortfallBps is how much worse than quoted the execution was, in bps of
+	// the quoted amount: less output for exactIn, more input for exactOut.
+	// Negative when the execution beat the quote, zero without events.
+	ShortfallBps int64 `json:"shortfall_bps"`
+}
+
+// CompareQuoteToExecution diffs a quote (from ParseQuoteResponse or an on-chain
+// instruction) against an executed analysis. Amounts are compared in the output
+// token for exactIn and in the input token for exactOut.
+func CompareQuoteToExecution(quote *JupiterSwapParams, analysis *JupiterV6Analysis) *QuoteComparison {
+	comparison := &QuoteComparison{
+		QuotedSteps: len(quote.RoutePlan),
+		ExactOut:    isExactOutInstruction(quote.InstructionType),
+	}
+	for _, step := range quote.RoutePlan {
+		comparison.QuotedSwapTypes = append(comparison.QuotedSwapTypes, step.Swap.Type)
+	}
+	for _, inst := range analysis.Instructions {
+		comparison.ExecutedSteps += len(inst.RoutePlan)
+		for _, step := range inst.RoutePlan {
+			comparison.ExecutedSwapTypes = append(comparison.ExecutedSwapTypes, step.Swap.Type)
+		}
+	}
+
+	comparison.SameRoute = len(comparison.QuotedSwapTypes) == len(comparison.ExecutedSwapTypes)
+	for i := 0; comparison.SameRoute && i < len(comparison.QuotedSwapTypes); i++ {
+		comparison.SameRoute = comparison.QuotedSwapTypes[i] == comparison.ExecutedSwapTypes[i]
+	}
+
+	if comparison.ExactOut {
+		comparison.QuotedAmount = quote.QuotedInAmount
+		if len(analysis.Events) > 0 {
+			comparison.ExecutedAmount = analysis.Events[0].InputAmount
+		}
+	} else {
+		comparison.QuotedAmount = quote.QuotedOutAmount
+		if len(analysis.Events) > 0 {
+			comparison.ExecutedAmount = analysis.Events[len(analysis.Events)-1].OutputAmount
+		}
+	}
+	if len(analysis.Events) > 0 {
+		comparison.ShortfallBps = shortfallBps(comparison.QuotedAmount, comparison.ExecutedAmount, comparison.ExactOut)
+	}
+
+	return comparison
+}
+
+// shortfallBps returns executed against quoted in bps of quoted, positive when
+// the execution was worse: below quoted for exactIn, above it for exactOut.
+// It rounds toward zero and saturates at the int64 bounds.
+func shortfallBps(quoted, executed uint64, exactOut bool) int64 {
+	if quoted == 0 || quoted == executed {
+		return 0
+	}
+	worse := executed < quoted
+	if exactOut {
+		worse = !worse
+	}
+	diff := quoted - executed
+	if executed > quoted {
+		diff = executed - quoted
+	}
+
+	hi, lo := bits.Mul64(diff, 10000)
+	bps := uint64(math.MaxInt64)
+	if hi < quoted {
+		if q, _ := bits.Div64(hi, lo, quoted); q < bps {
+			bps = q
+		}
+	}
+	if worse {
+		return int64(bps)
+	}
+	return -int64(bps)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestCompareQuoteToExecution diffs the quote of each fixture in
+// testdata/quotes against the analysis of its executed transaction
+func TestCompareQuoteToExecution(t *testing.T) {
+	for _, tt := range []struct {
+		fixture   string
+		exactOut  bool
+		quoted    uint64
+		executed  uint64
+		shortfall int64
+	}{
+		// 918_000 received for 990_000 quoted
+		{"route-saber-whirlpool", false, 990_000, 918_000, 727},
+		// 1_000_000 paid for 990_000 quoted
+		{"shared-accounts-exact-out-route", true, 990_000, 1_000_000, 101},
+	} {
+		raw, err := os.ReadFile(filepath.Join("testdata", "quotes", tt.fixture+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		quote, err := ParseQuoteResponse(raw)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.fixture, err)
+		}
+		tx, err := loadFixture(filepath.Join(defaultFixturesDir, tt.fixture+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		analysis, err := NewAnalyzer(nil, WithNoNetwork(), WithLogOutput(io.Discard)).analyzeOffline(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		comparison := CompareQuoteToExecution(quote, analysis)
+		want := &QuoteComparison{
+			QuotedSteps:       2,
+			ExecutedSteps:     2,
+			QuotedSwapTypes:   []SwapType{SwapSaber, SwapWhirlpool},
+			ExecutedSwapTypes: []SwapType{SwapSaber, SwapWhirlpool},
+			SameRoute:         true,
+			QuotedAmount:      tt.quoted,
+			ExecutedAmount:    tt.executed,
+			ExactOut:          tt.exactOut,
+			ShortfallBps:      tt.shortfall,
+		}
+		if !reflect.DeepEqual(comparison, want) {
+			t.Errorf("%s: comparison %+v, want %+v", tt.fixture, comparison, want)
+		}
+	}
+}
+
+func TestShortfallBps(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		quoted   uint64
+		executed uint64
+		exactOut bool
+		want     int64
+	}{
+		{"exactIn less output", 10_000, 9_900, false, 100},
+		{"exactIn more output", 10_000, 10_150, false, -150},
+		{"exactOut more input", 10_000, 10_025, true, 25},
+		{"exactOut less input", 10_000, 9_990, true, -10},
+		{"as quoted", 10_000, 10_000, false, 0},
+		{"no quote", 0, 10_000, false, 0},
+		{"rounds toward zero", 3, 2, false, 3333},
+		{"no output", math.MaxUint64, 0, false, 10_000},
+		{"saturates", 1, math.MaxUint64, true, math.MaxInt64},
+	} {
+		if got := shortfallBps(tt.quoted, tt.executed, tt.exactOut); got != tt.want {
+			t.Errorf("%s: %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
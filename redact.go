@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"reflect"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// SinkMiddleware transforms an analysis before it reaches one sink. A
+// middleware must not modify its argument, which other sinks receive as well.
+type SinkMiddleware func(*JupiterV6Analysis) *JupiterV6Analysis
+
+// WrapSink returns a sink applying middleware in order before calling sink
+func WrapSink(sink func(*JupiterV6Analysis), middleware ...SinkMiddleware) func(*JupiterV6Analysis) {
+	return func(analysis *JupiterV6Analysis) {
+		for _, m := range middleware {
+			analysis = m(analysis)
+		}
+		sink(analysis)
+	}
+}
+
+// publicAddressFields lists the address fields naming tokens, programs and
+// pools rather than users, keyed by "Type.Field". They are forwarded as is by
+// HashingMiddleware. Every other address, including fields added later and
+// addresses inside maps, is hashed.
+var publicAddressFields = map[string]bool{
+	"JupiterSwapParams.Authority":       true, // Jupiter program authority PDA
+	"JupiterV6Analysis.OtherPrograms":   true,
+	"JupiterSwapParams.PlatformFeeMint": true,
+	"SwapEvent.AMM":                     true,
+	"SwapEvent.InputMint":               true,
+	"SwapEvent.OutputMint":              true,
+	"SwapEvent.Pool":                    true,
+	"TokenLedgerInfo.Mint":              true,
+	"ExecutionQuality.Mint":             true,
+	"LedgerEntry.Mint":                  true,
+	"BondingCurveState.Account":         true,
+	"BondingCurveState.Mint":            true,
+	"MintRisk.Mint":                     true,
+	"SwapFailure.Program":               true,
+	"SwapFailure.InputMint":             true,
+	"SwapFailure.OutputMint":            true,
+	"MintRisk.Program":                  true,
+	"MintRisk.MintAuthority":            true,
+	"MintRisk.FreezeAuthority":          true,
+	"MintRisk.PermanentDelegate":        true,
+	"MintRisk.TransferHookProgram":      true,
+}
+
+var (
+	publicKeyType = reflect.TypeOf(solana.PublicKey{})
+	bigAmountType = reflect.TypeOf(BigAmount{})
+)
+
+// HashingMiddleware replaces every user identifying address of the analysis
+// (user wallets, token accounts and their owners, fee accounts, ledger entries,
+// named step accounts) by HMAC-SHA256(key, address), leaving amounts, mints,
+// AMMs and programs intact. The analysis is deep copied first.
+func HashingMiddleware(key []byte) SinkMiddleware {
+	r := addressRedactor{key: key}
+	return func(analysis *JupiterV6Analysis) *JupiterV6Analysis {
+		if analysis == nil {
+			return nil
+		}
+		redacted := r.copy(reflect.ValueOf(analysis), true).Interface().(*JupiterV6Analysis)
+		linkInstructionResults(redacted)
+		return redacted
+	}
+}
+
+// addressRedactor deep copies values, hashing addresses
+type addressRedactor struct {
+	key []byte
+}
+
+// hash returns the keyed hash of address, shaped as an address
+func (r addressRedactor) hash(address solana.PublicKey) solana.PublicKey {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write(address[:])
+	return solana.PublicKeyFromBytes(mac.Sum(nil))
+}
+
+// copy returns a deep copy of v, hashing the addresses it holds when redact is set
+func (r addressRedactor) copy(v reflect.Value, redact bool) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(r.copy(v.Elem(), redact))
+		return c
+	case reflect.Array:
+		if v.Type() == publicKeyType && redact {
+			return reflect.ValueOf(r.hash(v.Interface().(solana.PublicKey)))
+		}
+		return v
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(r.copy(v.Index(i), redact))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(r.copy(iter.Key(), redact), r.copy(iter.Value(), redact))
+		}
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(r.copy(v.Elem(), redact))
+		return c
+	case reflect.Struct:
+		if v.Type() == bigAmountType {
+			amount := v.Interface().(BigAmount)
+			return reflect.ValueOf(bigAmountOf(amount.Int())).Elem()
+		}
+		// Unexported fields are carried over as is, they hold no addresses
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			public := publicAddressFields[v.Type().Name()+"."+field.Name]
+			c.Field(i).Set(r.copy(v.Field(i), redact && !public))
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestHashingMiddleware(t *testing.T) {
+	tx := policyTransaction(t)
+	parsedTx, err := tx.Transaction.GetTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysis := analyzeTest(t, newTestAnalyzer(), tx, parsedTx)
+	wallet := analysis.Instructions[0].UserWallet
+	if wallet.IsZero() {
+		t.Fatal("fixture has no user wallet")
+	}
+
+	key := []byte("secret")
+	mac := hmac.New(sha256.New, key)
+	mac.Write(wallet[:])
+	hashed := solana.PublicKeyFromBytes(mac.Sum(nil))
+
+	var redacted *JupiterV6Analysis
+	WrapSink(func(a *JupiterV6Analysis) { redacted = a }, HashingMiddleware(key))(analysis)
+
+	if got := redacted.Instructions[0].UserWallet; got != hashed {
+		t.Errorf("user wallet %s, want %s", got, hashed)
+	}
+	if analysis.Instructions[0].UserWallet != wallet {
+		t.Error("original analysis modified")
+	}
+	for i, event := range redacted.Events {
+		original := analysis.Events[i]
+		if event.AMM != original.AMM || event.InputMint != original.InputMint || event.OutputMint != original.OutputMint {
+			t.Errorf("event %d: public addresses hashed", i)
+		}
+		if event.InputAmount != original.InputAmount || event.OutputAmount != original.OutputAmount {
+			t.Errorf("event %d: amounts changed", i)
+		}
+	}
+	if len(redacted.Results) > 0 && redacted.Results[0].Params != &redacted.Instructions[0] {
+		t.Error("results not linked to the copied instructions")
+	}
+
+	// The hash is stable for a key, so redacted records still join on the wallet
+	again := HashingMiddleware(key)(analysis)
+	other := HashingMiddleware([]byte("other"))(analysis)
+	if again.Instructions[0].UserWallet != hashed || other.Instructions[0].UserWallet == hashed {
+		t.Error("hash not keyed")
+	}
+	if HashingMiddleware(key)(nil) != nil {
+		t.Error("nil analysis")
+	}
+}
+
+func TestWrapSinkOrder(t *testing.T) {
+	var order []string
+	step := func(name string) SinkMiddleware {
+		return func(analysis *JupiterV6Analysis) *JupiterV6Analysis {
+			order = append(order, name)
+			return analysis
+		}
+	}
+	WrapSink(func(*JupiterV6Analysis) { order = append(order, "sink") }, step("first"), step("second"))(&JupiterV6Analysis{})
+	if len(order) != 3 || order[0] != "first" || order[1] != "second" || order[2] != "sink" {
+		t.Errorf("order %q", order)
+	}
+}
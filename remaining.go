@@ -0,0 +1,10 @@
+package main
+
+import "sol-tx/jupiterv6"
+
+// Trailing account roles of V2 swaps, decoded by the jupiterv6 package
+type (
+	AccountsType           = jupiterv6.AccountsType
+	RemainingAccountsSlice = jupiterv6.RemainingAccountsSlice
+	RemainingAccountsInfo  = jupiterv6.RemainingAccountsInfo
+)
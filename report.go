@@ -0,0 +1,167 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// AMMAggregate is the swap count and volume routed through one AMM program
+type AMMAggregate struct {
+	AMM   solana.PublicKey `json:"amm"`
+	Name  string           `json:"name,omitempty"`
+	Swaps int              `json:"swaps"`
+	// InputVolume and OutputVolume are keyed by mint
+	InputVolume  map[solana.PublicKey]*BigAmount `json:"input_volume"`
+	OutputVolume map[solana.PublicKey]*BigAmount `json:"output_volume"`
+}
+
+// AggregateByAMM groups the non dust events by AMM program, busiest first
+func AggregateByAMM(events []SwapEvent, registry *AMMRegistry) []AMMAggregate {
+	byAMM := make(map[solana.PublicKey]*AMMAggregate)
+	for _, event := range nonDustEvents(events) {
+		aggregate := byAMM[event.AMM]
+		if aggregate == nil {
+			aggregate = &AMMAggregate{
+				AMM:          event.AMM,
+				InputVolume:  make(map[solana.PublicKey]*BigAmount),
+				OutputVolume: make(map[solana.PublicKey]*BigAmount),
+			}
+			aggregate.Name, _ = registry.Lookup(event.AMM)
+			byAMM[event.AMM] = aggregate
+		}
+		aggregate.Swaps++
+		addAmount(aggregate.InputVolume, event.InputMint, event.InputAmount)
+		addAmount(aggregate.OutputVolume, event.OutputMint, event.OutputAmount)
+	}
+
+	aggregates := make([]AMMAggregate, 0, len(byAMM))
+	for _, aggregate := range byAMM {
+		aggregates = append(aggregates, *aggregate)
+	}
+	sort.Slice(aggregates, func(i, j int) bool {
+		if aggregates[i].Swaps != aggregates[j].Swaps {
+			return aggregates[i].Swaps > aggregates[j].Swaps
+		}
+		return bytesCompare(aggregates[i].AMM[:], aggregates[j].AMM[:]) < 0
+	})
+	return aggregates
+}
+
+// routeEnds returns the first and last non dust events of a route, whose input
+// is what the route sold and whose output is what it bought
+func routeEnds(events []SwapEvent) (first, last SwapEvent, ok bool) {
+	kept := nonDustEvents(events)
+	if len(kept) == 0 {
+		return SwapEvent{}, SwapEvent{}, false
+	}
+	return kept[0], kept[len(kept)-1], true
+}
+
+// sortedMints returns the keys of mints in byte order
+func sortedMints(mints map[solana.PublicKey]bool) []solana.PublicKey {
+	keys := make([]solana.PublicKey, 0, len(mints))
+	for mint := range mints {
+		keys = append(keys, mint)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytesCompare(keys[i][:], keys[j][:]) < 0
+	})
+	return keys
+}
+
+// MintVolume is the amount of one mint sold and bought over the analyses
+type MintVolume struct {
+	Mint   solana.PublicKey `json:"mint"`
+	Sold   *BigAmount       `json:"sold"`
+	Bought *BigAmount       `json:"bought"`
+}
+
+// AggregateReport rolls up a set of analyses, typically the swaps of one
+// wallet over a day. Volumes count what each route sold and bought, the
+// intermediate hops only appear in the venue breakdown.
+type AggregateReport struct {
+	Analyses int `json:"analyses"`
+	Swaps    int `json:"swaps"`  // Analyses with at least one non dust event
+	Failed   int `json:"failed"` // Analyses of failed transactions
+	Hops     int `json:"hops"`   // Non dust events over all analyses
+
+	// From and To bound the known timestamps, nil when none is known
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+
+	Mints  []MintVolume   `json:"mints"`  // Sorted by mint
+	Venues []AMMAggregate `json:"venues"` // Busiest first
+	// PlatformFees sums the platform fees of all instructions, keyed by fee mint
+	PlatformFees map[solana.PublicKey]*BigAmount `json:"platform_fees"`
+}
+
+// AggregateAnalyses rolls analyses up into one report. Venue names come from
+// DefaultAMMRegistry; nil analyses are skipped.
+func AggregateAnalyses(analyses []*JupiterV6Analysis) *AggregateReport {
+	report := &AggregateReport{
+		Mints:        []MintVolume{},
+		PlatformFees: make(map[solana.PublicKey]*BigAmount),
+	}
+	sold := make(map[solana.PublicKey]*BigAmount)
+	bought := make(map[solana.PublicKey]*BigAmount)
+	var events []SwapEvent
+
+	for _, analysis := range analyses {
+		if analysis == nil {
+			continue
+		}
+		report.Analyses++
+		if analysis.TransactionError != "" {
+			report.Failed++
+		}
+		if analysis.Timestamp.Known() {
+			at := analysis.Timestamp.Time
+			if report.From == nil || at.Before(*report.From) {
+				report.From = &at
+			}
+			if report.To == nil || at.After(*report.To) {
+				report.To = &at
+			}
+		}
+
+		if first, last, ok := routeEnds(analysis.Events); ok {
+			report.Swaps++
+			addAmount(sold, first.InputMint, first.InputAmount)
+			addAmount(bought, last.OutputMint, last.OutputAmount)
+		}
+		events = append(events, analysis.Events...)
+
+		for mint, amount := range analysis.TotalPlatformFeesBig() {
+			if report.PlatformFees[mint] == nil {
+				report.PlatformFees[mint] = NewBigAmount(0)
+			}
+			report.PlatformFees[mint].Int().Add(report.PlatformFees[mint].Int(), amount.Int())
+		}
+	}
+
+	report.Venues = AggregateByAMM(events, DefaultAMMRegistry)
+	for _, venue := range report.Venues {
+		report.Hops += venue.Swaps
+	}
+
+	mints := make(map[solana.PublicKey]bool)
+	for mint := range sold {
+		mints[mint] = true
+	}
+	for mint := range bought {
+		mints[mint] = true
+	}
+	for _, mint := range sortedMints(mints) {
+		volume := MintVolume{Mint: mint, Sold: sold[mint], Bought: bought[mint]}
+		if volume.Sold == nil {
+			volume.Sold = NewBigAmount(0)
+		}
+		if volume.Bought == nil {
+			volume.Bought = NewBigAmount(0)
+		}
+		report.Mints = append(report.Mints, volume)
+	}
+	return report
+}
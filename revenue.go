@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// revenuePageSize is the number of signatures requested per getSignaturesForAddress call
+const revenuePageSize = 1000
+
+// RevenueCursor is the walk position in the history of one fee token account.
+// History is walked newest first, Before is the last signature processed.
+type RevenueCursor struct {
+	Account solana.PublicKey `json:"account"`
+	Before  solana.Signature `json:"before"`
+	Done    bool             `json:"done"`
+}
+
+// RevenuePayer is the platform fee paid by one wallet in one mint
+type RevenuePayer struct {
+	Wallet solana.PublicKey `json:"wallet"`
+	Amount *BigAmount       `json:"amount"`
+}
+
+// RevenueReport aggregates the platform fees received by the token accounts of
+// a fee owner from Jupiter swaps between From and To. The report carries its
+// walk cursors and can be saved as JSON and resumed.
+type RevenueReport struct {
+	Owner solana.PublicKey `json:"owner"`
+	From  time.Time        `json:"from"`
+	To    time.Time        `json:"to"`
+
+	Totals map[solana.PublicKey]*BigAmount `json:"totals"` // By fee mint
+	// Daily buckets are keyed by UTC date (2006-01-02), then fee mint
+	Daily map[string]map[solana.PublicKey]*BigAmount `json:"daily"`
+	// Payers holds the fees paid per fee mint, then wallet
+	Payers map[solana.PublicKey]map[solana.PublicKey]*BigAmount `json:"payers"`
+
+	Swaps  int `json:"swaps"`  // Transactions that paid a fee
+	Failed int `json:"failed"` // Transactions that could not be analyzed
+	// Undated counts the fee paying transactions left out of Daily, their time is unknown
+	Undated int `json:"undated,omitempty"`
+
+	Accounts []RevenueCursor `json:"accounts"`
+	Complete bool            `json:"complete"`
+}
+
+// NewRevenueReport creates an empty report for the fee owner over [from, to]
+func NewRevenueReport(owner solana.PublicKey, from, to time.Time) *RevenueReport {
+	return &RevenueReport{
+		Owner:  owner,
+		From:   from,
+		To:     to,
+		Totals: make(map[solana.PublicKey]*BigAmount),
+		Daily:  make(map[string]map[solana.PublicKey]*BigAmount),
+		Payers: make(map[solana.PublicKey]map[solana.PublicKey]*BigAmount),
+	}
+}
+
+// TopPayers returns the n wallets that paid the most fees in mint, largest first
+func (r *RevenueReport) TopPayers(mint solana.PublicKey, n int) []RevenuePayer {
+	payers := make([]RevenuePayer, 0, len(r.Payers[mint]))
+	for wallet, amount := range r.Payers[mint] {
+		payers = append(payers, RevenuePayer{Wallet: wallet, Amount: amount})
+	}
+	sort.Slice(payers, func(i, j int) bool {
+		if c := payers[i].Amount.Int().Cmp(payers[j].Amount.Int()); c != 0 {
+			return c > 0
+		}
+		return bytesCompare(payers[i].Wallet[:], payers[j].Wallet[:]) < 0
+	})
+	if n > 0 && len(payers) > n {
+		payers = payers[:n]
+	}
+	return payers
+}
+
+// addAmount adds amount to the entry of key, creating it when missing
+func addAmount(amounts map[solana.PublicKey]*BigAmount, key solana.PublicKey, amount uint64) {
+	if amounts[key] == nil {
+		amounts[key] = NewBigAmount(0)
+	}
+	amounts[key].AddUint64(amount)
+}
+
+// record adds the fees received by account in the analysis
+func (r *RevenueReport) record(account solana.PublicKey, analysis *JupiterV6Analysis) {
+	var wallet solana.PublicKey
+	if len(analysis.Instructions) > 0 {
+		wallet = analysis.Instructions[0].UserWallet
+	}
+
+	paid := false
+	for _, entry := range analysis.Ledger {
+		if entry.Role != LedgerRoleFee || entry.Kind != LedgerKindToken || entry.Mint == nil {
+			continue
+		}
+		if !entry.Account.Equals(account) || entry.Post <= entry.Pre {
+			continue
+		}
+		amount := entry.Post - entry.Pre
+		mint := *entry.Mint
+		paid = true
+
+		addAmount(r.Totals, mint, amount)
+		if analysis.Timestamp.Known() {
+			day := analysis.Timestamp.Time.UTC().Format("2006-01-02")
+			if r.Daily[day] == nil {
+				r.Daily[day] = make(map[solana.PublicKey]*BigAmount)
+			}
+			addAmount(r.Daily[day], mint, amount)
+		}
+		if r.Payers[mint] == nil {
+			r.Payers[mint] = make(map[solana.PublicKey]*BigAmount)
+		}
+		addAmount(r.Payers[mint], wallet, amount)
+	}
+	if paid {
+		r.Swaps++
+		if !analysis.Timestamp.Known() {
+			r.Undated++
+		}
+	}
+}
+
+// feeTokenAccounts lists the token accounts of owner under both token programs
+func (a *Analyzer) feeTokenAccounts(ctx context.Context, owner solana.PublicKey) ([]solana.PublicKey, error) {
+	var accounts []solana.PublicKey
+	for _, program := range []solana.PublicKey{solana.TokenProgramID, solana.Token2022ProgramID} {
+		programID := program
+		var result *rpc.GetTokenAccountsResult
+		err := a.withRPCSlot(ctx, func() error {
+			var err error
+			result, err = a.rpcClient.GetTokenAccountsByOwner(ctx, owner,
+				&rpc.GetTokenAccountsConfig{ProgramId: &programID},
+				&rpc.GetTokenAccountsOpts{Commitment: a.commitment, Encoding: solana.EncodingBase64},
+			)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error getting token accounts: %v", err)
+		}
+		for _, account := range result.Value {
+			accounts = append(accounts, account.Pubkey)
+		}
+	}
+	return accounts, nil
+}
+
+// ResumeFeeRevenueReport continues walking the fee account histories of report,
+// analyzing at most maxTransactions transactions (0 for no limit). It returns
+// with the cursors saved on errors and when the limit is hit, so the same
+// report can be passed again. report.Complete is set once every history
+// reached report.From.
+func (a *Analyzer) ResumeFeeRevenueReport(ctx context.Context, report *RevenueReport, maxTransactions int) error {
+	if a.noNetwork {
+		return fmt.Errorf("%w: cannot walk fee account history", ErrNetworkDisabled)
+	}
+	if a.rpcClient == nil {
+		return fmt.Errorf("analyzer has no rpc client")
+	}
+
+	if report.Accounts == nil {
+		accounts, err := a.feeTokenAccounts(ctx, report.Owner)
+		if err != nil {
+			return err
+		}
+		report.Accounts = make([]RevenueCursor, 0, len(accounts))
+		for _, account := range accounts {
+			report.Accounts = append(report.Accounts, RevenueCursor{Account: account})
+		}
+	}
+
+	analyzed := 0
+	for i := range report.Accounts {
+		cursor := &report.Accounts[i]
+		for !cursor.Done {
+			limit := revenuePageSize
+			var page []*rpc.TransactionSignature
+			err := a.withRPCSlot(ctx, func() error {
+				var err error
+				page, err = a.rpcClient.GetSignaturesForAddressWithOpts(ctx, cursor.Account, &rpc.GetSignaturesForAddressOpts{
+					Limit:      &limit,
+					Before:     cursor.Before,
+					Commitment: a.commitment,
+				})
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("error getting signatures for %s: %v", cursor.Account, err)
+			}
+			if len(page) == 0 {
+				cursor.Done = true
+				break
+			}
+
+			for _, sig := range page {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				// A zero block time is a missing one, not 1970
+				if sig.BlockTime != nil && *sig.BlockTime > 0 {
+					blockTime := sig.BlockTime.Time()
+					if blockTime.Before(report.From) {
+						cursor.Done = true
+						break
+					}
+					if blockTime.After(report.To) {
+						cursor.Before = sig.Signature
+						continue
+					}
+				}
+				if sig.Err == nil {
+					if maxTransactions > 0 && analyzed >= maxTransactions {
+						return nil
+					}
+					analyzed++
+
+					analysis, err := a.AnalyzeSignature(ctx, sig.Signature)
+					if err != nil {
+						if ctx.Err() != nil {
+							return ctx.Err()
+						}
+						report.Failed++
+					} else {
+						report.record(cursor.Account, analysis)
+					}
+				}
+				cursor.Before = sig.Signature
+			}
+		}
+	}
+
+	report.Complete = true
+	return nil
+}
+
+// FeeRevenueReport walks the inbound platform fees of every token account of
+// feeAccountOwner between from and to. Large ranges are better served by
+// ResumeFeeRevenueReport, which can stop and continue.
+func FeeRevenueReport(ctx context.Context, client *rpc.Client, feeAccountOwner solana.PublicKey, from, to time.Time) (*RevenueReport, error) {
+	report := NewRevenueReport(feeAccountOwner, from, to)
+	analyzer := NewAnalyzer(client, WithLogOutput(io.Discard))
+	if err := analyzer.ResumeFeeRevenueReport(ctx, report, 0); err != nil {
+		return report, err
+	}
+	return report, nil
+}
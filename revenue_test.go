@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// historyRPCClient serves one page of getSignaturesForAddress per account,
+// then an empty page once the walk asks for older signatures
+type historyRPCClient struct {
+	pages map[solana.PublicKey][]*rpc.TransactionSignature
+}
+
+func (c *historyRPCClient) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	if method != "getSignaturesForAddress" {
+		return errors.New("unexpected rpc call " + method)
+	}
+	var page []*rpc.TransactionSignature
+	if opts, _ := params[1].(rpc.M); opts["before"] == nil {
+		page = c.pages[params[0].(solana.PublicKey)]
+	}
+	raw, err := json.Marshal(page)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func (c *historyRPCClient) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return errors.New("unexpected rpc call " + method)
+}
+
+func (c *historyRPCClient) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, errors.New("unexpected rpc batch")
+}
+
+// historySource serves the transaction of each signature and records the
+// signatures fetched
+type historySource struct {
+	txs     map[solana.Signature]*rpc.GetTransactionResult
+	fetched []solana.Signature
+}
+
+func (s *historySource) GetTransaction(ctx context.Context, signature solana.Signature, opts *rpc.GetTransactionOpts) (*rpc.GetTransactionResult, error) {
+	s.fetched = append(s.fetched, signature)
+	return s.txs[signature], nil
+}
+
+func TestResumeFeeRevenueReportWindow(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 2, 23, 59, 59, 0, time.UTC)
+	feeAccount, otherAccount := testKey(13), testKey(20)
+	user, feeMint := testKey(1), testKey(3)
+
+	fixture, _ := ledgerFixture(t)
+	source := &historySource{txs: make(map[solana.Signature]*rpc.GetTransactionResult)}
+	signature := func(n byte, at *time.Time, failed bool) *rpc.TransactionSignature {
+		sig := &rpc.TransactionSignature{Signature: solana.Signature{n}}
+		tx := *fixture
+		if at != nil {
+			blockTime := solana.UnixTimeSeconds(at.Unix())
+			sig.BlockTime, tx.BlockTime = &blockTime, &blockTime
+		}
+		if failed {
+			sig.Err = map[string]interface{}{"InstructionError": []interface{}{0, "Custom"}}
+		}
+		source.txs[sig.Signature] = &tx
+		return sig
+	}
+	at := func(t time.Time) *time.Time { return &t }
+
+	transport := &historyRPCClient{pages: map[solana.PublicKey][]*rpc.TransactionSignature{
+		// Newest first
+		feeAccount: {
+			signature(1, at(to.Add(time.Second)), false), // after the window
+			signature(2, at(to), false),                  // on the upper bound
+			signature(3, at(to.Add(-time.Hour)), true),   // failed on chain
+			signature(4, at(from.Add(12*time.Hour)), false),
+			signature(5, nil, false),                        // no block time
+			signature(6, at(from), false),                   // on the lower bound
+			signature(7, at(from.Add(-time.Second)), false), // before the window, ends the walk
+			signature(8, at(from.Add(time.Hour)), false),
+		},
+		// The same swap seen from an account that did not receive its fee
+		otherAccount: {signature(9, at(from.Add(time.Hour)), false)},
+	}}
+
+	a := NewAnalyzer(rpc.NewWithCustomRPCClient(transport), WithTransactionSource(source), WithLogOutput(io.Discard))
+	report := NewRevenueReport(testKey(4), from, to)
+	report.Accounts = []RevenueCursor{{Account: feeAccount}, {Account: otherAccount}}
+	if err := a.ResumeFeeRevenueReport(context.Background(), report, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	wantFetched := []solana.Signature{{2}, {4}, {5}, {6}, {9}}
+	if !reflect.DeepEqual(source.fetched, wantFetched) {
+		t.Errorf("fetched %v, want %v", source.fetched, wantFetched)
+	}
+	if !report.Complete || report.Swaps != 4 || report.Undated != 1 || report.Failed != 0 {
+		t.Errorf("complete %v, swaps %d, undated %d, failed %d, want true, 4, 1, 0",
+			report.Complete, report.Swaps, report.Undated, report.Failed)
+	}
+	amounts := map[string]string{
+		"total":      report.Totals[feeMint].String(),
+		"2024-03-01": report.Daily["2024-03-01"][feeMint].String(),
+		"2024-03-02": report.Daily["2024-03-02"][feeMint].String(),
+		"user":       report.Payers[feeMint][user].String(),
+	}
+	want := map[string]string{"total": "20", "2024-03-01": "10", "2024-03-02": "5", "user": "20"}
+	if !reflect.DeepEqual(amounts, want) {
+		t.Errorf("amounts %v, want %v", amounts, want)
+	}
+	if len(report.Daily) != 2 || len(report.Totals) != 1 {
+		t.Errorf("daily %v, totals %v", report.Daily, report.Totals)
+	}
+	for i, cursor := range report.Accounts {
+		if !cursor.Done {
+			t.Errorf("cursor %d not done", i)
+		}
+	}
+}
+
+func TestRevenueRecordAttribution(t *testing.T) {
+	tx, parsedTx := ledgerFixture(t)
+	analysis := analyzeTest(t, newTestAnalyzer(), tx, parsedTx)
+
+	report := NewRevenueReport(testKey(4), time.Time{}, time.Now())
+	// The user destination account gains mint B too, but is not a fee account
+	report.record(testKey(12), analysis)
+	if report.Swaps != 0 || len(report.Totals) != 0 || len(report.Payers) != 0 {
+		t.Errorf("fee attributed to the user account: %+v", report)
+	}
+	report.record(testKey(13), analysis)
+	if report.Swaps != 1 || report.Totals[testKey(3)].String() != "5" || report.Payers[testKey(3)][testKey(1)].String() != "5" {
+		t.Errorf("swaps %d, totals %v, payers %v", report.Swaps, report.Totals, report.Payers)
+	}
+}
+
+func TestTopPayers(t *testing.T) {
+	mint := testKey(3)
+	report := NewRevenueReport(testKey(4), time.Time{}, time.Now())
+	report.Payers[mint] = map[solana.PublicKey]*BigAmount{
+		testKey(5): NewBigAmount(10),
+		testKey(2): NewBigAmount(30),
+		testKey(7): NewBigAmount(10),
+		testKey(6): NewBigAmount(10),
+		testKey(1): NewBigAmount(1),
+	}
+	report.Payers[testKey(9)] = map[solana.PublicKey]*BigAmount{testKey(8): NewBigAmount(100)}
+
+	wallets := func(payers []RevenuePayer) []solana.PublicKey {
+		out := make([]solana.PublicKey, len(payers))
+		for i, payer := range payers {
+			out[i] = payer.Wallet
+		}
+		return out
+	}
+	// Ties are broken by wallet key, smallest first
+	for _, tt := range []struct {
+		n    int
+		want []solana.PublicKey
+	}{
+		{0, []solana.PublicKey{testKey(2), testKey(5), testKey(6), testKey(7), testKey(1)}},
+		{3, []solana.PublicKey{testKey(2), testKey(5), testKey(6)}},
+		{10, []solana.PublicKey{testKey(2), testKey(5), testKey(6), testKey(7), testKey(1)}},
+	} {
+		if got := wallets(report.TopPayers(mint, tt.n)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TopPayers(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+	if payers := report.TopPayers(testKey(10), 5); len(payers) != 0 {
+		t.Errorf("TopPayers of an unseen mint = %v", payers)
+	}
+}
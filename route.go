@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// PoolRegistry lists known liquidity pools for a token pair
+type PoolRegistry interface {
+	PoolsForPair(a, b solana.PublicKey) []solana.PublicKey
+}
+
+// PoolPair identifies a pair by its canonical base and quote mints
+type PoolPair struct {
+	Base  solana.PublicKey
+	Quote solana.PublicKey
+}
+
+// NewPoolPair returns the canonical pair for two mints in any order
+func NewPoolPair(a, b solana.PublicKey) PoolPair {
+	base, quote := canonicalPair(a, b)
+	return PoolPair{Base: base, Quote: quote}
+}
+
+// StaticPoolRegistry is a PoolRegistry backed by a fixed map
+type StaticPoolRegistry map[PoolPair][]solana.PublicKey
+
+// PoolsForPair returns the pools registered for the pair of a and b
+func (r StaticPoolRegistry) PoolsForPair(a, b solana.PublicKey) []solana.PublicKey {
+	return r[NewPoolPair(a, b)]
+}
+
+// DefaultPoolRegistry seeds the deepest pools of the top pairs. It is not
+// exhaustive, a missing pair only means no direct pool is known.
+var DefaultPoolRegistry = StaticPoolRegistry{
+	NewPoolPair(solana.SolMint, mintUSDC): {
+		solana.MustPublicKeyFromBase58("58oQChx4yWmvKdwLLZzBi4ChoCc2fqCUWBkwMihLYQo2"), // Raydium AMM v4
+		solana.MustPublicKeyFromBase58("HJPjoWUrhoZzkNfRpHuieeFk9WcZWjwy6PBjZ81ngndJ"), // Whirlpool
+		solana.MustPublicKeyFromBase58("2QdhepnKRTLjjSqPL1PtKNwqrUkoLee5Gqs8bvZhRdMv"), // Raydium CLMM
+	},
+	NewPoolPair(solana.SolMint, mintUSDT): {
+		solana.MustPublicKeyFromBase58("7XawhbbxtsRcQA8KTkHT9f9nc6d69UwqCDh6U5EEbEmX"), // Raydium AMM v4
+	},
+	NewPoolPair(mintUSDC, mintUSDT): {
+		solana.MustPublicKeyFromBase58("4fuUiYxTQ6QCrdSq9ouBYcTM7bqSwYTSyLueGZLTy4T4"), // Whirlpool
+	},
+}
+
+// RouteAssessment is a heuristic annotation of the route shape. DirectPoolKnown
+// only reflects the configured registry: a multi-hop route with a known direct
+// pool suggests the router found better pricing elsewhere, not that it did.
+type RouteAssessment struct {
+	Heuristic       bool `json:"heuristic"`
+	DirectPoolKnown bool `json:"direct_pool_known"`
+	HopsUsed        int  `json:"hops_used"`
+	SplitCount      int  `json:"split_count"`
+}
+
+// WithPoolRegistry enables the route assessment pass using registry
+func WithPoolRegistry(registry PoolRegistry) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.poolRegistry = registry
+	}
+}
+
+// routeShape returns the longest chain of steps through the route plan token
+// indexes and the largest number of steps leaving a single token index
+func routeShape(plan []RoutePlanStep) (hops, splits int) {
+	depth := map[uint8]int{}
+	legs := map[uint8]int{}
+	for _, step := range plan {
+		d := depth[step.InputIndex] + 1
+		if d > depth[step.OutputIndex] {
+			depth[step.OutputIndex] = d
+		}
+		if d > hops {
+			hops = d
+		}
+		legs[step.InputIndex]++
+		if legs[step.InputIndex] > splits {
+			splits = legs[step.InputIndex]
+		}
+	}
+	return hops, splits
+}
+
+// assessRoute annotates the analysis with the route shape of its instructions
+func assessRoute(analysis *JupiterV6Analysis, registry PoolRegistry) *RouteAssessment {
+	if registry == nil || len(analysis.Instructions) == 0 {
+		return nil
+	}
+
+	assessment := &RouteAssessment{Heuristic: true}
+	for _, inst := range analysis.Instructions {
+		hops, splits := routeShape(inst.RoutePlan)
+		if hops > assessment.HopsUsed {
+			assessment.HopsUsed = hops
+		}
+		if splits > assessment.SplitCount {
+			assessment.SplitCount = splits
+		}
+	}
+
+	if len(analysis.Events) > 0 {
+		input := analysis.Events[0].InputMint
+		output := analysis.Events[len(analysis.Events)-1].OutputMint
+		if !input.Equals(output) {
+			assessment.DirectPoolKnown = len(registry.PoolsForPair(input, output)) > 0
+		}
+	}
+	return assessment
+}
+
+// RenderRouteTree renders the route plan as an indented tree of token indexes.
+// Each branch shows the swap type and percent of the step, a branch into a
+// token index already expanded elsewhere is marked as a merge.
+func RenderRouteTree(steps []RoutePlanStep) string {
+	children := map[uint8][]RoutePlanStep{}
+	isOutput := map[uint8]bool{}
+	var order []uint8
+	for _, step := range steps {
+		if _, ok := children[step.InputIndex]; !ok {
+			order = append(order, step.InputIndex)
+		}
+		children[step.InputIndex] = append(children[step.InputIndex], step)
+		isOutput[step.OutputIndex] = true
+	}
+
+	var b strings.Builder
+	expanded := map[uint8]bool{}
+	var walk func(index uint8, prefix string)
+	walk = func(index uint8, prefix string) {
+		expanded[index] = true
+		legs := children[index]
+		for i, step := range legs {
+			branch, next := "├── ", "│   "
+			if i == len(legs)-1 {
+				branch, next = "└── ", "    "
+			}
+			fmt.Fprintf(&b, "%s%s%s %d%% -> token %d", prefix, branch, step.Swap.Type, step.Percent, step.OutputIndex)
+			if expanded[step.OutputIndex] {
+				b.WriteString(" (merge)\n")
+				continue
+			}
+			b.WriteString("\n")
+			walk(step.OutputIndex, prefix+next)
+		}
+	}
+
+	// Start from token indexes no step swaps into, then anything left unreached (cycles)
+	for _, root := range order {
+		if !isOutput[root] && !expanded[root] {
+			fmt.Fprintf(&b, "token %d\n", root)
+			walk(root, "")
+		}
+	}
+	for _, root := range order {
+		if !expanded[root] {
+			fmt.Fprintf(&b, "token %d\n", root)
+			walk(root, "")
+		}
+	}
+	return b.String()
+}
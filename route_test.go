@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// pairRegistry records the pairs it is asked for
+type pairRegistry struct {
+	StaticPoolRegistry
+	asked []PoolPair
+}
+
+func (r *pairRegistry) PoolsForPair(a, b solana.PublicKey) []solana.PublicKey {
+	r.asked = append(r.asked, NewPoolPair(a, b))
+	return r.StaticPoolRegistry.PoolsForPair(a, b)
+}
+
+func TestAssessRoute(t *testing.T) {
+	token := testKey(7)
+	// USDC -> token -> SOL, the first leg split 60/40 over two pools
+	plan := []RoutePlanStep{
+		{Swap: Swap{Type: SwapWhirlpool}, Percent: 60, InputIndex: 0, OutputIndex: 1},
+		{Swap: Swap{Type: SwapRaydiumClmm}, Percent: 40, InputIndex: 0, OutputIndex: 1},
+		{Swap: Swap{Type: SwapMeteoraDlmm}, Percent: 100, InputIndex: 1, OutputIndex: 2},
+	}
+	analysisFor := func(input, output solana.PublicKey) *JupiterV6Analysis {
+		return &JupiterV6Analysis{
+			Instructions: []JupiterSwapParams{{RoutePlan: plan}},
+			Events: []SwapEvent{
+				{InputMint: input, OutputMint: token},
+				{InputMint: input, OutputMint: token},
+				{InputMint: token, OutputMint: output},
+			},
+		}
+	}
+
+	for _, tt := range []struct {
+		name          string
+		input, output solana.PublicKey
+		registry      StaticPoolRegistry
+		known         bool
+	}{
+		{"known pool", mintUSDC, solana.SolMint, DefaultPoolRegistry, true},
+		{"known pool in reverse order", solana.SolMint, mintUSDC, DefaultPoolRegistry, true},
+		{"unknown pool", mintUSDC, testKey(8), DefaultPoolRegistry, false},
+		{"registry miss", mintUSDC, solana.SolMint, StaticPoolRegistry{NewPoolPair(mintUSDC, mintUSDT): {testKey(9)}}, false},
+		{"circular route", mintUSDC, mintUSDC, DefaultPoolRegistry, false},
+	} {
+		registry := &pairRegistry{StaticPoolRegistry: tt.registry}
+		assessment := assessRoute(analysisFor(tt.input, tt.output), registry)
+		want := &RouteAssessment{Heuristic: true, DirectPoolKnown: tt.known, HopsUsed: 2, SplitCount: 2}
+		if !reflect.DeepEqual(assessment, want) {
+			t.Errorf("%s: %+v, want %+v", tt.name, assessment, want)
+		}
+		// Only the end to end pair is looked up, never for a circular route
+		var asked []PoolPair
+		if !tt.input.Equals(tt.output) {
+			asked = []PoolPair{NewPoolPair(tt.input, tt.output)}
+		}
+		if !reflect.DeepEqual(registry.asked, asked) {
+			t.Errorf("%s: registry asked for %v, want %v", tt.name, registry.asked, asked)
+		}
+	}
+
+	if assessment := assessRoute(analysisFor(mintUSDC, solana.SolMint), nil); assessment != nil {
+		t.Errorf("assessed without a registry: %+v", assessment)
+	}
+	if assessment := assessRoute(&JupiterV6Analysis{}, DefaultPoolRegistry); assessment != nil {
+		t.Errorf("assessed without instructions: %+v", assessment)
+	}
+	noEvents := analysisFor(mintUSDC, solana.SolMint)
+	noEvents.Events = nil
+	if assessment := assessRoute(noEvents, DefaultPoolRegistry); assessment == nil || assessment.DirectPoolKnown || assessment.HopsUsed != 2 {
+		t.Errorf("assessed without events: %+v", assessment)
+	}
+}
+
+func TestPoolRegistryAnalysis(t *testing.T) {
+	tx := policyTransaction(t)
+	for _, tt := range []struct {
+		name     string
+		registry PoolRegistry
+		want     *RouteAssessment
+	}{
+		{"no registry", nil, nil},
+		// the events run mint 2 -> mint 3 -> mint 4
+		{"known pool", StaticPoolRegistry{NewPoolPair(testKey(4), testKey(2)): {testKey(9)}}, &RouteAssessment{Heuristic: true, DirectPoolKnown: true, HopsUsed: 2, SplitCount: 1}},
+		{"registry miss", DefaultPoolRegistry, &RouteAssessment{Heuristic: true, HopsUsed: 2, SplitCount: 1}},
+	} {
+		opts := []AnalyzerOption{WithTransactionSource(staticSource{tx})}
+		if tt.registry != nil {
+			opts = append(opts, WithPoolRegistry(tt.registry))
+		}
+		analysis, err := newTestAnalyzer(opts...).AnalyzeSignature(context.Background(), solana.Signature{1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(analysis.RouteAssessment, tt.want) {
+			t.Errorf("%s: %+v, want %+v", tt.name, analysis.RouteAssessment, tt.want)
+		}
+	}
+}
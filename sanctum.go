@@ -0,0 +1,87 @@
+package main
+
+import (
+	"github.com/gagliardetto/solana-go"
+)
+
+// sanctumSProgramID is the Sanctum S controller program
+var sanctumSProgramID = solana.MustPublicKeyFromBase58("5ocnV1qiCgaQR8Jb8xWnVbApfaygJ8tNoZfgPwsgx9kx")
+
+// Sanctum S account layout within the Jupiter remaining accounts.
+//
+// Each SanctumS step starts with the S controller program account, followed by
+// the fixed accounts of the S instruction and then the variable length
+// value-calculator account ranges whose sizes are encoded in the step params:
+//
+//	SanctumS (swap):
+//	  [0]      S controller program
+//	  [1..12]  signer, src_lst_mint, dst_lst_mint, src_lst_acc, dst_lst_acc,
+//	           protocol_fee_accumulator, src_lst_token_program, dst_lst_token_program,
+//	           pool_state, lst_state_list, src_pool_reserves, dst_pool_reserves
+//	  [13..]   src_lst_value_calc_accs accounts
+//	  [..]     dst_lst_value_calc_accs accounts
+//	  [..]     pricing program accounts
+//
+//	SanctumSAddLiquidity / SanctumSRemoveLiquidity:
+//	  [0]      S controller program
+//	  [1..11]  signer, lst_mint, lst_acc, lp_acc, lp_token_mint,
+//	           protocol_fee_accumulator, lst_token_program, lp_token_program,
+//	           pool_state, lst_state_list, pool_reserves
+//	  [12..]   lst_value_calc_accs accounts
+//	  [..]     pricing program accounts
+const (
+	sanctumSSwapFixedAccounts      = 12
+	sanctumSLiquidityFixedAccounts = 11
+)
+
+// mapSanctumSAccounts attaches the value-calculator account ranges to SanctumS steps.
+// The nth SanctumS step is matched to the nth occurrence of the S controller program
+// in the remaining accounts. Steps whose ranges run past the account list are left untouched.
+func mapSanctumSAccounts(params *JupiterSwapParams, remaining solana.PublicKeySlice) {
+	var programPositions []int
+	for i, key := range remaining {
+		if key.Equals(sanctumSProgramID) {
+			programPositions = append(programPositions, i)
+		}
+	}
+
+	occurrence := 0
+	for i := range params.RoutePlan {
+		step := &params.RoutePlan[i]
+		switch step.Swap.Type {
+		case SwapSanctumS, SwapSanctumSAddLiquidity, SwapSanctumSRemoveLiquidity:
+		default:
+			continue
+		}
+		if occurrence >= len(programPositions) {
+			return
+		}
+		start := programPositions[occurrence] + 1
+		occurrence++
+
+		if step.Swap.Type == SwapSanctumS {
+			srcCount, _ := step.Swap.Params["src_lst_value_calc_accs"].(uint8)
+			dstCount, _ := step.Swap.Params["dst_lst_value_calc_accs"].(uint8)
+
+			srcStart := start + sanctumSSwapFixedAccounts
+			dstStart := srcStart + int(srcCount)
+			if dstStart+int(dstCount) > len(remaining) {
+				continue
+			}
+			step.Accounts = map[string]solana.PublicKeySlice{
+				"src_lst_value_calc_accs": remaining[srcStart:dstStart],
+				"dst_lst_value_calc_accs": remaining[dstStart : dstStart+int(dstCount)],
+			}
+			continue
+		}
+
+		count, _ := step.Swap.Params["lst_value_calc_accs"].(uint8)
+		calcStart := start + sanctumSLiquidityFixedAccounts
+		if calcStart+int(count) > len(remaining) {
+			continue
+		}
+		step.Accounts = map[string]solana.PublicKeySlice{
+			"lst_value_calc_accs": remaining[calcStart : calcStart+int(count)],
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Sanity violation codes
+const (
+	SuspectSlippage    = "slippage_bps" // slippage_bps above MaxSlippageBps
+	SuspectPercent     = "percent"      // a route plan step percent above MaxPercent
+	SuspectRouteLength = "route_length" // more route plan steps than MaxRouteSteps
+	SuspectAmount      = "amount"       // an amount above the ceiling of its mint
+	SuspectExactOut    = "exact_out"    // exactOut out_amount differs from the output of the events
+)
+
+// errSuspectInstruction classifies instructions rejected by strict sanity bounds
+var errSuspectInstruction = errors.New("parsed values out of bounds")
+
+// SanityViolation is a parsed value outside the sanity bounds, typically the
+// result of a misparsed instruction
+type SanityViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// SanityBounds are the limits parsed instructions are checked against. Zero
+// fields disable their check.
+type SanityBounds struct {
+	MaxSlippageBps uint16 `json:"max_slippage_bps"`
+	MaxPercent     uint8  `json:"max_percent"`
+	MaxRouteSteps  int    `json:"max_route_steps"`
+	// MaxAmounts caps the raw amounts of known mints, optional
+	MaxAmounts map[solana.PublicKey]uint64 `json:"max_amounts,omitempty"`
+	// ExactOutToleranceBps is the accepted difference between the out_amount of
+	// an exactOut instruction and the output of its events, 0 requires equality
+	ExactOutToleranceBps uint16 `json:"exact_out_tolerance_bps,omitempty"`
+	// Strict reports suspect instructions as parse errors instead of output
+	Strict bool `json:"strict,omitempty"`
+}
+
+// DefaultSanityBounds returns bounds no valid Jupiter instruction exceeds
+func DefaultSanityBounds() SanityBounds {
+	return SanityBounds{
+		MaxSlippageBps: 10000,
+		MaxPercent:     100,
+		MaxRouteSteps:  8,
+	}
+}
+
+// WithSanityBounds sets the bounds parsed instructions are checked against.
+// DefaultSanityBounds apply unless set.
+func WithSanityBounds(bounds SanityBounds) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.sanity = bounds
+	}
+}
+
+// checkSanity records the bound violations of params in params.Suspect. With
+// strict bounds, an error listing them is returned instead.
+func checkSanity(params *JupiterSwapParams, bounds SanityBounds, accounts, accountKeys solana.PublicKeySlice, meta *rpc.TransactionMeta) error {
+	var violations []SanityViolation
+	add := func(code, format string, args ...interface{}) {
+		violations = append(violations, SanityViolation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if bounds.MaxSlippageBps > 0 && params.SlippageBps > bounds.MaxSlippageBps {
+		add(SuspectSlippage, "slippage_bps %d exceeds %d", params.SlippageBps, bounds.MaxSlippageBps)
+	}
+	if bounds.MaxRouteSteps > 0 && len(params.RoutePlan) > bounds.MaxRouteSteps {
+		add(SuspectRouteLength, "%d route plan steps exceed %d", len(params.RoutePlan), bounds.MaxRouteSteps)
+	}
+	if bounds.MaxPercent > 0 {
+		for i, step := range params.RoutePlan {
+			if step.Percent > bounds.MaxPercent {
+				add(SuspectPercent, "route plan step %d percent %d exceeds %d", i, step.Percent, bounds.MaxPercent)
+			}
+		}
+	}
+	if len(bounds.MaxAmounts) > 0 {
+		inputMint, outputMint := instructionMints(params, accounts, accountKeys, meta)
+		for _, amount := range []struct {
+			name  string
+			value uint64
+			mint  *solana.PublicKey
+		}{
+			{"in_amount", params.InAmount, inputMint},
+			{"quoted_in_amount", params.QuotedInAmount, inputMint},
+			{"out_amount", params.OutAmount, outputMint},
+			{"quoted_out_amount", params.QuotedOutAmount, outputMint},
+			{"min_amount_out", params.MinAmountOut, outputMint},
+		} {
+			if amount.mint == nil {
+				continue
+			}
+			if ceiling, ok := bounds.MaxAmounts[*amount.mint]; ok && amount.value > ceiling {
+				add(SuspectAmount, "%s %d exceeds %d for mint %s", amount.name, amount.value, ceiling, amount.mint)
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	if bounds.Strict {
+		messages := make([]string, len(violations))
+		for i, violation := range violations {
+			messages[i] = violation.Message
+		}
+		return fmt.Errorf("%w: %s", errSuspectInstruction, strings.Join(messages, "; "))
+	}
+	params.Suspect = violations
+	return nil
+}
+
+// instructionMints returns the mints of the source and destination token
+// accounts of the instruction according to the token balances, nil when unknown
+func instructionMints(params *JupiterSwapParams, accounts, accountKeys solana.PublicKeySlice, meta *rpc.TransactionMeta) (input, output *solana.PublicKey) {
+	source, destination, ok := userTokenAccounts(params.InstructionType, accounts)
+	if !ok || meta == nil {
+		return nil, nil
+	}
+	return tokenAccountMint(source, accountKeys, meta), tokenAccountMint(destination, accountKeys, meta)
+}
+
+// checkExactOutAmounts cross-checks the out_amount of exactOut instructions,
+// which the program enforces exactly, with the output of their events. A
+// mismatch points at a misparse and is recorded like a checkSanity violation;
+// with strict bounds the instruction is moved from Instructions to Errors.
+func checkExactOutAmounts(analysis *JupiterV6Analysis, bounds SanityBounds) {
+	kept := analysis.Instructions[:0]
+	for _, params := range analysis.Instructions {
+		violation, mismatch := exactOutMismatch(&params, analysis.Events, bounds.ExactOutToleranceBps)
+		if !mismatch {
+			kept = append(kept, params)
+			continue
+		}
+		if bounds.Strict {
+			message := fmt.Errorf("%w: %s", errSuspectInstruction, violation.Message).Error()
+			analysis.Stats.ParsedInstructions--
+			analysis.Stats.FailedInstructions++
+			analysis.Errors = append(analysis.Errors, InstructionError{Index: params.InstructionIndex, Code: CodeSuspectInstruction, Error: message})
+			for i := range analysis.Results {
+				if analysis.Results[i].Index == params.InstructionIndex {
+					analysis.Results[i].Code = CodeSuspectInstruction
+					analysis.Results[i].Error = message
+				}
+			}
+			continue
+		}
+		params.Suspect = append(params.Suspect, violation)
+		analysis.addWarning(CodeSuspectInstruction, "instruction %d: %s", params.InstructionIndex, violation.Message)
+		kept = append(kept, params)
+	}
+	analysis.Instructions = kept
+}
+
+// exactOutMismatch compares the out_amount of an exactOut instruction with the
+// net amount of the final mint its non dust events produced
+func exactOutMismatch(params *JupiterSwapParams, events []SwapEvent, toleranceBps uint16) (SanityViolation, bool) {
+	if !isExactOutInstruction(params.InstructionType) || params.OutAmount == 0 {
+		return SanityViolation{}, false
+	}
+	var own []SwapEvent
+	for _, event := range nonDustEvents(events) {
+		if event.InstructionIndex == params.InstructionIndex {
+			own = append(own, event)
+		}
+	}
+	if len(own) == 0 {
+		return SanityViolation{}, false
+	}
+
+	// Split routes may deliver the output mint from several legs
+	final := own[len(own)-1].OutputMint
+	realized := new(big.Int)
+	for _, event := range own {
+		if event.OutputMint.Equals(final) {
+			realized.Add(realized, new(big.Int).SetUint64(event.OutputAmount))
+		}
+		if event.InputMint.Equals(final) {
+			realized.Sub(realized, new(big.Int).SetUint64(event.InputAmount))
+		}
+	}
+
+	expected := new(big.Int).SetUint64(params.OutAmount)
+	difference := new(big.Int).Abs(new(big.Int).Sub(realized, expected))
+	tolerance := new(big.Int).Mul(expected, big.NewInt(int64(toleranceBps)))
+	tolerance.Quo(tolerance, big.NewInt(10000))
+	if difference.Cmp(tolerance) <= 0 {
+		return SanityViolation{}, false
+	}
+	return SanityViolation{
+		Code:    SuspectExactOut,
+		Message: fmt.Sprintf("out_amount %d differs from the %s output of the events by %s", params.OutAmount, realized, difference),
+	}, true
+}
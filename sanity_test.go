@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"sol-tx/testgen"
+)
+
+func TestCheckSanity(t *testing.T) {
+	var (
+		user        = testKey(1)
+		authority   = testKey(2)
+		source      = testKey(3)
+		destination = testKey(4)
+		inputMint   = testKey(5)
+		outputMint  = testKey(6)
+	)
+	accountKeys := solana.PublicKeySlice{user, source, destination, jupiterV6ProgramID, authority}
+	meta := &rpc.TransactionMeta{PreTokenBalances: []rpc.TokenBalance{
+		testTokenBalance(1, inputMint, user, 1000),
+		testTokenBalance(2, outputMint, user, 0),
+	}}
+	routeAccounts := solana.PublicKeySlice{solana.TokenProgramID, user, source, destination, jupiterV6ProgramID}
+	sharedAccounts := solana.PublicKeySlice{solana.TokenProgramID, authority, user, source, testKey(7), testKey(8), destination}
+
+	steps := func(n int, percent uint8) []RoutePlanStep {
+		plan := make([]RoutePlanStep, n)
+		for i := range plan {
+			plan[i].Percent = percent
+		}
+		return plan
+	}
+	bounds := DefaultSanityBounds()
+	bounds.MaxAmounts = map[solana.PublicKey]uint64{inputMint: 1000, outputMint: 500}
+
+	tests := []struct {
+		name     string
+		params   JupiterSwapParams
+		accounts solana.PublicKeySlice
+		want     []string
+	}{
+		{
+			name:     "within bounds",
+			params:   JupiterSwapParams{InstructionType: "route", RoutePlan: steps(2, 50), InAmount: 1000, QuotedOutAmount: 500, SlippageBps: 10000},
+			accounts: routeAccounts,
+		},
+		{
+			name:     "slippage",
+			params:   JupiterSwapParams{InstructionType: "route", RoutePlan: steps(1, 100), SlippageBps: 10001},
+			accounts: routeAccounts,
+			want:     []string{SuspectSlippage},
+		},
+		{
+			name:     "percent",
+			params:   JupiterSwapParams{InstructionType: "route", RoutePlan: steps(2, 101)},
+			accounts: routeAccounts,
+			want:     []string{SuspectPercent, SuspectPercent},
+		},
+		{
+			name:     "route length",
+			params:   JupiterSwapParams{InstructionType: "route", RoutePlan: steps(9, 100)},
+			accounts: routeAccounts,
+			want:     []string{SuspectRouteLength},
+		},
+		{
+			name:     "amounts of the route accounts",
+			params:   JupiterSwapParams{InstructionType: "route", RoutePlan: steps(1, 100), InAmount: 1001, QuotedOutAmount: 501},
+			accounts: routeAccounts,
+			want:     []string{SuspectAmount, SuspectAmount},
+		},
+		{
+			name:     "amounts of the shared accounts",
+			params:   JupiterSwapParams{InstructionType: "sharedAccountsExactOutRoute", RoutePlan: steps(1, 100), OutAmount: 501, QuotedInAmount: 1001},
+			accounts: sharedAccounts,
+			want:     []string{SuspectAmount, SuspectAmount},
+		},
+		{
+			name:   "amounts of unknown mints",
+			params: JupiterSwapParams{InstructionType: "route", RoutePlan: steps(1, 100), InAmount: 1 << 60},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			if err := checkSanity(&params, bounds, tt.accounts, accountKeys, meta); err != nil {
+				t.Fatalf("checkSanity: %v", err)
+			}
+			var got []string
+			for _, violation := range params.Suspect {
+				got = append(got, violation.Code)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("violations %v, want %v", params.Suspect, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("violation %d is %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+
+			strict := bounds
+			strict.Strict = true
+			params = tt.params
+			err := checkSanity(&params, strict, tt.accounts, accountKeys, meta)
+			if (err != nil) != (len(tt.want) > 0) || (err != nil && !errors.Is(err, errSuspectInstruction)) {
+				t.Errorf("strict checkSanity: %v", err)
+			}
+			if params.Suspect != nil {
+				t.Errorf("strict checkSanity recorded %v", params.Suspect)
+			}
+		})
+	}
+}
+
+func TestCheckExactOutAmounts(t *testing.T) {
+	var (
+		mintA = testKey(1)
+		mintB = testKey(2)
+	)
+	events := []SwapEvent{
+		{InstructionIndex: 0, InputMint: mintA, InputAmount: 500, OutputMint: mintB, OutputAmount: 990},
+	}
+	analysis := func(outAmount uint64) *JupiterV6Analysis {
+		return &JupiterV6Analysis{
+			Instructions: []JupiterSwapParams{{InstructionType: "exactOutRoute", OutAmount: outAmount}},
+			Results:      []InstructionResult{{Index: 0}},
+			Events:       events,
+			Stats:        AnalysisStats{ParsedInstructions: 1},
+		}
+	}
+
+	exact := analysis(990)
+	checkExactOutAmounts(exact, DefaultSanityBounds())
+	if len(exact.Instructions) != 1 || exact.Instructions[0].Suspect != nil {
+		t.Errorf("matching out_amount flagged: %+v", exact.Instructions)
+	}
+
+	tolerated := analysis(1000)
+	bounds := DefaultSanityBounds()
+	bounds.ExactOutToleranceBps = 100
+	checkExactOutAmounts(tolerated, bounds)
+	if tolerated.Instructions[0].Suspect != nil {
+		t.Errorf("out_amount within tolerance flagged: %v", tolerated.Instructions[0].Suspect)
+	}
+
+	soft := analysis(1000)
+	checkExactOutAmounts(soft, DefaultSanityBounds())
+	if suspect := soft.Instructions[0].Suspect; len(suspect) != 1 || suspect[0].Code != SuspectExactOut {
+		t.Errorf("suspect %v, want %s", suspect, SuspectExactOut)
+	}
+
+	hard := analysis(1000)
+	bounds = DefaultSanityBounds()
+	bounds.Strict = true
+	checkExactOutAmounts(hard, bounds)
+	if len(hard.Instructions) != 0 || len(hard.Errors) != 1 || hard.Errors[0].Code != CodeSuspectInstruction {
+		t.Errorf("strict: instructions %v errors %v", hard.Instructions, hard.Errors)
+	}
+	if hard.Results[0].Code != CodeSuspectInstruction || hard.Stats.FailedInstructions != 1 || hard.Stats.ParsedInstructions != 0 {
+		t.Errorf("strict: result %+v stats %+v", hard.Results[0], hard.Stats)
+	}
+}
+
+func TestExactOutMismatch(t *testing.T) {
+	var (
+		mintA = testKey(1)
+		mintB = testKey(2)
+		mintC = testKey(3)
+	)
+	hop := func(index int, in solana.PublicKey, inAmount uint64, out solana.PublicKey, outAmount uint64) SwapEvent {
+		return SwapEvent{InstructionIndex: index, InputMint: in, InputAmount: inAmount, OutputMint: out, OutputAmount: outAmount}
+	}
+	dust := hop(0, mintA, 1, mintB, 50)
+	dust.Dust = true
+
+	for _, tt := range []struct {
+		name            string
+		instructionType string
+		outAmount       uint64
+		events          []SwapEvent
+		mismatch        bool
+	}{
+		{"not exact out", "route", 1000, []SwapEvent{hop(0, mintA, 500, mintB, 900)}, false},
+		{"no out amount", "exactOutRoute", 0, []SwapEvent{hop(0, mintA, 500, mintB, 900)}, false},
+		{"no events", "exactOutRoute", 1000, nil, false},
+		{"split legs", "exactOutRoute", 1000, []SwapEvent{hop(0, mintA, 200, mintB, 400), hop(0, mintA, 300, mintB, 600)}, false},
+		{"split legs short", "exactOutRoute", 1100, []SwapEvent{hop(0, mintA, 200, mintB, 400), hop(0, mintA, 300, mintB, 600)}, true},
+		// 1000 B out, 300 B spent and 250 B back nets 950
+		{"final mint spent", "sharedAccountsExactOutRoute", 950, []SwapEvent{hop(0, mintA, 500, mintB, 1000), hop(0, mintB, 300, mintC, 90), hop(0, mintC, 90, mintB, 250)}, false},
+		{"final mint spent gross", "sharedAccountsExactOutRoute", 1250, []SwapEvent{hop(0, mintA, 500, mintB, 1000), hop(0, mintB, 300, mintC, 90), hop(0, mintC, 90, mintB, 250)}, true},
+		{"dust leg", "exactOutRoute", 1000, []SwapEvent{hop(0, mintA, 500, mintB, 1000), dust}, false},
+		{"other instruction", "exactOutRoute", 1000, []SwapEvent{hop(0, mintA, 500, mintB, 1000), hop(1, mintA, 500, mintB, 700)}, false},
+	} {
+		params := JupiterSwapParams{InstructionType: tt.instructionType, OutAmount: tt.outAmount}
+		violation, mismatch := exactOutMismatch(&params, tt.events, 0)
+		if mismatch != tt.mismatch {
+			t.Errorf("%s: mismatch %v, want %v", tt.name, mismatch, tt.mismatch)
+		}
+		if mismatch && violation.Code != SuspectExactOut {
+			t.Errorf("%s: violation %+v", tt.name, violation)
+		}
+	}
+}
+
+func TestAnalyzeFlagsExactOutMismatch(t *testing.T) {
+	// testgen simulates the hops from out_amount as if it were the input at
+	// seeded rates. Both hops of seed 7 return 100%, seed 1 loses on the way.
+	for seed, flagged := range map[int64]bool{7: false, 1: true} {
+		spec := testgenSpec("exactOutRoute")
+		spec.Seed = seed
+		gen, err := testgen.Generate(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		analysis := analyzeTest(t, newTestAnalyzer(), gen.Result, gen.Transaction)
+		last := analysis.Events[len(analysis.Events)-1]
+		if (last.OutputAmount != spec.Amount) != flagged {
+			t.Fatalf("seed %d: fixture output %d, out_amount %d", seed, last.OutputAmount, spec.Amount)
+		}
+		suspect := analysis.Instructions[0].Suspect
+		if flagged && (len(suspect) != 1 || suspect[0].Code != SuspectExactOut) || !flagged && len(suspect) != 0 {
+			t.Errorf("seed %d: suspect %v", seed, suspect)
+		}
+	}
+}
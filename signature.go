@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ErrTransactionNotFound is returned when the node has no transaction for a
+// signature, either because it does not exist or is not confirmed yet
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// TransactionOptions configures how transactions are requested from the RPC node
+type TransactionOptions struct {
+	// MaxSupportedTransactionVersion is sent as maxSupportedTransactionVersion.
+	// nil omits the field, which legacy-only nodes may require.
+	MaxSupportedTransactionVersion *uint64
+	Encoding                       solana.EncodingType
+	// Commitment overrides the analyzer commitment for GetTransaction when set
+	Commitment rpc.CommitmentType
+}
+
+// defaultTransactionOptions returns version 0 and base64 encoding
+func defaultTransactionOptions() TransactionOptions {
+	version := uint64(0)
+	return TransactionOptions{
+		MaxSupportedTransactionVersion: &version,
+		Encoding:                       solana.EncodingBase64,
+	}
+}
+
+// WithTransactionOptions overrides the options used by AnalyzeSignature to fetch transactions
+func WithTransactionOptions(opts TransactionOptions) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.txOpts = opts
+	}
+}
+
+// getTransactionOpts builds the rpc options for GetTransaction
+func (a *Analyzer) getTransactionOpts() *rpc.GetTransactionOpts {
+	opts := &rpc.GetTransactionOpts{
+		MaxSupportedTransactionVersion: a.txOpts.MaxSupportedTransactionVersion,
+		Encoding:                       a.txOpts.Encoding,
+		Commitment:                     a.txOpts.Commitment,
+	}
+	if opts.Encoding == "" {
+		opts.Encoding = solana.EncodingBase64
+	}
+	if opts.Commitment == "" {
+		opts.Commitment = a.commitment
+	}
+	opts.Commitment = transactionCommitment(opts.Commitment)
+	return opts
+}
+
+// transactionCommitment returns the commitment to fetch transactions at.
+// getTransaction does not serve processed, confirmed is the closest level.
+func transactionCommitment(commitment rpc.CommitmentType) rpc.CommitmentType {
+	if commitment == rpc.CommitmentProcessed {
+		return rpc.CommitmentConfirmed
+	}
+	return commitment
+}
+
+// fetchTransaction fetches and decodes a transaction, resolving its address lookup tables
+func (a *Analyzer) fetchTransaction(ctx context.Context, signature solana.Signature) (*rpc.GetTransactionResult, *solana.Transaction, error) {
+	return a.fetchTransactionWithOpts(ctx, signature, a.getTransactionOpts())
+}
+
+// fetchTransactionWithOpts is fetchTransaction with explicit GetTransaction options
+func (a *Analyzer) fetchTransactionWithOpts(ctx context.Context, signature solana.Signature, opts *rpc.GetTransactionOpts) (*rpc.GetTransactionResult, *solana.Transaction, error) {
+	if a.source == nil {
+		return nil, nil, fmt.Errorf("analyzer has no transaction source")
+	}
+
+	// Get transaction with version support
+	var tx *rpc.GetTransactionResult
+	err := a.withRPCSlot(ctx, func() error {
+		var err error
+		tx, err = a.source.GetTransaction(ctx, signature, opts)
+		return err
+	})
+	if errors.Is(err, rpc.ErrNotFound) || (err == nil && (tx == nil || tx.Transaction == nil)) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrTransactionNotFound, signature)
+	}
+	if errors.Is(err, ErrNetworkDisabled) {
+		return nil, nil, fmt.Errorf("%w: cannot fetch %s", ErrNetworkDisabled, signature)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting transaction: %v", err)
+	}
+
+	// Parse the transaction
+	parsedTx, err := tx.Transaction.GetTransaction()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing transaction: %v", err)
+	}
+
+	// Process versioned transactions with address lookup tables
+	if parsedTx.Message.IsVersioned() {
+		if a.lookupTableProvider() != nil {
+			err = a.resolveAddressLookupTables(ctx, parsedTx)
+		} else {
+			err = resolveLookupsFromMeta(parsedTx, tx.Meta)
+		}
+		if err != nil && a.lenientLookups {
+			// Analyze with the static keys only, LookupsFullyResolved reports it
+			fmt.Fprintf(a.logOutput, "Lookup tables left unresolved: %v\n", err)
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("error resolving address lookup tables: %v", err)
+		}
+	}
+
+	return tx, parsedTx, nil
+}
+
+// AnalyzeSignature fetches the transaction for signature and analyzes it. With
+// WithForbiddenRouteError, a route using a forbidden variant returns the
+// analysis together with a *ForbiddenRouteError.
+func (a *Analyzer) AnalyzeSignature(ctx context.Context, signature solana.Signature) (*JupiterV6Analysis, error) {
+	tx, parsedTx, err := a.fetchTransaction(ctx, signature)
+	if err != nil {
+		return nil, err
+	}
+	// Sources serving from memory never observe ctx, check it before parsing
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.forbiddenRouteResult(a.Analyze(tx, parsedTx))
+}
+
+// commitmentRank orders commitment levels, unknown levels rank lowest
+func commitmentRank(level string) int {
+	switch level {
+	case string(rpc.CommitmentProcessed):
+		return 1
+	case string(rpc.CommitmentConfirmed):
+		return 2
+	case string(rpc.CommitmentFinalized):
+		return 3
+	default:
+		return 0
+	}
+}
+
+// AnalyzeConfirmedSignature checks the signature status with getSignatureStatuses
+// and only fetches and analyzes the transaction once it reached minCommitment.
+// Signatures that are unknown or not yet at minCommitment return
+// ErrTransactionNotFound without a getTransaction call, so polling loops can
+// retry cheaply. The transaction is fetched at minCommitment, or confirmed
+// when minCommitment is processed since getTransaction does not serve it.
+// Forbidden routes are reported like AnalyzeSignature does.
+func (a *Analyzer) AnalyzeConfirmedSignature(ctx context.Context, signature solana.Signature, minCommitment rpc.CommitmentType) (*JupiterV6Analysis, error) {
+	if a.noNetwork {
+		return nil, fmt.Errorf("%w: cannot check status of %s", ErrNetworkDisabled, signature)
+	}
+	if a.rpcClient == nil {
+		return nil, fmt.Errorf("analyzer has no rpc client")
+	}
+	if commitmentRank(string(minCommitment)) == 0 {
+		return nil, fmt.Errorf("unsupported commitment: %q", minCommitment)
+	}
+
+	var statuses *rpc.GetSignatureStatusesResult
+	err := a.withRPCSlot(ctx, func() error {
+		var err error
+		statuses, err = a.rpcClient.GetSignatureStatuses(ctx, false, signature)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting signature status: %v", err)
+	}
+	if statuses == nil || len(statuses.Value) == 0 || statuses.Value[0] == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTransactionNotFound, signature)
+	}
+	status := statuses.Value[0]
+	if commitmentRank(string(status.ConfirmationStatus)) < commitmentRank(string(minCommitment)) {
+		return nil, fmt.Errorf("%w: %s is %s, waiting for %s", ErrTransactionNotFound, signature, status.ConfirmationStatus, minCommitment)
+	}
+
+	opts := a.getTransactionOpts()
+	opts.Commitment = transactionCommitment(minCommitment)
+	tx, parsedTx, err := a.fetchTransactionWithOpts(ctx, signature, opts)
+	if err != nil {
+		return nil, err
+	}
+	return a.forbiddenRouteResult(a.Analyze(tx, parsedTx))
+}
+
+// SummarizeSignature analyzes the transaction for signature and returns only its summary
+func (a *Analyzer) SummarizeSignature(ctx context.Context, signature solana.Signature) (*SwapSummary, error) {
+	analysis, err := a.AnalyzeSignature(ctx, signature)
+	if err != nil {
+		return nil, err
+	}
+	return &analysis.Summary, nil
+}
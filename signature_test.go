@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"sol-tx/testgen"
+)
+
+// recordingSource records the options of every fetch and has no transactions
+type recordingSource struct {
+	opts []*rpc.GetTransactionOpts
+}
+
+func (s *recordingSource) GetTransaction(ctx context.Context, signature solana.Signature, opts *rpc.GetTransactionOpts) (*rpc.GetTransactionResult, error) {
+	s.opts = append(s.opts, opts)
+	return nil, rpc.ErrNotFound
+}
+
+func TestTransactionCommitment(t *testing.T) {
+	for commitment, want := range map[rpc.CommitmentType]rpc.CommitmentType{
+		rpc.CommitmentProcessed: rpc.CommitmentConfirmed,
+		rpc.CommitmentConfirmed: rpc.CommitmentConfirmed,
+		rpc.CommitmentFinalized: rpc.CommitmentFinalized,
+	} {
+		source := &recordingSource{}
+		a := newTestAnalyzer(WithTransactionSource(source), WithCommitment(commitment))
+		if _, err := a.AnalyzeSignature(context.Background(), solana.Signature{1}); err == nil {
+			t.Fatal("expected not found")
+		}
+		if len(source.opts) != 1 || source.opts[0].Commitment != want {
+			t.Errorf("%s: fetched at %v, want %s", commitment, source.opts, want)
+		}
+	}
+}
+
+// commitmentRecorder records the commitment each provider call receives
+type commitmentRecorder struct {
+	seen []rpc.CommitmentType
+}
+
+func (r *commitmentRecorder) record(ctx context.Context) {
+	r.seen = append(r.seen, CommitmentFromContext(ctx, "none"))
+}
+
+func (r *commitmentRecorder) MintRisk(ctx context.Context, mint solana.PublicKey) (*MintRisk, error) {
+	r.record(ctx)
+	return &MintRisk{Mint: mint}, nil
+}
+
+func (r *commitmentRecorder) BlockComputeUnitPrices(ctx context.Context, slot uint64) ([]uint64, error) {
+	r.record(ctx)
+	return nil, nil
+}
+
+func (r *commitmentRecorder) GetLookupTable(ctx context.Context, tableID solana.PublicKey) (solana.PublicKeySlice, error) {
+	r.record(ctx)
+	return solana.PublicKeySlice{testKey(40)}, nil
+}
+
+func TestProvidersReceiveCommitment(t *testing.T) {
+	gen, err := testgen.Generate(testgenSpec("route"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := &commitmentRecorder{}
+	a := newTestAnalyzer(WithCommitment(rpc.CommitmentConfirmed), WithMintRiskProvider(recorder), WithPriorityFeeProvider(recorder), WithLookupTableProvider(recorder))
+	analyzeTest(t, a, gen.Result, gen.Transaction)
+
+	parsedTx := &solana.Transaction{Message: solana.Message{AccountKeys: solana.PublicKeySlice{testKey(1)}}}
+	parsedTx.Message.SetAddressTableLookups([]solana.MessageAddressTableLookup{{AccountKey: testKey(30), ReadonlyIndexes: []uint8{0}}})
+	if err := a.resolveAddressLookupTables(context.Background(), parsedTx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Three mints, one block and one lookup table
+	if len(recorder.seen) != 5 {
+		t.Fatalf("%d provider calls, want 5", len(recorder.seen))
+	}
+	for i, commitment := range recorder.seen {
+		if commitment != rpc.CommitmentConfirmed {
+			t.Errorf("call %d received %q, want confirmed", i, commitment)
+		}
+	}
+}
+
+func TestAnalyzeSignatureConcatenatedEvents(t *testing.T) {
+	gen, err := testgen.Generate(testgenSpec("route"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both swap events and a fee event in one self-CPI payload, followed by an
+	// unknown event that ends decoding
+	payload := append([]byte{}, gen.Events[0]...)
+	payload = append(payload, gen.Events[1][8:]...)
+	payload = append(payload, feeEventTypeDiscriminator...)
+	payload = append(payload, make([]byte, feeEventBodySize)...)
+	unknown := []byte{0xDE, 0xAD, 0xBE, 0xEF, 0, 0, 0, 0, 1, 2}
+	payload = append(payload, unknown...)
+	inner := &gen.Result.Meta.InnerInstructions[0]
+	inner.Instructions[0].Data = payload
+	inner.Instructions = inner.Instructions[:1]
+
+	a := newTestAnalyzer(WithTransactionSource(staticSource{gen.Result}))
+	analysis, err := a.AnalyzeSignature(context.Background(), solana.Signature{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.Events) != 2 {
+		t.Fatalf("%d events, want 2", len(analysis.Events))
+	}
+	for i, event := range analysis.Events {
+		if !bytes.Equal(event.Encode(), gen.Events[i]) || event.InstructionIndex != 0 {
+			t.Errorf("event %d: %+v", i, event)
+		}
+	}
+	found := false
+	for _, warning := range analysis.Warnings {
+		if warning.Code == CodeUnparsedEventData {
+			found = strings.Contains(warning.Message, fmt.Sprintf("%X", unknown))
+		}
+	}
+	if !found {
+		t.Errorf("unknown event bytes not reported: %v", analysis.Warnings)
+	}
+}
+
+// sourceFunc adapts a function to a TransactionSource
+type sourceFunc func() (*rpc.GetTransactionResult, error)
+
+func (f sourceFunc) GetTransaction(ctx context.Context, signature solana.Signature, opts *rpc.GetTransactionOpts) (*rpc.GetTransactionResult, error) {
+	return f()
+}
+
+func TestTransactionNotFound(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		source   sourceFunc
+		notFound bool
+	}{
+		{"rpc not found", func() (*rpc.GetTransactionResult, error) { return nil, rpc.ErrNotFound }, true},
+		{"nil result", func() (*rpc.GetTransactionResult, error) { return nil, nil }, true},
+		{"result without transaction", func() (*rpc.GetTransactionResult, error) { return &rpc.GetTransactionResult{}, nil }, true},
+		{"rpc failure", func() (*rpc.GetTransactionResult, error) { return nil, errors.New("connection reset") }, false},
+	} {
+		a := newTestAnalyzer(WithTransactionSource(tt.source))
+		_, err := a.AnalyzeSignature(context.Background(), solana.Signature{1})
+		if err == nil || errors.Is(err, ErrTransactionNotFound) != tt.notFound {
+			t.Errorf("%s: error %v, not found %v", tt.name, err, tt.notFound)
+		}
+	}
+
+	if _, err := newTestAnalyzer().Analyze(nil, nil); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("Analyze without transaction: %v", err)
+	}
+}
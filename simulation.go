@@ -0,0 +1,37 @@
+package main
+
+import "fmt"
+
+// AnalyzeSimulation analyzes the result of a simulateTransaction call.
+// A simulation has no confirmed transaction to decode instructions from,
+// so the analysis is built from the events found in the simulation logs
+// and, when present, the returnData payload.
+func AnalyzeSimulation(logs []string, returnData []byte) (*JupiterV6Analysis, error) {
+	if len(logs) == 0 && len(returnData) == 0 {
+		return nil, fmt.Errorf("simulation has no logs or return data")
+	}
+
+	analysis := &JupiterV6Analysis{
+		Instructions: []JupiterSwapParams{},
+		Events:       []SwapEvent{},
+	}
+
+	analysis.JupiterVersion = detectJupiterVersion(logs)
+
+	// 1. Extract events from logs
+	analysis.Events = append(analysis.Events, extractJupiterEventsFromLogs(logs, defaultScanLimits(), &analysis.Stats)...)
+
+	// 2. Return data may carry a swap event as well
+	if len(returnData) > 0 {
+		event, err := parseJupiterSwapEvent(returnData)
+		if err == nil {
+			event.InstructionIndex = -1
+			analysis.Events = append(analysis.Events, *event)
+		}
+	}
+
+	// 3. Generate summary
+	analysis.Summary = generateSwapSummary(analysis.Instructions, analysis.Events)
+
+	return analysis, nil
+}
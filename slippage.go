@@ -0,0 +1,94 @@
+package main
+
+import (
+	"github.com/gagliardetto/solana-go"
+
+	"sol-tx/jupiterv6"
+)
+
+// ExecutionQuality compares the quoted amount of the first Jupiter instruction
+// with the amount actually realized by the swap events. Amounts are denominated
+// in the output token for exactIn and in the input token for exactOut.
+type ExecutionQuality struct {
+	ExactOut            bool             `json:"exact_out"`
+	Mint                solana.PublicKey `json:"mint"`
+	QuotedAmount        uint64           `json:"quoted_amount"`
+	ExecutedAmount      uint64           `json:"executed_amount"`
+	SlippageAllowance   uint64           `json:"slippage_allowance"`
+	SlippageAllowanceUI string           `json:"slippage_allowance_ui,omitempty"`
+	GiveUp              uint64           `json:"give_up"`     // How much worse than quote
+	Improvement         uint64           `json:"improvement"` // How much better than quote
+	RealizedUI          string           `json:"realized_ui,omitempty"`
+}
+
+// applySlippageBps applies bps to amount, see jupiterv6.ApplySlippageBps
+var applySlippageBps = jupiterv6.ApplySlippageBps
+
+// isExactOutInstruction reports whether the instruction type fixes the output amount
+func isExactOutInstruction(instructionType string) bool {
+	return jupiterv6.IsExactOut(instructionType)
+}
+
+// formatSlippageUI formats an absolute slippage allowance, e.g. "up to 0.42 USDC worse than quote"
+func formatSlippageUI(amount uint64, mint solana.PublicKey, registry TokenRegistry) string {
+	return "up to " + formatTokenAmount(amount, mint, registry) + " worse than quote"
+}
+
+// computeExecutionQuality derives the execution quality of the analysis and fills the
+// denominated slippage strings of its instructions. Like the summary it leaves dust
+// events out. It returns nil when there is no instruction or no event to compare against.
+func computeExecutionQuality(analysis *JupiterV6Analysis, registry TokenRegistry) *ExecutionQuality {
+	events := nonDustEvents(analysis.Events)
+	if len(analysis.Instructions) == 0 || len(events) == 0 {
+		return nil
+	}
+
+	first := events[0]
+	last := events[len(events)-1]
+
+	for i := range analysis.Instructions {
+		inst := &analysis.Instructions[i]
+		mint := last.OutputMint
+		if isExactOutInstruction(inst.InstructionType) {
+			mint = first.InputMint
+		}
+		inst.SlippageAllowanceUI = formatSlippageUI(inst.SlippageAllowance, mint, registry)
+	}
+
+	inst := analysis.Instructions[0]
+	quality := &ExecutionQuality{
+		ExactOut:          isExactOutInstruction(inst.InstructionType),
+		SlippageAllowance: inst.SlippageAllowance,
+	}
+
+	if quality.ExactOut {
+		quality.Mint = first.InputMint
+		quality.QuotedAmount = inst.QuotedInAmount
+		quality.ExecutedAmount = first.InputAmount
+		// Spending more input than quoted is worse
+		if quality.ExecutedAmount > quality.QuotedAmount {
+			quality.GiveUp = quality.ExecutedAmount - quality.QuotedAmount
+		} else {
+			quality.Improvement = quality.QuotedAmount - quality.ExecutedAmount
+		}
+	} else {
+		quality.Mint = last.OutputMint
+		quality.QuotedAmount = inst.QuotedOutAmount
+		quality.ExecutedAmount = last.OutputAmount
+		// Receiving less output than quoted is worse
+		if quality.ExecutedAmount < quality.QuotedAmount {
+			quality.GiveUp = quality.QuotedAmount - quality.ExecutedAmount
+		} else {
+			quality.Improvement = quality.ExecutedAmount - quality.QuotedAmount
+		}
+	}
+
+	quality.SlippageAllowanceUI = formatSlippageUI(quality.SlippageAllowance, quality.Mint, registry)
+	if quality.GiveUp > 0 {
+		quality.RealizedUI = formatTokenAmount(quality.GiveUp, quality.Mint, registry) + " worse than quote"
+	} else {
+		quality.RealizedUI = formatTokenAmount(quality.Improvement, quality.Mint, registry) + " better than quote"
+	}
+
+	return quality
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"sol-tx/testgen"
+)
+
+func TestApplySlippageBps(t *testing.T) {
+	for _, tt := range []struct {
+		amount uint64
+		bps    uint16
+		up     bool
+		want   uint64
+	}{
+		{1000, 0, false, 1000},
+		{1000, 9999, false, 0},
+		{1000, 10000, false, 0},
+		{1000, math.MaxUint16, false, 0},
+		{1000, 0, true, 1000},
+		{1000, 9999, true, 1999},
+		{1000, 10000, true, 2000},
+		{math.MaxUint64, 0, false, math.MaxUint64},
+		{math.MaxUint64, 9999, false, math.MaxUint64 / 10000},
+		{math.MaxUint64, 10000, false, 0},
+		{math.MaxUint64, 0, true, math.MaxUint64},
+		// Results above uint64 saturate instead of wrapping
+		{math.MaxUint64, 9999, true, math.MaxUint64},
+		{math.MaxUint64, 10000, true, math.MaxUint64},
+		{math.MaxUint64 - 1, 1, true, math.MaxUint64},
+	} {
+		if got := applySlippageBps(tt.amount, tt.bps, tt.up); got != tt.want {
+			t.Errorf("applySlippageBps(%d, %d, %v) = %d, want %d", tt.amount, tt.bps, tt.up, got, tt.want)
+		}
+	}
+}
+
+func TestExecutionQualitySkipsDust(t *testing.T) {
+	mintA, mintB, mintC := testKey(1), testKey(2), testKey(3)
+	// Dust hops before and after the real swap
+	events := []SwapEvent{
+		{InputMint: mintC, InputAmount: 1, OutputMint: mintA, OutputAmount: 1},
+		{InputMint: mintA, InputAmount: 1000, OutputMint: mintB, OutputAmount: 950},
+		{InputMint: mintB, InputAmount: 1, OutputMint: mintC, OutputAmount: 1},
+	}
+	markDustEvents(events, DustThreshold{Default: 10})
+
+	for _, tt := range []struct {
+		inst         JupiterSwapParams
+		mint         uint8
+		executed     uint64
+		giveUp       uint64
+		improvement  uint64
+		summaryTotal func(SwapSummary) uint64
+	}{
+		{
+			inst:         JupiterSwapParams{InstructionType: "route", QuotedOutAmount: 960},
+			mint:         2,
+			executed:     950,
+			giveUp:       10,
+			summaryTotal: func(s SwapSummary) uint64 { return s.TotalOutput },
+		},
+		{
+			inst:         JupiterSwapParams{InstructionType: "exactOutRoute", QuotedInAmount: 1005},
+			mint:         1,
+			executed:     1000,
+			improvement:  5,
+			summaryTotal: func(s SwapSummary) uint64 { return s.TotalInput },
+		},
+	} {
+		analysis := &JupiterV6Analysis{Instructions: []JupiterSwapParams{tt.inst}, Events: events}
+		quality := computeExecutionQuality(analysis, nil)
+		if quality == nil {
+			t.Fatalf("%s: no execution quality", tt.inst.InstructionType)
+		}
+		if !quality.Mint.Equals(testKey(tt.mint)) || quality.ExecutedAmount != tt.executed || quality.GiveUp != tt.giveUp || quality.Improvement != tt.improvement {
+			t.Errorf("%s: quality %+v", tt.inst.InstructionType, quality)
+		}
+		// The summary and the execution quality read the same events
+		summary := generateSwapSummary(analysis.Instructions, nonDustEvents(analysis.Events))
+		if got := tt.summaryTotal(summary); got != quality.ExecutedAmount {
+			t.Errorf("%s: summary total %d, executed %d", tt.inst.InstructionType, got, quality.ExecutedAmount)
+		}
+	}
+
+	// Only dust: nothing to compare against
+	dust := &JupiterV6Analysis{Instructions: []JupiterSwapParams{{InstructionType: "route"}}, Events: []SwapEvent{events[0], events[2]}}
+	if quality := computeExecutionQuality(dust, nil); quality != nil {
+		t.Errorf("dust only: quality %+v", quality)
+	}
+}
+
+func TestSlippageAllowance(t *testing.T) {
+	for _, tt := range []struct {
+		instructionType string
+		slippageBps     uint16
+		allowance       uint64
+		ui              string
+		realized        string
+	}{
+		// 990000 quoted out, at least 985050 out, 918000 received
+		{"route", 50, 4_950, "up to 4.95 OUT worse than quote", "72 OUT worse than quote"},
+		// 990000 quoted in, at most 999900 in, 1000000 spent
+		{"exactOutRoute", 100, 9_900, "up to 0.0099 IN worse than quote", "0.01 IN worse than quote"},
+	} {
+		spec := testgenSpec(tt.instructionType)
+		spec.SlippageBps = tt.slippageBps
+		gen, err := testgen.Generate(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		registry := StaticTokenRegistry{
+			gen.Mints[0]: {Symbol: "IN", Decimals: 6},
+			gen.Mints[2]: {Symbol: "OUT", Decimals: 3},
+		}
+		analysis := analyzeTest(t, newTestAnalyzer(WithTokenRegistry(registry)), gen.Result, gen.Transaction)
+
+		inst := analysis.Instructions[0]
+		if inst.SlippageAllowance != tt.allowance || inst.SlippageAllowanceUI != tt.ui {
+			t.Errorf("%s: instruction allowance %d %q, want %d %q", tt.instructionType, inst.SlippageAllowance, inst.SlippageAllowanceUI, tt.allowance, tt.ui)
+		}
+		quality := analysis.ExecutionQuality
+		if quality == nil {
+			t.Fatalf("%s: no execution quality", tt.instructionType)
+		}
+		if quality.SlippageAllowance != tt.allowance || quality.SlippageAllowanceUI != tt.ui || quality.RealizedUI != tt.realized {
+			t.Errorf("%s: quality allowance %d %q realized %q, want %d %q %q", tt.instructionType,
+				quality.SlippageAllowance, quality.SlippageAllowanceUI, quality.RealizedUI, tt.allowance, tt.ui, tt.realized)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Snapshot harness layout
+const (
+	defaultFixturesDir = "testdata/fixtures"
+	defaultGoldenDir   = "testdata/golden"
+	allowlistFile      = "allowlist.txt"
+)
+
+// SnapshotDiff lists the JSON paths that differ between a golden snapshot and the current output
+type SnapshotDiff struct {
+	Fixture string   `json:"fixture"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// Empty reports whether the snapshot is unchanged
+func (d SnapshotDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// loadFixture reads a getTransaction result stored as JSON
+func loadFixture(path string) (*rpc.GetTransactionResult, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tx rpc.GetTransactionResult
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		return nil, fmt.Errorf("error decoding fixture %s: %v", path, err)
+	}
+	return &tx, nil
+}
+
+// analyzeOffline analyzes an already fetched transaction without any rpc call,
+// resolving lookup tables from the loaded addresses in its meta
+func (a *Analyzer) analyzeOffline(tx *rpc.GetTransactionResult) (*JupiterV6Analysis, error) {
+	if tx == nil || tx.Transaction == nil {
+		return nil, ErrTransactionNotFound
+	}
+	parsedTx, err := tx.Transaction.GetTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing transaction: %v", err)
+	}
+	if err := resolveLookupsFromMeta(parsedTx, tx.Meta); err != nil {
+		return nil, err
+	}
+	return a.Analyze(tx, parsedTx)
+}
+
+// flattenJSON flattens decoded JSON into dotted paths mapped to their encoded leaf values
+func flattenJSON(prefix string, value interface{}, out map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flattenJSON(prefix+"."+key, child, out)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenJSON(fmt.Sprintf("%s[%d]", prefix, i), child, out)
+		}
+	default:
+		encoded, _ := json.Marshal(v)
+		out[prefix] = string(encoded)
+	}
+}
+
+// diffSnapshots compares two JSON documents path by path, ignoring allowlisted paths
+func diffSnapshots(fixture string, golden, current []byte, allowed map[string]bool) (SnapshotDiff, error) {
+	diff := SnapshotDiff{Fixture: fixture}
+
+	var goldenValue, currentValue interface{}
+	if err := json.Unmarshal(golden, &goldenValue); err != nil {
+		return diff, fmt.Errorf("error decoding golden %s: %v", fixture, err)
+	}
+	if err := json.Unmarshal(current, &currentValue); err != nil {
+		return diff, fmt.Errorf("error decoding output %s: %v", fixture, err)
+	}
+
+	before := make(map[string]string)
+	after := make(map[string]string)
+	flattenJSON("$", goldenValue, before)
+	flattenJSON("$", currentValue, after)
+
+	for path, value := range after {
+		if allowed[fixture+" "+path] || allowed["* "+path] {
+			continue
+		}
+		old, ok := before[path]
+		if !ok {
+			diff.Added = append(diff.Added, path)
+		} else if old != value {
+			diff.Changed = append(diff.Changed, fmt.Sprintf("%s: %s -> %s", path, old, value))
+		}
+	}
+	for path := range before {
+		if allowed[fixture+" "+path] || allowed["* "+path] {
+			continue
+		}
+		if _, ok := after[path]; !ok {
+			diff.Removed = append(diff.Removed, path)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff, nil
+}
+
+// loadAllowlist reads "<fixture|*> <json path>" lines of intentional changes
+func loadAllowlist(path string) (map[string]bool, error) {
+	allowed := make(map[string]bool)
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return allowed, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		allowed[strings.Join(strings.Fields(line), " ")] = true
+	}
+	return allowed, scanner.Err()
+}
+
+// runSnapshots analyzes every fixture and compares it with its golden snapshot,
+// or rewrites the goldens when update is set. It returns the diffs of changed fixtures.
+func runSnapshots(fixturesDir, goldenDir string, update bool) ([]SnapshotDiff, error) {
+	paths, err := filepath.Glob(filepath.Join(fixturesDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	allowed, err := loadAllowlist(filepath.Join(goldenDir, allowlistFile))
+	if err != nil {
+		return nil, fmt.Errorf("error reading allowlist: %v", err)
+	}
+
+	analyzer := NewAnalyzer(nil, WithNoNetwork(), WithLogOutput(io.Discard))
+	var diffs []SnapshotDiff
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+
+		tx, err := loadFixture(path)
+		if err != nil {
+			return nil, err
+		}
+		analysis, err := analyzer.analyzeOffline(tx)
+		if err != nil {
+			return nil, fmt.Errorf("error analyzing fixture %s: %v", name, err)
+		}
+		current, err := json.MarshalIndent(analysis, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		goldenPath := filepath.Join(goldenDir, name+".json")
+		if update {
+			if err := os.MkdirAll(goldenDir, 0o755); err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(goldenPath, append(current, '\n'), 0o644); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		golden, err := os.ReadFile(goldenPath)
+		if os.IsNotExist(err) {
+			diffs = append(diffs, SnapshotDiff{Fixture: name, Added: []string{"$"}})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(bytes.TrimSpace(golden), bytes.TrimSpace(current)) {
+			continue
+		}
+
+		diff, err := diffSnapshots(name, golden, current, allowed)
+		if err != nil {
+			return nil, err
+		}
+		if !diff.Empty() {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs, nil
+}
+
+// writeSnapshotReport prints a human-readable change report
+func writeSnapshotReport(w io.Writer, diffs []SnapshotDiff) {
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "All snapshots match.")
+		return
+	}
+	for _, diff := range diffs {
+		fmt.Fprintf(w, "%s: %d added, %d removed, %d changed\n", diff.Fixture, len(diff.Added), len(diff.Removed), len(diff.Changed))
+		for _, path := range diff.Added {
+			fmt.Fprintf(w, "  + %s\n", path)
+		}
+		for _, path := range diff.Removed {
+			fmt.Fprintf(w, "  - %s\n", path)
+		}
+		for _, change := range diff.Changed {
+			fmt.Fprintf(w, "  ~ %s\n", change)
+		}
+	}
+}
+
+// runSnapshotCommand implements "snapshot [-update] [-fixtures dir] [-golden dir]"
+func runSnapshotCommand(args []string) int {
+	flags := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	update := flags.Bool("update", false, "regenerate golden snapshots")
+	fixturesDir := flags.String("fixtures", defaultFixturesDir, "directory of getTransaction fixtures")
+	goldenDir := flags.String("golden", defaultGoldenDir, "directory of golden snapshots")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	diffs, err := runSnapshots(*fixturesDir, *goldenDir, *update)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running snapshots: %v\n", err)
+		return 1
+	}
+	if *update {
+		fmt.Println("Golden snapshots updated.")
+		return 0
+	}
+
+	writeSnapshotReport(os.Stdout, diffs)
+	if len(diffs) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// TestSnapshots compares the analysis of every fixture in testdata/fixtures
+// with its golden snapshot. Regenerate them with "go run . snapshot -update".
+func TestSnapshots(t *testing.T) {
+	diffs, err := runSnapshots(defaultFixturesDir, defaultGoldenDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) > 0 {
+		var report bytes.Buffer
+		writeSnapshotReport(&report, diffs)
+		t.Errorf("snapshots differ:\n%s", report.String())
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	golden := []byte(`{"a": 1, "b": {"c": [1, 2]}, "stats": {"n": 1}, "gone": true}`)
+	current := []byte(`{"a": 2, "b": {"c": [1, 2, 3]}, "stats": {"n": 2}}`)
+	allowed := map[string]bool{"* $.stats.n": true}
+
+	diff, err := diffSnapshots("fixture", golden, current, allowed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SnapshotDiff{
+		Fixture: "fixture",
+		Added:   []string{"$.b.c[2]"},
+		Removed: []string{"$.gone"},
+		Changed: []string{"$.a: 1 -> 2"},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("diff %+v, want %+v", diff, want)
+	}
+
+	allowed["fixture $.a"] = true
+	allowed["fixture $.b.c[2]"] = true
+	allowed["* $.gone"] = true
+	if diff, _ := diffSnapshots("fixture", golden, current, allowed); !diff.Empty() {
+		t.Errorf("allowlisted changes reported: %+v", diff)
+	}
+}
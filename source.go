@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// TransactionSource provides transactions to the analyzer. Implementations may
+// cache, replay fixtures or read from a non-RPC feed such as Geyser.
+type TransactionSource interface {
+	GetTransaction(ctx context.Context, signature solana.Signature, opts *rpc.GetTransactionOpts) (*rpc.GetTransactionResult, error)
+}
+
+// ErrNetworkDisabled is returned instead of performing an rpc call when the
+// analyzer was created with WithNoNetwork
+var ErrNetworkDisabled = errors.New("network access disabled")
+
+// rpcTransactionSource is the default source backed by an rpc client
+type rpcTransactionSource struct {
+	client *rpc.Client
+}
+
+// GetTransaction calls getTransaction on the rpc node
+func (s rpcTransactionSource) GetTransaction(ctx context.Context, signature solana.Signature, opts *rpc.GetTransactionOpts) (*rpc.GetTransactionResult, error) {
+	return s.client.GetTransaction(ctx, signature, opts)
+}
+
+// NewRPCTransactionSource wraps an rpc client as a TransactionSource
+func NewRPCTransactionSource(client *rpc.Client) TransactionSource {
+	return rpcTransactionSource{client: client}
+}
+
+// WithTransactionSource sets the source AnalyzeSignature fetches transactions from.
+// Without an rpc client, lookup tables are resolved from the loaded addresses
+// in the transaction meta.
+func WithTransactionSource(source TransactionSource) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.source = source
+	}
+}
+
+// disabledSource rejects every fetch, it replaces the rpc source under WithNoNetwork
+type disabledSource struct{}
+
+// GetTransaction returns ErrNetworkDisabled
+func (disabledSource) GetTransaction(ctx context.Context, signature solana.Signature, opts *rpc.GetTransactionOpts) (*rpc.GetTransactionResult, error) {
+	return nil, ErrNetworkDisabled
+}
+
+// disabledRPCClient is the transport of the rpc client installed by
+// WithNoNetwork, it fails every call with ErrNetworkDisabled
+type disabledRPCClient struct{}
+
+func (disabledRPCClient) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	return ErrNetworkDisabled
+}
+
+func (disabledRPCClient) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return ErrNetworkDisabled
+}
+
+func (disabledRPCClient) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return nil, ErrNetworkDisabled
+}
+
+// WithNoNetwork guarantees the analyzer performs no rpc calls. AnalyzeSignature
+// fails with ErrNetworkDisabled unless a non-rpc TransactionSource is set, and
+// lookup tables are resolved from the transaction meta or a configured
+// LookupTableProvider instead of being fetched.
+func WithNoNetwork() AnalyzerOption {
+	return func(a *Analyzer) {
+		a.noNetwork = true
+	}
+}
+
+// disableNetwork replaces the rpc client and rpc backed sources with guards.
+// A call path that misses the noNetwork check fails with ErrNetworkDisabled
+// instead of reaching the node or a nil client.
+func (a *Analyzer) disableNetwork() {
+	a.rpcClient = rpc.NewWithCustomRPCClient(disabledRPCClient{})
+	a.endpoint = ""
+	if _, ok := a.source.(rpcTransactionSource); ok {
+		a.source = disabledSource{}
+	}
+	if _, ok := a.lookupTables.(rpcLookupTables); ok {
+		a.lookupTables = nil
+	}
+	if _, ok := a.mintRisks.(*rpcMintRisks); ok {
+		a.mintRisks = nil
+	}
+	if _, ok := a.bondingCurves.(*rpcBondingCurves); ok {
+		a.bondingCurves = nil
+	}
+	if _, ok := a.blockFees.(*rpcBlockFees); ok {
+		a.blockFees = nil
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// countingRPCClient counts the calls reaching the rpc transport and fails them
+type countingRPCClient struct {
+	calls atomic.Int64
+}
+
+func (c *countingRPCClient) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	c.calls.Add(1)
+	return errors.New("unexpected rpc call " + method)
+}
+
+func (c *countingRPCClient) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	c.calls.Add(1)
+	return errors.New("unexpected rpc call " + method)
+}
+
+func (c *countingRPCClient) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	c.calls.Add(1)
+	return nil, errors.New("unexpected rpc batch")
+}
+
+func TestWithNoNetwork(t *testing.T) {
+	transport := &countingRPCClient{}
+	client := rpc.NewWithCustomRPCClient(transport)
+	providers := []AnalyzerOption{
+		WithLogOutput(io.Discard),
+		WithMintRiskProvider(NewRPCMintRiskProvider(client, rpc.CommitmentFinalized)),
+		WithBondingCurveProvider(NewRPCBondingCurveProvider(client, rpc.CommitmentFinalized, 0, nil)),
+		WithPriorityFeeProvider(NewRPCBlockFeeProvider(client, rpc.CommitmentFinalized)),
+	}
+	ctx := context.Background()
+
+	for name, a := range map[string]*Analyzer{
+		"option": NewAnalyzer(client, append(providers, WithNoNetwork())...),
+		"with":   NewAnalyzer(client, providers...).With(WithNoNetwork()),
+	} {
+		if _, err := a.AnalyzeSignature(ctx, solana.Signature{1}); !errors.Is(err, ErrNetworkDisabled) {
+			t.Errorf("%s: AnalyzeSignature %v", name, err)
+		}
+		if _, err := a.AnalyzeConfirmedSignature(ctx, solana.Signature{1}, rpc.CommitmentConfirmed); !errors.Is(err, ErrNetworkDisabled) {
+			t.Errorf("%s: AnalyzeConfirmedSignature %v", name, err)
+		}
+		if err := a.Triage(ctx, 1, 2, &TriageReport{}); !errors.Is(err, ErrNetworkDisabled) {
+			t.Errorf("%s: Triage %v", name, err)
+		}
+		if err := a.ResumeFeeRevenueReport(ctx, &RevenueReport{}, 1); !errors.Is(err, ErrNetworkDisabled) {
+			t.Errorf("%s: ResumeFeeRevenueReport %v", name, err)
+		}
+		if a.mintRisks != nil || a.bondingCurves != nil || a.blockFees != nil {
+			t.Errorf("%s: rpc providers kept", name)
+		}
+		if a.lookupTableProvider() != nil {
+			t.Errorf("%s: lookup tables fall back to rpc", name)
+		}
+
+		// A path that skips the noNetwork check gets the guard, not the client
+		if a.rpcClient == nil {
+			t.Fatalf("%s: no rpc client installed", name)
+		}
+		if _, err := a.rpcClient.GetSlot(ctx, rpc.CommitmentFinalized); !errors.Is(err, ErrNetworkDisabled) {
+			t.Errorf("%s: GetSlot %v", name, err)
+		}
+		if _, err := (rpcLookupTables{a: a}).GetLookupTable(ctx, testKey(30)); err == nil {
+			t.Errorf("%s: lookup table fetched", name)
+		}
+	}
+
+	if calls := transport.calls.Load(); calls != 0 {
+		t.Errorf("%d rpc calls reached the client", calls)
+	}
+}
+
+func TestWithNoNetworkKeepsSource(t *testing.T) {
+	transport := &countingRPCClient{}
+	tx := policyTransaction(t)
+	a := NewAnalyzer(rpc.NewWithCustomRPCClient(transport), WithTransactionSource(staticSource{tx}), WithNoNetwork(), WithLogOutput(io.Discard))
+	analysis, err := a.AnalyzeSignature(context.Background(), solana.Signature{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.Instructions) != 1 {
+		t.Errorf("%d instructions", len(analysis.Instructions))
+	}
+
+	// Lookup tables come from the loaded addresses of the meta
+	route := testInstruction("route", 0, [][]byte{testStep(0, 100, 0, 1)}, 1000, 900, 50, 0)
+	parsedTx, _, meta := filterMessage(t, route, true)
+	a = a.With(WithTransactionSource(staticSource{testTransactionResult(t, parsedTx, meta)}))
+	if _, err := a.AnalyzeSignature(context.Background(), solana.Signature{1}); err != nil {
+		t.Errorf("v0 transaction: %v", err)
+	}
+
+	if calls := transport.calls.Load(); calls != 0 {
+		t.Errorf("%d rpc calls reached the client", calls)
+	}
+}
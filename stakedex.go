@@ -0,0 +1,81 @@
+package main
+
+import (
+	"github.com/gagliardetto/solana-go"
+)
+
+// stakeDexProgramID is the StakeDex router program
+var stakeDexProgramID = solana.MustPublicKeyFromBase58("stkitrT1Uoy18Dk1fTrgPw8W1XACcEhZyk3uukcNkjy")
+
+// StakeDex PrefundWithdrawStake account layout within the Jupiter remaining accounts.
+//
+// The step starts with the StakeDex program account, followed by the fixed
+// accounts of the prefund_withdraw_stake instruction. The withdraw stake accounts
+// of the source pool and the deposit stake accounts of the destination pool come
+// after those and are left unmapped since their count depends on the pools.
+//
+//	StakeDexPrefundWithdrawStakeAndDepositStake:
+//	  [0]      StakeDex program
+//	  [1..16]  user, src_token_from, bridge_stake, src_token_mint, prefunder,
+//	           slumdog_stake, unstakeit_program, unstake_pool, pool_sol_reserves,
+//	           unstake_fee, slumdog_stake_acc_record, unstake_protocol_fee,
+//	           unstake_protocol_fee_dest, clock, stake_program, system_program
+//	  [17..]   withdraw stake accounts, then deposit stake accounts
+var stakeDexPrefundWithdrawStakeAccounts = []string{
+	"user",
+	"src_token_from",
+	"bridge_stake",
+	"src_token_mint",
+	"prefunder",
+	"slumdog_stake",
+	"unstakeit_program",
+	"unstake_pool",
+	"pool_sol_reserves",
+	"unstake_fee",
+	"slumdog_stake_acc_record",
+	"unstake_protocol_fee",
+	"unstake_protocol_fee_dest",
+	"clock",
+	"stake_program",
+	"system_program",
+}
+
+// stakeDexStakeAccounts are the prefund accounts of interest for stake tracking
+var stakeDexStakeAccounts = []string{"bridge_stake", "slumdog_stake", "unstake_pool", "pool_sol_reserves", "prefunder"}
+
+// mapStakeDexAccounts attaches the stake accounts to StakeDexPrefundWithdrawStake steps.
+// The nth such step is matched to the nth occurrence of the StakeDex program in the
+// remaining accounts that is followed by a full set of prefund accounts.
+func mapStakeDexAccounts(params *JupiterSwapParams, remaining solana.PublicKeySlice) {
+	var programPositions []int
+	for i, key := range remaining {
+		if key.Equals(stakeDexProgramID) {
+			programPositions = append(programPositions, i)
+		}
+	}
+
+	occurrence := 0
+	for i := range params.RoutePlan {
+		step := &params.RoutePlan[i]
+		if step.Swap.Type != SwapStakeDexPrefundWithdrawStake {
+			continue
+		}
+		if occurrence >= len(programPositions) {
+			return
+		}
+		start := programPositions[occurrence] + 1
+		occurrence++
+		if start+len(stakeDexPrefundWithdrawStakeAccounts) > len(remaining) {
+			continue
+		}
+
+		named := make(map[string]solana.PublicKey, len(stakeDexPrefundWithdrawStakeAccounts))
+		for j, name := range stakeDexPrefundWithdrawStakeAccounts {
+			named[name] = remaining[start+j]
+		}
+		step.Accounts = make(map[string]solana.PublicKeySlice, len(stakeDexStakeAccounts))
+		for _, name := range stakeDexStakeAccounts {
+			step.Accounts[name] = solana.PublicKeySlice{named[name]}
+		}
+	}
+}
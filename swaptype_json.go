@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// swapTypeJSONValues are the serialized names of every swap type. They are part
+// of the stored data format and must never change once released; new variants
+// only ever add entries.
+var swapTypeJSONValues = map[SwapType]string{
+	SwapSaber:                        "Saber",
+	SwapSaberAddDecimalsDeposit:      "SaberAddDecimalsDeposit",
+	SwapSaberAddDecimalsWithdraw:     "SaberAddDecimalsWithdraw",
+	SwapTokenSwap:                    "TokenSwap",
+	SwapSencha:                       "Sencha",
+	SwapStep:                         "Step",
+	SwapCropper:                      "Cropper",
+	SwapRaydium:                      "Raydium",
+	SwapCrema:                        "Crema",
+	SwapLifinity:                     "Lifinity",
+	SwapMercurial:                    "Mercurial",
+	SwapCykura:                       "Cykura",
+	SwapSerum:                        "Serum",
+	SwapMarinadeDeposit:              "MarinadeDeposit",
+	SwapMarinadeUnstake:              "MarinadeUnstake",
+	SwapAldrin:                       "Aldrin",
+	SwapAldrinV2:                     "AldrinV2",
+	SwapWhirlpool:                    "Whirlpool",
+	SwapInvariant:                    "Invariant",
+	SwapMeteora:                      "Meteora",
+	SwapGooseFX:                      "GooseFX",
+	SwapDeltaFi:                      "DeltaFi",
+	SwapBalansol:                     "Balansol",
+	SwapMarcoPolo:                    "MarcoPolo",
+	SwapDradex:                       "Dradex",
+	SwapLifinityV2:                   "LifinityV2",
+	SwapRaydiumClmm:                  "RaydiumClmm",
+	SwapOpenbook:                     "Openbook",
+	SwapPhoenix:                      "Phoenix",
+	SwapSymmetry:                     "Symmetry",
+	SwapTokenSwapV2:                  "TokenSwapV2",
+	SwapHeliumTreasuryManagement:     "HeliumTreasuryManagementRedeemV0",
+	SwapStakeDexStakeWrappedSol:      "StakeDexStakeWrappedSol",
+	SwapStakeDexSwapViaStake:         "StakeDexSwapViaStake",
+	SwapGooseFXV2:                    "GooseFXV2",
+	SwapPerps:                        "Perps",
+	SwapPerpsAddLiquidity:            "PerpsAddLiquidity",
+	SwapPerpsRemoveLiquidity:         "PerpsRemoveLiquidity",
+	SwapMeteoraDlmm:                  "MeteoraDlmm",
+	SwapOpenBookV2:                   "OpenBookV2",
+	SwapRaydiumClmmV2:                "RaydiumClmmV2",
+	SwapStakeDexPrefundWithdrawStake: "StakeDexPrefundWithdrawStakeAndDepositStake",
+	SwapClone:                        "Clone",
+	SwapSanctumS:                     "SanctumS",
+	SwapSanctumSAddLiquidity:         "SanctumSAddLiquidity",
+	SwapSanctumSRemoveLiquidity:      "SanctumSRemoveLiquidity",
+	SwapRaydiumCP:                    "RaydiumCP",
+	SwapWhirlpoolSwapV2:              "WhirlpoolSwapV2",
+	SwapOneIntro:                     "OneIntro",
+	SwapPumpdotfunWrappedBuy:         "PumpdotfunWrappedBuy",
+	SwapPumpdotfunWrappedSell:        "PumpdotfunWrappedSell",
+	SwapPerpsV2:                      "PerpsV2",
+	SwapPerpsV2AddLiquidity:          "PerpsV2AddLiquidity",
+	SwapPerpsV2RemoveLiquidity:       "PerpsV2RemoveLiquidity",
+	SwapMoonshotWrappedBuy:           "MoonshotWrappedBuy",
+	SwapMoonshotWrappedSell:          "MoonshotWrappedSell",
+	SwapStabbleStableSwap:            "StabbleStableSwap",
+	SwapStabbleWeightedSwap:          "StabbleWeightedSwap",
+	SwapObric:                        "Obric",
+	SwapFoxBuyFromEstimatedCost:      "FoxBuyFromEstimatedCost",
+	SwapFoxClaimPartial:              "FoxClaimPartial",
+	SwapSolFi:                        "SolFi",
+	Woofi:                            "Woofi",
+	SwapPumpdotfunAmmBuy:             "PumpdotfunAmmBuy",
+	SwapPumpdotfunAmmSell:            "PumpdotfunAmmSell",
+}
+
+// unknownSwapTypeJSONPrefix prefixes the variant index of unknown swap types
+const unknownSwapTypeJSONPrefix = "unknown:"
+
+// Jupiter API labels without a swap type (see quoteLabelSwapTypes) are kept as
+// quoteLabelSwapTypePrefix + label, serialized as quoteLabelJSONPrefix + label
+const (
+	quoteLabelSwapTypePrefix = "Label_"
+	quoteLabelJSONPrefix     = "label:"
+)
+
+// MarshalJSON encodes the swap type using its stable serialized name
+func (t SwapType) MarshalJSON() ([]byte, error) {
+	if value, ok := swapTypeJSONValues[t]; ok {
+		return json.Marshal(value)
+	}
+	if index, ok := unknownSwapIndex(t); ok {
+		return json.Marshal(fmt.Sprintf("%s%d", unknownSwapTypeJSONPrefix, index))
+	}
+	if label, ok := strings.CutPrefix(string(t), quoteLabelSwapTypePrefix); ok {
+		return json.Marshal(quoteLabelJSONPrefix + label)
+	}
+	return nil, fmt.Errorf("swap type %q has no serialized name", string(t))
+}
+
+// UnmarshalJSON decodes a stable serialized swap type name
+func (t *SwapType) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	for swapType, name := range swapTypeJSONValues {
+		if name == value {
+			*t = swapType
+			return nil
+		}
+	}
+
+	if strings.HasPrefix(value, unknownSwapTypeJSONPrefix) {
+		var index uint8
+		if _, err := fmt.Sscanf(strings.TrimPrefix(value, unknownSwapTypeJSONPrefix), "%d", &index); err != nil {
+			return fmt.Errorf("invalid unknown swap type %q: %v", value, err)
+		}
+		*t = SwapType(fmt.Sprintf("Unknown_%d", index))
+		return nil
+	}
+
+	if label, ok := strings.CutPrefix(value, quoteLabelJSONPrefix); ok {
+		*t = SwapType(quoteLabelSwapTypePrefix + label)
+		return nil
+	}
+
+	return fmt.Errorf("unknown swap type name %q", value)
+}
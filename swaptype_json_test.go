@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSwapTypeJSONStrict(t *testing.T) {
+	for _, value := range []string{`"NotAVenue"`, `"Unknown_3"`, `""`, `"unknown:x"`, `3`} {
+		var swapType SwapType
+		if err := json.Unmarshal([]byte(value), &swapType); err == nil {
+			t.Errorf("%s decoded as %q", value, swapType)
+		}
+	}
+	if encoded, err := json.Marshal(SwapType("NotAVenue")); err == nil {
+		t.Errorf("unnamed swap type encoded as %s", encoded)
+	}
+}
+
+func TestSwapTypeJSONRoundTrip(t *testing.T) {
+	for swapType, want := range map[SwapType]string{
+		SwapWhirlpool:                  `"Whirlpool"`,
+		SwapHeliumTreasuryManagement:   `"HeliumTreasuryManagementRedeemV0"`,
+		"Unknown_250":                  `"unknown:250"`,
+		quoteLabelSwapTypePrefix + "X": `"label:X"`,
+	} {
+		encoded, err := json.Marshal(swapType)
+		if err != nil || string(encoded) != want {
+			t.Errorf("%s encoded as %s (%v), want %s", swapType, encoded, err, want)
+			continue
+		}
+		var decoded SwapType
+		if err := json.Unmarshal(encoded, &decoded); err != nil || decoded != swapType {
+			t.Errorf("%s decoded as %q (%v), want %s", encoded, decoded, err, swapType)
+		}
+	}
+}
+
+func TestQuoteResponseUnmappedLabel(t *testing.T) {
+	quote := `{"inputMint":"So11111111111111111111111111111111111111112","inAmount":"1000","outputMint":"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v","outAmount":"990","otherAmountThreshold":"985","swapMode":"ExactIn","slippageBps":50,
+		"routePlan":[{"swapInfo":{"ammKey":"11111111111111111111111111111111","label":"Brand New DEX","inputMint":"So11111111111111111111111111111111111111112","outputMint":"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v","inAmount":"1000","outAmount":"990"},"percent":100}]}`
+	params, err := ParseQuoteResponse([]byte(quote))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := params.RoutePlan[0].Swap.Type; got != "Label_Brand New DEX" {
+		t.Errorf("swap type %q", got)
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		t.Fatalf("marshaling the quote: %v", err)
+	}
+	var decoded JupiterSwapParams
+	if err := json.Unmarshal(encoded, &decoded); err != nil || decoded.RoutePlan[0].Swap.Type != params.RoutePlan[0].Swap.Type {
+		t.Errorf("round trip: %v %+v", err, decoded.RoutePlan)
+	}
+}
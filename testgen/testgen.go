@@ -0,0 +1,227 @@
+// Package testgen builds synthetic but structurally valid Jupiter V6 route
+// transactions for testing downstream systems. Output is deterministic for a
+// given Spec, including its Seed.
+package testgen
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"sol-tx/jupiterv6"
+)
+
+// JupiterProgramID is the Jupiter V6 program
+var JupiterProgramID = solana.MustPublicKeyFromBase58(jupiterv6.ProgramAddress)
+
+// Hop is one route plan step
+type Hop struct {
+	SwapIndex   uint8  // Swap enum variant index
+	Params      []byte // Borsh encoded variant fields, empty for unit variants
+	Percent     uint8
+	InputIndex  uint8
+	OutputIndex uint8
+}
+
+// Spec describes the transaction to generate
+type Spec struct {
+	InstructionType string
+	ID              uint8 // shared accounts variants only
+	Hops            []Hop
+	// Amount is in_amount, or out_amount for exact out variants. Token ledger
+	// variants do not encode it but still use it for the events.
+	Amount uint64
+	// QuotedAmount is quoted_out_amount, or quoted_in_amount for exact out variants
+	QuotedAmount   uint64
+	SlippageBps    uint16
+	PlatformFeeBps uint8
+	// ExpireAt adds the optional expire_at tail of newer revisions when set
+	ExpireAt *int64
+	Seed     int64
+}
+
+// Generated is a synthetic transaction and the keys it was built with
+type Generated struct {
+	Data        []byte
+	Transaction *solana.Transaction
+	Result      *rpc.GetTransactionResult
+	Payer       solana.PublicKey
+	Mints       []solana.PublicKey // by route plan token index
+	AMMs        []solana.PublicKey // by hop
+	Events      [][]byte           // emit-CPI payload of each hop
+}
+
+// EncodeInstruction encodes the instruction data of spec following the IDL argument order
+func EncodeInstruction(spec Spec) ([]byte, error) {
+	inst := jupiterv6.Instruction{
+		Type:           spec.InstructionType,
+		ID:             spec.ID,
+		Amount:         spec.Amount,
+		QuotedAmount:   spec.QuotedAmount,
+		SlippageBps:    spec.SlippageBps,
+		PlatformFeeBps: spec.PlatformFeeBps,
+		ExpireAt:       spec.ExpireAt,
+	}
+	for _, hop := range spec.Hops {
+		step := append([]byte{hop.SwapIndex}, hop.Params...)
+		inst.Steps = append(inst.Steps, append(step, hop.Percent, hop.InputIndex, hop.OutputIndex))
+	}
+	return jupiterv6.EncodeInstruction(inst)
+}
+
+// hopAmounts simulates the route: each hop takes Percent of the amount
+// reaching its input index and returns it at a seeded rate to its output index
+func hopAmounts(spec Spec, rng *rand.Rand) (in, out []uint64) {
+	held := map[uint8]uint64{0: spec.Amount}
+	for _, hop := range spec.Hops {
+		amount := held[hop.InputIndex] * uint64(hop.Percent) / 100
+		returned := amount * uint64(90+rng.Intn(21)) / 100
+		in = append(in, amount)
+		out = append(out, returned)
+		held[hop.OutputIndex] += returned
+	}
+	return in, out
+}
+
+// newKey returns a seeded public key
+func newKey(rng *rand.Rand) solana.PublicKey {
+	var key solana.PublicKey
+	rng.Read(key[:])
+	return key
+}
+
+// Generate builds the instruction, transaction and meta for spec
+func Generate(spec Spec) (*Generated, error) {
+	data, err := EncodeInstruction(spec)
+	if err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(spec.Seed))
+
+	gen := &Generated{Data: data, Payer: newKey(rng)}
+
+	// One mint per token index used by the route
+	maxIndex := uint8(0)
+	for _, hop := range spec.Hops {
+		if hop.InputIndex > maxIndex {
+			maxIndex = hop.InputIndex
+		}
+		if hop.OutputIndex > maxIndex {
+			maxIndex = hop.OutputIndex
+		}
+	}
+	for i := 0; i <= int(maxIndex); i++ {
+		gen.Mints = append(gen.Mints, newKey(rng))
+	}
+
+	// Fixed accounts: the user authority is the payer, the last two are the
+	// event authority and the program
+	fixed := jupiterv6.FixedAccountCounts[spec.InstructionType]
+	authority := 1
+	if jupiterv6.IsShared(spec.InstructionType) {
+		authority = 2
+	}
+	eventAuthority := newKey(rng)
+	accounts := make(solana.AccountMetaSlice, 0, fixed+2*len(spec.Hops))
+	for i := 0; i < fixed; i++ {
+		switch i {
+		case authority:
+			accounts = append(accounts, solana.Meta(gen.Payer).SIGNER().WRITE())
+		case fixed - 2:
+			accounts = append(accounts, solana.Meta(eventAuthority))
+		case fixed - 1:
+			accounts = append(accounts, solana.Meta(JupiterProgramID))
+		default:
+			accounts = append(accounts, solana.Meta(newKey(rng)).WRITE())
+		}
+	}
+
+	// Remaining accounts: an AMM program and a pool per hop
+	for range spec.Hops {
+		amm := newKey(rng)
+		gen.AMMs = append(gen.AMMs, amm)
+		accounts = append(accounts, solana.Meta(amm), solana.Meta(newKey(rng)).WRITE())
+	}
+
+	instruction := solana.NewInstruction(JupiterProgramID, accounts, data)
+	var blockhash solana.Hash
+	rng.Read(blockhash[:])
+	tx, err := solana.NewTransaction([]solana.Instruction{instruction}, blockhash, solana.TransactionPayer(gen.Payer))
+	if err != nil {
+		return nil, fmt.Errorf("error building transaction: %v", err)
+	}
+	tx.Signatures = []solana.Signature{{}}
+	rng.Read(tx.Signatures[0][:])
+	gen.Transaction = tx
+
+	// Self-CPI events, one per hop
+	programIndex, eventAuthorityIndex := -1, -1
+	for i, key := range tx.Message.AccountKeys {
+		if key.Equals(JupiterProgramID) {
+			programIndex = i
+		}
+		if key.Equals(eventAuthority) {
+			eventAuthorityIndex = i
+		}
+	}
+	in, out := hopAmounts(spec, rng)
+	inner := rpc.InnerInstruction{Index: 0}
+	for i, hop := range spec.Hops {
+		event := jupiterv6.EncodeSwapEvent(gen.AMMs[i], gen.Mints[hop.InputIndex], in[i], gen.Mints[hop.OutputIndex], out[i])
+		gen.Events = append(gen.Events, event)
+		inner.Instructions = append(inner.Instructions, solana.CompiledInstruction{
+			ProgramIDIndex: uint16(programIndex),
+			Accounts:       []uint16{uint16(eventAuthorityIndex)},
+			Data:           event,
+		})
+	}
+
+	const fee = 5000
+	balances := make([]uint64, len(tx.Message.AccountKeys))
+	postBalances := make([]uint64, len(balances))
+	balances[0] = 1_000_000_000
+	postBalances[0] = balances[0] - fee
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("error encoding transaction: %v", err)
+	}
+	envelope, err := json.Marshal([]string{base64.StdEncoding.EncodeToString(raw), "base64"})
+	if err != nil {
+		return nil, err
+	}
+
+	blockTime := solana.UnixTimeSeconds(1_700_000_000 + rng.Int63n(1_000_000))
+	gen.Result = &rpc.GetTransactionResult{
+		Slot:      250_000_000 + uint64(rng.Int63n(1_000_000)),
+		BlockTime: &blockTime,
+		Meta: &rpc.TransactionMeta{
+			Fee:               fee,
+			PreBalances:       balances,
+			PostBalances:      postBalances,
+			InnerInstructions: []rpc.InnerInstruction{inner},
+			LogMessages: []string{
+				"Program " + JupiterProgramID.String() + " invoke [1]",
+				"Program log: Instruction: " + instructionLogName(spec.InstructionType),
+				"Program " + JupiterProgramID.String() + " success",
+			},
+		},
+	}
+	gen.Result.Transaction = &rpc.TransactionResultEnvelope{}
+	if err := gen.Result.Transaction.UnmarshalJSON(envelope); err != nil {
+		return nil, fmt.Errorf("error wrapping transaction: %v", err)
+	}
+	return gen, nil
+}
+
+// instructionLogName returns the Anchor log name of an instruction
+func instructionLogName(instructionType string) string {
+	if instructionType == "" {
+		return ""
+	}
+	return string(instructionType[0]-'a'+'A') + instructionType[1:]
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"sol-tx/jupiterv6"
+	"sol-tx/testgen"
+)
+
+// testgenSpec is a two hop route through a unit variant and a variant with fields
+func testgenSpec(instructionType string) testgen.Spec {
+	return testgen.Spec{
+		InstructionType: instructionType,
+		ID:              2,
+		Hops: []testgen.Hop{
+			{SwapIndex: 0, Percent: 100, InputIndex: 0, OutputIndex: 1},                     // Saber
+			{SwapIndex: 17, Params: []byte{1}, Percent: 100, InputIndex: 1, OutputIndex: 2}, // Whirlpool a_to_b
+		},
+		Amount:         1_000_000,
+		QuotedAmount:   990_000,
+		SlippageBps:    50,
+		PlatformFeeBps: 10,
+		Seed:           7,
+	}
+}
+
+func TestTestgenRoundTrip(t *testing.T) {
+	for instructionType := range InstructionDiscriminators {
+		t.Run(instructionType, func(t *testing.T) {
+			spec := testgenSpec(instructionType)
+			gen, err := testgen.Generate(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			params, err := parseJupiterV6Instruction(gen.Data)
+			if err != nil {
+				t.Fatalf("generated data does not parse: %v", err)
+			}
+			if params.InstructionType != instructionType || len(params.RoutePlan) != len(spec.Hops) {
+				t.Fatalf("got %s with %d steps", params.InstructionType, len(params.RoutePlan))
+			}
+			for i, hop := range spec.Hops {
+				step := params.RoutePlan[i]
+				if index, _ := swapVariantIndex(step.Swap.Type); index != hop.SwapIndex || step.Percent != hop.Percent ||
+					step.InputIndex != hop.InputIndex || step.OutputIndex != hop.OutputIndex {
+					t.Errorf("step %d: got %+v", i, step)
+				}
+			}
+			if params.RoutePlan[1].Swap.Params["a_to_b"] != true {
+				t.Errorf("whirlpool params %v", params.RoutePlan[1].Swap.Params)
+			}
+
+			amount, quoted := params.InAmount, params.QuotedOutAmount
+			if isExactOutInstruction(instructionType) {
+				amount, quoted = params.OutAmount, params.QuotedInAmount
+			}
+			if isTokenLedgerInstruction(instructionType) {
+				if amount != 0 {
+					t.Errorf("token ledger in_amount %d, want 0", amount)
+				}
+			} else if amount != spec.Amount {
+				t.Errorf("amount %d, want %d", amount, spec.Amount)
+			}
+			if quoted != spec.QuotedAmount || params.SlippageBps != spec.SlippageBps || params.PlatformFeeBps != spec.PlatformFeeBps {
+				t.Errorf("quoted %d slippage %d fee %d", quoted, params.SlippageBps, params.PlatformFeeBps)
+			}
+			if jupiterv6.IsShared(instructionType) && params.AuthorityID != spec.ID {
+				t.Errorf("id %d, want %d", params.AuthorityID, spec.ID)
+			}
+		})
+	}
+}
+
+func TestTestgenAnalysis(t *testing.T) {
+	spec := testgenSpec("route")
+	gen, err := testgen.Generate(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysis := analyzeTest(t, newTestAnalyzer(), gen.Result, gen.Transaction)
+
+	if len(analysis.Instructions) != 1 || len(analysis.Events) != len(spec.Hops) {
+		t.Fatalf("got %d instructions and %d events, errors %v", len(analysis.Instructions), len(analysis.Events), analysis.Errors)
+	}
+	for i, event := range analysis.Events {
+		hop := spec.Hops[i]
+		if !event.AMM.Equals(gen.AMMs[i]) || !event.InputMint.Equals(gen.Mints[hop.InputIndex]) || !event.OutputMint.Equals(gen.Mints[hop.OutputIndex]) {
+			t.Errorf("event %d does not match hop: %+v", i, event)
+		}
+	}
+
+	summary := analysis.Summary
+	if summary.TotalSwaps != 2 || summary.InputToken != gen.Mints[0].String() || summary.OutputToken != gen.Mints[2].String() {
+		t.Errorf("summary %+v", summary)
+	}
+	if summary.TotalInput != spec.Amount || summary.TotalOutput != analysis.Events[1].OutputAmount {
+		t.Errorf("summary amounts %d -> %d", summary.TotalInput, summary.TotalOutput)
+	}
+}
+
+func TestTestgenDeterministic(t *testing.T) {
+	first, err := testgen.Generate(testgenSpec("sharedAccountsRoute"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := testgen.Generate(testgenSpec("sharedAccountsRoute"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Error("the same spec generated different transactions")
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ErrUnknownTimestamp is returned by consumers that need the time of an
+// analysis whose transaction has neither a block time nor an estimate
+var ErrUnknownTimestamp = errors.New("transaction timestamp unknown")
+
+// TimestampSource tells how the time of a transaction was derived
+type TimestampSource string
+
+const (
+	TimestampBlockTime TimestampSource = "block_time" // blockTime reported by the node
+	TimestampEstimated TimestampSource = "estimated"  // estimated from the slot
+	TimestampUnknown   TimestampSource = "unknown"    // neither is available
+)
+
+// Timestamp is the time of a transaction with its source. The zero value is unknown.
+type Timestamp struct {
+	Time   time.Time       `json:"time,omitempty"`
+	Source TimestampSource `json:"source"`
+}
+
+// Known reports whether the timestamp carries a time
+func (t Timestamp) Known() bool {
+	return t.Source == TimestampBlockTime || t.Source == TimestampEstimated
+}
+
+// MarshalJSON omits the time of an unknown timestamp instead of writing the zero time
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if !t.Known() {
+		return json.Marshal(struct {
+			Source TimestampSource `json:"source"`
+		}{TimestampUnknown})
+	}
+	type plain Timestamp
+	return json.Marshal(plain(t))
+}
+
+// SlotTimeEstimator estimates the time of a slot by extrapolating from a
+// reference slot whose time is known, at a fixed slot duration
+type SlotTimeEstimator struct {
+	ReferenceSlot uint64    `json:"reference_slot"`
+	ReferenceTime time.Time `json:"reference_time"`
+	// SlotDuration defaults to defaultSlotDuration
+	SlotDuration time.Duration `json:"slot_duration,omitempty"`
+}
+
+// defaultSlotDuration is the target duration of a Solana slot
+const defaultSlotDuration = 400 * time.Millisecond
+
+// Estimate returns the estimated time of slot, false without a reference
+func (e *SlotTimeEstimator) Estimate(slot uint64) (time.Time, bool) {
+	if e == nil || e.ReferenceTime.IsZero() || slot == 0 {
+		return time.Time{}, false
+	}
+	duration := e.SlotDuration
+	if duration <= 0 {
+		duration = defaultSlotDuration
+	}
+	delta := time.Duration(int64(slot)-int64(e.ReferenceSlot)) * duration
+	return e.ReferenceTime.Add(delta).UTC(), true
+}
+
+// WithSlotTimeEstimator estimates the timestamp of transactions returned without a block time
+func WithSlotTimeEstimator(estimator *SlotTimeEstimator) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.slotTimes = estimator
+	}
+}
+
+// deriveTimestamp prefers the block time of tx, then the slot estimate. A
+// block time of 0 is treated as missing, it is what some nodes send for null.
+func deriveTimestamp(tx *rpc.GetTransactionResult, estimator *SlotTimeEstimator) Timestamp {
+	if tx.BlockTime != nil && *tx.BlockTime > 0 {
+		return Timestamp{Time: tx.BlockTime.Time().UTC(), Source: TimestampBlockTime}
+	}
+	if at, ok := estimator.Estimate(tx.Slot); ok {
+		return Timestamp{Time: at, Source: TimestampEstimated}
+	}
+	return Timestamp{Source: TimestampUnknown}
+}
+
+// tradeTime resolves the time used for the trades of an analysis: timestamp
+// when set, else the analysis timestamp when known, else the zero time
+func tradeTime(analysis *JupiterV6Analysis, timestamp time.Time) time.Time {
+	if !timestamp.IsZero() {
+		return timestamp
+	}
+	if analysis.Timestamp.Known() {
+		return analysis.Timestamp.Time
+	}
+	return time.Time{}
+}
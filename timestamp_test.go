@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestAnalysisTimestamp(t *testing.T) {
+	reference := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	estimator := &SlotTimeEstimator{ReferenceSlot: 250_000_000, ReferenceTime: reference}
+	blockTime := func(at int64) *solana.UnixTimeSeconds {
+		seconds := solana.UnixTimeSeconds(at)
+		return &seconds
+	}
+
+	for _, tt := range []struct {
+		name      string
+		blockTime *solana.UnixTimeSeconds
+		slot      uint64
+		estimator *SlotTimeEstimator
+		want      Timestamp
+	}{
+		{"block time", blockTime(reference.Unix()), 250_000_010, estimator, Timestamp{Time: reference, Source: TimestampBlockTime}},
+		{"estimated after the reference", nil, 250_000_010, estimator, Timestamp{Time: reference.Add(4 * time.Second), Source: TimestampEstimated}},
+		{"estimated before the reference", nil, 249_999_990, estimator, Timestamp{Time: reference.Add(-4 * time.Second), Source: TimestampEstimated}},
+		{"zero block time is missing", blockTime(0), 250_000_010, estimator, Timestamp{Time: reference.Add(4 * time.Second), Source: TimestampEstimated}},
+		{"custom slot duration", nil, 250_000_010, &SlotTimeEstimator{ReferenceSlot: 250_000_000, ReferenceTime: reference, SlotDuration: time.Second}, Timestamp{Time: reference.Add(10 * time.Second), Source: TimestampEstimated}},
+		{"no estimator", nil, 250_000_010, nil, Timestamp{Source: TimestampUnknown}},
+		{"estimator without reference", nil, 250_000_010, &SlotTimeEstimator{}, Timestamp{Source: TimestampUnknown}},
+		{"no slot", nil, 0, estimator, Timestamp{Source: TimestampUnknown}},
+	} {
+		tx, parsedTx := ledgerFixture(t)
+		tx.BlockTime, tx.Slot = tt.blockTime, tt.slot
+		got := analyzeTest(t, newTestAnalyzer(WithSlotTimeEstimator(tt.estimator)), tx, parsedTx).Timestamp
+		if !got.Time.Equal(tt.want.Time) || got.Source != tt.want.Source {
+			t.Errorf("%s: %+v, want %+v", tt.name, got, tt.want)
+		}
+		if got.Known() != (tt.want.Source != TimestampUnknown) {
+			t.Errorf("%s: Known %v", tt.name, got.Known())
+		}
+	}
+}
+
+func TestTimestampMarshalJSON(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name      string
+		timestamp Timestamp
+		want      string
+	}{
+		{"block time", Timestamp{Time: at, Source: TimestampBlockTime}, `{"time":"2024-03-01T12:00:00Z","source":"block_time"}`},
+		{"estimated", Timestamp{Time: at, Source: TimestampEstimated}, `{"time":"2024-03-01T12:00:00Z","source":"estimated"}`},
+		{"unknown", Timestamp{Source: TimestampUnknown}, `{"source":"unknown"}`},
+		{"zero value", Timestamp{}, `{"source":"unknown"}`},
+		{"time without source", Timestamp{Time: at}, `{"source":"unknown"}`},
+	} {
+		got, err := json.Marshal(tt.timestamp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	// The unknown encoding decodes back to an unknown timestamp
+	var decoded Timestamp
+	if err := json.Unmarshal([]byte(`{"source":"unknown"}`), &decoded); err != nil || decoded.Known() || !decoded.Time.IsZero() {
+		t.Errorf("decoded %+v, %v", decoded, err)
+	}
+}
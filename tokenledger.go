@@ -0,0 +1,142 @@
+package main
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"sol-tx/jupiterv6"
+)
+
+// setTokenLedgerDiscriminator is the Jupiter V6 setTokenLedger instruction discriminator
+var setTokenLedgerDiscriminator = []byte{0xE4, 0x55, 0xB9, 0x70, 0x4E, 0x4F, 0x4D, 0x02}
+
+// isTokenLedgerInstruction reports whether the instruction takes its input
+// amount from a token ledger set by a preceding setTokenLedger instruction
+func isTokenLedgerInstruction(instructionType string) bool {
+	return jupiterv6.IsTokenLedger(instructionType)
+}
+
+// TokenLedgerInfo describes how the input amount of a token ledger route was recovered
+type TokenLedgerInfo struct {
+	Ledger       solana.PublicKey `json:"ledger"`        // Token ledger account
+	TokenAccount solana.PublicKey `json:"token_account"` // Account whose balance was recorded
+	Mint         solana.PublicKey `json:"mint"`
+	PreBalance   uint64           `json:"pre_balance"`    // Token account balance before the transaction
+	PostBalance  uint64           `json:"post_balance"`   // Token account balance after the transaction
+	InAmountFrom string           `json:"in_amount_from"` // "event", "balances" or "unknown"
+}
+
+// correlateTokenLedger recovers the real input amount of token ledger route variants.
+//
+// routeWithTokenLedger and sharedAccountsRouteWithTokenLedger have no in_amount
+// argument; the program swaps the difference between the token account balance
+// at route time and the balance recorded by a preceding setTokenLedger
+// instruction. The heuristic is:
+//
+//  1. Find the last Jupiter setTokenLedger instruction before the route and take
+//     its token account (second account), which may be loaded from a lookup table.
+//  2. Take the pre and post balances of that account and its mint.
+//  3. The input of the first hop events of the instruction is the real input
+//     amount: the steps reading the input token account when the events match
+//     the route plan step for step, the first event otherwise.
+//  4. Without events, fall back to the net decrease of the account over the
+//     transaction.
+//
+// The balance fallback understates the input when tokens are credited to the
+// account after setTokenLedger, for example by a withdrawal feeding the ledger;
+// a non positive decrease, a missing setTokenLedger instruction or missing or
+// unreadable balances leave InAmount at zero and InAmountFrom at "unknown".
+func correlateTokenLedger(analysis *JupiterV6Analysis, parsedTx *solana.Transaction, meta *rpc.TransactionMeta) {
+	accountKeys := transactionAccountKeys(&parsedTx.Message, meta)
+	for i := range analysis.Instructions {
+		params := &analysis.Instructions[i]
+		if !isTokenLedgerInstruction(params.InstructionType) {
+			continue
+		}
+
+		info := &TokenLedgerInfo{InAmountFrom: "unknown"}
+		params.TokenLedger = info
+		if meta == nil {
+			continue
+		}
+
+		// 1. Find the preceding setTokenLedger instruction
+		found := false
+		for j := params.InstructionIndex - 1; j >= 0 && !found; j-- {
+			inst := parsedTx.Message.Instructions[j]
+			if int(inst.ProgramIDIndex) >= len(accountKeys) ||
+				!accountKeys[inst.ProgramIDIndex].Equals(jupiterV6ProgramID) ||
+				len(inst.Data) < 8 || !bytesEqual(inst.Data[:8], setTokenLedgerDiscriminator) {
+				continue
+			}
+			accounts := instructionAccountKeys(inst, accountKeys)
+			if len(accounts) < 2 {
+				break
+			}
+			info.Ledger, info.TokenAccount = accounts[0], accounts[1]
+			found = true
+		}
+		if !found {
+			continue
+		}
+
+		// 2. Balances of the token account
+		balancesOK := false
+		if index, ok := accountIndex(accountKeys, info.TokenAccount); ok {
+			pre, hasPre := findTokenBalance(meta.PreTokenBalances, index)
+			post, hasPost := findTokenBalance(meta.PostTokenBalances, index)
+			if hasPre && hasPost {
+				info.Mint = pre.Mint
+				var preOK, postOK bool
+				info.PreBalance, preOK = tokenBalanceAmount(pre)
+				info.PostBalance, postOK = tokenBalanceAmount(post)
+				// An unreadable balance is not a zero balance
+				balancesOK = preOK && postOK
+			}
+		}
+
+		// 3. Real input is what the first hops took in
+		if amount, ok := firstHopInput(params, analysis.Events); ok {
+			params.InAmount = amount
+			info.InAmountFrom = "event"
+			continue
+		}
+
+		// 4. Otherwise what left the account
+		if balancesOK && info.PreBalance > info.PostBalance {
+			params.InAmount = info.PreBalance - info.PostBalance
+			info.InAmountFrom = "balances"
+		}
+	}
+}
+
+// firstHopInput sums the input of the events of the instruction's first hops.
+// When the instruction has one event per route plan step, the first hops are
+// the steps reading input index 0; otherwise only the first event is used.
+func firstHopInput(params *JupiterSwapParams, events []SwapEvent) (uint64, bool) {
+	var own []SwapEvent
+	for _, event := range events {
+		if event.InstructionIndex == params.InstructionIndex {
+			own = append(own, event)
+		}
+	}
+	if len(own) == 0 {
+		return 0, false
+	}
+	if len(own) != len(params.RoutePlan) {
+		return own[0].InputAmount, true
+	}
+
+	var total uint64
+	for j, step := range params.RoutePlan {
+		if step.InputIndex != 0 {
+			continue
+		}
+		sum, ok := addUint64Checked(total, own[j].InputAmount)
+		if !ok {
+			return 0, false
+		}
+		total = sum
+	}
+	return total, total > 0
+}
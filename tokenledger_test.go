@@ -0,0 +1,179 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestParseTokenLedgerTail(t *testing.T) {
+	steps := [][]byte{testStep(0, 100, 0, 1)}
+	for _, instructionType := range []string{"routeWithTokenLedger", "sharedAccountsRouteWithTokenLedger"} {
+		data := testInstruction(instructionType, 3, steps, 0, 990, 50, 20)
+		params, err := parseJupiterV6Instruction(data)
+		if err != nil {
+			t.Fatalf("%s: %v", instructionType, err)
+		}
+		if params.InAmount != 0 || params.QuotedOutAmount != 990 || params.SlippageBps != 50 || params.PlatformFeeBps != 20 {
+			t.Errorf("%s: got in %d quoted out %d slippage %d fee %d", instructionType, params.InAmount, params.QuotedOutAmount, params.SlippageBps, params.PlatformFeeBps)
+		}
+		if _, err := parseJupiterV6Instruction(data[:len(data)-1]); err == nil {
+			t.Errorf("%s: truncated tail parsed", instructionType)
+		}
+	}
+}
+
+// tokenLedgerTransaction builds setTokenLedger followed by routeWithTokenLedger,
+// with a two hop route A -> B -> A whose intermediate hop spends the ledger mint
+// again. Without events the route emits no swap events. With lookup the token
+// account is loaded from an address lookup table that is left unresolved.
+func tokenLedgerTransaction(t *testing.T, pre, post uint64, withEvents, lookup bool) (*rpc.GetTransactionResult, *solana.Transaction) {
+	payer, tokenAccount, ledger := testKey(1), testKey(2), testKey(3)
+	mintA, mintB, amm := testKey(4), testKey(5), testKey(6)
+	keys := solana.PublicKeySlice{payer, tokenAccount, ledger, jupiterV6ProgramID, amm}
+	for i := byte(10); i < 16; i++ {
+		keys = append(keys, testKey(i))
+	}
+	tokenIndex := uint16(1)
+	if lookup {
+		// Keep the static key slots, the token account slot holds an unrelated key
+		keys[1] = testKey(20)
+		tokenIndex = uint16(len(keys))
+	}
+
+	route := testInstruction("routeWithTokenLedger", 0, [][]byte{testStep(0, 100, 0, 1), testStep(0, 100, 1, 2)}, 0, 590, 50, 0)
+	parsedTx := &solana.Transaction{
+		Signatures: []solana.Signature{{1}},
+		Message: solana.Message{
+			AccountKeys: keys,
+			Instructions: []solana.CompiledInstruction{
+				{ProgramIDIndex: 3, Accounts: []uint16{2, tokenIndex}, Data: setTokenLedgerDiscriminator},
+				{ProgramIDIndex: 3, Accounts: []uint16{5, 0, tokenIndex, 6, 7, 8, 9, 10, 2, 3, 4}, Data: route},
+			},
+		},
+	}
+	meta := &rpc.TransactionMeta{
+		PreTokenBalances:  []rpc.TokenBalance{testTokenBalance(int(tokenIndex), mintA, payer, pre)},
+		PostTokenBalances: []rpc.TokenBalance{testTokenBalance(int(tokenIndex), mintA, payer, post)},
+	}
+	if lookup {
+		parsedTx.Message.SetAddressTableLookups([]solana.MessageAddressTableLookup{{AccountKey: testKey(30), WritableIndexes: []uint8{0}}})
+		meta.LoadedAddresses.Writable = solana.PublicKeySlice{tokenAccount}
+	}
+
+	if withEvents {
+		events := []SwapEvent{
+			{AMM: amm, InputMint: mintA, InputAmount: 600, OutputMint: mintB, OutputAmount: 300},
+			{AMM: amm, InputMint: mintB, InputAmount: 300, OutputMint: mintA, OutputAmount: 590},
+		}
+		var inner []solana.CompiledInstruction
+		for _, event := range events {
+			inner = append(inner, solana.CompiledInstruction{ProgramIDIndex: 3, Data: event.Encode()})
+		}
+		meta.InnerInstructions = []rpc.InnerInstruction{{Index: 1, Instructions: inner}}
+	}
+	return testTransactionResult(t, parsedTx, meta), parsedTx
+}
+
+func TestCorrelateTokenLedger(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		pre, post uint64
+	}{
+		{"spent from the balance", 1000, 400},
+		// A deposit lands after setTokenLedger and is swapped: no net change
+		{"deposit after setTokenLedger", 400, 400},
+		// A withdrawal lands after setTokenLedger and is swapped: a net increase
+		{"withdrawal after setTokenLedger", 0, 0},
+	} {
+		tx, parsedTx := tokenLedgerTransaction(t, tt.pre, tt.post, true, false)
+		analysis := analyzeTest(t, newTestAnalyzer(), tx, parsedTx)
+		if len(analysis.Instructions) != 1 {
+			t.Fatalf("%s: got %d instructions, errors %v", tt.name, len(analysis.Instructions), analysis.Errors)
+		}
+		params := analysis.Instructions[0]
+		if params.TokenLedger == nil || params.TokenLedger.InAmountFrom != "event" {
+			t.Fatalf("%s: token ledger %+v", tt.name, params.TokenLedger)
+		}
+		// The intermediate hop spending the ledger mint again is not counted
+		if params.InAmount != 600 {
+			t.Errorf("%s: in_amount %d, want 600", tt.name, params.InAmount)
+		}
+		if !params.TokenLedger.TokenAccount.Equals(testKey(2)) || !params.TokenLedger.Ledger.Equals(testKey(3)) {
+			t.Errorf("%s: accounts %+v", tt.name, params.TokenLedger)
+		}
+	}
+}
+
+func TestCorrelateTokenLedgerBalances(t *testing.T) {
+	// Without events the net decrease of the token account is the input
+	tx, parsedTx := tokenLedgerTransaction(t, 1000, 400, false, false)
+	analysis := analyzeTest(t, newTestAnalyzer(), tx, parsedTx)
+	params := analysis.Instructions[0]
+	if params.InAmount != 600 || params.TokenLedger.InAmountFrom != "balances" {
+		t.Errorf("in_amount %d from %q", params.InAmount, params.TokenLedger.InAmountFrom)
+	}
+}
+
+func TestCorrelateTokenLedgerLookupTable(t *testing.T) {
+	for _, withEvents := range []bool{true, false} {
+		tx, parsedTx := tokenLedgerTransaction(t, 1000, 400, withEvents, true)
+		analysis := analyzeTest(t, newTestAnalyzer(), tx, parsedTx)
+		params := analysis.Instructions[0]
+		info := params.TokenLedger
+		if !info.TokenAccount.Equals(testKey(2)) || !info.Mint.Equals(testKey(4)) || info.PreBalance != 1000 || info.PostBalance != 400 {
+			t.Errorf("events %v: token ledger %+v", withEvents, info)
+		}
+		if params.InAmount != 600 || info.InAmountFrom == "unknown" {
+			t.Errorf("events %v: in_amount %d from %q", withEvents, params.InAmount, info.InAmountFrom)
+		}
+	}
+}
+
+func TestCorrelateTokenLedgerUnknown(t *testing.T) {
+	// Credited and spent within the transaction without events: the balances do not tell
+	tx, parsedTx := tokenLedgerTransaction(t, 1000, 1000, false, false)
+	analysis := analyzeTest(t, newTestAnalyzer(), tx, parsedTx)
+	params := analysis.Instructions[0]
+	if params.InAmount != 0 || params.TokenLedger.InAmountFrom != "unknown" {
+		t.Errorf("in_amount %d from %q", params.InAmount, params.TokenLedger.InAmountFrom)
+	}
+}
+
+func TestCorrelateTokenLedgerUnreadableBalance(t *testing.T) {
+	// A post balance that does not fit uint64 must not read as an empty account
+	tx, parsedTx := tokenLedgerTransaction(t, 1000, 400, false, false)
+	tx.Meta.PostTokenBalances[0].UiTokenAmount.Amount = "18446744073709551616"
+	analysis := analyzeTest(t, newTestAnalyzer(), tx, parsedTx)
+	params := analysis.Instructions[0]
+	if params.InAmount != 0 || params.TokenLedger.InAmountFrom != "unknown" {
+		t.Errorf("in_amount %d from %q", params.InAmount, params.TokenLedger.InAmountFrom)
+	}
+}
+
+func TestCorrelateTokenLedgerUnreadablePreBalance(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		unread func(*rpc.TokenBalance)
+		events bool
+		amount uint64
+		from   string
+	}{
+		{"missing amount", func(b *rpc.TokenBalance) { b.UiTokenAmount = nil }, false, 0, "unknown"},
+		{"not a number", func(b *rpc.TokenBalance) { b.UiTokenAmount.Amount = "1e3" }, false, 0, "unknown"},
+		// Events do not depend on the balances
+		{"missing amount with events", func(b *rpc.TokenBalance) { b.UiTokenAmount = nil }, true, 600, "event"},
+	} {
+		tx, parsedTx := tokenLedgerTransaction(t, 1000, 400, tt.events, false)
+		tt.unread(&tx.Meta.PreTokenBalances[0])
+		analysis := analyzeTest(t, newTestAnalyzer(), tx, parsedTx)
+		params := analysis.Instructions[0]
+		if params.InAmount != tt.amount || params.TokenLedger.InAmountFrom != tt.from {
+			t.Errorf("%s: in_amount %d from %q, want %d from %q", tt.name, params.InAmount, params.TokenLedger.InAmountFrom, tt.amount, tt.from)
+		}
+		if params.TokenLedger.PreBalance != 0 {
+			t.Errorf("%s: unreadable pre balance read as %d", tt.name, params.TokenLedger.PreBalance)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// TokenInfo describes display metadata for a token mint
+type TokenInfo struct {
+	Symbol   string `json:"symbol"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// TokenRegistry resolves display metadata for token mints
+type TokenRegistry interface {
+	Lookup(mint solana.PublicKey) (TokenInfo, bool)
+}
+
+// StaticTokenRegistry is a TokenRegistry backed by a fixed map
+type StaticTokenRegistry map[solana.PublicKey]TokenInfo
+
+// Lookup returns the token info registered for mint
+func (r StaticTokenRegistry) Lookup(mint solana.PublicKey) (TokenInfo, bool) {
+	info, ok := r[mint]
+	return info, ok
+}
+
+// formatUnits formats a raw integer amount with the given number of decimals,
+// trimming trailing zeros (e.g. 1500000000 with 9 decimals -> "1.5")
+func formatUnits(amount uint64, decimals uint8) string {
+	raw := strconv.FormatUint(amount, 10)
+	if decimals == 0 {
+		return raw
+	}
+
+	d := int(decimals)
+	if len(raw) <= d {
+		raw = strings.Repeat("0", d-len(raw)+1) + raw
+	}
+
+	whole := raw[:len(raw)-d]
+	frac := strings.TrimRight(raw[len(raw)-d:], "0")
+	if frac == "" {
+		return whole
+	}
+	return whole + "." + frac
+}
+
+// formatTokenAmount formats an amount of mint using the registry when possible,
+// falling back to the raw amount and mint address
+func formatTokenAmount(amount uint64, mint solana.PublicKey, registry TokenRegistry) string {
+	if registry != nil {
+		if info, ok := registry.Lookup(mint); ok {
+			return formatUnits(amount, info.Decimals) + " " + info.Symbol
+		}
+	}
+	return strconv.FormatUint(amount, 10) + " " + mint.String()
+}
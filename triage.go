@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"sol-tx/jupiterv6"
+)
+
+// TriageSample is one occurrence of an undecodable shape
+type TriageSample struct {
+	Signature solana.Signature `json:"signature"`
+	Slot      uint64           `json:"slot"`
+	Index     int              `json:"index,omitempty"` // top-level instruction index
+	Data      string           `json:"data,omitempty"`  // hex instruction data
+	Message   string           `json:"message,omitempty"`
+}
+
+// TriageGroup counts the occurrences of one shape and keeps the first samples
+type TriageGroup struct {
+	Shape   string         `json:"shape"`
+	Count   int            `json:"count"`
+	Samples []TriageSample `json:"samples"`
+}
+
+// TriageReport collects only the data the parser could not decode, grouped by
+// shape so it stays small enough to attach to an issue
+type TriageReport struct {
+	FromSlot     uint64 `json:"from_slot,omitempty"`
+	ToSlot       uint64 `json:"to_slot,omitempty"`
+	Transactions int    `json:"transactions"`
+	SlotErrors   int    `json:"slot_errors,omitempty"`
+
+	UnknownDiscriminators []TriageGroup `json:"unknown_discriminators"`
+	UnknownVariants       []TriageGroup `json:"unknown_variants"`
+	ParseFailures         []TriageGroup `json:"parse_failures"`
+	TrailingBytes         []TriageGroup `json:"trailing_bytes"`
+	EventFailures         []TriageGroup `json:"event_failures"`
+
+	maxSamples int
+	groups     map[string]int // index in its category, by category and shape
+}
+
+// NewTriageReport creates a report keeping at most maxSamples samples per shape
+func NewTriageReport(maxSamples int) *TriageReport {
+	return &TriageReport{
+		UnknownDiscriminators: []TriageGroup{},
+		UnknownVariants:       []TriageGroup{},
+		ParseFailures:         []TriageGroup{},
+		TrailingBytes:         []TriageGroup{},
+		EventFailures:         []TriageGroup{},
+		maxSamples:            maxSamples,
+		groups:                make(map[string]int),
+	}
+}
+
+// note records a sample under category and shape
+func (r *TriageReport) note(category *[]TriageGroup, name, shape string, sample TriageSample) {
+	key := name + "/" + shape
+	i, ok := r.groups[key]
+	if !ok {
+		*category = append(*category, TriageGroup{Shape: shape})
+		i = len(*category) - 1
+		r.groups[key] = i
+	}
+	group := &(*category)[i]
+	group.Count++
+	if len(group.Samples) < r.maxSamples {
+		group.Samples = append(group.Samples, sample)
+	}
+}
+
+// Add records the undecodable parts of an analysis of signature at slot
+func (r *TriageReport) Add(signature solana.Signature, slot uint64, analysis *JupiterV6Analysis) {
+	r.Transactions++
+	for _, result := range analysis.Results {
+		if result.Skipped {
+			continue
+		}
+		sample := TriageSample{Signature: signature, Slot: slot, Index: result.Index, Data: hex.EncodeToString(result.Data)}
+
+		switch {
+		case result.Code == CodeUnknownDiscriminator:
+			r.note(&r.UnknownDiscriminators, "discriminator", hex.EncodeToString(result.Data[:8]), sample)
+		case result.Error != "":
+			sample.Message = result.Error
+			shape := string(result.Code)
+			if instructionType, ok := InstructionTypeOf(result.Data); ok {
+				shape += " " + instructionType
+			}
+			r.note(&r.ParseFailures, "failure", shape, sample)
+		case result.Params != nil:
+			for _, step := range result.Params.RoutePlan {
+				if index, ok := unknownSwapIndex(step.Swap.Type); ok {
+					r.note(&r.UnknownVariants, "variant", fmt.Sprintf("variant %d", index), sample)
+				}
+			}
+			if trailing, ok := instructionTrailingBytes(result.Data); ok && trailing != 0 {
+				r.note(&r.TrailingBytes, "trailing", fmt.Sprintf("%s %+d bytes", result.Params.InstructionType, trailing), sample)
+			}
+		}
+	}
+
+	for _, warning := range analysis.Warnings {
+		if warning.Code == CodeEventsMissing || warning.Code == CodeUnparsedEventData {
+			r.note(&r.EventFailures, "event", string(warning.Code), TriageSample{Signature: signature, Slot: slot, Message: warning.Message})
+		}
+	}
+}
+
+// sortGroups orders every category by descending count
+func (r *TriageReport) sortGroups() {
+	categories := map[string]*[]TriageGroup{
+		"discriminator": &r.UnknownDiscriminators,
+		"variant":       &r.UnknownVariants,
+		"failure":       &r.ParseFailures,
+		"trailing":      &r.TrailingBytes,
+		"event":         &r.EventFailures,
+	}
+	for name, category := range categories {
+		groups := *category
+		sort.SliceStable(groups, func(i, j int) bool { return groups[i].Count > groups[j].Count })
+		for i, group := range groups {
+			r.groups[name+"/"+group.Shape] = i
+		}
+	}
+}
+
+// instructionTrailingBytes returns how many bytes the instruction data has
+// beyond the decoded layout, including an optional tail that ends the data,
+// negative when it is short
+func instructionTrailingBytes(data []byte) (int, bool) {
+	instructionType, ok := InstructionTypeOf(data)
+	if !ok {
+		return 0, false
+	}
+	offset := 8
+	if jupiterv6.IsShared(instructionType) {
+		offset++
+	}
+	length, err := jupiterv6.RoutePlanByteLength(data, offset)
+	if err != nil {
+		return 0, false
+	}
+	end := offset + length + jupiterv6.TailSize(instructionType)
+	// The optional tail of newer revisions is part of the layout when it ends the data
+	if end <= len(data) {
+		if _, size, ok := jupiterv6.DecodeOptionalTail(data[end:]); ok && end+size == len(data) {
+			end += size
+		}
+	}
+	return len(data) - end, true
+}
+
+// Triage scans the blocks from fromSlot to toSlot and records the undecodable
+// parts of every Jupiter transaction into report. Slots that cannot be fetched,
+// such as skipped slots, are counted in SlotErrors.
+func (a *Analyzer) Triage(ctx context.Context, fromSlot, toSlot uint64, report *TriageReport) error {
+	if a.noNetwork {
+		return fmt.Errorf("%w: cannot fetch blocks", ErrNetworkDisabled)
+	}
+	if a.rpcClient == nil {
+		return fmt.Errorf("analyzer has no rpc client")
+	}
+	report.FromSlot, report.ToSlot = fromSlot, toSlot
+	defer report.sortGroups()
+
+	rewards := false
+	opts := &rpc.GetBlockOpts{
+		Encoding:                       solana.EncodingBase64,
+		TransactionDetails:             rpc.TransactionDetailsFull,
+		Rewards:                        &rewards,
+		Commitment:                     a.commitment,
+		MaxSupportedTransactionVersion: a.txOpts.MaxSupportedTransactionVersion,
+	}
+
+	for slot := fromSlot; slot <= toSlot; slot++ {
+		var block *rpc.GetBlockResult
+		err := a.withRPCSlot(ctx, func() error {
+			var err error
+			block, err = a.rpcClient.GetBlockWithOpts(ctx, slot, opts)
+			return err
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil || block == nil {
+			report.SlotErrors++
+			continue
+		}
+
+		for _, blockTx := range block.Transactions {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			tx, parsedTx, err := blockTransaction(slot, blockTx)
+			if err != nil || !parsedTx.Message.AccountKeys.Contains(jupiterV6ProgramID) || len(parsedTx.Signatures) == 0 {
+				continue
+			}
+			if parsedTx.Message.IsVersioned() {
+				// Loaded addresses come with the block, no lookup table fetch is needed
+				_ = resolveLookupsFromMeta(parsedTx, tx.Meta)
+			}
+			analysis, err := a.Analyze(tx, parsedTx)
+			if err != nil {
+				continue
+			}
+			report.Add(parsedTx.Signatures[0], slot, analysis)
+		}
+	}
+	return nil
+}
+
+// blockTransaction converts a transaction of a block into the GetTransaction
+// form Analyze takes
+func blockTransaction(slot uint64, blockTx rpc.TransactionWithMeta) (*rpc.GetTransactionResult, *solana.Transaction, error) {
+	raw, err := json.Marshal(blockTx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tx rpc.GetTransactionResult
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		return nil, nil, err
+	}
+	if tx.Transaction == nil {
+		return nil, nil, fmt.Errorf("block transaction has no data")
+	}
+	tx.Slot = slot
+	parsedTx, err := tx.Transaction.GetTransaction()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &tx, parsedTx, nil
+}
+
+// runTriageCommand implements the triage subcommand:
+// triage -from-slot A -to-slot B [-samples N]
+func runTriageCommand(args []string) int {
+	flags := flag.NewFlagSet("triage", flag.ContinueOnError)
+	fromSlot := flags.Uint64("from-slot", 0, "first slot to scan")
+	toSlot := flags.Uint64("to-slot", 0, "last slot to scan")
+	samples := flags.Int("samples", 3, "samples kept per shape")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *fromSlot == 0 || *toSlot < *fromSlot {
+		fmt.Fprintln(os.Stderr, "usage: triage -from-slot A -to-slot B [-samples N]")
+		return 2
+	}
+
+	report := NewTriageReport(*samples)
+	analyzer := NewAnalyzer(newMainnetRPCClient(), WithLogOutput(io.Discard))
+	err := analyzer.Triage(context.Background(), *fromSlot, *toSlot, report)
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(report); encodeErr != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", encodeErr)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Triage stopped: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"sol-tx/testgen"
+)
+
+// triageFixture generates a transaction for instructionType whose instruction
+// data is replaced by mutate
+func triageFixture(t *testing.T, instructionType string, seed int64, mutate func([]byte) []byte) (*rpc.GetTransactionResult, *solana.Transaction) {
+	t.Helper()
+	spec := testgenSpec(instructionType)
+	spec.Seed = seed
+	gen, err := testgen.Generate(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mutate != nil {
+		inst := &gen.Transaction.Message.Instructions[0]
+		inst.Data = mutate(append([]byte{}, inst.Data...))
+	}
+	return gen.Result, gen.Transaction
+}
+
+func TestInstructionTrailingBytes(t *testing.T) {
+	for instructionType := range InstructionDiscriminators {
+		gen, err := testgen.Generate(testgenSpec(instructionType))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if trailing, ok := instructionTrailingBytes(gen.Data); !ok || trailing != 0 {
+			t.Errorf("%s: %d trailing bytes", instructionType, trailing)
+		}
+		if trailing, ok := instructionTrailingBytes(append(gen.Data, 0xFF, 0xFF)); !ok || trailing != 2 {
+			t.Errorf("%s: %d trailing bytes, want 2", instructionType, trailing)
+		}
+		// The optional expire_at tail of newer revisions is not drift
+		for _, tail := range [][]byte{{0}, {1, 0x80, 0x51, 0x01, 0, 0, 0, 0, 0}} {
+			if trailing, ok := instructionTrailingBytes(append(append([]byte{}, gen.Data...), tail...)); !ok || trailing != 0 {
+				t.Errorf("%s with optional tail %x: %d trailing bytes", instructionType, tail, trailing)
+			}
+		}
+	}
+}
+
+func TestTriageReport(t *testing.T) {
+	fixtures := []struct {
+		instructionType string
+		mutate          func([]byte) []byte
+	}{
+		// Decodable token ledger routes report nothing
+		{"routeWithTokenLedger", nil},
+		{"sharedAccountsRouteWithTokenLedger", nil},
+		// Unknown discriminator, twice
+		{"route", func(data []byte) []byte { data[0] ^= 0xFF; return data }},
+		{"route", func(data []byte) []byte { data[0] ^= 0xFF; return data }},
+		// Unknown variant 250 in place of Saber
+		{"route", func(data []byte) []byte { data[12] = 250; return data }},
+		// Truncated amounts
+		{"exactOutRoute", func(data []byte) []byte { return data[:len(data)-4] }},
+		// Trailing bytes
+		{"sharedAccountsRoute", func(data []byte) []byte { return append(data, 1, 2, 3) }},
+	}
+
+	report := NewTriageReport(1)
+	analyzer := newTestAnalyzer()
+	for i, fixture := range fixtures {
+		tx, parsedTx := triageFixture(t, fixture.instructionType, int64(i), fixture.mutate)
+		report.Add(parsedTx.Signatures[0], tx.Slot, analyzeTest(t, analyzer, tx, parsedTx))
+	}
+	report.sortGroups()
+
+	shapes := func(groups []TriageGroup) map[string]int {
+		counts := make(map[string]int)
+		for _, group := range groups {
+			counts[group.Shape] = group.Count
+			if len(group.Samples) != 1 {
+				t.Errorf("%s: %d samples, want 1", group.Shape, len(group.Samples))
+			}
+		}
+		return counts
+	}
+	want := map[string]map[string]int{
+		"unknown_discriminators": {"1a17cb977ae3ad2a": 2},
+		"unknown_variants":       {"variant 250": 1},
+		"parse_failures":         {"JUP002 exactOutRoute": 1},
+		"trailing_bytes":         {"sharedAccountsRoute +3 bytes": 1},
+		"event_failures":         {},
+	}
+	got := map[string]map[string]int{
+		"unknown_discriminators": shapes(report.UnknownDiscriminators),
+		"unknown_variants":       shapes(report.UnknownVariants),
+		"parse_failures":         shapes(report.ParseFailures),
+		"trailing_bytes":         shapes(report.TrailingBytes),
+		"event_failures":         shapes(report.EventFailures),
+	}
+	for category, shapes := range want {
+		if len(got[category]) != len(shapes) {
+			t.Errorf("%s: got %v, want %v", category, got[category], shapes)
+			continue
+		}
+		for shape, count := range shapes {
+			if got[category][shape] != count {
+				t.Errorf("%s: got %v, want %v", category, got[category], shapes)
+			}
+		}
+	}
+	if report.Transactions != len(fixtures) {
+		t.Errorf("%d transactions, want %d", report.Transactions, len(fixtures))
+	}
+
+	// The report structure is part of the issue template
+	raw, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"transactions", "unknown_discriminators", "unknown_variants", "parse_failures", "trailing_bytes", "event_failures"} {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("report has no %q field", field)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// checkStepAfter decodes a route whose unit variant step is followed by a
+// Whirlpool step, checking that the following step and the tail stay aligned
+func checkStepAfter(t *testing.T, want SwapType) {
+	t.Helper()
+	steps := [][]byte{
+		testStep(SwapTypeToIndex[want], 100, 0, 1),
+		{SwapTypeToIndex[SwapWhirlpool], 1, 100, 1, 2}, // a_to_b
+	}
+	params, err := parseJupiterV6Instruction(testInstruction("route", 0, steps, 1000, 900, 50, 0))
+	if err != nil {
+		t.Fatalf("%s: %v", want, err)
+	}
+	if len(params.RoutePlan) != 2 {
+		t.Fatalf("%s: %d steps, want 2", want, len(params.RoutePlan))
+	}
+	first, next := params.RoutePlan[0], params.RoutePlan[1]
+	if first.Swap.Type != want || first.Percent != 100 || first.InputIndex != 0 || first.OutputIndex != 1 {
+		t.Errorf("%s: first step %+v", want, first)
+	}
+	if next.Swap.Type != SwapWhirlpool || next.Swap.Params["a_to_b"] != true || next.Percent != 100 || next.InputIndex != 1 || next.OutputIndex != 2 {
+		t.Errorf("%s: following step %+v", want, next)
+	}
+	if params.InAmount != 1000 || params.QuotedOutAmount != 900 || params.SlippageBps != 50 {
+		t.Errorf("%s: tail misread: %+v", want, params)
+	}
+}
+
+func TestStepAfterWoofi(t *testing.T) {
+	checkStepAfter(t, Woofi)
+}
+
+func TestStepAfterPerps(t *testing.T) {
+	for _, swapType := range []SwapType{
+		SwapPerps, SwapPerpsAddLiquidity, SwapPerpsRemoveLiquidity,
+		SwapPerpsV2, SwapPerpsV2AddLiquidity, SwapPerpsV2RemoveLiquidity,
+	} {
+		checkStepAfter(t, swapType)
+	}
+}
+
+func TestStepAfterMoonshot(t *testing.T) {
+	checkStepAfter(t, SwapMoonshotWrappedBuy)
+	checkStepAfter(t, SwapMoonshotWrappedSell)
+}
+
+func TestStepAfterStabble(t *testing.T) {
+	checkStepAfter(t, SwapStabbleStableSwap)
+	checkStepAfter(t, SwapStabbleWeightedSwap)
+}
+
+func TestStepAfterOneIntro(t *testing.T) {
+	checkStepAfter(t, SwapOneIntro)
+}
+
+func TestFailOnUnknownSwapType(t *testing.T) {
+	data := testInstruction("route", 0, [][]byte{testStep(0, 100, 0, 1), testStep(250, 100, 1, 2)}, 1000, 900, 50, 0)
+
+	params, err := newTestAnalyzer().parseInstruction(data, 0)
+	if err != nil || params.RoutePlan[1].Swap.Type != "Unknown_250" {
+		t.Fatalf("without the option: %+v, %v", params, err)
+	}
+	params, err = newTestAnalyzer(WithFailOnUnknownSwapType()).parseInstruction(data, 0)
+	if !errors.Is(err, ErrUnknownSwapType) || params != nil {
+		t.Errorf("with the option: %+v, %v", params, err)
+	}
+
+	// Decode uses the same decode path through the default analyzer
+	resetDefaults(t)
+	if err := SetDefault(WithFailOnUnknownSwapType()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decode(data); !errors.Is(err, ErrUnknownSwapType) {
+		t.Errorf("Decode with the option: %v", err)
+	}
+
+	tx := policyTransaction(t)
+	analysis, err := newTestAnalyzer(WithTransactionSource(staticSource{tx})).AnalyzeSignature(context.Background(), solana.Signature{1})
+	if err != nil {
+		t.Fatalf("Analyze without the option: %v", err)
+	}
+	warned := false
+	for _, warning := range analysis.Warnings {
+		warned = warned || warning.Code == CodeUnknownSwapVariant
+	}
+	if !warned {
+		t.Errorf("Analyze without the option: warnings %+v", analysis.Warnings)
+	}
+	_, err = newTestAnalyzer(WithTransactionSource(staticSource{tx}), WithFailOnUnknownSwapType()).AnalyzeSignature(context.Background(), solana.Signature{1})
+	if !errors.Is(err, ErrUnknownSwapType) {
+		t.Errorf("Analyze with the option: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// jupiterProgramVersions maps known Jupiter aggregator program IDs to version labels
+var jupiterProgramVersions = map[solana.PublicKey]string{
+	solana.MustPublicKeyFromBase58("JUP2jxvXaqu7NQY1GmNF4m1vodw12LVXYxbFL2uJvfo"): "v2",
+	solana.MustPublicKeyFromBase58("JUP3c2Uh3WA4Ng34tw6kPd2G4C5BB21Xo36Je1s32Ph"): "v3",
+	solana.MustPublicKeyFromBase58("JUP4Fb2cqiRUcaTHdrPC8h2gNsA2ETXiPDD33WcGuJB"): "v4",
+	jupiterV6ProgramID: "v6",
+}
+
+// detectJupiterVersion returns the version label of the first Jupiter program
+// invoked in the logs ("Program <id> invoke [n]"), or "" when none is found
+func detectJupiterVersion(logs []string) string {
+	for _, logMsg := range logs {
+		if !strings.HasPrefix(logMsg, "Program ") || !strings.Contains(logMsg, " invoke [") {
+			continue
+		}
+
+		fields := strings.Fields(logMsg)
+		if len(fields) < 3 {
+			continue
+		}
+		programID, err := solana.PublicKeyFromBase58(fields[1])
+		if err != nil {
+			continue
+		}
+		if version, ok := jupiterProgramVersions[programID]; ok {
+			return version
+		}
+	}
+	return ""
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"container/list"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// volumeWindowSpec is a rolling window made of equally sized buckets
+type volumeWindowSpec struct {
+	name   string
+	bucket time.Duration
+	count  int
+}
+
+// volumeWindows are the rolling windows kept for every mint and pair
+var volumeWindows = []volumeWindowSpec{
+	{name: "5m", bucket: time.Minute, count: 5},
+	{name: "1h", bucket: 5 * time.Minute, count: 12},
+	{name: "24h", bucket: time.Hour, count: 24},
+}
+
+// volumeBucket accumulates the trades of one bucket interval
+type volumeBucket struct {
+	index       int64 // bucket start divided by the bucket size
+	count       uint64
+	volume      *big.Int
+	quoteVolume *big.Int
+	failures    uint64
+	codes       map[string]uint64 // failures by error code
+}
+
+// volumeRing is a ring buffer of buckets covering one window
+type volumeRing struct {
+	spec    volumeWindowSpec
+	buckets []volumeBucket
+}
+
+// add records a trade at t, ignoring trades older than the window
+func (r *volumeRing) add(t, now time.Time, amount, quoteAmount uint64) {
+	index := t.UnixNano() / int64(r.spec.bucket)
+	if index <= now.UnixNano()/int64(r.spec.bucket)-int64(r.spec.count) {
+		return
+	}
+
+	slot := r.bucket(index)
+	slot.count++
+	slot.volume.Add(slot.volume, new(big.Int).SetUint64(amount))
+	slot.quoteVolume.Add(slot.quoteVolume, new(big.Int).SetUint64(quoteAmount))
+}
+
+// addFailure records a failed swap with its error code at t, ignoring failures older than the window
+func (r *volumeRing) addFailure(t, now time.Time, code string) {
+	index := t.UnixNano() / int64(r.spec.bucket)
+	if index <= now.UnixNano()/int64(r.spec.bucket)-int64(r.spec.count) {
+		return
+	}
+
+	slot := r.bucket(index)
+	slot.failures++
+	if slot.codes == nil {
+		slot.codes = make(map[string]uint64)
+	}
+	slot.codes[code]++
+}
+
+// bucket returns the bucket of index, resetting the slot when it held an older bucket
+func (r *volumeRing) bucket(index int64) *volumeBucket {
+	slot := &r.buckets[int(index%int64(r.spec.count))]
+	if slot.volume == nil || slot.index != index {
+		*slot = volumeBucket{index: index, volume: new(big.Int), quoteVolume: new(big.Int)}
+	}
+	return slot
+}
+
+// totals sums the buckets still inside the window at now
+func (r *volumeRing) totals(now time.Time) VolumeTotals {
+	totals := VolumeTotals{}
+	volume, quote := new(big.Int), new(big.Int)
+	oldest := now.UnixNano()/int64(r.spec.bucket) - int64(r.spec.count)
+	for _, bucket := range r.buckets {
+		if bucket.volume == nil || bucket.index <= oldest {
+			continue
+		}
+		totals.Count += bucket.count
+		volume.Add(volume, bucket.volume)
+		quote.Add(quote, bucket.quoteVolume)
+		totals.Failures += bucket.failures
+		for code, n := range bucket.codes {
+			if totals.FailureCodes == nil {
+				totals.FailureCodes = make(map[string]uint64)
+			}
+			totals.FailureCodes[code] += n
+		}
+	}
+	totals.FailureRate = failureRate(totals.Count, totals.Failures)
+	totals.Volume = (*BigAmount)(volume)
+	if quote.Sign() > 0 {
+		totals.QuoteVolume = (*BigAmount)(quote)
+	}
+	return totals
+}
+
+// volumeSeries holds the rings of one tracked mint or pair
+type volumeSeries struct {
+	key   string
+	rings []volumeRing
+}
+
+// newVolumeSeries creates empty rings for every window
+func newVolumeSeries(key string) *volumeSeries {
+	series := &volumeSeries{key: key, rings: make([]volumeRing, len(volumeWindows))}
+	for i, spec := range volumeWindows {
+		series.rings[i] = volumeRing{spec: spec, buckets: make([]volumeBucket, spec.count)}
+	}
+	return series
+}
+
+// volumeLRU bounds the number of series, evicting the least recently traded one
+type volumeLRU struct {
+	max   int
+	order *list.List // front is the most recently traded
+	items map[string]*list.Element
+}
+
+// get returns the series of key, creating it and evicting a cold one when full
+func (l *volumeLRU) get(key string) *volumeSeries {
+	if element, ok := l.items[key]; ok {
+		l.order.MoveToFront(element)
+		return element.Value.(*volumeSeries)
+	}
+	if l.max > 0 && l.order.Len() >= l.max {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*volumeSeries).key)
+	}
+	series := newVolumeSeries(key)
+	l.items[key] = l.order.PushFront(series)
+	return series
+}
+
+// VolumeTotals is the swap count and raw volume within a window. QuoteVolume
+// is only set for pairs. Failures counts failed swaps attributed to the pair or
+// AMM; FailureRate is Failures over Count plus Failures.
+type VolumeTotals struct {
+	Count        uint64            `json:"count"`
+	Volume       *BigAmount        `json:"volume"`
+	QuoteVolume  *BigAmount        `json:"quote_volume,omitempty"`
+	Failures     uint64            `json:"failures,omitempty"`
+	FailureRate  float64           `json:"failure_rate,omitempty"`
+	FailureCodes map[string]uint64 `json:"failure_codes,omitempty"`
+}
+
+// VolumeStats are the rolling window totals of one mint or pair
+type VolumeStats struct {
+	Key     string                  `json:"key"`
+	Windows map[string]VolumeTotals `json:"windows"`
+}
+
+// VolumeSnapshot is a point in time view of the tracker
+type VolumeSnapshot struct {
+	At    time.Time     `json:"at"`
+	Mints []VolumeStats `json:"mints"`
+	Pairs []VolumeStats `json:"pairs"`
+	// AMMs counts executed swap events and attributed failures per AMM, their volume is always zero
+	AMMs []VolumeStats `json:"amms"`
+}
+
+// VolumeTracker keeps in-process rolling swap counts and volumes per mint and
+// per pair, and swap and failure counts per AMM. Memory is bounded by the
+// maximum number of tracked keys.
+type VolumeTracker struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	mints volumeLRU
+	pairs volumeLRU
+	amms  volumeLRU
+}
+
+// NewVolumeTracker creates a tracker keeping at most maxKeys mints and maxKeys
+// pairs, 0 for no limit. now defaults to time.Now.
+func NewVolumeTracker(maxKeys int, now func() time.Time) *VolumeTracker {
+	if now == nil {
+		now = time.Now
+	}
+	return &VolumeTracker{
+		now:   now,
+		mints: volumeLRU{max: maxKeys, order: list.New(), items: make(map[string]*list.Element)},
+		pairs: volumeLRU{max: maxKeys, order: list.New(), items: make(map[string]*list.Element)},
+		amms:  volumeLRU{max: maxKeys, order: list.New(), items: make(map[string]*list.Element)},
+	}
+}
+
+// pairKey names a canonical pair
+func pairKey(base, quote solana.PublicKey) string {
+	return base.String() + "/" + quote.String()
+}
+
+// Add records a trade. Trades without a timestamp are recorded now.
+func (v *VolumeTracker) Add(trade Trade) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.now()
+	at := trade.Timestamp
+	if at.IsZero() {
+		at = now
+	}
+
+	for _, side := range []struct {
+		mint   solana.PublicKey
+		amount uint64
+	}{{trade.Base, trade.BaseAmount}, {trade.Quote, trade.QuoteAmount}} {
+		series := v.mints.get(side.mint.String())
+		for i := range series.rings {
+			series.rings[i].add(at, now, side.amount, 0)
+		}
+	}
+
+	series := v.pairs.get(pairKey(trade.Base, trade.Quote))
+	for i := range series.rings {
+		series.rings[i].add(at, now, trade.BaseAmount, trade.QuoteAmount)
+	}
+}
+
+// AddAnalysis records every trade of an analysis at timestamp and counts its
+// swap events per AMM. A failed analysis is recorded as a failure of its AMM
+// and, when its mints are known, of its pair.
+func (v *VolumeTracker) AddAnalysis(analysis *JupiterV6Analysis, timestamp time.Time) {
+	if analysis.Failure != nil {
+		v.AddFailure(*analysis.Failure, tradeTime(analysis, timestamp))
+		return
+	}
+
+	for _, trade := range TradesFromAnalysis(analysis, timestamp) {
+		v.Add(trade)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := v.now()
+	at := tradeTime(analysis, timestamp)
+	if at.IsZero() {
+		at = now
+	}
+	for _, event := range analysis.Events {
+		series := v.amms.get(ammKey(event.AMM))
+		for i := range series.rings {
+			series.rings[i].add(at, now, 0, 0)
+		}
+	}
+}
+
+// AddFailure records a failed swap under its AMM, "unknown" when unattributed,
+// and its pair. Failures without a timestamp are recorded now.
+func (v *VolumeTracker) AddFailure(failure SwapFailure, timestamp time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.now()
+	at := timestamp
+	if at.IsZero() {
+		at = now
+	}
+
+	keys := []*volumeSeries{v.amms.get(failure.AMM)}
+	if failure.InputMint != nil && failure.OutputMint != nil && !failure.InputMint.Equals(*failure.OutputMint) {
+		keys = append(keys, v.pairs.get(pairKey(canonicalPair(*failure.InputMint, *failure.OutputMint))))
+	}
+	for _, series := range keys {
+		for i := range series.rings {
+			series.rings[i].addFailure(at, now, failure.Code)
+		}
+	}
+}
+
+// snapshotStats collects the totals of every series of an lru, sorted by key
+func snapshotStats(l *volumeLRU, now time.Time) []VolumeStats {
+	stats := make([]VolumeStats, 0, len(l.items))
+	for element := l.order.Front(); element != nil; element = element.Next() {
+		series := element.Value.(*volumeSeries)
+		entry := VolumeStats{Key: series.key, Windows: make(map[string]VolumeTotals, len(series.rings))}
+		for _, ring := range series.rings {
+			entry.Windows[ring.spec.name] = ring.totals(now)
+		}
+		stats = append(stats, entry)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Key < stats[j].Key })
+	return stats
+}
+
+// Snapshot returns the current window totals of every tracked mint and pair
+func (v *VolumeTracker) Snapshot() VolumeSnapshot {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.now()
+	return VolumeSnapshot{
+		At:    now,
+		Mints: snapshotStats(&v.mints, now),
+		Pairs: snapshotStats(&v.pairs, now),
+		AMMs:  snapshotStats(&v.amms, now),
+	}
+}
+
+// ServeHTTP serves the snapshot as JSON so the tracker can be mounted on any mux
+func (v *VolumeTracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v.Snapshot()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// volumeClock is a settable clock for NewVolumeTracker
+type volumeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *volumeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *volumeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+// pairWindows returns the window totals of the pair key in the snapshot
+func pairWindows(t *testing.T, snapshot VolumeSnapshot, key string) map[string]VolumeTotals {
+	t.Helper()
+	for _, stats := range snapshot.Pairs {
+		if stats.Key == key {
+			return stats.Windows
+		}
+	}
+	t.Fatalf("pair %s not tracked", key)
+	return nil
+}
+
+func TestVolumeTrackerWindows(t *testing.T) {
+	clock := &volumeClock{t: time.Date(2026, 1, 2, 12, 0, 30, 0, time.UTC)}
+	v := NewVolumeTracker(0, clock.now)
+	base := testKey(1)
+	key := pairKey(base, mintUSDC)
+
+	v.Add(Trade{Timestamp: clock.now(), Base: base, Quote: mintUSDC, BaseAmount: 100, QuoteAmount: 200})
+	v.Add(Trade{Base: base, Quote: mintUSDC, BaseAmount: 10, QuoteAmount: 20}) // no timestamp, recorded now
+	// older than the 5m window, inside the 1h and 24h windows
+	v.Add(Trade{Timestamp: clock.now().Add(-10 * time.Minute), Base: base, Quote: mintUSDC, BaseAmount: 1, QuoteAmount: 2})
+	// older than every window
+	v.Add(Trade{Timestamp: clock.now().Add(-25 * time.Hour), Base: base, Quote: mintUSDC, BaseAmount: 1000, QuoteAmount: 1000})
+
+	check := func(when string, want map[string]uint64) {
+		t.Helper()
+		windows := pairWindows(t, v.Snapshot(), key)
+		for name, count := range want {
+			if windows[name].Count != count {
+				t.Errorf("%s: %s count %d, want %d", when, name, windows[name].Count, count)
+			}
+		}
+	}
+
+	windows := pairWindows(t, v.Snapshot(), key)
+	if totals := windows["5m"]; totals.Count != 2 || totals.Volume.Int().Uint64() != 110 || totals.QuoteVolume.Int().Uint64() != 220 {
+		t.Errorf("5m totals %+v", totals)
+	}
+	if totals := windows["24h"]; totals.Count != 3 || totals.Volume.Int().Uint64() != 111 {
+		t.Errorf("24h totals %+v", totals)
+	}
+
+	// Windows expire bucket by bucket: the 12:00 minute leaves the 5m window at 12:05
+	clock.advance(4 * time.Minute)
+	check("12:04:30", map[string]uint64{"5m": 2, "1h": 3, "24h": 3})
+	clock.advance(time.Minute)
+	check("12:05:30", map[string]uint64{"5m": 0, "1h": 3, "24h": 3})
+	// the 11:50 five minutes leave the 1h window at 12:50, the 12:00 ones at 13:00
+	clock.advance(45 * time.Minute)
+	check("12:50:30", map[string]uint64{"1h": 2, "24h": 3})
+	clock.advance(10 * time.Minute)
+	check("13:00:30", map[string]uint64{"1h": 0, "24h": 3})
+	// the 11:00 hour leaves the 24h window at 11:00 the next day
+	clock.advance(22 * time.Hour)
+	check("next day 11:00:30", map[string]uint64{"24h": 2})
+	clock.advance(time.Hour)
+	check("next day 12:00:30", map[string]uint64{"24h": 0})
+	if totals := pairWindows(t, v.Snapshot(), key)["24h"]; totals.Volume.Int().Sign() != 0 || totals.QuoteVolume != nil {
+		t.Errorf("expired totals %+v", totals)
+	}
+}
+
+func TestVolumeTrackerEviction(t *testing.T) {
+	clock := &volumeClock{t: time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)}
+	v := NewVolumeTracker(2, clock.now)
+	a, b, c := testKey(1), testKey(2), testKey(3)
+	add := func(mint solana.PublicKey) {
+		v.Add(Trade{Base: mint, Quote: mintUSDC, BaseAmount: 1, QuoteAmount: 1})
+	}
+
+	add(a)
+	add(b)
+	add(a) // a/USDC is now the most recently traded pair
+	add(c) // evicts b/USDC, the least recently traded
+
+	snapshot := v.Snapshot()
+	var pairs []string
+	for _, stats := range snapshot.Pairs {
+		pairs = append(pairs, stats.Key)
+	}
+	want := []string{pairKey(a, mintUSDC), pairKey(c, mintUSDC)}
+	sort.Strings(want) // snapshots are sorted by key
+	if !reflect.DeepEqual(pairs, want) {
+		t.Errorf("pairs %v, want %v", pairs, want)
+	}
+	if windows := pairWindows(t, snapshot, pairKey(a, mintUSDC)); windows["5m"].Count != 2 {
+		t.Errorf("a/USDC count %d, want 2", windows["5m"].Count)
+	}
+	// USDC is traded every time and stays, the other mints are evicted in turn
+	if len(snapshot.Mints) != 2 {
+		t.Fatalf("%d mints tracked, want 2", len(snapshot.Mints))
+	}
+	tracked := map[string]uint64{}
+	for _, stats := range snapshot.Mints {
+		tracked[stats.Key] = stats.Windows["5m"].Count
+	}
+	if tracked[mintUSDC.String()] != 4 || tracked[c.String()] != 1 {
+		t.Errorf("mints %v, want USDC and %s", tracked, c)
+	}
+
+	// An evicted key starts from zero when it trades again
+	add(b)
+	if windows := pairWindows(t, v.Snapshot(), pairKey(b, mintUSDC)); windows["5m"].Count != 1 {
+		t.Errorf("re-added b/USDC count %d, want 1", windows["5m"].Count)
+	}
+}
+
+func TestVolumeTrackerConcurrentAdd(t *testing.T) {
+	clock := &volumeClock{t: time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)}
+	v := NewVolumeTracker(0, clock.now)
+	base := testKey(1)
+
+	const goroutines, trades = 8, 200
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range trades {
+				v.Add(Trade{Timestamp: clock.now(), Base: base, Quote: mintUSDC, BaseAmount: 3, QuoteAmount: 5})
+				v.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+
+	totals := pairWindows(t, v.Snapshot(), pairKey(base, mintUSDC))["5m"]
+	if totals.Count != goroutines*trades || totals.Volume.Int().Uint64() != 3*goroutines*trades || totals.QuoteVolume.Int().Uint64() != 5*goroutines*trades {
+		t.Errorf("totals %+v after %d concurrent trades", totals, goroutines*trades)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// User wallet provenance values
+const (
+	WalletFromAccounts = "accounts"  // user_transfer_authority of the instruction
+	WalletFromBalances = "balances"  // owner of the destination token account
+	WalletFromFeePayer = "fee_payer" // first account of the transaction
+)
+
+// userAccountPosition locates the user accounts in the fixed accounts of an
+// instruction type. userDestination is user_destination_token_account, used
+// when the optional destination_token_account is omitted, or -1 when the
+// destination is required (the shared accounts variants).
+type userAccountPosition struct {
+	authority, source, destination, userDestination int
+}
+
+// userAccountPositions gives the user account positions of each instruction type
+var userAccountPositions = map[string]userAccountPosition{
+	"route":                              {1, 2, 4, 3},
+	"routeWithTokenLedger":               {1, 2, 4, 3},
+	"exactOutRoute":                      {1, 2, 4, 3},
+	"sharedAccountsRoute":                {2, 3, 6, -1},
+	"sharedAccountsRouteWithTokenLedger": {2, 3, 6, -1},
+	"sharedAccountsExactOutRoute":        {2, 3, 6, -1},
+}
+
+// userTokenAccounts returns the source and destination token accounts of the
+// instruction. An omitted optional destination_token_account is passed as the
+// Jupiter program ID, the output then goes to user_destination_token_account.
+func userTokenAccounts(instructionType string, accounts solana.PublicKeySlice) (source, destination solana.PublicKey, ok bool) {
+	positions, ok := userAccountPositions[instructionType]
+	if !ok || positions.destination >= len(accounts) {
+		return solana.PublicKey{}, solana.PublicKey{}, false
+	}
+	destination = accounts[positions.destination]
+	if destination.Equals(jupiterV6ProgramID) && positions.userDestination >= 0 {
+		destination = accounts[positions.userDestination]
+	}
+	return accounts[positions.source], destination, true
+}
+
+// TokenFlow is where the input tokens came from and the output tokens went to.
+// Owners are nil when the token balances do not record the account.
+type TokenFlow struct {
+	SourceAccount      solana.PublicKey  `json:"source_account"`
+	SourceOwner        *solana.PublicKey `json:"source_owner,omitempty"`
+	DestinationAccount solana.PublicKey  `json:"destination_account"`
+	DestinationOwner   *solana.PublicKey `json:"destination_owner,omitempty"`
+}
+
+// CrossWallet reports whether both owners are known and differ, e.g. a swap
+// paying out to another wallet
+func (f *TokenFlow) CrossWallet() bool {
+	return f.SourceOwner != nil && f.DestinationOwner != nil && !f.SourceOwner.Equals(*f.DestinationOwner)
+}
+
+// tokenAccountOwner returns the owner recorded in the token balances for account
+func tokenAccountOwner(account solana.PublicKey, accountKeys solana.PublicKeySlice, meta *rpc.TransactionMeta) *solana.PublicKey {
+	index, found := accountIndex(accountKeys, account)
+	if !found {
+		return nil
+	}
+	for _, balances := range [][]rpc.TokenBalance{meta.PostTokenBalances, meta.PreTokenBalances} {
+		if balance, found := findTokenBalance(balances, index); found && balance.Owner != nil {
+			owner := *balance.Owner
+			return &owner
+		}
+	}
+	return nil
+}
+
+// deriveTokenFlow attaches the source and destination token accounts of the
+// instruction and their owners
+func deriveTokenFlow(params *JupiterSwapParams, accounts solana.PublicKeySlice, accountKeys solana.PublicKeySlice, meta *rpc.TransactionMeta) {
+	source, destination, ok := userTokenAccounts(params.InstructionType, accounts)
+	if !ok || meta == nil {
+		return
+	}
+	params.TokenFlow = &TokenFlow{
+		SourceAccount:      source,
+		SourceOwner:        tokenAccountOwner(source, accountKeys, meta),
+		DestinationAccount: destination,
+		DestinationOwner:   tokenAccountOwner(destination, accountKeys, meta),
+	}
+}
+
+// deriveUserWallet attributes the instruction to a user wallet. The shared accounts
+// variants route tokens through Jupiter's program authority, so the wallet is taken
+// from user_transfer_authority when it signed the transaction, then from the owner
+// recorded in the token balances for the destination account, and finally from the
+// fee payer. An authority that did not sign, such as the PDA of a program invoking
+// Jupiter, is not the user.
+func deriveUserWallet(params *JupiterSwapParams, accounts solana.PublicKeySlice, message *solana.Message, meta *rpc.TransactionMeta) {
+	positions, ok := userAccountPositions[params.InstructionType]
+	if ok && positions.authority < len(accounts) && message.IsSigner(accounts[positions.authority]) {
+		params.UserWallet = accounts[positions.authority]
+		params.UserWalletSource = WalletFromAccounts
+		return
+	}
+
+	if _, destination, ok := userTokenAccounts(params.InstructionType, accounts); ok && meta != nil {
+		if owner := tokenAccountOwner(destination, message.AccountKeys, meta); owner != nil {
+			params.UserWallet = *owner
+			params.UserWalletSource = WalletFromBalances
+			return
+		}
+	}
+
+	if len(message.AccountKeys) > 0 {
+		params.UserWallet = message.AccountKeys[0]
+		params.UserWalletSource = WalletFromFeePayer
+	}
+}
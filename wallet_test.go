@@ -0,0 +1,162 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestDeriveTokenFlow(t *testing.T) {
+	var (
+		user            = testKey(1)
+		recipient       = testKey(2)
+		source          = testKey(3)
+		userDestination = testKey(4)
+		destination     = testKey(5)
+		mint            = testKey(6)
+		tokenProgram    = solana.TokenProgramID
+		authority       = testKey(7)
+	)
+	accountKeys := solana.PublicKeySlice{user, source, userDestination, destination, jupiterV6ProgramID, tokenProgram, authority}
+	meta := &rpc.TransactionMeta{PostTokenBalances: []rpc.TokenBalance{
+		testTokenBalance(1, mint, user, 0),
+		testTokenBalance(2, mint, user, 10),
+		testTokenBalance(3, mint, recipient, 10),
+	}}
+
+	tests := []struct {
+		name            string
+		instructionType string
+		accounts        solana.PublicKeySlice
+		destination     solana.PublicKey
+		owner           *solana.PublicKey
+		crossWallet     bool
+	}{
+		{
+			name:            "route with destination_token_account",
+			instructionType: "route",
+			accounts:        solana.PublicKeySlice{tokenProgram, user, source, userDestination, destination},
+			destination:     destination,
+			owner:           &recipient,
+			crossWallet:     true,
+		},
+		{
+			name:            "route with omitted destination_token_account",
+			instructionType: "route",
+			accounts:        solana.PublicKeySlice{tokenProgram, user, source, userDestination, jupiterV6ProgramID},
+			destination:     userDestination,
+			owner:           &user,
+		},
+		{
+			name:            "exactOutRoute with omitted destination_token_account",
+			instructionType: "exactOutRoute",
+			accounts:        solana.PublicKeySlice{tokenProgram, user, source, userDestination, jupiterV6ProgramID},
+			destination:     userDestination,
+			owner:           &user,
+		},
+		{
+			name:            "sharedAccountsRoute",
+			instructionType: "sharedAccountsRoute",
+			accounts:        solana.PublicKeySlice{tokenProgram, authority, user, source, testKey(8), testKey(9), destination},
+			destination:     destination,
+			owner:           &recipient,
+			crossWallet:     true,
+		},
+		{
+			// destination_token_account is required, it is never remapped to
+			// the user accounts
+			name:            "sharedAccountsRoute to the program ID",
+			instructionType: "sharedAccountsRoute",
+			accounts:        solana.PublicKeySlice{tokenProgram, authority, user, source, testKey(8), testKey(9), jupiterV6ProgramID},
+			destination:     jupiterV6ProgramID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := &JupiterSwapParams{InstructionType: tt.instructionType}
+			deriveTokenFlow(params, tt.accounts, accountKeys, meta)
+			flow := params.TokenFlow
+			if flow == nil {
+				t.Fatal("no token flow")
+			}
+			if !flow.SourceAccount.Equals(source) || flow.SourceOwner == nil || !flow.SourceOwner.Equals(user) {
+				t.Errorf("source %s owned by %v, want %s owned by %s", flow.SourceAccount, flow.SourceOwner, source, user)
+			}
+			if !flow.DestinationAccount.Equals(tt.destination) {
+				t.Errorf("destination %s, want %s", flow.DestinationAccount, tt.destination)
+			}
+			if (flow.DestinationOwner == nil) != (tt.owner == nil) || (tt.owner != nil && !flow.DestinationOwner.Equals(*tt.owner)) {
+				t.Errorf("destination owner %v, want %s", flow.DestinationOwner, tt.owner)
+			}
+			if flow.CrossWallet() != tt.crossWallet {
+				t.Errorf("CrossWallet() = %v, want %v", flow.CrossWallet(), tt.crossWallet)
+			}
+		})
+	}
+}
+
+// sharedRouteTransaction builds a sharedAccountsRoute paid by sponsor, with
+// user_transfer_authority at key 1 signing or not, and the destination token
+// account owned by owner in the token balances when owner is not nil
+func sharedRouteTransaction(t *testing.T, authoritySigns bool, owner *solana.PublicKey) (*rpc.GetTransactionResult, *solana.Transaction) {
+	sponsor, authority := testKey(1), testKey(2)
+	keys := solana.PublicKeySlice{sponsor, authority, jupiterV6ProgramID}
+	for i := byte(10); i < 20; i++ {
+		keys = append(keys, testKey(i))
+	}
+	signers := uint8(1)
+	if authoritySigns {
+		signers = 2
+	}
+
+	// token_program, program_authority, user_transfer_authority, source_token_account,
+	// program_source_token_account, program_destination_token_account,
+	// destination_token_account, source_mint, destination_mint, then the optional
+	// and event accounts
+	accounts := []uint16{3, 4, 1, 5, 6, 7, 8, 9, 10, 2, 2, 11, 2}
+	data := testInstruction("sharedAccountsRoute", 0, [][]byte{testStep(0, 100, 0, 1)}, 1000, 990, 50, 0)
+	parsedTx := &solana.Transaction{
+		Signatures: make([]solana.Signature, signers),
+		Message: solana.Message{
+			Header:       solana.MessageHeader{NumRequiredSignatures: signers},
+			AccountKeys:  keys,
+			Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 2, Accounts: accounts, Data: data}},
+		},
+	}
+	meta := &rpc.TransactionMeta{}
+	if owner != nil {
+		meta.PostTokenBalances = []rpc.TokenBalance{testTokenBalance(8, testKey(30), *owner, 990)}
+	}
+	return testTransactionResult(t, parsedTx, meta), parsedTx
+}
+
+func TestDeriveUserWallet(t *testing.T) {
+	sponsor, authority, user := testKey(1), testKey(2), testKey(3)
+	tests := []struct {
+		name           string
+		authoritySigns bool
+		owner          *solana.PublicKey
+		want           solana.PublicKey
+		source         string
+	}{
+		// The fee payer sponsors the transaction the user signed
+		{"sponsored", true, &user, authority, WalletFromAccounts},
+		// A program invoked Jupiter with its PDA as the authority
+		{"unsigned authority", false, &user, user, WalletFromBalances},
+		{"unsigned authority without balances", false, nil, sponsor, WalletFromFeePayer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, parsedTx := sharedRouteTransaction(t, tt.authoritySigns, tt.owner)
+			analysis := analyzeTest(t, newTestAnalyzer(), tx, parsedTx)
+			if len(analysis.Instructions) != 1 {
+				t.Fatalf("got %d instructions, errors %v", len(analysis.Instructions), analysis.Errors)
+			}
+			params := analysis.Instructions[0]
+			if !params.UserWallet.Equals(tt.want) || params.UserWalletSource != tt.source {
+				t.Errorf("user wallet %s from %s, want %s from %s", params.UserWallet, params.UserWalletSource, tt.want, tt.source)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+//go:build wasip1
+
+// Command wasm is the WASI build of the instruction decoder, built from the
+// repository root with
+//
+//	GOOS=wasip1 GOARCH=wasm go build -o soltx.wasm ./wasm
+//
+// It reads hex encoded instruction data from its first argument, or from
+// stdin when there is none, prints the decoded instruction as JSON and exits
+// with the result code of jupiterv6.DecodeInstructionJSON.
+//
+//	wasmtime soltx.wasm e517cb977ae3ad2a...
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"sol-tx/jupiterv6"
+)
+
+func main() {
+	var input string
+	if len(os.Args) > 1 {
+		input = os.Args[1]
+	} else {
+		raw, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading stdin: %v\n", err)
+			os.Exit(jupiterv6.ResultInvalidInput)
+		}
+		input = string(raw)
+	}
+
+	data, err := hex.DecodeString(strings.TrimSpace(input))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid hex data: %v\n", err)
+		os.Exit(jupiterv6.ResultInvalidInput)
+	}
+
+	result, code := jupiterv6.DecodeInstructionJSON(data)
+	fmt.Println(string(result))
+	os.Exit(code)
+}
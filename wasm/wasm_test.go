@@ -0,0 +1,52 @@
+//go:build !wasip1
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWASI builds the decoder for wasip1 and runs it under wasmtime, skipped
+// where wasmtime is not installed
+func TestWASI(t *testing.T) {
+	wasmtime, err := exec.LookPath("wasmtime")
+	if err != nil {
+		t.Skip("wasmtime not installed")
+	}
+
+	module := filepath.Join(t.TempDir(), "soltx.wasm")
+	build := exec.Command("go", "build", "-o", module, ".")
+	build.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("build: %v\n%s", err, out)
+	}
+
+	// route: one Saber step, in_amount 1000, quoted_out_amount 990, slippage 50 bps
+	data := "e517cb977ae3ad2a" + "01000000" + "00640001" + "e803000000000000" + "de03000000000000" + "3200" + "00"
+	out, err := exec.Command(wasmtime, module, data).Output()
+	if err != nil {
+		t.Fatalf("run: %v\n%s", err, out)
+	}
+	var decoded struct {
+		InstructionType string `json:"instruction_type"`
+		InAmount        uint64 `json:"in_amount"`
+		QuotedOutAmount uint64 `json:"quoted_out_amount"`
+	}
+	if err := json.Unmarshal(out, &decoded); err != nil || decoded.InstructionType != "route" || decoded.InAmount != 1000 || decoded.QuotedOutAmount != 990 {
+		t.Errorf("decoded %s", out)
+	}
+
+	// the same data on stdin, truncated after the route plan length
+	run := exec.Command(wasmtime, module)
+	run.Stdin = strings.NewReader(data[:24] + "\n")
+	out, err = run.Output()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 2 || !strings.Contains(string(out), `"code":"JUP002"`) {
+		t.Errorf("truncated data: %v\n%s", err, out)
+	}
+}